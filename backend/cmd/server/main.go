@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 
@@ -9,6 +10,10 @@ import (
 )
 
 func main() {
+	// --check 仅执行初始化自检并打印报告，不启动服务
+	checkOnly := flag.Bool("check", false, "仅执行启动自检并打印报告")
+	flag.Parse()
+
 	// 加载配置
 	cfg := config.Load()
 	// 创建服务器
@@ -21,6 +26,11 @@ func main() {
 	// 确保服务器资源被正确关闭
 	defer srv.Close()
 
+	// 自检模式: 初始化链路已执行完毕并打印了报告，直接退出
+	if *checkOnly {
+		return
+	}
+
 	// 启动服务器
 	if err := srv.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "failed to start server: %v\n", err)
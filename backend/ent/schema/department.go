@@ -0,0 +1,65 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+)
+
+// Department holds the schema definition for the Department entity.
+type Department struct {
+	ent.Schema
+}
+
+// Fields of the Department.
+func (Department) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("code").
+			Unique().
+			NotEmpty().
+			MaxLen(50).
+			Comment("部门代码，如 rd、hr"),
+		field.String("name").
+			NotEmpty().
+			MaxLen(100).
+			Comment("部门显示名称"),
+		field.String("description").
+			Optional().
+			MaxLen(500).
+			Comment("部门描述"),
+		field.Int("sort_order").
+			Default(0).
+			Comment("排序顺序"),
+		field.Time("created_at").
+			Default(time.Now).
+			Immutable().
+			Comment("创建时间"),
+		field.Time("updated_at").
+			Default(time.Now).
+			UpdateDefault(time.Now).
+			Comment("更新时间"),
+	}
+}
+
+// Edges of the Department.
+func (Department) Edges() []ent.Edge {
+	return []ent.Edge{
+		// 部门树形结构：一个部门可以有多个子部门
+		edge.To("children", Department.Type).
+			From("parent").
+			Unique(),
+		// 部门下的用户
+		edge.To("users", User.Type),
+	}
+}
+
+// Indexes of the Department.
+func (Department) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("code"),
+		index.Fields("sort_order"),
+	}
+}
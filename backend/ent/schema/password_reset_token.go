@@ -0,0 +1,47 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+)
+
+// PasswordResetToken holds the schema definition for the PasswordResetToken entity.
+// 忘记密码流程的一次性重置令牌，数据库只保存令牌哈希，单次使用且过期失效。
+type PasswordResetToken struct {
+	ent.Schema
+}
+
+// Fields of the PasswordResetToken.
+func (PasswordResetToken) Fields() []ent.Field {
+	return []ent.Field{
+		field.Int("user_id").
+			Comment("令牌所属用户ID"),
+		field.String("token_hash").
+			Unique().
+			NotEmpty().
+			MaxLen(64).
+			Comment("重置令牌哈希（SHA256），不保存令牌明文"),
+		field.Time("expires_at").
+			Comment("过期时间"),
+		field.Time("used_at").
+			Optional().
+			Nillable().
+			Comment("使用时间，非空表示已使用"),
+		field.Time("created_at").
+			Default(time.Now).
+			Immutable().
+			Comment("创建时间"),
+	}
+}
+
+// Indexes of the PasswordResetToken.
+func (PasswordResetToken) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("token_hash"),
+		index.Fields("user_id"),
+		index.Fields("expires_at"),
+	}
+}
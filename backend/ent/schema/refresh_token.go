@@ -0,0 +1,63 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+)
+
+// RefreshToken holds the schema definition for the RefreshToken entity.
+// 每个刷新令牌只允许使用一次，用于实现令牌轮换和重放检测。
+type RefreshToken struct {
+	ent.Schema
+}
+
+// Fields of the RefreshToken.
+func (RefreshToken) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("token_id").
+			Unique().
+			NotEmpty().
+			MaxLen(64).
+			Comment("刷新令牌唯一标识 (JWT ID)"),
+		field.String("family_id").
+			Default("").
+			MaxLen(64).
+			Comment("令牌家族标识（同一次登录轮换出的令牌链共享，首个令牌的 token_id）"),
+		field.Bool("used").
+			Default(false).
+			Comment("是否已被使用（轮换后标记）"),
+		field.Bool("revoked").
+			Default(false).
+			Comment("是否已被吊销"),
+		field.Time("expires_at").
+			Comment("过期时间"),
+		field.Time("created_at").
+			Default(time.Now).
+			Immutable().
+			Comment("创建时间"),
+	}
+}
+
+// Edges of the RefreshToken.
+func (RefreshToken) Edges() []ent.Edge {
+	return []ent.Edge{
+		// 令牌所属用户（反向边）
+		edge.From("user", User.Type).
+			Ref("refresh_tokens").
+			Unique().
+			Required(),
+	}
+}
+
+// Indexes of the RefreshToken.
+func (RefreshToken) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("token_id"),
+		index.Fields("family_id"),
+		index.Fields("expires_at"),
+	}
+}
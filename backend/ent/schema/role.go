@@ -30,6 +30,18 @@ func (Role) Fields() []ent.Field {
 			Optional().
 			MaxLen(500).
 			Comment("角色描述"),
+		field.String("default_route").
+			Optional().
+			MaxLen(200).
+			Comment("角色默认首页路由（如 /admin/dashboard），为空使用系统默认"),
+		field.Enum("data_scope").
+			Values("all", "department", "department_and_children", "self").
+			Default("all").
+			Comment("数据范围: 限制该角色可管理的用户范围（全部/本部门/本部门及子部门/仅自己）"),
+		field.Int("parent_id").
+			Optional().
+			Nillable().
+			Comment("父角色 ID，子角色继承父角色的全部权限"),
 		field.Bool("is_system").
 			Default(false).
 			Comment("是否为系统内置角色，内置角色不可删除"),
@@ -44,6 +56,10 @@ func (Role) Fields() []ent.Field {
 			Default(time.Now).
 			UpdateDefault(time.Now).
 			Comment("更新时间"),
+		field.Time("deleted_at").
+			Optional().
+			Nillable().
+			Comment("软删除时间，非空表示角色已删除，用户关联保留以便恢复"),
 	}
 }
 
@@ -55,6 +71,11 @@ func (Role) Edges() []ent.Edge {
 			Ref("roles"),
 		// 角色拥有多个权限
 		edge.To("permissions", Permission.Type),
+		// 角色继承：子角色继承父角色的全部权限（自引用）
+		edge.To("children", Role.Type).
+			From("parent").
+			Unique().
+			Field("parent_id"),
 	}
 }
 
@@ -63,5 +84,7 @@ func (Role) Indexes() []ent.Index {
 	return []ent.Index{
 		index.Fields("code"),
 		index.Fields("is_system"),
+		index.Fields("parent_id"),
+		index.Fields("deleted_at"),
 	}
 }
@@ -0,0 +1,56 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+)
+
+// RoleChangeRequest holds the schema definition for the RoleChangeRequest entity.
+// 敏感角色（系统内置角色）的权限变更不直接生效，先入库为变更请求，
+// 由另一个拥有审批权限的管理员审批通过后才应用
+type RoleChangeRequest struct {
+	ent.Schema
+}
+
+// Fields of the RoleChangeRequest.
+func (RoleChangeRequest) Fields() []ent.Field {
+	return []ent.Field{
+		field.Int("role_id").
+			Comment("目标角色ID"),
+		field.JSON("permissions", []string{}).
+			Comment("请求变更后的权限代码列表（完整替换）"),
+		field.Enum("status").
+			Values("pending", "approved", "rejected").
+			Default("pending").
+			Comment("审批状态"),
+		field.Int("requested_by").
+			Comment("发起人用户ID"),
+		field.Int("reviewed_by").
+			Optional().
+			Nillable().
+			Comment("审批人用户ID"),
+		field.String("review_comment").
+			Optional().
+			MaxLen(500).
+			Comment("审批意见"),
+		field.Time("created_at").
+			Default(time.Now).
+			Immutable().
+			Comment("创建时间"),
+		field.Time("reviewed_at").
+			Optional().
+			Nillable().
+			Comment("审批时间"),
+	}
+}
+
+// Indexes of the RoleChangeRequest.
+func (RoleChangeRequest) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("status"),
+		index.Fields("role_id"),
+	}
+}
@@ -0,0 +1,46 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+)
+
+// Tag holds the schema definition for the Tag entity.
+type Tag struct {
+	ent.Schema
+}
+
+// Fields of the Tag.
+func (Tag) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("name").
+			Unique().
+			NotEmpty().
+			MaxLen(50).
+			Comment("标签名称，如 VIP、测试账号"),
+		field.Time("created_at").
+			Default(time.Now).
+			Immutable().
+			Comment("创建时间"),
+	}
+}
+
+// Edges of the Tag.
+func (Tag) Edges() []ent.Edge {
+	return []ent.Edge{
+		// 标签关联的用户（反向边）
+		edge.From("users", User.Type).
+			Ref("tags"),
+	}
+}
+
+// Indexes of the Tag.
+func (Tag) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("name"),
+	}
+}
@@ -35,23 +35,27 @@ func (User) Fields() []ent.Field {
 			Optional().
 			MaxLen(100).
 			Comment("昵称"),
+		field.String("pinyin").
+			Optional().
+			MaxLen(255).
+			Comment("昵称拼音索引（全拼 + 首字母），用于拼音搜索"),
 		field.String("avatar").
 			Optional().
 			MaxLen(500).
 			Comment("头像URL"),
 		field.Enum("status").
-			Values("active", "inactive", "banned").
+			Values("active", "inactive", "banned", "softban").
 			Default("active").
-			Comment("用户状态"),
+			Comment("用户状态: softban 为软封禁，可登录但权限降级为只读"),
 		field.Enum("auth_provider").
-			Values("local", "cas").
+			Values("local", "cas", "ldap", "oidc").
 			Default("local").
-			Comment("认证来源: local(本地), cas(CAS单点登录)"),
+			Comment("认证来源: local(本地), cas(CAS单点登录), ldap(LDAP/AD), oidc(OIDC/OAuth2)"),
 		field.String("external_id").
 			Optional().
 			Nillable().
 			MaxLen(255).
-			Comment("外部系统用户ID (CAS user id)"),
+			Comment("外部系统用户ID (CAS user id / LDAP DN)"),
 		field.Time("created_at").
 			Default(time.Now).
 			Immutable().
@@ -64,6 +68,19 @@ func (User) Fields() []ent.Field {
 			Optional().
 			Nillable().
 			Comment("最后登录时间"),
+		field.String("last_login_region").
+			Optional().
+			Nillable().
+			MaxLen(100).
+			Comment("最后登录地区（GeoIP 解析结果）"),
+		field.String("last_login_device").
+			Optional().
+			Nillable().
+			MaxLen(64).
+			Comment("最后登录设备指纹（User-Agent 哈希），用于新设备检测"),
+		field.Bool("login_notify").
+			Default(true).
+			Comment("是否接收新设备/异地登录提醒邮件"),
 		field.Int("login_attempts").
 			Default(0).
 			Comment("登录失败次数"),
@@ -71,6 +88,26 @@ func (User) Fields() []ent.Field {
 			Optional().
 			Nillable().
 			Comment("账号锁定截止时间"),
+		field.String("terms_accepted_version").
+			Optional().
+			Default("").
+			MaxLen(50).
+			Comment("用户已同意的服务条款版本号，低于当前版本时需重新同意"),
+		field.Bool("totp_enabled").
+			Default(false).
+			Comment("是否开启 TOTP 两步验证"),
+		field.String("totp_secret").
+			Sensitive().
+			Optional().
+			MaxLen(200).
+			Comment("TOTP 密钥（AES-GCM 加密后存储）"),
+		field.JSON("totp_recovery_codes", []string{}).
+			Optional().
+			Sensitive().
+			Comment("一次性恢复码哈希列表，使用后移除"),
+		field.JSON("metadata", map[string]string{}).
+			Optional().
+			Comment("自定义扩展属性（如工号、职位、座机），组织特有字段无需改 schema"),
 	}
 }
 
@@ -79,6 +116,16 @@ func (User) Edges() []ent.Edge {
 	return []ent.Edge{
 		// 用户可以拥有多个角色
 		edge.To("roles", Role.Type),
+		// 用户所属部门（反向边，一个用户只属于一个部门）
+		edge.From("department", Department.Type).
+			Ref("users").
+			Unique(),
+		// 用户签发的刷新令牌
+		edge.To("refresh_tokens", RefreshToken.Type),
+		// 用户可以拥有多个标签（自由分类，不涉及权限）
+		edge.To("tags", Tag.Type),
+		// 用户直接授予的权限（绕过角色的一次性授权），认证时并入角色权限
+		edge.To("direct_permissions", Permission.Type),
 	}
 }
 
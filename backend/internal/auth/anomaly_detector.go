@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 异常检测维度
+const (
+	// AnomalyScopeGlobal 全局登录失败异常
+	AnomalyScopeGlobal = "global"
+	// AnomalyScopeIP 单 IP 登录失败异常
+	AnomalyScopeIP = "ip"
+	// AnomalyScopeAccount 单账号登录失败异常
+	AnomalyScopeAccount = "account"
+)
+
+// anomalyAlertCooldown 同一维度+键的告警冷却时间，避免持续攻击期间告警刷屏
+const anomalyAlertCooldown = 5 * time.Minute
+
+// anomalyWebhookTimeout webhook 通知的请求超时
+const anomalyWebhookTimeout = 5 * time.Second
+
+// LoginAnomalyAlert 登录异常告警事件
+type LoginAnomalyAlert struct {
+	// Scope 异常维度：global / ip / account
+	Scope string `json:"scope"`
+	// Key 触发异常的键（IP 或账号标识，global 维度为空）
+	Key string `json:"key,omitempty"`
+	// Failures 窗口内的失败次数
+	Failures int `json:"failures"`
+	// Threshold 触发告警的基线
+	Threshold int `json:"threshold"`
+	// WindowSeconds 统计窗口长度（秒）
+	WindowSeconds int64 `json:"window_seconds"`
+	// OccurredAt 告警触发时间
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// LoginAnomalyDetector 登录行为异常检测器
+// 按滑动窗口统计全局/单 IP/单账号的登录失败次数，超过基线时
+// 记录告警事件、触发 webhook 通知，并临时将该 IP 标记为需要验证码；
+// 当前提供内存实现，分布式部署可替换为共享存储实现
+type LoginAnomalyDetector struct {
+	mu               sync.Mutex
+	window           time.Duration
+	globalThreshold  int
+	ipThreshold      int
+	accountThreshold int
+	webhookURL       string
+
+	global    []time.Time
+	byIP      map[string][]time.Time
+	byAccount map[string][]time.Time
+	// cooldown 各维度+键的告警冷却截止时间
+	cooldown map[string]time.Time
+	// captchaIPs 触发异常后临时要求验证码的 IP 及截止时间
+	captchaIPs map[string]time.Time
+
+	// onAlert 告警回调，由接入方设置（如写入结构化日志）
+	onAlert func(alert LoginAnomalyAlert)
+}
+
+// NewLoginAnomalyDetector 创建登录异常检测器
+// 阈值小于等于 0 的维度不参与检测；webhookURL 为空时不发送 webhook 通知
+func NewLoginAnomalyDetector(window time.Duration, globalThreshold, ipThreshold, accountThreshold int, webhookURL string) *LoginAnomalyDetector {
+	return &LoginAnomalyDetector{
+		window:           window,
+		globalThreshold:  globalThreshold,
+		ipThreshold:      ipThreshold,
+		accountThreshold: accountThreshold,
+		webhookURL:       webhookURL,
+		byIP:             make(map[string][]time.Time),
+		byAccount:        make(map[string][]time.Time),
+		cooldown:         make(map[string]time.Time),
+		captchaIPs:       make(map[string]time.Time),
+	}
+}
+
+// SetAlertFunc 设置告警回调，告警在独立 goroutine 中触发，不阻塞登录路径
+func (d *LoginAnomalyDetector) SetAlertFunc(fn func(alert LoginAnomalyAlert)) {
+	d.onAlert = fn
+}
+
+// RecordFailure 记录一次登录失败并检查各维度是否超过基线
+func (d *LoginAnomalyDetector) RecordFailure(ip, account string) {
+	d.mu.Lock()
+
+	now := time.Now()
+	cutoff := now.Add(-d.window)
+	d.sweep(now, cutoff)
+
+	d.global = appendPruned(d.global, cutoff, now)
+	d.byIP[ip] = appendPruned(d.byIP[ip], cutoff, now)
+	d.byAccount[account] = appendPruned(d.byAccount[account], cutoff, now)
+
+	var alerts []LoginAnomalyAlert
+	if d.globalThreshold > 0 && len(d.global) >= d.globalThreshold {
+		if alert, ok := d.buildAlert(AnomalyScopeGlobal, "", len(d.global), d.globalThreshold, now); ok {
+			alerts = append(alerts, alert)
+		}
+	}
+	if d.ipThreshold > 0 && len(d.byIP[ip]) >= d.ipThreshold {
+		if alert, ok := d.buildAlert(AnomalyScopeIP, ip, len(d.byIP[ip]), d.ipThreshold, now); ok {
+			// 该 IP 在窗口期内临时提高验证码要求
+			d.captchaIPs[ip] = now.Add(d.window)
+			alerts = append(alerts, alert)
+		}
+	}
+	if d.accountThreshold > 0 && len(d.byAccount[account]) >= d.accountThreshold {
+		if alert, ok := d.buildAlert(AnomalyScopeAccount, account, len(d.byAccount[account]), d.accountThreshold, now); ok {
+			alerts = append(alerts, alert)
+		}
+	}
+	d.mu.Unlock()
+
+	// 告警异步触发，不阻塞登录请求
+	for _, alert := range alerts {
+		go d.fire(alert)
+	}
+}
+
+// RequiresCaptcha 判断该 IP 当前是否被临时提高验证码要求
+// TODO: 登录流程尚未接入验证码，接入后在 Login 中根据该标记要求验证码
+func (d *LoginAnomalyDetector) RequiresCaptcha(ip string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	until, ok := d.captchaIPs[ip]
+	if !ok {
+		return false
+	}
+	if time.Now().After(until) {
+		delete(d.captchaIPs, ip)
+		return false
+	}
+	return true
+}
+
+// buildAlert 构造告警事件，处于冷却期时返回 false，调用方须持有锁
+func (d *LoginAnomalyDetector) buildAlert(scope, key string, failures, threshold int, now time.Time) (LoginAnomalyAlert, bool) {
+	cooldownKey := scope + "|" + key
+	if until, ok := d.cooldown[cooldownKey]; ok && now.Before(until) {
+		return LoginAnomalyAlert{}, false
+	}
+	d.cooldown[cooldownKey] = now.Add(anomalyAlertCooldown)
+
+	return LoginAnomalyAlert{
+		Scope:         scope,
+		Key:           key,
+		Failures:      failures,
+		Threshold:     threshold,
+		WindowSeconds: int64(d.window / time.Second),
+		OccurredAt:    now,
+	}, true
+}
+
+// fire 触发告警回调并发送 webhook 通知
+func (d *LoginAnomalyDetector) fire(alert LoginAnomalyAlert) {
+	if d.onAlert != nil {
+		d.onAlert(alert)
+	}
+
+	if d.webhookURL == "" {
+		return
+	}
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return
+	}
+	client := &http.Client{Timeout: anomalyWebhookTimeout}
+	resp, err := client.Post(d.webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+}
+
+// sweep 清理已完全过期的计数、冷却与验证码标记，调用方须持有锁
+func (d *LoginAnomalyDetector) sweep(now, cutoff time.Time) {
+	for key, times := range d.byIP {
+		if allBefore(times, cutoff) {
+			delete(d.byIP, key)
+		}
+	}
+	for key, times := range d.byAccount {
+		if allBefore(times, cutoff) {
+			delete(d.byAccount, key)
+		}
+	}
+	for key, until := range d.cooldown {
+		if now.After(until) {
+			delete(d.cooldown, key)
+		}
+	}
+	for ip, until := range d.captchaIPs {
+		if now.After(until) {
+			delete(d.captchaIPs, ip)
+		}
+	}
+}
+
+// allBefore 判断所有时间戳是否都早于截止时间
+func allBefore(times []time.Time, cutoff time.Time) bool {
+	for _, t := range times {
+		if t.After(cutoff) {
+			return false
+		}
+	}
+	return true
+}
+
+// appendPruned 清理窗口外的时间戳并追加新条目
+func appendPruned(times []time.Time, cutoff, now time.Time) []time.Time {
+	recent := times[:0]
+	for _, t := range times {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	return append(recent, now)
+}
@@ -1,8 +1,14 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"zera/internal/config"
@@ -18,8 +24,18 @@ const (
 	AccessToken TokenType = "access"
 	// RefreshToken 刷新令牌
 	RefreshToken TokenType = "refresh"
+	// SudoToken 二次密码确认令牌（sudo 模式）
+	SudoToken TokenType = "sudo"
+	// MFAToken 两步验证 challenge 令牌（密码校验通过后、TOTP 校验前）
+	MFAToken TokenType = "mfa"
 )
 
+// sudoTokenExpire sudo 令牌有效期，窗口内允许执行高危操作
+const sudoTokenExpire = 5 * time.Minute
+
+// mfaTokenExpire MFA challenge 令牌有效期，窗口内需完成 TOTP 校验
+const mfaTokenExpire = 5 * time.Minute
+
 // Claims JWT 声明
 type Claims struct {
 	UserID      int       `json:"uid"`
@@ -27,23 +43,161 @@ type Claims struct {
 	Roles       []string  `json:"roles,omitempty"`
 	Permissions []string  `json:"permissions,omitempty"`
 	Type        TokenType `json:"type"`
+	// ClientType 签发令牌的客户端类型（如 web、app），便于会话管理区分
+	ClientType string `json:"client,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// clientTokenExpire 单个客户端类型的令牌有效期
+type clientTokenExpire struct {
+	access  time.Duration
+	refresh time.Duration
+}
+
 // JWTManager JWT 管理器
+// 支持 HS256（对称密钥）和 RS256（非对称密钥）两种签名算法：
+// HS256 使用配置中的 secret 签名和校验；RS256 从 PEM 文件加载私钥签名，
+// 公钥按 kid 索引校验，保留旧 kid 公钥可实现密钥平滑轮换
 type JWTManager struct {
-	secret             []byte
+	method jwt.SigningMethod
+	secret []byte
+	// privateKey RS256 签名私钥
+	privateKey *rsa.PrivateKey
+	// publicKeys RS256/KMS 校验公钥，按 kid 索引
+	publicKeys map[string]*rsa.PublicKey
+	// remoteSigner KMS 签名后端，私钥不离开 KMS
+	remoteSigner RemoteSigner
+	// keyID 当前签名密钥的 kid，签发时写入令牌 header
+	keyID              string
 	accessTokenExpire  time.Duration
 	refreshTokenExpire time.Duration
+	// clients 按客户端类型差异化的令牌有效期，未配置的类型使用默认值
+	clients map[string]clientTokenExpire
 }
 
 // NewJWTManager 创建 JWT 管理器
-func NewJWTManager(cfg *config.JWTConfig) *JWTManager {
-	return &JWTManager{
+// 算法未配置时默认 HS256，保持与既有配置向后兼容
+func NewJWTManager(cfg *config.JWTConfig) (*JWTManager, error) {
+	clients := make(map[string]clientTokenExpire, len(cfg.Clients))
+	for clientType, c := range cfg.Clients {
+		clients[clientType] = clientTokenExpire{
+			access:  time.Duration(c.AccessTokenExpire) * time.Second,
+			refresh: time.Duration(c.RefreshTokenExpire) * time.Second,
+		}
+	}
+	m := &JWTManager{
 		secret:             []byte(cfg.Secret),
 		accessTokenExpire:  time.Duration(cfg.AccessTokenExpire) * time.Second,
 		refreshTokenExpire: time.Duration(cfg.RefreshTokenExpire) * time.Second,
+		clients:            clients,
+	}
+
+	switch cfg.SigningBackend {
+	case "", "local":
+		switch strings.ToUpper(cfg.Algorithm) {
+		case "", "HS256":
+			m.method = jwt.SigningMethodHS256
+		case "RS256":
+			m.method = jwt.SigningMethodRS256
+			if err := m.loadRSAKeys(cfg); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported JWT algorithm: %s", cfg.Algorithm)
+		}
+	case "kms":
+		m.method = SigningMethodRemote
+		if err := m.loadKMSBackend(cfg); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported JWT signing backend: %s", cfg.SigningBackend)
+	}
+
+	return m, nil
+}
+
+// loadKMSBackend 初始化 KMS 签名后端
+// 签名通过远程 API 完成，验签用 KMS 导出的公钥在本地执行
+func (m *JWTManager) loadKMSBackend(cfg *config.JWTConfig) error {
+	switch cfg.KMS.Provider {
+	case "vault":
+		if cfg.KMS.Endpoint == "" || cfg.KMS.KeyName == "" {
+			return errors.New("jwt.kms.endpoint and jwt.kms.key_name are required for vault provider")
+		}
+		m.remoteSigner = NewVaultTransitSigner(&cfg.KMS)
+	default:
+		// TODO: 支持 AWS KMS 等其他提供方
+		return fmt.Errorf("unsupported KMS provider: %s", cfg.KMS.Provider)
+	}
+
+	if cfg.KMS.PublicKeyFile == "" {
+		return errors.New("jwt.kms.public_key_file is required when signing backend is kms")
+	}
+	pemData, err := os.ReadFile(cfg.KMS.PublicKeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read KMS public key file: %w", err)
+	}
+	publicKey, err := jwt.ParseRSAPublicKeyFromPEM(pemData)
+	if err != nil {
+		return fmt.Errorf("failed to parse KMS public key: %w", err)
+	}
+
+	m.keyID = cfg.KeyID
+	m.publicKeys = map[string]*rsa.PublicKey{m.keyID: publicKey}
+
+	// 附加的历史公钥（密钥轮换后校验存量令牌）
+	for kid, file := range cfg.PublicKeyFiles {
+		pemData, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read JWT public key file for kid %q: %w", kid, err)
+		}
+		key, err := jwt.ParseRSAPublicKeyFromPEM(pemData)
+		if err != nil {
+			return fmt.Errorf("failed to parse JWT public key for kid %q: %w", kid, err)
+		}
+		m.publicKeys[kid] = key
+	}
+
+	return nil
+}
+
+// loadRSAKeys 加载 RS256 私钥和校验公钥
+func (m *JWTManager) loadRSAKeys(cfg *config.JWTConfig) error {
+	if cfg.PrivateKeyFile == "" {
+		return errors.New("jwt.private_key_file is required when algorithm is RS256")
+	}
+
+	pemData, err := os.ReadFile(cfg.PrivateKeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read JWT private key file: %w", err)
+	}
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(pemData)
+	if err != nil {
+		return fmt.Errorf("failed to parse JWT private key: %w", err)
 	}
+	m.privateKey = privateKey
+	m.keyID = cfg.KeyID
+
+	m.publicKeys = make(map[string]*rsa.PublicKey, len(cfg.PublicKeyFiles)+1)
+	for kid, file := range cfg.PublicKeyFiles {
+		pemData, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read JWT public key file for kid %q: %w", kid, err)
+		}
+		publicKey, err := jwt.ParseRSAPublicKeyFromPEM(pemData)
+		if err != nil {
+			return fmt.Errorf("failed to parse JWT public key for kid %q: %w", kid, err)
+		}
+		m.publicKeys[kid] = publicKey
+	}
+
+	// 当前签名密钥的公钥未显式配置时，由私钥派生，保证自签令牌始终可校验
+	if _, ok := m.publicKeys[m.keyID]; !ok {
+		m.publicKeys[m.keyID] = &privateKey.PublicKey
+	}
+
+	return nil
 }
 
 // SetAccessTokenExpire 动态设置访问令牌过期时间（分钟）
@@ -55,7 +209,7 @@ func (m *JWTManager) SetAccessTokenExpire(minutes int) {
 
 // GenerateAccessToken 生成访问令牌
 func (m *JWTManager) GenerateAccessToken(userID int, username string, roles []string, permissions []string) (string, error) {
-	return m.generateToken(userID, username, roles, permissions, AccessToken, m.accessTokenExpire)
+	return m.generateToken(userID, username, roles, permissions, AccessToken, m.accessTokenExpire, "", "")
 }
 
 // GenerateAccessTokenWithExpire 生成指定过期时间的访问令牌
@@ -64,17 +218,70 @@ func (m *JWTManager) GenerateAccessTokenWithExpire(userID int, username string,
 	if expireMinutes > 0 {
 		expire = time.Duration(expireMinutes) * time.Minute
 	}
-	return m.generateToken(userID, username, roles, permissions, AccessToken, expire)
+	return m.generateToken(userID, username, roles, permissions, AccessToken, expire, "", "")
+}
+
+// GenerateClientAccessToken 按客户端类型生成访问令牌
+// 有效期优先级：客户端专属配置 > fallbackMinutes（系统会话超时）> 全局默认
+func (m *JWTManager) GenerateClientAccessToken(userID int, username string, roles []string, permissions []string, clientType string, fallbackMinutes int) (string, error) {
+	expire := m.ClientAccessTokenExpire(clientType, fallbackMinutes)
+	return m.generateToken(userID, username, roles, permissions, AccessToken, expire, "", clientType)
+}
+
+// ClientAccessTokenExpire 计算客户端类型的访问令牌有效期
+// 有效期优先级：客户端专属配置 > fallbackMinutes（系统会话超时）> 全局默认
+func (m *JWTManager) ClientAccessTokenExpire(clientType string, fallbackMinutes int) time.Duration {
+	if c, ok := m.clients[clientType]; ok && c.access > 0 {
+		return c.access
+	}
+	if fallbackMinutes > 0 {
+		return time.Duration(fallbackMinutes) * time.Minute
+	}
+	return m.accessTokenExpire
+}
+
+// ClientRefreshTokenExpire 计算客户端类型的刷新令牌有效期，未配置时使用全局默认
+func (m *JWTManager) ClientRefreshTokenExpire(clientType string) time.Duration {
+	if c, ok := m.clients[clientType]; ok && c.refresh > 0 {
+		return c.refresh
+	}
+	return m.refreshTokenExpire
 }
 
 // GenerateRefreshToken 生成刷新令牌
-func (m *JWTManager) GenerateRefreshToken(userID int, username string) (string, error) {
+// 返回令牌字符串和令牌唯一标识 (jti)，jti 用于服务端记录令牌的使用状态
+func (m *JWTManager) GenerateRefreshToken(userID int, username string) (string, string, error) {
+	return m.GenerateClientRefreshToken(userID, username, "")
+}
+
+// GenerateClientRefreshToken 按客户端类型生成刷新令牌（如 App 30 天、Web 7 天）
+func (m *JWTManager) GenerateClientRefreshToken(userID int, username, clientType string) (string, string, error) {
+	jti := newTokenID()
 	// 刷新令牌不包含角色和权限信息
-	return m.generateToken(userID, username, nil, nil, RefreshToken, m.refreshTokenExpire)
+	token, err := m.generateToken(userID, username, nil, nil, RefreshToken, m.ClientRefreshTokenExpire(clientType), jti, clientType)
+	if err != nil {
+		return "", "", err
+	}
+	return token, jti, nil
+}
+
+// GenerateSudoToken 生成二次密码确认令牌
+// 令牌绑定用户且有效期很短，用于高危操作的 sudo 模式确认
+func (m *JWTManager) GenerateSudoToken(userID int, username string) (string, error) {
+	// sudo 令牌不包含角色和权限信息
+	return m.generateToken(userID, username, nil, nil, SudoToken, sudoTokenExpire, "", "")
+}
+
+// GenerateMFAToken 生成两步验证 challenge 令牌
+// 密码校验通过后签发，完成 TOTP 校验前不代表登录成功
+// 记录客户端类型，第二步校验通过后按相同类型签发正式令牌
+func (m *JWTManager) GenerateMFAToken(userID int, username, clientType string) (string, error) {
+	// challenge 令牌不包含角色和权限信息
+	return m.generateToken(userID, username, nil, nil, MFAToken, mfaTokenExpire, "", clientType)
 }
 
 // generateToken 生成令牌
-func (m *JWTManager) generateToken(userID int, username string, roles []string, permissions []string, tokenType TokenType, expire time.Duration) (string, error) {
+func (m *JWTManager) generateToken(userID int, username string, roles []string, permissions []string, tokenType TokenType, expire time.Duration, jti, clientType string) (string, error) {
 	now := time.Now()
 	claims := Claims{
 		UserID:      userID,
@@ -82,7 +289,9 @@ func (m *JWTManager) generateToken(userID int, username string, roles []string,
 		Roles:       roles,
 		Permissions: permissions,
 		Type:        tokenType,
+		ClientType:  clientType,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
 			ExpiresAt: jwt.NewNumericDate(now.Add(expire)),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
@@ -90,18 +299,55 @@ func (m *JWTManager) generateToken(userID int, username string, roles []string,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(m.secret)
+	token := jwt.NewWithClaims(m.method, claims)
+	switch m.method {
+	case jwt.SigningMethodRS256:
+		if m.keyID != "" {
+			token.Header["kid"] = m.keyID
+		}
+		return token.SignedString(m.privateKey)
+	case SigningMethodRemote:
+		if m.keyID != "" {
+			token.Header["kid"] = m.keyID
+		}
+		return token.SignedString(m.remoteSigner)
+	default:
+		return token.SignedString(m.secret)
+	}
 }
 
-// ParseToken 解析令牌
-func (m *JWTManager) ParseToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+// verifyKey 解析令牌时按签名算法返回校验密钥
+// RS256/KMS 按 header 中的 kid 选择公钥，兼容无 kid 的令牌（轮换前签发）
+func (m *JWTManager) verifyKey(token *jwt.Token) (interface{}, error) {
+	if m.method != jwt.SigningMethodHS256 {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
-		return m.secret, nil
-	})
+		kid, _ := token.Header["kid"].(string)
+		if key, ok := m.publicKeys[kid]; ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf("unknown signing key id: %q", kid)
+	}
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, errors.New("unexpected signing method")
+	}
+	return m.secret, nil
+}
+
+// newTokenID 生成随机令牌唯一标识
+func newTokenID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// 随机源不可用时退化为时间戳
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+// ParseToken 解析令牌
+func (m *JWTManager) ParseToken(tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, m.verifyKey)
 
 	if err != nil {
 		return nil, err
@@ -114,6 +360,22 @@ func (m *JWTManager) ParseToken(tokenString string) (*Claims, error) {
 	return nil, errors.New("invalid token")
 }
 
+// ParseTokenAllowExpired 解析令牌但不校验有效期
+// 仅验证签名，用于排查工具查看已过期 token 的内容
+func (m *JWTManager) ParseTokenAllowExpired(tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, m.verifyKey, jwt.WithoutClaimsValidation())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*Claims); ok {
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid token")
+}
+
 // ValidateAccessToken 验证访问令牌
 func (m *JWTManager) ValidateAccessToken(tokenString string) (*Claims, error) {
 	claims, err := m.ParseToken(tokenString)
@@ -142,7 +404,45 @@ func (m *JWTManager) ValidateRefreshToken(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
+// ValidateSudoToken 验证二次密码确认令牌
+func (m *JWTManager) ValidateSudoToken(tokenString string) (*Claims, error) {
+	claims, err := m.ParseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Type != SudoToken {
+		return nil, errors.New("invalid token type")
+	}
+
+	return claims, nil
+}
+
+// ValidateMFAToken 验证两步验证 challenge 令牌
+func (m *JWTManager) ValidateMFAToken(tokenString string) (*Claims, error) {
+	claims, err := m.ParseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Type != MFAToken {
+		return nil, errors.New("invalid token type")
+	}
+
+	return claims, nil
+}
+
+// GetSudoTokenExpire 获取 sudo 令牌过期时间（秒）
+func (m *JWTManager) GetSudoTokenExpire() int64 {
+	return int64(sudoTokenExpire.Seconds())
+}
+
 // GetAccessTokenExpire 获取访问令牌过期时间（秒）
 func (m *JWTManager) GetAccessTokenExpire() int64 {
 	return int64(m.accessTokenExpire.Seconds())
 }
+
+// GetRefreshTokenExpire 获取刷新令牌过期时间（秒）
+func (m *JWTManager) GetRefreshTokenExpire() int64 {
+	return int64(m.refreshTokenExpire.Seconds())
+}
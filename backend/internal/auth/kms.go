@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"zera/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// RemoteSigner 外部签名器接口
+// 私钥保存在 KMS 中不落地，签名通过远程 API 完成；
+// 验签使用 KMS 导出的公钥在本地执行，不依赖 KMS 可用性
+type RemoteSigner interface {
+	// Sign 对签名输入（JWT header.payload）进行 RS256 签名
+	Sign(signingInput []byte) ([]byte, error)
+}
+
+// signingMethodRemote 委托外部签名器的 JWT 签名方法
+// Alg 返回 RS256，签出的令牌与标准 RS256 完全兼容，
+// 解析时按 alg 头命中标准 RS256 方法用公钥本地验签
+type signingMethodRemote struct{}
+
+// SigningMethodRemote 远程签名方法单例
+var SigningMethodRemote = &signingMethodRemote{}
+
+// Alg 返回算法标识
+func (m *signingMethodRemote) Alg() string {
+	return "RS256"
+}
+
+// Sign 调用外部签名器签名
+func (m *signingMethodRemote) Sign(signingString string, key interface{}) ([]byte, error) {
+	signer, ok := key.(RemoteSigner)
+	if !ok {
+		return nil, jwt.ErrInvalidKeyType
+	}
+	return signer.Sign([]byte(signingString))
+}
+
+// Verify 用本地公钥验签（与标准 RS256 一致）
+func (m *signingMethodRemote) Verify(signingString string, sig []byte, key interface{}) error {
+	return jwt.SigningMethodRS256.Verify(signingString, sig, key)
+}
+
+// VaultTransitSigner 基于 HashiCorp Vault Transit 引擎的签名器
+type VaultTransitSigner struct {
+	endpoint   string
+	token      string
+	keyName    string
+	mount      string
+	httpClient *http.Client
+}
+
+// NewVaultTransitSigner 创建 Vault Transit 签名器
+func NewVaultTransitSigner(cfg *config.JWTKMSConfig) *VaultTransitSigner {
+	mount := cfg.Mount
+	if mount == "" {
+		mount = "transit"
+	}
+	return &VaultTransitSigner{
+		endpoint:   strings.TrimSuffix(cfg.Endpoint, "/"),
+		token:      cfg.Token,
+		keyName:    cfg.KeyName,
+		mount:      mount,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Sign 调用 Vault Transit 的 sign 接口
+// Vault 侧完成 SHA-256 摘要和 PKCS#1 v1.5 签名（即 RS256）
+func (s *VaultTransitSigner) Sign(signingInput []byte) ([]byte, error) {
+	reqBody, err := json.Marshal(map[string]string{
+		"input":               base64.StdEncoding.EncodeToString(signingInput),
+		"hash_algorithm":      "sha2-256",
+		"signature_algorithm": "pkcs1v15",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	signURL := fmt.Sprintf("%s/v1/%s/sign/%s", s.endpoint, s.mount, s.keyName)
+	req, err := http.NewRequest(http.MethodPost, signURL, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", s.token)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault transit sign request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault transit sign returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	var signResp struct {
+		Data struct {
+			Signature string `json:"signature"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &signResp); err != nil {
+		return nil, err
+	}
+
+	// 签名格式: vault:v<版本>:<base64 签名>
+	parts := strings.SplitN(signResp.Data.Signature, ":", 3)
+	if len(parts) != 3 {
+		return nil, errors.New("unexpected vault signature format")
+	}
+	sig, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode vault signature: %w", err)
+	}
+
+	return sig, nil
+}
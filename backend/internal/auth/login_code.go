@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+const (
+	// LoginCodeTTL 登录验证码有效期
+	LoginCodeTTL = 5 * time.Minute
+	// loginCodeResendInterval 同一邮箱两次发送验证码的最小间隔
+	loginCodeResendInterval = time.Minute
+	// loginCodeMaxAttempts 单个验证码的最大校验次数，超过后作废防止爆破
+	loginCodeMaxAttempts = 5
+)
+
+var (
+	// ErrInvalidLoginCode 验证码不存在、错误或已过期
+	ErrInvalidLoginCode = errors.New("invalid or expired login code")
+	// ErrLoginCodeThrottled 发送过于频繁
+	ErrLoginCodeThrottled = errors.New("login code requested too frequently")
+)
+
+type loginCodeEntry struct {
+	code      string
+	issuedAt  time.Time
+	expiresAt time.Time
+	attempts  int
+}
+
+// LoginCodeStore 邮箱登录验证码存储（进程内，短 TTL）
+// 每个邮箱同一时间只有一个有效验证码，校验次数超限或校验通过后作废
+type LoginCodeStore struct {
+	mu    sync.Mutex
+	codes map[string]*loginCodeEntry
+}
+
+// NewLoginCodeStore 创建登录验证码存储
+func NewLoginCodeStore() *LoginCodeStore {
+	return &LoginCodeStore{
+		codes: make(map[string]*loginCodeEntry),
+	}
+}
+
+// Issue 为邮箱生成 6 位数字验证码，返回验证码和有效期
+// 距上次发送不足最小间隔时返回 ErrLoginCodeThrottled
+func (s *LoginCodeStore) Issue(email string) (string, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	// 顺带清理已过期的验证码，避免存储无限增长
+	for e, entry := range s.codes {
+		if now.After(entry.expiresAt) {
+			delete(s.codes, e)
+		}
+	}
+
+	if entry, ok := s.codes[email]; ok && now.Sub(entry.issuedAt) < loginCodeResendInterval {
+		return "", 0, ErrLoginCodeThrottled
+	}
+
+	code, err := randomDigits(6)
+	if err != nil {
+		return "", 0, err
+	}
+
+	s.codes[email] = &loginCodeEntry{
+		code:      code,
+		issuedAt:  now,
+		expiresAt: now.Add(LoginCodeTTL),
+	}
+	return code, LoginCodeTTL, nil
+}
+
+// Verify 校验邮箱验证码
+// 校验通过后验证码立即作废；连续校验失败超过上限也会作废，防止穷举
+func (s *LoginCodeStore) Verify(email, code string) error {
+	if code == "" {
+		return ErrInvalidLoginCode
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.codes[email]
+	if !ok {
+		return ErrInvalidLoginCode
+	}
+
+	if time.Now().After(entry.expiresAt) {
+		delete(s.codes, email)
+		return ErrInvalidLoginCode
+	}
+
+	if entry.code != code {
+		entry.attempts++
+		if entry.attempts >= loginCodeMaxAttempts {
+			delete(s.codes, email)
+		}
+		return ErrInvalidLoginCode
+	}
+
+	// 一次性语义：校验通过即作废
+	delete(s.codes, email)
+	return nil
+}
+
+// randomDigits 生成指定位数的随机数字字符串
+func randomDigits(n int) (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < n; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	v, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", n, v), nil
+}
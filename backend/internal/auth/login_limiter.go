@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// LoginRateLimiter 登录失败限流器
+// 按 IP+用户名 组合做滑动窗口计数，防止撞库绕过按用户名的账号锁定；
+// 当前提供内存实现，分布式部署可替换为 Redis 等共享存储实现
+type LoginRateLimiter interface {
+	// Allow 判断该 IP+用户名 组合当前是否允许继续尝试登录
+	Allow(ip, username string) bool
+	// RecordFailure 记录一次登录失败
+	RecordFailure(ip, username string)
+	// Reset 登录成功后清除该组合的失败记录
+	Reset(ip, username string)
+}
+
+// MemoryLoginRateLimiter 基于内存的登录失败限流器
+type MemoryLoginRateLimiter struct {
+	mu          sync.Mutex
+	window      time.Duration
+	maxFailures int
+	// failures 每个 IP+用户名 组合在窗口内的失败时间戳
+	failures map[string][]time.Time
+}
+
+// NewMemoryLoginRateLimiter 创建内存登录失败限流器
+func NewMemoryLoginRateLimiter(window time.Duration, maxFailures int) *MemoryLoginRateLimiter {
+	return &MemoryLoginRateLimiter{
+		window:      window,
+		maxFailures: maxFailures,
+		failures:    make(map[string][]time.Time),
+	}
+}
+
+// Allow 判断该 IP+用户名 组合当前是否允许继续尝试登录
+func (l *MemoryLoginRateLimiter) Allow(ip, username string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := limiterKey(ip, username)
+	recent := l.prune(key, time.Now())
+	return len(recent) < l.maxFailures
+}
+
+// RecordFailure 记录一次登录失败，顺带清理其他过期条目
+func (l *MemoryLoginRateLimiter) RecordFailure(ip, username string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	key := limiterKey(ip, username)
+	l.failures[key] = append(l.prune(key, now), now)
+}
+
+// Reset 登录成功后清除该组合的失败记录
+func (l *MemoryLoginRateLimiter) Reset(ip, username string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.failures, limiterKey(ip, username))
+}
+
+// prune 返回指定 key 在窗口内的失败时间戳，调用方须持有锁
+func (l *MemoryLoginRateLimiter) prune(key string, now time.Time) []time.Time {
+	cutoff := now.Add(-l.window)
+	recent := l.failures[key][:0]
+	for _, t := range l.failures[key] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) == 0 {
+		delete(l.failures, key)
+		return nil
+	}
+	l.failures[key] = recent
+	return recent
+}
+
+// sweep 清理所有已完全过期的条目，调用方须持有锁
+func (l *MemoryLoginRateLimiter) sweep(now time.Time) {
+	cutoff := now.Add(-l.window)
+	for key, times := range l.failures {
+		expired := true
+		for _, t := range times {
+			if t.After(cutoff) {
+				expired = false
+				break
+			}
+		}
+		if expired {
+			delete(l.failures, key)
+		}
+	}
+}
+
+// limiterKey 组合限流键，NAT 共享 IP 的不同用户互不影响
+func limiterKey(ip, username string) string {
+	return ip + "|" + username
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// nonceTTL nonce 有效期，获取后需在窗口内使用
+const nonceTTL = 5 * time.Minute
+
+// ErrInvalidNonce nonce 不存在、已使用或已过期
+var ErrInvalidNonce = errors.New("invalid or used nonce")
+
+type nonceEntry struct {
+	userID    int
+	expiresAt time.Time
+}
+
+// NonceStore 一次性 nonce 存储（进程内，短 TTL）
+// 敏感写接口要求请求携带 nonce 防止抓包重放，nonce 用后即作废
+type NonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]nonceEntry
+}
+
+// NewNonceStore 创建 nonce 存储
+func NewNonceStore() *NonceStore {
+	return &NonceStore{
+		nonces: make(map[string]nonceEntry),
+	}
+}
+
+// Issue 为用户签发一次性 nonce，返回 nonce 和有效期
+func (s *NonceStore) Issue(userID int) (string, time.Duration, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", 0, err
+	}
+	nonce := hex.EncodeToString(buf)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 顺带清理已过期的 nonce，避免存储无限增长
+	now := time.Now()
+	for n, entry := range s.nonces {
+		if now.After(entry.expiresAt) {
+			delete(s.nonces, n)
+		}
+	}
+
+	s.nonces[nonce] = nonceEntry{
+		userID:    userID,
+		expiresAt: now.Add(nonceTTL),
+	}
+	return nonce, nonceTTL, nil
+}
+
+// Consume 校验并消费 nonce
+// nonce 必须存在、未过期且属于同一用户；无论校验结果如何，命中即作废
+func (s *NonceStore) Consume(userID int, nonce string) error {
+	if nonce == "" {
+		return ErrInvalidNonce
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.nonces[nonce]
+	if !ok {
+		return ErrInvalidNonce
+	}
+	// 一次性语义：查到即删除，即使校验失败也不允许再次尝试
+	delete(s.nonces, nonce)
+
+	if time.Now().After(entry.expiresAt) || entry.userID != userID {
+		return ErrInvalidNonce
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcryptHashPrefix bcrypt 哈希的统一前缀（$2a$/$2b$ 等变体均以 $2 开头）
+// 旧版 SHA256 哈希为 64 位 hex 字符串，据此区分新旧格式
+const bcryptHashPrefix = "$2"
+
+// bcryptCost bcrypt 成本因子，通过 SetBcryptCost 由配置注入
+var bcryptCost = bcrypt.DefaultCost
+
+// SetBcryptCost 设置 bcrypt 成本因子
+// 超出合法范围（4-31）时回退到默认值，避免生成哈希时报错
+func SetBcryptCost(cost int) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		bcryptCost = bcrypt.DefaultCost
+		return
+	}
+	bcryptCost = cost
+}
+
+// HashPassword 使用 bcrypt 生成密码哈希
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// VerifyPassword 校验密码
+// 兼容旧版无盐 SHA256 哈希，调用方可结合 IsLegacyHash 在校验通过后升级哈希
+func VerifyPassword(password, hash string) bool {
+	if IsLegacyHash(hash) {
+		legacy := legacyHashPassword(password)
+		return subtle.ConstantTimeCompare([]byte(legacy), []byte(hash)) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// IsLegacyHash 判断是否为旧版 SHA256 哈希（需要升级为 bcrypt）
+func IsLegacyHash(hash string) bool {
+	return !strings.HasPrefix(hash, bcryptHashPrefix)
+}
+
+// legacyHashPassword 旧版无盐 SHA256 哈希，仅用于存量哈希的校验
+func legacyHashPassword(password string) string {
+	h := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(h[:])
+}
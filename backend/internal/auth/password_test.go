@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TestHashPasswordProducesBcrypt 新生成的哈希应为 bcrypt 格式且可通过校验
+func TestHashPasswordProducesBcrypt(t *testing.T) {
+	SetBcryptCost(bcrypt.MinCost)
+	defer SetBcryptCost(bcrypt.DefaultCost)
+
+	hash, err := HashPassword("secret123")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	if IsLegacyHash(hash) {
+		t.Errorf("新生成的哈希被识别为旧格式: %q", hash)
+	}
+	if !VerifyPassword("secret123", hash) {
+		t.Error("正确密码校验未通过")
+	}
+	if VerifyPassword("wrong-password", hash) {
+		t.Error("错误密码校验通过")
+	}
+}
+
+// TestVerifyPasswordMixedFormats 新旧混合场景：
+// 同一套校验入口需同时识别 bcrypt 哈希和旧版无盐 SHA256 哈希
+func TestVerifyPasswordMixedFormats(t *testing.T) {
+	SetBcryptCost(bcrypt.MinCost)
+	defer SetBcryptCost(bcrypt.DefaultCost)
+
+	bcryptHash, err := HashPassword("secret123")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+		want     bool
+	}{
+		{"bcrypt 正确密码", "secret123", bcryptHash, true},
+		{"bcrypt 错误密码", "wrong", bcryptHash, false},
+		{"旧 SHA256 正确密码", "secret123", legacyHashPassword("secret123"), true},
+		{"旧 SHA256 错误密码", "wrong", legacyHashPassword("secret123"), false},
+		// 旧哈希为 hex 字符串，直接拿密码明文当哈希不应通过
+		{"明文冒充哈希", "secret123", "secret123", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyPassword(tt.password, tt.hash); got != tt.want {
+				t.Errorf("VerifyPassword(%q, %q) = %v, want %v", tt.password, tt.hash, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestIsLegacyHash 旧版 SHA256 哈希需被识别出来，登录后触发升级
+func TestIsLegacyHash(t *testing.T) {
+	if !IsLegacyHash(legacyHashPassword("secret123")) {
+		t.Error("旧 SHA256 哈希未被识别为旧格式")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt.GenerateFromPassword: %v", err)
+	}
+	if IsLegacyHash(string(hash)) {
+		t.Error("bcrypt 哈希被误识别为旧格式")
+	}
+}
+
+// TestSetBcryptCostRange 非法成本因子应回退到默认值，避免生成哈希时报错
+func TestSetBcryptCostRange(t *testing.T) {
+	defer SetBcryptCost(bcrypt.DefaultCost)
+
+	SetBcryptCost(bcrypt.MaxCost + 1)
+	if bcryptCost != bcrypt.DefaultCost {
+		t.Errorf("超出上限的成本因子未回退: %d", bcryptCost)
+	}
+
+	SetBcryptCost(bcrypt.MinCost)
+	if bcryptCost != bcrypt.MinCost {
+		t.Errorf("合法成本因子未生效: %d", bcryptCost)
+	}
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// TokenRevoker 令牌吊销接口（黑名单）
+// 登出的 access token 在自然过期前加入黑名单，拦截器校验 JWT 后查询黑名单
+// 默认为进程内实现，分布式部署可替换为 Redis 等共享存储实现
+type TokenRevoker interface {
+	// Revoke 吊销令牌，expiresAt 为令牌自然过期时间（黑名单条目到期后自动清理）
+	Revoke(token string, expiresAt time.Time) error
+	// IsRevoked 令牌是否已被吊销
+	IsRevoked(token string) bool
+}
+
+// MemoryTokenRevoker 进程内令牌黑名单
+// 以令牌摘要为键，条目保留到令牌自然过期，写入时顺带清理过期条目
+type MemoryTokenRevoker struct {
+	mu      sync.RWMutex
+	revoked map[string]time.Time
+}
+
+// NewMemoryTokenRevoker 创建进程内令牌黑名单
+func NewMemoryTokenRevoker() *MemoryTokenRevoker {
+	return &MemoryTokenRevoker{
+		revoked: make(map[string]time.Time),
+	}
+}
+
+// Revoke 吊销令牌，已过期的令牌直接忽略
+func (r *MemoryTokenRevoker) Revoke(token string, expiresAt time.Time) error {
+	now := time.Now()
+	if !expiresAt.After(now) {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// 顺带清理已到期的黑名单条目，避免内存无限增长
+	for k, exp := range r.revoked {
+		if now.After(exp) {
+			delete(r.revoked, k)
+		}
+	}
+
+	r.revoked[tokenDigest(token)] = expiresAt
+	return nil
+}
+
+// IsRevoked 令牌是否已被吊销（条目到期视为未吊销，令牌本身也已过期）
+func (r *MemoryTokenRevoker) IsRevoked(token string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	exp, ok := r.revoked[tokenDigest(token)]
+	return ok && time.Now().Before(exp)
+}
+
+// tokenDigest 计算令牌摘要，避免在内存中保留完整令牌
+func tokenDigest(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,66 @@
+// Package cache 提供轻量级的进程内缓存实现
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// CountCache 查询总数的短 TTL 缓存
+// 用于避免在大表上重复执行高开销的 COUNT 查询，
+// 适合对精确度要求不高的列表总数展示场景
+type CountCache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	entries map[string]countEntry
+}
+
+type countEntry struct {
+	count     int
+	expiresAt time.Time
+}
+
+// NewCountCache 创建总数缓存，ttl 为缓存有效期
+func NewCountCache(ttl time.Duration) *CountCache {
+	return &CountCache{
+		ttl:     ttl,
+		entries: make(map[string]countEntry),
+	}
+}
+
+// Get 获取缓存的总数，过期或不存在时返回 false
+func (c *CountCache) Get(key string) (int, bool) {
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+	if !ok || time.Now().After(entry.expiresAt) {
+		return 0, false
+	}
+	return entry.count, true
+}
+
+// Set 写入总数缓存
+func (c *CountCache) Set(key string, count int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 顺带清理已过期的条目，避免缓存无限增长
+	now := time.Now()
+	for k, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+
+	c.entries[key] = countEntry{
+		count:     count,
+		expiresAt: now.Add(c.ttl),
+	}
+}
+
+// Clear 清空全部缓存（数据变更后调用）
+func (c *CountCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]countEntry)
+}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// onlineRetention 活跃记录的最长保留时间，超过后在查询时清理
+const onlineRetention = 24 * time.Hour
+
+// OnlineTracker 用户活跃时间追踪器
+// 由权限拦截器在每次认证请求后更新，用于查询最近活跃的在线用户
+type OnlineTracker struct {
+	mu       sync.RWMutex
+	lastSeen map[int]time.Time
+}
+
+// NewOnlineTracker 创建在线用户追踪器
+func NewOnlineTracker() *OnlineTracker {
+	return &OnlineTracker{
+		lastSeen: make(map[int]time.Time),
+	}
+}
+
+// Touch 更新用户的最近活跃时间
+func (t *OnlineTracker) Touch(userID int) {
+	if userID <= 0 {
+		return
+	}
+	t.mu.Lock()
+	t.lastSeen[userID] = time.Now()
+	t.mu.Unlock()
+}
+
+// ActiveSince 返回窗口期内有活跃请求的用户及其最近活跃时间
+// 顺带清理超过保留期的陈旧记录，避免追踪表无限增长
+func (t *OnlineTracker) ActiveSince(window time.Duration) map[int]time.Time {
+	now := time.Now()
+	cutoff := now.Add(-window)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	active := make(map[int]time.Time)
+	for id, seen := range t.lastSeen {
+		if now.Sub(seen) > onlineRetention {
+			delete(t.lastSeen, id)
+			continue
+		}
+		if !seen.Before(cutoff) {
+			active[id] = seen
+		}
+	}
+	return active
+}
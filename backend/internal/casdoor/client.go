@@ -48,11 +48,12 @@ type Client struct {
 	config      *Config
 	initialized bool
 	client      *casdoorsdk.Client
+	http        *traceHTTPClient
 }
 
 // NewClient 创建 Casdoor 客户端
 func NewClient() *Client {
-	return &Client{}
+	return &Client{http: newTraceHTTPClient()}
 }
 
 // Init 使用配置初始化 SDK
@@ -96,6 +97,10 @@ func (c *Client) Init(config *Config) error {
 		config.Application,
 	)
 
+	// SDK 的出站请求走包级 HttpClient，替换为注入 trace 上下文的实现，
+	// 使 Casdoor 侧日志能与 zera 的 trace 关联
+	casdoorsdk.SetHttpClient(c.http)
+
 	c.client = client
 	c.initialized = true
 
@@ -131,6 +136,10 @@ func (c *Client) GetUser(ctx context.Context, username string) (*casdoorsdk.User
 		return nil, ErrClientNotInitialized
 	}
 
+	// 绑定 context 使出站请求携带当前 trace 上下文
+	release := c.http.bind(ctx)
+	defer release()
+
 	user, err := c.client.GetUser(username)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user from casdoor: %w", err)
@@ -163,6 +172,10 @@ func (c *Client) AddUserAndGetID(ctx context.Context, user *User) (string, error
 		return "", ErrSyncDisabled
 	}
 
+	// 绑定 context 使出站请求携带当前 trace 上下文
+	release := c.http.bind(ctx)
+	defer release()
+
 	logger.Debug("attempting to add user to casdoor",
 		"username", user.Username,
 		"organization", c.config.Organization,
@@ -233,6 +246,10 @@ func (c *Client) UpdateUser(ctx context.Context, user *User) error {
 		return ErrSyncDisabled
 	}
 
+	// 绑定 context 使出站请求携带当前 trace 上下文
+	release := c.http.bind(ctx)
+	defer release()
+
 	// 获取现有用户
 	existingUser, err := c.client.GetUser(user.Username)
 	if err != nil {
@@ -289,6 +306,10 @@ func (c *Client) DeleteUser(ctx context.Context, username string) error {
 		return ErrSyncDisabled
 	}
 
+	// 绑定 context 使出站请求携带当前 trace 上下文
+	release := c.http.bind(ctx)
+	defer release()
+
 	// 获取现有用户
 	existingUser, err := c.client.GetUser(username)
 	if err != nil {
@@ -332,6 +353,10 @@ func (c *Client) UpdatePassword(ctx context.Context, username, newPassword strin
 		return ErrSyncDisabled
 	}
 
+	// 绑定 context 使出站请求携带当前 trace 上下文
+	release := c.http.bind(ctx)
+	defer release()
+
 	// 获取现有用户
 	existingUser, err := c.client.GetUser(username)
 	if err != nil {
@@ -361,6 +386,61 @@ func (c *Client) UpdatePassword(ctx context.Context, username, newPassword strin
 	return nil
 }
 
+// SetUserForbidden 设置 Casdoor 用户的封禁状态
+// 本地封禁/解封用户时调用，保持两侧登录状态一致
+func (c *Client) SetUserForbidden(ctx context.Context, username string, forbidden bool) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.initialized {
+		return ErrClientNotInitialized
+	}
+
+	if c.config == nil || !c.config.SyncEnabled {
+		return ErrSyncDisabled
+	}
+
+	// 绑定 context 使出站请求携带当前 trace 上下文
+	release := c.http.bind(ctx)
+	defer release()
+
+	// 获取现有用户
+	existingUser, err := c.client.GetUser(username)
+	if err != nil {
+		return fmt.Errorf("failed to get user from casdoor: %w", err)
+	}
+
+	if existingUser == nil || existingUser.Name == "" {
+		logger.Warn("user not found in casdoor, cannot set forbidden",
+			"username", username,
+		)
+		return ErrUserNotFound
+	}
+
+	if existingUser.IsForbidden == forbidden {
+		logger.Debug("casdoor user forbidden state already in sync",
+			"username", username,
+			"forbidden", forbidden,
+		)
+		return nil
+	}
+
+	existingUser.IsForbidden = forbidden
+
+	// 调用 SDK 更新用户
+	_, err = c.client.UpdateUser(existingUser)
+	if err != nil {
+		return fmt.Errorf("failed to update user forbidden state in casdoor: %w", err)
+	}
+
+	logger.Info("user forbidden state updated in casdoor successfully",
+		"username", username,
+		"forbidden", forbidden,
+	)
+
+	return nil
+}
+
 // mapToCasdoorUser 将 Zera User 映射为 Casdoor User
 func (c *Client) mapToCasdoorUser(user *User) *casdoorsdk.User {
 	return &casdoorsdk.User{
@@ -0,0 +1,61 @@
+package casdoor
+
+import (
+	"sync"
+)
+
+// Route 按部门路由到独立 Casdoor 组织的规则
+type Route struct {
+	// Department 用户所属部门名称，精确匹配
+	Department string
+	// Config 该部门对应的 Casdoor 连接配置
+	Config *Config
+}
+
+// Router 多 Casdoor 组织路由器
+// 大型部署可按用户部门将同步请求路由到不同的 Casdoor 组织，
+// 未配置路由或未命中规则时使用默认客户端，单组织场景行为不变
+type Router struct {
+	mu            sync.RWMutex
+	defaultClient *Client
+	clients       map[string]*Client
+}
+
+// NewRouter 创建路由器，defaultClient 为未命中规则时使用的默认客户端
+func NewRouter(defaultClient *Client) *Router {
+	return &Router{
+		defaultClient: defaultClient,
+		clients:       make(map[string]*Client),
+	}
+}
+
+// SetRoutes 设置路由规则，为每条规则初始化独立的客户端实例
+func (r *Router) SetRoutes(routes []Route) error {
+	clients := make(map[string]*Client, len(routes))
+	for _, route := range routes {
+		if route.Department == "" || route.Config == nil {
+			continue
+		}
+		c := NewClient()
+		if err := c.Init(route.Config); err != nil {
+			return err
+		}
+		clients[route.Department] = c
+	}
+
+	r.mu.Lock()
+	r.clients = clients
+	r.mu.Unlock()
+	return nil
+}
+
+// ClientFor 按部门名称选择客户端，未命中规则时返回默认客户端
+func (r *Router) ClientFor(department string) *Client {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if c, ok := r.clients[department]; ok {
+		return c
+	}
+	return r.defaultClient
+}
@@ -0,0 +1,50 @@
+package casdoor
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// traceHTTPClient 在 Casdoor 出站请求头中注入当前 trace 上下文
+// 使 Casdoor 侧的访问日志能与 zera 的 trace 关联，实现端到端追踪
+//
+// casdoor-go-sdk 的方法不接收 context，请求也不携带调用方上下文，
+// 因此无法从 req.Context() 取到 trace。这里由调用方在发起 SDK 调用前
+// 通过 bind 绑定当前请求的 context，Do 注入时从绑定的 context 读取；
+// 绑定期间持有互斥锁，出站 Casdoor 请求串行执行。用户同步调用量很低，
+// 串行化不构成瓶颈
+type traceHTTPClient struct {
+	mu   sync.Mutex
+	ctx  context.Context
+	base *http.Client
+}
+
+// newTraceHTTPClient 创建注入 trace 上下文的 HTTP 客户端
+func newTraceHTTPClient() *traceHTTPClient {
+	return &traceHTTPClient{base: &http.Client{}}
+}
+
+// bind 绑定当前调用的 context 并返回解绑函数
+// 返回的函数须在 SDK 调用完成后执行（通常配合 defer 使用）
+func (t *traceHTTPClient) bind(ctx context.Context) func() {
+	t.mu.Lock()
+	t.ctx = ctx
+	return func() {
+		t.ctx = nil
+		t.mu.Unlock()
+	}
+}
+
+// Do 实现 casdoorsdk.HttpClient 接口
+// 请求发出前将绑定 context 中的 trace 上下文写入请求头
+// （未启用 telemetry 时全局 propagator 为空实现，注入为 no-op）
+func (t *traceHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	if t.ctx != nil {
+		otel.GetTextMapPropagator().Inject(t.ctx, propagation.HeaderCarrier(req.Header))
+	}
+	return t.base.Do(req)
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+
+	"zera/internal/config"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/cobra"
+)
+
+var configFile string
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "配置文件工具",
+	Long:  `校验配置文件和查看最终生效配置（环境变量覆盖后）。`,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "校验配置文件",
+	Long: `加载配置（含环境变量覆盖）并逐项检查，打印每个检查项的通过/失败和原因。
+
+有任一检查未通过时退出码为 1，便于部署脚本判断。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadCLIConfig()
+		results := cfg.Validate()
+
+		failed := 0
+		for _, r := range results {
+			if r.OK {
+				fmt.Printf("  ✓ %s\n", r.Name)
+			} else {
+				failed++
+				fmt.Printf("  ✗ %s: %s\n", r.Name, r.Message)
+			}
+		}
+
+		if failed > 0 {
+			checkError(fmt.Errorf("%d/%d 项检查未通过", failed, len(results)))
+		}
+		fmt.Printf("全部 %d 项检查通过\n", len(results))
+	},
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "打印最终生效配置（脱敏）",
+	Long:  `打印环境变量覆盖后的最终配置，密码和密钥已脱敏，用于确认覆盖是否生效。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadCLIConfig()
+
+		data, err := toml.Marshal(cfg.Redacted())
+		if err != nil {
+			checkError(fmt.Errorf("序列化配置失败: %w", err))
+		}
+		fmt.Print(string(data))
+	},
+}
+
+var configDefaultsCmd = &cobra.Command{
+	Use:   "defaults",
+	Short: "生成带注释的默认配置样例",
+	Long: `反射遍历配置结构，输出包含全部字段、默认值和对应环境变量名的样例 config.toml。
+
+可重定向到文件作为新部署的配置起点: zera config defaults > config.toml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Print(config.DefaultsTOML())
+	},
+}
+
+// loadCLIConfig 加载配置，--file 指定时从该路径加载
+func loadCLIConfig() *config.Config {
+	if configFile != "" {
+		return config.LoadFromPath(configFile)
+	}
+	return config.Load()
+}
+
+func init() {
+	configCmd.PersistentFlags().StringVarP(&configFile, "file", "f", "", "配置文件路径（默认按内置顺序查找）")
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configDefaultsCmd)
+}
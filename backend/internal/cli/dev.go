@@ -31,7 +31,8 @@ var (
 	devFrontendOnly bool
 	devBackendOnly  bool
 	devProjectRoot  string
-	devNoTUI        bool // 禁用 TUI 模式
+	devNoTUI        bool   // 禁用 TUI 模式
+	devBuildMode    string // 后端构建策略: run(go run) 或 prebuild(编译缓存)
 )
 
 // devCmd dev 命令
@@ -65,6 +66,7 @@ var devCmd = &cobra.Command{
   zera dev --no-tui     # 启动前端和后端 (传统模式)
   zera dev --frontend   # 仅启动前端
   zera dev --backend    # 仅启动后端
+  zera dev --build-mode prebuild    # 后端预编译为二进制，源码未变时重启直接复用
   zera dev --root /path/to/project  # 指定项目根目录`,
 	RunE: runDev,
 }
@@ -74,6 +76,8 @@ func init() {
 	devCmd.Flags().BoolVarP(&devBackendOnly, "backend", "b", false, "仅启动后端开发服务器")
 	devCmd.Flags().StringVarP(&devProjectRoot, "root", "r", "", "项目根目录 (默认: 当前目录或自动检测)")
 	devCmd.Flags().BoolVar(&devNoTUI, "no-tui", false, "禁用 TUI 模式，使用传统流式输出")
+	devCmd.Flags().StringVar(&devBuildMode, "build-mode", BuildModeRun,
+		"后端构建策略: run(每次 go run) 或 prebuild(编译缓存二进制，源码未变时重启免编译)")
 }
 
 // runDev 运行开发服务器
@@ -84,6 +88,10 @@ func runDev(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("无法检测项目根目录: %w", err)
 	}
 
+	if err := validateBuildMode(devBuildMode); err != nil {
+		return err
+	}
+
 	if verbose {
 		fmt.Printf("📁 项目根目录: %s\n", root)
 	}
@@ -372,7 +380,21 @@ func (m *devServiceManager) runBackendService() error {
 
 	m.tui.SendLog(TabBackend, "🚀 启动后端开发服务器...")
 	m.tui.SendLog(TabBackend, fmt.Sprintf("   目录: %s", backendDir))
-	m.tui.SendLog(TabBackend, "   命令: go run ./cmd/server")
+
+	// 按构建策略确定启动命令
+	var binPath string
+	if devBuildMode == BuildModePrebuild {
+		m.tui.SendLog(TabBackend, "   命令: "+backendBinaryPath(backendDir))
+		var err error
+		binPath, err = prepareBackendBinary(backendDir, func(format string, args ...interface{}) {
+			m.tui.SendLog(TabBackend, fmt.Sprintf(format, args...))
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		m.tui.SendLog(TabBackend, "   命令: go run ./cmd/server")
+	}
 
 	// 检测 go 是否可用
 	goPath, err := exec.LookPath("go")
@@ -383,7 +405,12 @@ func (m *devServiceManager) runBackendService() error {
 	// 创建独立的上下文
 	ctx, cancel := context.WithCancel(m.mainCtx)
 
-	cmd := exec.CommandContext(ctx, goPath, "run", "./cmd/server")
+	var cmd *exec.Cmd
+	if binPath != "" {
+		cmd = exec.CommandContext(ctx, binPath)
+	} else {
+		cmd = exec.CommandContext(ctx, goPath, "run", "./cmd/server")
+	}
 	cmd.Dir = backendDir
 	cmd.Env = os.Environ()
 
@@ -728,7 +755,23 @@ func runBackend(ctx context.Context, root string) error {
 	fmt.Println("🚀 启动后端开发服务器...")
 	if verbose {
 		fmt.Printf("   目录: %s\n", backendDir)
-		fmt.Println("   命令: go run ./cmd/server")
+		if devBuildMode == BuildModePrebuild {
+			fmt.Printf("   命令: %s\n", backendBinaryPath(backendDir))
+		} else {
+			fmt.Println("   命令: go run ./cmd/server")
+		}
+	}
+
+	// 按构建策略确定启动命令
+	var binPath string
+	if devBuildMode == BuildModePrebuild {
+		var err error
+		binPath, err = prepareBackendBinary(backendDir, func(format string, args ...interface{}) {
+			fmt.Printf("\033[33m[backend]\033[0m  "+format+"\n", args...)
+		})
+		if err != nil {
+			return err
+		}
 	}
 
 	// 检测 go 是否可用
@@ -737,7 +780,12 @@ func runBackend(ctx context.Context, root string) error {
 		return fmt.Errorf("未找到 go，请先安装 Go: https://go.dev")
 	}
 
-	cmd := exec.CommandContext(ctx, goPath, "run", "./cmd/server")
+	var cmd *exec.Cmd
+	if binPath != "" {
+		cmd = exec.CommandContext(ctx, binPath)
+	} else {
+		cmd = exec.CommandContext(ctx, goPath, "run", "./cmd/server")
+	}
 	cmd.Dir = backendDir
 	cmd.Env = os.Environ()
 
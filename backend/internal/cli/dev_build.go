@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// 后端构建策略
+const (
+	// BuildModeRun 每次用 go run 启动（默认，行为与旧版一致）
+	BuildModeRun = "run"
+	// BuildModePrebuild 先 go build 出二进制再运行，源码未变时重启直接复用
+	BuildModePrebuild = "prebuild"
+)
+
+// devBuildCacheDir 预构建二进制的缓存目录（相对 backend 目录）
+const devBuildCacheDir = ".zera-cache"
+
+// validateBuildMode 校验构建策略取值
+func validateBuildMode(mode string) error {
+	switch mode {
+	case BuildModeRun, BuildModePrebuild:
+		return nil
+	default:
+		return fmt.Errorf("无效的构建策略: %s (可选: %s, %s)", mode, BuildModeRun, BuildModePrebuild)
+	}
+}
+
+// backendBinaryPath 预构建二进制的路径
+func backendBinaryPath(backendDir string) string {
+	name := "server"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return filepath.Join(backendDir, devBuildCacheDir, name)
+}
+
+// prepareBackendBinary 准备后端二进制
+// 缓存的二进制比所有源码文件新时直接复用，否则重新 go build；
+// logf 用于输出进度信息（TUI 或终端）
+func prepareBackendBinary(backendDir string, logf func(format string, args ...interface{})) (string, error) {
+	binPath := backendBinaryPath(backendDir)
+
+	srcTime, err := latestBackendSourceTime(backendDir)
+	if err != nil {
+		return "", fmt.Errorf("扫描源码文件失败: %w", err)
+	}
+
+	if info, err := os.Stat(binPath); err == nil && info.ModTime().After(srcTime) {
+		logf("♻️  源码未变化，复用缓存的二进制: %s", binPath)
+		return binPath, nil
+	}
+
+	goPath, err := exec.LookPath("go")
+	if err != nil {
+		return "", fmt.Errorf("未找到 go，请先安装 Go: https://go.dev")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(binPath), 0o755); err != nil {
+		return "", err
+	}
+
+	logf("🔨 编译后端二进制: go build -o %s ./cmd/server", binPath)
+	start := time.Now()
+
+	cmd := exec.Command(goPath, "build", "-o", binPath, "./cmd/server")
+	cmd.Dir = backendDir
+	cmd.Env = os.Environ()
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if len(output) > 0 {
+			for _, line := range strings.Split(strings.TrimRight(string(output), "\n"), "\n") {
+				logf("%s", line)
+			}
+		}
+		return "", fmt.Errorf("编译失败: %w", err)
+	}
+
+	logf("✅ 编译完成 (耗时 %s)", time.Since(start).Round(time.Millisecond))
+	return binPath, nil
+}
+
+// latestBackendSourceTime 计算后端源码的最新修改时间
+// 覆盖 .go 文件和 go.mod/go.sum/config.toml，跳过缓存目录和隐藏目录
+func latestBackendSourceTime(backendDir string) (time.Time, error) {
+	var latest time.Time
+
+	err := filepath.WalkDir(backendDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			// 跳过缓存目录和隐藏目录（如 .git），根目录本身除外
+			if path != backendDir && (name == devBuildCacheDir || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		name := d.Name()
+		if !strings.HasSuffix(name, ".go") &&
+			name != "go.mod" && name != "go.sum" && name != "config.toml" {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return latest, nil
+}
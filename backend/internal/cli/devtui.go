@@ -29,12 +29,29 @@ const (
 	StatusFailed                        // 启动失败
 )
 
+// LogLevel 日志级别（用于按级别过滤显示）
+type LogLevel int
+
+const (
+	LevelUnknown LogLevel = iota // 未识别出级别
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
 // 日志消息类型
 type logMsg struct {
 	source  Tab // TabFrontend 或 TabBackend
 	content string
 }
 
+// logLine 带级别元数据的日志行
+type logLine struct {
+	text  string
+	level LogLevel
+}
+
 // serviceStatusMsg 服务状态变更消息
 type serviceStatusMsg struct {
 	service Tab           // TabFrontend 或 TabBackend
@@ -55,12 +72,15 @@ type DevTUI struct {
 	tabs      []string
 
 	// 日志缓冲区
-	allLogs      []string
-	frontendLogs []string
-	backendLogs  []string
+	allLogs      []logLine
+	frontendLogs []logLine
+	backendLogs  []logLine
 	maxLogLines  int
 	logMutex     sync.Mutex
 
+	// 日志级别过滤，LevelUnknown 表示不过滤
+	filterLevel LogLevel
+
 	// UI 状态
 	width        int
 	height       int
@@ -186,9 +206,9 @@ func NewDevTUI() *DevTUI {
 		tabs:           []string{"All", "Frontend", "Backend"},
 		activeTab:      TabAll,
 		maxLogLines:    10000, // 最大保留日志行数
-		allLogs:        make([]string, 0, 1000),
-		frontendLogs:   make([]string, 0, 1000),
-		backendLogs:    make([]string, 0, 1000),
+		allLogs:        make([]logLine, 0, 1000),
+		frontendLogs:   make([]logLine, 0, 1000),
+		backendLogs:    make([]logLine, 0, 1000),
 		autoScroll:     true,
 		logChan:        make(chan logMsg, 100),
 		statusChan:     make(chan serviceStatusMsg, 10),
@@ -395,6 +415,21 @@ func (m *DevTUI) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.viewport.GotoBottom()
 		}
 
+	case "f":
+		// 循环切换日志级别过滤: 全部 → warn → error → 全部
+		switch m.filterLevel {
+		case LevelUnknown:
+			m.filterLevel = LevelWarn
+		case LevelWarn:
+			m.filterLevel = LevelError
+		default:
+			m.filterLevel = LevelUnknown
+		}
+		m.updateViewportContent()
+		if m.autoScroll {
+			m.viewport.GotoBottom()
+		}
+
 	case "C":
 		// 清空当前 Tab 日志
 		m.clearCurrentLogs()
@@ -476,6 +511,9 @@ func (m *DevTUI) addLog(log logMsg) {
 			continue
 		}
 
+		// 推断日志级别，作为元数据附带在行上用于过滤
+		level := detectLogLevel(line)
+
 		// 根据来源添加前缀和颜色
 		var formattedLine string
 		if m.copyMode {
@@ -501,12 +539,13 @@ func (m *DevTUI) addLog(log logMsg) {
 		}
 
 		// 添加到对应日志列表
-		m.allLogs = append(m.allLogs, formattedLine)
+		entry := logLine{text: formattedLine, level: level}
+		m.allLogs = append(m.allLogs, entry)
 		switch log.source {
 		case TabFrontend:
-			m.frontendLogs = append(m.frontendLogs, formattedLine)
+			m.frontendLogs = append(m.frontendLogs, entry)
 		case TabBackend:
-			m.backendLogs = append(m.backendLogs, formattedLine)
+			m.backendLogs = append(m.backendLogs, entry)
 		}
 
 		// 限制日志行数
@@ -529,28 +568,47 @@ func (m *DevTUI) clearCurrentLogs() {
 
 	switch m.activeTab {
 	case TabAll:
-		m.allLogs = make([]string, 0, 1000)
+		m.allLogs = make([]logLine, 0, 1000)
+	case TabFrontend:
+		m.frontendLogs = make([]logLine, 0, 1000)
+	case TabBackend:
+		m.backendLogs = make([]logLine, 0, 1000)
+	}
+}
+
+// currentLogs 获取当前 Tab 的日志列表
+// 调用方需持有 logMutex
+func (m *DevTUI) currentLogs() []logLine {
+	switch m.activeTab {
 	case TabFrontend:
-		m.frontendLogs = make([]string, 0, 1000)
+		return m.frontendLogs
 	case TabBackend:
-		m.backendLogs = make([]string, 0, 1000)
+		return m.backendLogs
+	default:
+		return m.allLogs
 	}
 }
 
+// visibleLogs 应用级别过滤后返回可见的日志文本
+// 解析不出级别的行默认显示
+// 调用方需持有 logMutex
+func (m *DevTUI) visibleLogs(logs []logLine) []string {
+	lines := make([]string, 0, len(logs))
+	for _, l := range logs {
+		if m.filterLevel != LevelUnknown && l.level != LevelUnknown && l.level < m.filterLevel {
+			continue
+		}
+		lines = append(lines, l.text)
+	}
+	return lines
+}
+
 // updateViewportContent 更新视口内容
 func (m *DevTUI) updateViewportContent() {
 	m.logMutex.Lock()
 	defer m.logMutex.Unlock()
 
-	var logs []string
-	switch m.activeTab {
-	case TabAll:
-		logs = m.allLogs
-	case TabFrontend:
-		logs = m.frontendLogs
-	case TabBackend:
-		logs = m.backendLogs
-	}
+	logs := m.visibleLogs(m.currentLogs())
 
 	content := strings.Join(logs, "\n")
 	m.viewport.SetContent(content)
@@ -585,15 +643,7 @@ func (m *DevTUI) viewCopyMode() string {
 
 	// 日志内容（纯文本）
 	m.logMutex.Lock()
-	var logs []string
-	switch m.activeTab {
-	case TabAll:
-		logs = m.allLogs
-	case TabFrontend:
-		logs = m.frontendLogs
-	case TabBackend:
-		logs = m.backendLogs
-	}
+	logs := m.visibleLogs(m.currentLogs())
 	m.logMutex.Unlock()
 
 	// 显示最后 N 行（根据窗口高度）
@@ -772,6 +822,16 @@ func (m *DevTUI) renderSidebar(height int) string {
 		lines = append(lines, statusStyle.Render("📜 Manual"))
 	}
 
+	// 日志级别过滤状态
+	switch m.filterLevel {
+	case LevelWarn:
+		lines = append(lines, statusStartingStyle.Render("🔍 >= warn"))
+	case LevelError:
+		lines = append(lines, statusFailedStyle.Render("🔍 >= error"))
+	default:
+		lines = append(lines, statusStyle.Render("🔍 全部级别"))
+	}
+
 	// 填充空行
 	for len(lines) < height {
 		lines = append(lines, "")
@@ -858,17 +918,9 @@ func (m *DevTUI) renderRunnerStatusCompact() string {
 
 // renderStatusBar 渲染状态栏
 func (m *DevTUI) renderStatusBar() string {
-	// 获取当前日志行数
-	var logCount int
+	// 获取当前可见日志行数
 	m.logMutex.Lock()
-	switch m.activeTab {
-	case TabAll:
-		logCount = len(m.allLogs)
-	case TabFrontend:
-		logCount = len(m.frontendLogs)
-	case TabBackend:
-		logCount = len(m.backendLogs)
-	}
+	logCount := len(m.visibleLogs(m.currentLogs()))
 	m.logMutex.Unlock()
 
 	// 滚动位置信息
@@ -881,9 +933,9 @@ func (m *DevTUI) renderStatusBar() string {
 	// 帮助提示
 	var helpText string
 	if m.showHelp {
-		helpText = "j/k:切换Tab | Ctrl+j/k:滚动 | d/u:半页 | g/G:顶/底 | r:重启 | c:复制 | C:清空 | q:退出"
+		helpText = "j/k:切换Tab | Ctrl+j/k:滚动 | d/u:半页 | g/G:顶/底 | f:级别过滤 | r:重启 | c:复制 | C:清空 | q:退出"
 	} else {
-		helpText = "? 帮助 | j/k 切换 | r 重启 | q 退出"
+		helpText = "? 帮助 | j/k 切换 | f 过滤 | r 重启 | q 退出"
 	}
 
 	leftStatus := statusStyle.Render(scrollInfo)
@@ -897,23 +949,67 @@ func (m *DevTUI) renderStatusBar() string {
 	return leftStatus + strings.Repeat(" ", gap) + rightStatus
 }
 
+// detectLogLevel 从日志行中推断日志级别
+// 支持 `level=error`、`[ERROR]`、`ERROR:` 等常见格式，无法识别时返回 LevelUnknown
+func detectLogLevel(line string) LogLevel {
+	lower := strings.ToLower(stripAnsi(line))
+
+	patterns := []struct {
+		level    LogLevel
+		keywords []string
+	}{
+		{LevelError, []string{"level=error", "[error]", "error:", " error ", "err!"}},
+		{LevelWarn, []string{"level=warn", "[warn]", "[warning]", "warn:", "warning:", " warn "}},
+		{LevelInfo, []string{"level=info", "[info]", "info:", " info "}},
+		{LevelDebug, []string{"level=debug", "[debug]", "debug:", " debug "}},
+	}
+
+	for _, p := range patterns {
+		for _, kw := range p.keywords {
+			if strings.Contains(lower, kw) {
+				return p.level
+			}
+		}
+	}
+
+	return LevelUnknown
+}
+
 // stripAnsi 移除 ANSI 转义序列
+// 完整解析 CSI 序列（\033[ 后跟参数字节，直到 @-~ 范围内的终止字节），
+// 颜色（m 结尾）之外的光标移动、清屏等序列（如 \033[2J、\033[K）也能正确跳过
 func stripAnsi(str string) string {
 	var result strings.Builder
-	inEscape := false
 
 	for i := 0; i < len(str); i++ {
-		if str[i] == '\033' {
-			inEscape = true
+		if str[i] != '\033' {
+			result.WriteByte(str[i])
 			continue
 		}
-		if inEscape {
-			if str[i] == 'm' {
-				inEscape = false
+
+		// ESC 在行尾，直接丢弃
+		if i+1 >= len(str) {
+			break
+		}
+
+		if str[i+1] == '[' {
+			// CSI 序列: ESC [ 参数/中间字节(0x20-0x3F) 终止字节(0x40-0x7E)
+			j := i + 2
+			for j < len(str) && str[j] >= 0x20 && str[j] <= 0x3F {
+				j++
+			}
+			if j < len(str) {
+				// 跳过终止字节
+				i = j
+			} else {
+				// 序列不完整（被截断），丢弃剩余部分
+				i = len(str)
 			}
 			continue
 		}
-		result.WriteByte(str[i])
+
+		// 其他两字节转义序列（如 ESC c 复位），跳过 ESC 后的单个字节
+		i++
 	}
 
 	return result.String()
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"zera/internal/config"
+	"zera/internal/database"
+
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "手动执行数据库 schema 迁移",
+	Long: `按当前代码中的 ent schema 迁移数据库表结构。
+
+生产环境默认不自动迁移（database.auto_migrate = false），
+部署新版本后需手动执行本命令应用表结构变更。
+
+表结构之外的数据迁移使用版本化迁移管理：
+  zera migrate up      应用未执行的版本化迁移
+  zera migrate down    回退最近应用的版本化迁移
+  zera migrate status  查看各版本迁移的应用状态`,
+	Run: func(cmd *cobra.Command, args []string) {
+		db := openDatabase()
+		defer db.Close()
+
+		if err := db.Migrate(context.Background()); err != nil {
+			checkError(fmt.Errorf("迁移失败: %w", err))
+		}
+		fmt.Println("数据库迁移完成")
+	},
+}
+
+// migrateDownSteps down 命令回退的迁移数量
+var migrateDownSteps int
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "应用未执行的版本化迁移",
+	Long: `按版本号顺序应用所有未执行的版本化迁移，并记录到 migrations 表。
+
+版本化迁移承载数据迁移等自定义脚本，表结构变更请先执行 zera migrate。
+每个迁移在独立事务内执行，失败时该迁移回滚并停止后续迁移。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		db := openDatabase()
+		defer db.Close()
+
+		count, err := db.MigrateUp(context.Background())
+		if err != nil {
+			checkError(fmt.Errorf("版本化迁移失败: %w", err))
+		}
+		if count == 0 {
+			fmt.Println("没有待应用的迁移")
+			return
+		}
+		fmt.Printf("已应用 %d 个迁移\n", count)
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "回退最近应用的版本化迁移",
+	Long: `按版本号倒序回退最近应用的版本化迁移（默认 1 个）。
+
+不可回滚的迁移（未定义回退脚本）会报错并停止。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		db := openDatabase()
+		defer db.Close()
+
+		count, err := db.MigrateDown(context.Background(), migrateDownSteps)
+		if err != nil {
+			checkError(fmt.Errorf("回退迁移失败: %w", err))
+		}
+		if count == 0 {
+			fmt.Println("没有可回退的迁移")
+			return
+		}
+		fmt.Printf("已回退 %d 个迁移\n", count)
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "查看版本化迁移的应用状态",
+	Run: func(cmd *cobra.Command, args []string) {
+		db := openDatabase()
+		defer db.Close()
+
+		statuses, err := db.MigrationStatuses(context.Background())
+		if err != nil {
+			checkError(fmt.Errorf("查询迁移状态失败: %w", err))
+		}
+		if len(statuses) == 0 {
+			fmt.Println("当前没有定义任何版本化迁移")
+			return
+		}
+
+		for _, s := range statuses {
+			if s.Applied {
+				fmt.Printf("  [已应用] %4d  %s  (%s)\n", s.Version, s.Name, s.AppliedAt.Format("2006-01-02 15:04:05"))
+			} else {
+				fmt.Printf("  [待应用] %4d  %s\n", s.Version, s.Name)
+			}
+		}
+	},
+}
+
+// openDatabase 加载配置并建立数据库连接，失败时退出
+func openDatabase() *database.Database {
+	cfg := config.Load()
+
+	db, err := database.New(cfg)
+	if err != nil {
+		checkError(fmt.Errorf("连接数据库失败: %w", err))
+	}
+	return db
+}
+
+func init() {
+	migrateDownCmd.Flags().IntVar(&migrateDownSteps, "steps", 1, "回退的迁移数量")
+
+	migrateCmd.AddCommand(migrateUpCmd)
+	migrateCmd.AddCommand(migrateDownCmd)
+	migrateCmd.AddCommand(migrateStatusCmd)
+}
@@ -58,6 +58,9 @@ func init() {
 	// 添加子命令
 	rootCmd.AddCommand(devCmd)
 	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(whoamiCmd)
+	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(configCmd)
 }
 
 // checkError 检查错误并打印
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"zera/internal/auth"
+	"zera/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var whoamiToken string
+
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "解析 JWT token 并显示身份信息",
+	Long: `使用配置中的 JWT secret 在本地解析 token，打印用户、角色、权限和有效期。
+
+token 通过 --token 传入，未指定时读取 ZERA_TOKEN 环境变量。
+仅解析 token 内容（验证签名但允许已过期），不检查是否已被吊销。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		token := whoamiToken
+		if token == "" {
+			token = os.Getenv("ZERA_TOKEN")
+		}
+		if token == "" {
+			checkError(fmt.Errorf("请通过 --token 或 ZERA_TOKEN 环境变量提供 token"))
+		}
+
+		// 读取配置获取 JWT secret（支持 JWT_SECRET 环境变量覆盖）
+		cfg := config.Load()
+		jwtManager, err := auth.NewJWTManager(&cfg.JWT)
+		if err != nil {
+			checkError(fmt.Errorf("初始化 JWT 管理器失败: %w", err))
+		}
+
+		claims, err := jwtManager.ParseTokenAllowExpired(token)
+		if err != nil {
+			checkError(fmt.Errorf("解析 token 失败: %w", err))
+		}
+
+		fmt.Printf("Token 信息\n")
+		fmt.Printf("  用户ID:  %d\n", claims.UserID)
+		fmt.Printf("  用户名:  %s\n", claims.Username)
+		fmt.Printf("  类型:    %s\n", claims.Type)
+		fmt.Printf("  角色:    %s\n", joinOrDash(claims.Roles))
+		fmt.Printf("  权限:    %s\n", joinOrDash(claims.Permissions))
+		if claims.IssuedAt != nil {
+			fmt.Printf("  签发时间: %s\n", claims.IssuedAt.Format(time.RFC3339))
+		}
+		if claims.ExpiresAt != nil {
+			fmt.Printf("  过期时间: %s", claims.ExpiresAt.Format(time.RFC3339))
+			if time.Now().After(claims.ExpiresAt.Time) {
+				fmt.Printf(" (已过期)")
+			}
+			fmt.Println()
+		}
+	},
+}
+
+// joinOrDash 拼接字符串列表，空列表显示为 "-"
+func joinOrDash(items []string) string {
+	if len(items) == 0 {
+		return "-"
+	}
+	return strings.Join(items, ", ")
+}
+
+func init() {
+	whoamiCmd.Flags().StringVarP(&whoamiToken, "token", "t", "", "要解析的 JWT token")
+}
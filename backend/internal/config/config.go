@@ -11,15 +11,89 @@ import (
 
 // Config 应用配置
 type Config struct {
-	Server    ServerConfig    `toml:"server"`
-	Database  DatabaseConfig  `toml:"database"`
-	App       AppConfig       `toml:"app"`
-	Admin     AdminConfig     `toml:"admin"`
-	JWT       JWTConfig       `toml:"jwt"`
-	Storage   StorageConfig   `toml:"storage"`
-	Static    StaticConfig    `toml:"static"`
-	Log       LogConfig       `toml:"log"`
-	Telemetry TelemetryConfig `toml:"telemetry"`
+	Server   ServerConfig   `toml:"server"`
+	Database DatabaseConfig `toml:"database"`
+	App      AppConfig      `toml:"app"`
+	Admins   []AdminConfig  `toml:"admin"`
+	JWT      JWTConfig      `toml:"jwt"`
+	Password PasswordConfig `toml:"password"`
+	// LoginRateLimit 登录失败限流
+	LoginRateLimit LoginRateLimitConfig `toml:"login_rate_limit"`
+	// LoginAnomaly 登录异常检测
+	LoginAnomaly LoginAnomalyConfig `toml:"login_anomaly"`
+	Storage      StorageConfig      `toml:"storage"`
+	Static       StaticConfig       `toml:"static"`
+	Log          LogConfig          `toml:"log"`
+	Telemetry    TelemetryConfig    `toml:"telemetry"`
+	GeoIP        GeoIPConfig        `toml:"geoip"`
+	// AuditRetention 审计日志保留策略
+	AuditRetention AuditRetentionConfig `toml:"audit_retention"`
+	// Mail SMTP 邮件服务配置
+	Mail MailConfig `toml:"mail"`
+	// CasdoorRoutes 多 Casdoor 组织路由
+	// 按用户部门将同步请求路由到独立的 Casdoor 连接，未配置时使用系统设置中的默认连接
+	CasdoorRoutes []CasdoorRouteConfig `toml:"casdoor_route"`
+}
+
+// CasdoorRouteConfig 单条 Casdoor 路由配置
+type CasdoorRouteConfig struct {
+	// Department 匹配的用户部门名称
+	Department   string `toml:"department"`
+	ServerURL    string `toml:"server_url"`
+	ClientID     string `toml:"client_id"`
+	ClientSecret string `toml:"client_secret"`
+	JwtPublicKey string `toml:"jwt_public_key"`
+	Organization string `toml:"organization"`
+	Application  string `toml:"application"`
+}
+
+// MailConfig SMTP 邮件服务配置
+// 用于登录提醒等系统通知邮件
+type MailConfig struct {
+	// Enabled 是否启用邮件发送
+	Enabled bool `toml:"enabled"`
+	// Host SMTP 服务器地址
+	Host string `toml:"host"`
+	// Port SMTP 服务器端口
+	Port int `toml:"port"`
+	// Username SMTP 认证用户名，为空时不认证
+	Username string `toml:"username"`
+	// Password SMTP 认证密码
+	Password string `toml:"password"`
+	// From 发件人地址
+	From string `toml:"from"`
+}
+
+// AuditRetentionConfig 审计日志保留策略配置
+// 支持按 module/action 配置不同的保留天数，未匹配任何规则的日志按全局默认清理
+type AuditRetentionConfig struct {
+	// Enabled 是否启用定期清理
+	Enabled bool `toml:"enabled"`
+	// DefaultDays 全局默认保留天数
+	DefaultDays int `toml:"default_days"`
+	// IntervalHours 清理任务执行间隔（小时）
+	IntervalHours int `toml:"interval_hours"`
+	// Rules 按模块/操作匹配的保留规则
+	Rules []AuditRetentionRule `toml:"rules"`
+}
+
+// AuditRetentionRule 审计日志保留规则
+// Module/Action 为空表示不限制该维度；同一条日志命中多条规则时以先出现的为准
+type AuditRetentionRule struct {
+	// Module 匹配的模块（如 auth、user），为空匹配所有模块
+	Module string `toml:"module"`
+	// Action 匹配的操作（如 login、list），为空匹配所有操作
+	Action string `toml:"action"`
+	// Days 保留天数
+	Days int `toml:"days"`
+}
+
+// GeoIPConfig IP 地理位置解析配置
+type GeoIPConfig struct {
+	// Enabled 是否启用 IP 地理位置解析
+	Enabled bool `toml:"enabled"`
+	// DatabasePath MaxMind GeoIP2/GeoLite2 数据库文件路径（mmdb 格式）
+	DatabasePath string `toml:"database_path"`
 }
 
 // TelemetryConfig OpenTelemetry 遥测配置
@@ -38,6 +112,8 @@ type TelemetryConfig struct {
 	LogBatchInterval int `toml:"log_batch_interval"`
 	// LogBatchSize 日志批量导出大小
 	LogBatchSize int `toml:"log_batch_size"`
+	// ResourceAttributes 附加资源属性（如 cluster、region、pod），便于多集群部署时过滤
+	ResourceAttributes map[string]string `toml:"resource_attributes"`
 	// Logs 分类日志配置
 	Logs TelemetryLogsConfig `toml:"logs"`
 }
@@ -52,6 +128,9 @@ type TelemetryLogsConfig struct {
 	DBEnabled bool `toml:"db_enabled"`
 	// DBSlowQueryThresholdMs 数据库慢查询阈值（毫秒）
 	DBSlowQueryThresholdMs int64 `toml:"db_slow_query_threshold_ms"`
+	// DBNPlusOneThreshold 疑似 N+1 检测阈值
+	// 单个请求内对同一张表的查询次数超过该值时记录警告，0 表示关闭
+	DBNPlusOneThreshold int64 `toml:"db_nplusone_threshold"`
 }
 
 // LogConfig 日志配置
@@ -70,12 +149,45 @@ type LogConfig struct {
 	ServiceVersion string `toml:"service_version"`
 	// Environment 运行环境: development, staging, production
 	Environment string `toml:"environment"`
+	// BodySampling 请求体记录采样配置（作用于审计日志与 telemetry API 日志）
+	BodySampling BodySamplingConfig `toml:"body_sampling"`
+}
+
+// BodySamplingConfig 请求体记录采样配置
+// 全量序列化请求体开销较大，可按比例采样，慢请求与错误请求不受采样限制
+type BodySamplingConfig struct {
+	// SampleRate 正常请求的请求体记录比例 (0.0-1.0)，1.0 表示全量记录
+	SampleRate float64 `toml:"sample_rate"`
+	// SlowThresholdMs 慢请求阈值（毫秒），耗时达到该值的请求始终记录完整请求体，0 表示关闭
+	SlowThresholdMs int64 `toml:"slow_threshold_ms"`
+	// AlwaysOnError 请求出错时始终记录完整请求体
+	AlwaysOnError bool `toml:"always_on_error"`
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
 	Host string `toml:"host"`
 	Port int    `toml:"port"`
+	// H2C 是否启用明文 HTTP/2（内网无 TLS 场景下支持 gRPC 传输）
+	H2C bool `toml:"h2c"`
+	// RPCTimeout RPC 一元调用超时（秒），0 表示不限制
+	// 独立于通用 HTTP 超时，流式调用不受此限制
+	RPCTimeout int `toml:"rpc_timeout"`
+	// TLS 证书配置，配置后直接以 HTTPS + HTTP/2 提供服务
+	TLS TLSConfig `toml:"tls"`
+}
+
+// TLSConfig 服务端 TLS 配置
+type TLSConfig struct {
+	// CertFile 证书文件路径
+	CertFile string `toml:"cert_file"`
+	// KeyFile 私钥文件路径
+	KeyFile string `toml:"key_file"`
+}
+
+// Enabled 证书和私钥均已配置时启用 TLS
+func (c *TLSConfig) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
 }
 
 // DatabaseConfig 数据库配置
@@ -86,6 +198,9 @@ type DatabaseConfig struct {
 	Password string `toml:"password"`
 	DBName   string `toml:"dbname"`
 	SSLMode  string `toml:"sslmode"`
+	// AutoMigrate 启动时是否自动迁移表结构
+	// dev_mode 下始终迁移；生产环境默认跳过，需显式开启或用 zera migrate 手动迁移
+	AutoMigrate bool `toml:"auto_migrate"`
 }
 
 // AppConfig 应用配置
@@ -94,6 +209,7 @@ type AppConfig struct {
 }
 
 // AdminConfig 初始管理员配置
+// 支持通过 [[admin]] 数组配置多个初始管理员账号
 type AdminConfig struct {
 	Username string `toml:"username"`
 	Email    string `toml:"email"`
@@ -105,6 +221,84 @@ type JWTConfig struct {
 	Secret             string `toml:"secret"`
 	AccessTokenExpire  int64  `toml:"access_token_expire"`
 	RefreshTokenExpire int64  `toml:"refresh_token_expire"`
+	// Algorithm 签名算法: HS256(对称，默认) 或 RS256(非对称)
+	// RS256 便于多实例部署和把公钥分发给其它服务独立校验
+	Algorithm string `toml:"algorithm"`
+	// PrivateKeyFile RS256 签名私钥的 PEM 文件路径，仅 RS256 时需要
+	PrivateKeyFile string `toml:"private_key_file"`
+	// KeyID 当前签名密钥的 kid，签发时写入令牌 header，校验方按 kid 选择公钥
+	KeyID string `toml:"key_id"`
+	// PublicKeyFiles kid 到公钥 PEM 文件的映射
+	// 轮换密钥时保留旧 kid 的公钥条目，存量令牌在过期前仍可校验
+	PublicKeyFiles map[string]string `toml:"public_key_files"`
+	// SigningBackend 签名后端: local(默认，本地密钥) 或 kms(外部密钥管理服务)
+	// kms 模式下私钥不离开 KMS，签名调用远程 API，验签用导出的公钥在本地完成
+	SigningBackend string `toml:"signing_backend"`
+	// KMS 外部密钥管理服务配置，仅 signing_backend 为 kms 时生效
+	KMS JWTKMSConfig `toml:"kms"`
+	// Clients 按客户端类型差异化的令牌有效期（如 web 短、app 长）
+	// 键为客户端类型标识，登录请求未携带或未匹配时使用上面的默认值
+	Clients map[string]JWTClientConfig `toml:"clients"`
+}
+
+// JWTKMSConfig 外部密钥管理服务（KMS）签名配置
+type JWTKMSConfig struct {
+	// Provider KMS 提供方，当前支持 vault (HashiCorp Vault Transit)
+	Provider string `toml:"provider"`
+	// Endpoint KMS 服务地址（如 https://vault.example.com:8200）
+	Endpoint string `toml:"endpoint"`
+	// Token 访问令牌
+	Token string `toml:"token"`
+	// KeyName Transit 引擎中的密钥名
+	KeyName string `toml:"key_name"`
+	// Mount Transit 引擎挂载路径，默认 transit
+	Mount string `toml:"mount"`
+	// PublicKeyFile KMS 导出的公钥 PEM 文件路径，用于本地验签
+	PublicKeyFile string `toml:"public_key_file"`
+}
+
+// JWTClientConfig 单个客户端类型的令牌有效期配置
+type JWTClientConfig struct {
+	// AccessTokenExpire 访问令牌有效期（秒），0 表示使用默认值
+	AccessTokenExpire int64 `toml:"access_token_expire"`
+	// RefreshTokenExpire 刷新令牌有效期（秒），0 表示使用默认值
+	RefreshTokenExpire int64 `toml:"refresh_token_expire"`
+}
+
+// PasswordConfig 密码哈希配置
+type PasswordConfig struct {
+	// BcryptCost bcrypt 成本因子（4-31），越大越安全但越慢，超出范围时使用默认值
+	BcryptCost int `toml:"bcrypt_cost"`
+}
+
+// LoginRateLimitConfig 登录失败限流配置
+// 按 IP+用户名 组合做滑动窗口计数，避免撞库绕过按用户名的账号锁定，
+// 同时 NAT 共享出口 IP 的合法用户不会因他人失败被误伤
+type LoginRateLimitConfig struct {
+	// Enabled 是否启用登录失败限流
+	Enabled bool `toml:"enabled"`
+	// Window 滑动窗口长度（秒）
+	Window int64 `toml:"window"`
+	// MaxFailures 窗口内允许的最大失败次数，超过后拒绝登录尝试
+	MaxFailures int `toml:"max_failures"`
+}
+
+// LoginAnomalyConfig 登录异常检测配置
+// 按滑动窗口统计全局/单 IP/单账号的登录失败次数，超过基线时
+// 记录告警事件并触发 webhook 通知，用于发现暴力破解迹象
+type LoginAnomalyConfig struct {
+	// Enabled 是否启用登录异常检测
+	Enabled bool `toml:"enabled"`
+	// Window 统计窗口长度（秒）
+	Window int64 `toml:"window"`
+	// GlobalThreshold 窗口内全局失败次数基线，小于等于 0 时不检测该维度
+	GlobalThreshold int `toml:"global_threshold"`
+	// IPThreshold 窗口内单 IP 失败次数基线，小于等于 0 时不检测该维度
+	IPThreshold int `toml:"ip_threshold"`
+	// AccountThreshold 窗口内单账号失败次数基线，小于等于 0 时不检测该维度
+	AccountThreshold int `toml:"account_threshold"`
+	// WebhookURL 告警 webhook 地址，为空时不发送通知
+	WebhookURL string `toml:"webhook_url"`
 }
 
 // StorageConfig 对象存储配置（S3 兼容）
@@ -124,6 +318,9 @@ type StaticConfig struct {
 	MaxUploadSize int64  `toml:"max_upload_size"` // 最大上传大小（字节）
 }
 
+// defaultJWTSecret 默认 JWT secret，非开发模式下必须修改
+const defaultJWTSecret = "your-super-secret-key-please-change-in-production"
+
 // DSN 返回 PostgreSQL 连接字符串
 func (d *DatabaseConfig) DSN() string {
 	return fmt.Sprintf(
@@ -140,25 +337,50 @@ func defaultConfig() *Config {
 			Port: 8080,
 		},
 		Database: DatabaseConfig{
-			Host:     "localhost",
-			Port:     5432,
-			User:     "postgres",
-			Password: "",
-			DBName:   "zera",
-			SSLMode:  "disable",
+			Host:        "localhost",
+			Port:        5432,
+			User:        "postgres",
+			Password:    "",
+			DBName:      "zera",
+			SSLMode:     "disable",
+			AutoMigrate: false,
 		},
 		App: AppConfig{
 			DevMode: false,
 		},
-		Admin: AdminConfig{
-			Username: "admin",
-			Email:    "admin@zera.local",
-			Password: "admin123",
+		Admins: []AdminConfig{
+			{
+				Username: "admin",
+				Email:    "admin@zera.local",
+				Password: "admin123",
+			},
 		},
 		JWT: JWTConfig{
-			Secret:             "your-super-secret-key-please-change-in-production",
+			Secret:             defaultJWTSecret,
 			AccessTokenExpire:  3600,   // 1 小时
 			RefreshTokenExpire: 604800, // 7 天
+			Clients: map[string]JWTClientConfig{
+				// 移动 App 使用更长的有效期，减少重新登录
+				"app": {
+					AccessTokenExpire:  7200,    // 2 小时
+					RefreshTokenExpire: 2592000, // 30 天
+				},
+			},
+		},
+		Password: PasswordConfig{
+			BcryptCost: 10, // bcrypt 默认成本因子
+		},
+		LoginRateLimit: LoginRateLimitConfig{
+			Enabled:     true,
+			Window:      300, // 5 分钟滑动窗口
+			MaxFailures: 10,
+		},
+		LoginAnomaly: LoginAnomalyConfig{
+			Enabled:          false,
+			Window:           300, // 5 分钟滑动窗口
+			GlobalThreshold:  100,
+			IPThreshold:      20,
+			AccountThreshold: 10,
 		},
 		Storage: StorageConfig{
 			Enabled:      false,
@@ -181,6 +403,11 @@ func defaultConfig() *Config {
 			ServiceName:    "zera",
 			ServiceVersion: "1.0.0",
 			Environment:    "development",
+			BodySampling: BodySamplingConfig{
+				SampleRate:      1.0,
+				SlowThresholdMs: 1000,
+				AlwaysOnError:   true,
+			},
 		},
 		Telemetry: TelemetryConfig{
 			Enabled:          false,
@@ -195,8 +422,18 @@ func defaultConfig() *Config {
 				AppEnabled:             true,
 				DBEnabled:              true,
 				DBSlowQueryThresholdMs: 100,
+				DBNPlusOneThreshold:    10,
 			},
 		},
+		AuditRetention: AuditRetentionConfig{
+			Enabled:       false,
+			DefaultDays:   180,
+			IntervalHours: 24,
+		},
+		Mail: MailConfig{
+			Enabled: false,
+			Port:    587,
+		},
 	}
 }
 
@@ -240,7 +477,7 @@ func loadFromFile(cfg *Config) error {
 	}
 
 	// 如果设置了 CONFIG_PATH 环境变量，优先使用
-	if envPath := os.Getenv("CONFIG_PATH"); envPath != "" {
+	if envPath := getEnv("CONFIG_PATH"); envPath != "" {
 		paths = append([]string{envPath}, paths...)
 	}
 
@@ -269,53 +506,74 @@ func loadFromFilePath(cfg *Config, path string) error {
 }
 
 // applyEnvOverrides 应用环境变量覆盖
+// 变量名同时识别带 ZERA_ 前缀和不带前缀两种形式，前缀优先
 func applyEnvOverrides(cfg *Config) {
 	// Server 配置
-	if host := os.Getenv("SERVER_HOST"); host != "" {
+	if host := getEnv("SERVER_HOST"); host != "" {
 		cfg.Server.Host = host
 	}
 	if port := getEnvInt("SERVER_PORT"); port != 0 {
 		cfg.Server.Port = port
 	}
+	if h2c := getEnv("SERVER_H2C"); h2c != "" {
+		cfg.Server.H2C = h2c == "true" || h2c == "1"
+	}
+	if timeout := getEnvInt("SERVER_RPC_TIMEOUT"); timeout != 0 {
+		cfg.Server.RPCTimeout = timeout
+	}
+	if certFile := getEnv("SERVER_TLS_CERT_FILE"); certFile != "" {
+		cfg.Server.TLS.CertFile = certFile
+	}
+	if keyFile := getEnv("SERVER_TLS_KEY_FILE"); keyFile != "" {
+		cfg.Server.TLS.KeyFile = keyFile
+	}
 
 	// Database 配置
-	if host := os.Getenv("DB_HOST"); host != "" {
+	if host := getEnv("DB_HOST"); host != "" {
 		cfg.Database.Host = host
 	}
 	if port := getEnvInt("DB_PORT"); port != 0 {
 		cfg.Database.Port = port
 	}
-	if user := os.Getenv("DB_USER"); user != "" {
+	if user := getEnv("DB_USER"); user != "" {
 		cfg.Database.User = user
 	}
-	if password := os.Getenv("DB_PASSWORD"); password != "" {
+	if password := getEnv("DB_PASSWORD"); password != "" {
 		cfg.Database.Password = password
 	}
-	if dbname := os.Getenv("DB_NAME"); dbname != "" {
+	if dbname := getEnv("DB_NAME"); dbname != "" {
 		cfg.Database.DBName = dbname
 	}
-	if sslmode := os.Getenv("DB_SSLMODE"); sslmode != "" {
+	if sslmode := getEnv("DB_SSLMODE"); sslmode != "" {
 		cfg.Database.SSLMode = sslmode
 	}
+	if autoMigrate := getEnv("DB_AUTO_MIGRATE"); autoMigrate != "" {
+		cfg.Database.AutoMigrate = autoMigrate == "true" || autoMigrate == "1"
+	}
 
 	// App 配置
-	if devMode := os.Getenv("DEV_MODE"); devMode != "" {
+	if devMode := getEnv("DEV_MODE"); devMode != "" {
 		cfg.App.DevMode = devMode == "true" || devMode == "1"
 	}
 
-	// Admin 配置
-	if username := os.Getenv("ADMIN_USERNAME"); username != "" {
-		cfg.Admin.Username = username
+	// Admin 配置（环境变量只覆盖第一个管理员账号）
+	if getEnv("ADMIN_USERNAME") != "" || getEnv("ADMIN_EMAIL") != "" || getEnv("ADMIN_PASSWORD") != "" {
+		if len(cfg.Admins) == 0 {
+			cfg.Admins = append(cfg.Admins, AdminConfig{})
+		}
+	}
+	if username := getEnv("ADMIN_USERNAME"); username != "" {
+		cfg.Admins[0].Username = username
 	}
-	if email := os.Getenv("ADMIN_EMAIL"); email != "" {
-		cfg.Admin.Email = email
+	if email := getEnv("ADMIN_EMAIL"); email != "" {
+		cfg.Admins[0].Email = email
 	}
-	if password := os.Getenv("ADMIN_PASSWORD"); password != "" {
-		cfg.Admin.Password = password
+	if password := getEnv("ADMIN_PASSWORD"); password != "" {
+		cfg.Admins[0].Password = password
 	}
 
 	// JWT 配置
-	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+	if secret := getEnv("JWT_SECRET"); secret != "" {
 		cfg.JWT.Secret = secret
 	}
 	if expire := getEnvInt64("JWT_ACCESS_TOKEN_EXPIRE"); expire != 0 {
@@ -324,32 +582,83 @@ func applyEnvOverrides(cfg *Config) {
 	if expire := getEnvInt64("JWT_REFRESH_TOKEN_EXPIRE"); expire != 0 {
 		cfg.JWT.RefreshTokenExpire = expire
 	}
+	if algorithm := getEnv("JWT_ALGORITHM"); algorithm != "" {
+		cfg.JWT.Algorithm = algorithm
+	}
+	if keyFile := getEnv("JWT_PRIVATE_KEY_FILE"); keyFile != "" {
+		cfg.JWT.PrivateKeyFile = keyFile
+	}
+	if keyID := getEnv("JWT_KEY_ID"); keyID != "" {
+		cfg.JWT.KeyID = keyID
+	}
+	if backend := getEnv("JWT_SIGNING_BACKEND"); backend != "" {
+		cfg.JWT.SigningBackend = backend
+	}
+	if token := getEnv("JWT_KMS_TOKEN"); token != "" {
+		cfg.JWT.KMS.Token = token
+	}
+
+	// 密码哈希配置
+	if cost := getEnvInt64("PASSWORD_BCRYPT_COST"); cost != 0 {
+		cfg.Password.BcryptCost = int(cost)
+	}
+
+	// 登录失败限流配置
+	if enabled := getEnv("LOGIN_RATE_LIMIT_ENABLED"); enabled != "" {
+		cfg.LoginRateLimit.Enabled = enabled == "true" || enabled == "1"
+	}
+	if window := getEnvInt64("LOGIN_RATE_LIMIT_WINDOW"); window != 0 {
+		cfg.LoginRateLimit.Window = window
+	}
+	if maxFailures := getEnvInt64("LOGIN_RATE_LIMIT_MAX_FAILURES"); maxFailures != 0 {
+		cfg.LoginRateLimit.MaxFailures = int(maxFailures)
+	}
+
+	// 登录异常检测配置
+	if enabled := getEnv("LOGIN_ANOMALY_ENABLED"); enabled != "" {
+		cfg.LoginAnomaly.Enabled = enabled == "true" || enabled == "1"
+	}
+	if window := getEnvInt64("LOGIN_ANOMALY_WINDOW"); window != 0 {
+		cfg.LoginAnomaly.Window = window
+	}
+	if threshold := getEnvInt64("LOGIN_ANOMALY_GLOBAL_THRESHOLD"); threshold != 0 {
+		cfg.LoginAnomaly.GlobalThreshold = int(threshold)
+	}
+	if threshold := getEnvInt64("LOGIN_ANOMALY_IP_THRESHOLD"); threshold != 0 {
+		cfg.LoginAnomaly.IPThreshold = int(threshold)
+	}
+	if threshold := getEnvInt64("LOGIN_ANOMALY_ACCOUNT_THRESHOLD"); threshold != 0 {
+		cfg.LoginAnomaly.AccountThreshold = int(threshold)
+	}
+	if url := getEnv("LOGIN_ANOMALY_WEBHOOK_URL"); url != "" {
+		cfg.LoginAnomaly.WebhookURL = url
+	}
 
 	// Storage 配置
-	if enabled := os.Getenv("STORAGE_ENABLED"); enabled != "" {
+	if enabled := getEnv("STORAGE_ENABLED"); enabled != "" {
 		cfg.Storage.Enabled = enabled == "true" || enabled == "1"
 	}
-	if endpoint := os.Getenv("STORAGE_ENDPOINT"); endpoint != "" {
+	if endpoint := getEnv("STORAGE_ENDPOINT"); endpoint != "" {
 		cfg.Storage.Endpoint = endpoint
 	}
-	if accessKey := os.Getenv("STORAGE_ACCESS_KEY"); accessKey != "" {
+	if accessKey := getEnv("STORAGE_ACCESS_KEY"); accessKey != "" {
 		cfg.Storage.AccessKey = accessKey
 	}
-	if secretKey := os.Getenv("STORAGE_SECRET_KEY"); secretKey != "" {
+	if secretKey := getEnv("STORAGE_SECRET_KEY"); secretKey != "" {
 		cfg.Storage.SecretKey = secretKey
 	}
-	if bucket := os.Getenv("STORAGE_BUCKET"); bucket != "" {
+	if bucket := getEnv("STORAGE_BUCKET"); bucket != "" {
 		cfg.Storage.Bucket = bucket
 	}
-	if region := os.Getenv("STORAGE_REGION"); region != "" {
+	if region := getEnv("STORAGE_REGION"); region != "" {
 		cfg.Storage.Region = region
 	}
-	if usePathStyle := os.Getenv("STORAGE_USE_PATH_STYLE"); usePathStyle != "" {
+	if usePathStyle := getEnv("STORAGE_USE_PATH_STYLE"); usePathStyle != "" {
 		cfg.Storage.UsePathStyle = usePathStyle == "true" || usePathStyle == "1"
 	}
 
 	// Static 配置
-	if uploadsDir := os.Getenv("STATIC_UPLOADS_DIR"); uploadsDir != "" {
+	if uploadsDir := getEnv("STATIC_UPLOADS_DIR"); uploadsDir != "" {
 		cfg.Static.UploadsDir = uploadsDir
 	}
 	if maxUploadSize := getEnvInt64("STATIC_MAX_UPLOAD_SIZE"); maxUploadSize != 0 {
@@ -357,63 +666,129 @@ func applyEnvOverrides(cfg *Config) {
 	}
 
 	// Log 配置
-	if level := os.Getenv("LOG_LEVEL"); level != "" {
+	if level := getEnv("LOG_LEVEL"); level != "" {
 		cfg.Log.Level = level
 	}
-	if format := os.Getenv("LOG_FORMAT"); format != "" {
+	if format := getEnv("LOG_FORMAT"); format != "" {
 		cfg.Log.Format = format
 	}
-	if output := os.Getenv("LOG_OUTPUT"); output != "" {
+	if output := getEnv("LOG_OUTPUT"); output != "" {
 		cfg.Log.Output = output
 	}
-	if addSource := os.Getenv("LOG_ADD_SOURCE"); addSource != "" {
+	if addSource := getEnv("LOG_ADD_SOURCE"); addSource != "" {
 		cfg.Log.AddSource = addSource == "true" || addSource == "1"
 	}
-	if serviceName := os.Getenv("LOG_SERVICE_NAME"); serviceName != "" {
+	if serviceName := getEnv("LOG_SERVICE_NAME"); serviceName != "" {
 		cfg.Log.ServiceName = serviceName
 	}
-	if serviceVersion := os.Getenv("LOG_SERVICE_VERSION"); serviceVersion != "" {
+	if serviceVersion := getEnv("LOG_SERVICE_VERSION"); serviceVersion != "" {
 		cfg.Log.ServiceVersion = serviceVersion
 	}
-	if environment := os.Getenv("LOG_ENVIRONMENT"); environment != "" {
+	if environment := getEnv("LOG_ENVIRONMENT"); environment != "" {
 		cfg.Log.Environment = environment
 	}
+	if sampleRate := getEnv("LOG_BODY_SAMPLE_RATE"); sampleRate != "" {
+		if rate, err := strconv.ParseFloat(sampleRate, 64); err == nil {
+			cfg.Log.BodySampling.SampleRate = rate
+		}
+	}
+	if threshold := getEnvInt64("LOG_BODY_SLOW_THRESHOLD_MS"); threshold != 0 {
+		cfg.Log.BodySampling.SlowThresholdMs = threshold
+	}
+	if alwaysOnError := getEnv("LOG_BODY_ALWAYS_ON_ERROR"); alwaysOnError != "" {
+		cfg.Log.BodySampling.AlwaysOnError = alwaysOnError == "true" || alwaysOnError == "1"
+	}
 
 	// Telemetry 配置
-	if enabled := os.Getenv("OTEL_ENABLED"); enabled != "" {
+	if enabled := getEnv("OTEL_ENABLED"); enabled != "" {
 		cfg.Telemetry.Enabled = enabled == "true" || enabled == "1"
 	}
-	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+	if endpoint := getEnv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
 		cfg.Telemetry.Endpoint = endpoint
 	}
-	if protocol := os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"); protocol != "" {
+	if protocol := getEnv("OTEL_EXPORTER_OTLP_PROTOCOL"); protocol != "" {
 		cfg.Telemetry.Protocol = protocol
 	}
-	if insecure := os.Getenv("OTEL_EXPORTER_OTLP_INSECURE"); insecure != "" {
+	if insecure := getEnv("OTEL_EXPORTER_OTLP_INSECURE"); insecure != "" {
 		cfg.Telemetry.Insecure = insecure == "true" || insecure == "1"
 	}
-	if sampleRate := os.Getenv("OTEL_TRACE_SAMPLE_RATE"); sampleRate != "" {
+	if sampleRate := getEnv("OTEL_TRACE_SAMPLE_RATE"); sampleRate != "" {
 		if rate, err := strconv.ParseFloat(sampleRate, 64); err == nil {
 			cfg.Telemetry.TraceSampleRate = rate
 		}
 	}
-	if apiEnabled := os.Getenv("OTEL_LOGS_API_ENABLED"); apiEnabled != "" {
+	if apiEnabled := getEnv("OTEL_LOGS_API_ENABLED"); apiEnabled != "" {
 		cfg.Telemetry.Logs.APIEnabled = apiEnabled == "true" || apiEnabled == "1"
 	}
-	if appEnabled := os.Getenv("OTEL_LOGS_APP_ENABLED"); appEnabled != "" {
+	if appEnabled := getEnv("OTEL_LOGS_APP_ENABLED"); appEnabled != "" {
 		cfg.Telemetry.Logs.AppEnabled = appEnabled == "true" || appEnabled == "1"
 	}
-	if dbEnabled := os.Getenv("OTEL_LOGS_DB_ENABLED"); dbEnabled != "" {
+	if dbEnabled := getEnv("OTEL_LOGS_DB_ENABLED"); dbEnabled != "" {
 		cfg.Telemetry.Logs.DBEnabled = dbEnabled == "true" || dbEnabled == "1"
 	}
 	if threshold := getEnvInt64("OTEL_LOGS_DB_SLOW_QUERY_THRESHOLD_MS"); threshold != 0 {
 		cfg.Telemetry.Logs.DBSlowQueryThresholdMs = threshold
 	}
+	if threshold := getEnvInt64("OTEL_LOGS_DB_NPLUSONE_THRESHOLD"); threshold != 0 {
+		cfg.Telemetry.Logs.DBNPlusOneThreshold = threshold
+	}
+
+	// GeoIP 配置
+	if enabled := getEnv("GEOIP_ENABLED"); enabled != "" {
+		cfg.GeoIP.Enabled = enabled == "true" || enabled == "1"
+	}
+	if dbPath := getEnv("GEOIP_DATABASE_PATH"); dbPath != "" {
+		cfg.GeoIP.DatabasePath = dbPath
+	}
+
+	// 审计日志保留策略配置（规则列表仅支持配置文件）
+	if enabled := getEnv("AUDIT_RETENTION_ENABLED"); enabled != "" {
+		cfg.AuditRetention.Enabled = enabled == "true" || enabled == "1"
+	}
+	if days := getEnvInt("AUDIT_RETENTION_DEFAULT_DAYS"); days != 0 {
+		cfg.AuditRetention.DefaultDays = days
+	}
+	if hours := getEnvInt("AUDIT_RETENTION_INTERVAL_HOURS"); hours != 0 {
+		cfg.AuditRetention.IntervalHours = hours
+	}
+
+	// Mail 配置
+	if enabled := getEnv("MAIL_ENABLED"); enabled != "" {
+		cfg.Mail.Enabled = enabled == "true" || enabled == "1"
+	}
+	if host := getEnv("MAIL_HOST"); host != "" {
+		cfg.Mail.Host = host
+	}
+	if port := getEnvInt("MAIL_PORT"); port != 0 {
+		cfg.Mail.Port = port
+	}
+	if username := getEnv("MAIL_USERNAME"); username != "" {
+		cfg.Mail.Username = username
+	}
+	if password := getEnv("MAIL_PASSWORD"); password != "" {
+		cfg.Mail.Password = password
+	}
+	if from := getEnv("MAIL_FROM"); from != "" {
+		cfg.Mail.From = from
+	}
+}
+
+// envPrefix 统一的环境变量前缀，避免在共享环境中与其他程序撞名
+const envPrefix = "ZERA_"
+
+// getEnv 读取环境变量
+// 优先识别带 ZERA_ 前缀的变量名（如 ZERA_SERVER_HOST），
+// 未设置时回退到不带前缀的旧变量名，保持向后兼容
+func getEnv(key string) string {
+	if value := os.Getenv(envPrefix + key); value != "" {
+		return value
+	}
+	return os.Getenv(key)
 }
 
 // getEnvInt 获取整型环境变量，如果不存在或解析失败返回 0
 func getEnvInt(key string) int {
-	if value := os.Getenv(key); value != "" {
+	if value := getEnv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
 			return intValue
 		}
@@ -423,7 +798,7 @@ func getEnvInt(key string) int {
 
 // getEnvInt64 获取 int64 类型环境变量，如果不存在或解析失败返回 0
 func getEnvInt64(key string) int64 {
-	if value := os.Getenv(key); value != "" {
+	if value := getEnv(key); value != "" {
 		if intValue, err := strconv.ParseInt(value, 10, 64); err == nil {
 			return intValue
 		}
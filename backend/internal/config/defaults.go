@@ -0,0 +1,199 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// envNames 配置项到环境变量名的映射，键为 toml 路径
+// 环境变量命名有历史惯例（如 DB_*、OTEL_*），无法由字段名推导，集中维护于此
+var envNames = map[string]string{
+	"server.host":          "SERVER_HOST",
+	"server.port":          "SERVER_PORT",
+	"server.h2c":           "SERVER_H2C",
+	"server.rpc_timeout":   "SERVER_RPC_TIMEOUT",
+	"server.tls.cert_file": "SERVER_TLS_CERT_FILE",
+	"server.tls.key_file":  "SERVER_TLS_KEY_FILE",
+
+	"database.host":         "DB_HOST",
+	"database.port":         "DB_PORT",
+	"database.user":         "DB_USER",
+	"database.password":     "DB_PASSWORD",
+	"database.dbname":       "DB_NAME",
+	"database.sslmode":      "DB_SSLMODE",
+	"database.auto_migrate": "DB_AUTO_MIGRATE",
+
+	"app.dev_mode": "DEV_MODE",
+
+	"admin.username": "ADMIN_USERNAME",
+	"admin.email":    "ADMIN_EMAIL",
+	"admin.password": "ADMIN_PASSWORD",
+
+	"jwt.secret":               "JWT_SECRET",
+	"jwt.access_token_expire":  "JWT_ACCESS_TOKEN_EXPIRE",
+	"jwt.refresh_token_expire": "JWT_REFRESH_TOKEN_EXPIRE",
+
+	"password.bcrypt_cost": "PASSWORD_BCRYPT_COST",
+
+	"login_rate_limit.enabled":      "LOGIN_RATE_LIMIT_ENABLED",
+	"login_rate_limit.window":       "LOGIN_RATE_LIMIT_WINDOW",
+	"login_rate_limit.max_failures": "LOGIN_RATE_LIMIT_MAX_FAILURES",
+
+	"storage.enabled":        "STORAGE_ENABLED",
+	"storage.endpoint":       "STORAGE_ENDPOINT",
+	"storage.access_key":     "STORAGE_ACCESS_KEY",
+	"storage.secret_key":     "STORAGE_SECRET_KEY",
+	"storage.bucket":         "STORAGE_BUCKET",
+	"storage.region":         "STORAGE_REGION",
+	"storage.use_path_style": "STORAGE_USE_PATH_STYLE",
+
+	"static.uploads_dir":     "STATIC_UPLOADS_DIR",
+	"static.max_upload_size": "STATIC_MAX_UPLOAD_SIZE",
+
+	"log.level":           "LOG_LEVEL",
+	"log.format":          "LOG_FORMAT",
+	"log.output":          "LOG_OUTPUT",
+	"log.add_source":      "LOG_ADD_SOURCE",
+	"log.service_name":    "LOG_SERVICE_NAME",
+	"log.service_version": "LOG_SERVICE_VERSION",
+	"log.environment":     "LOG_ENVIRONMENT",
+
+	"telemetry.enabled":                         "OTEL_ENABLED",
+	"telemetry.endpoint":                        "OTEL_EXPORTER_OTLP_ENDPOINT",
+	"telemetry.protocol":                        "OTEL_EXPORTER_OTLP_PROTOCOL",
+	"telemetry.insecure":                        "OTEL_EXPORTER_OTLP_INSECURE",
+	"telemetry.trace_sample_rate":               "OTEL_TRACE_SAMPLE_RATE",
+	"telemetry.logs.api_enabled":                "OTEL_LOGS_API_ENABLED",
+	"telemetry.logs.app_enabled":                "OTEL_LOGS_APP_ENABLED",
+	"telemetry.logs.db_enabled":                 "OTEL_LOGS_DB_ENABLED",
+	"telemetry.logs.db_slow_query_threshold_ms": "OTEL_LOGS_DB_SLOW_QUERY_THRESHOLD_MS",
+	"telemetry.logs.db_nplusone_threshold":      "OTEL_LOGS_DB_NPLUSONE_THRESHOLD",
+
+	"geoip.enabled":       "GEOIP_ENABLED",
+	"geoip.database_path": "GEOIP_DATABASE_PATH",
+
+	"audit_retention.enabled":        "AUDIT_RETENTION_ENABLED",
+	"audit_retention.default_days":   "AUDIT_RETENTION_DEFAULT_DAYS",
+	"audit_retention.interval_hours": "AUDIT_RETENTION_INTERVAL_HOURS",
+
+	"mail.enabled":  "MAIL_ENABLED",
+	"mail.host":     "MAIL_HOST",
+	"mail.port":     "MAIL_PORT",
+	"mail.username": "MAIL_USERNAME",
+	"mail.password": "MAIL_PASSWORD",
+	"mail.from":     "MAIL_FROM",
+}
+
+// DefaultsTOML 生成带注释的完整样例配置
+// 反射遍历 Config 的 toml 标签与默认值，每个字段附带对应的环境变量名，
+// 作为配置参考，避免用户翻源码查找可用配置项
+func DefaultsTOML() string {
+	var b strings.Builder
+	b.WriteString("# Zera 配置参考（由 zera config defaults 生成）\n")
+	b.WriteString("# 所有字段均为默认值，环境变量优先级高于配置文件\n")
+	writeStructDefaults(&b, reflect.ValueOf(*defaultConfig()), "")
+	return b.String()
+}
+
+// writeStructDefaults 输出一个结构体层级的默认值
+// 先输出标量字段再输出子表，符合 TOML 的表归属语法
+func writeStructDefaults(b *strings.Builder, v reflect.Value, prefix string) {
+	type subTable struct {
+		path string
+		v    reflect.Value
+	}
+	var tables []subTable
+	var lists []subTable
+	var maps []string
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := tomlTagName(t.Field(i))
+		if tag == "" {
+			continue
+		}
+		path := tag
+		if prefix != "" {
+			path = prefix + "." + tag
+		}
+
+		fv := v.Field(i)
+		switch fv.Kind() {
+		case reflect.Struct:
+			tables = append(tables, subTable{path: path, v: fv})
+		case reflect.Slice:
+			if fv.Type().Elem().Kind() == reflect.Struct {
+				lists = append(lists, subTable{path: path, v: fv})
+			}
+		case reflect.Map:
+			maps = append(maps, path)
+		default:
+			if env := envNames[path]; env != "" {
+				fmt.Fprintf(b, "# 环境变量: %s\n", env)
+			}
+			fmt.Fprintf(b, "%s = %s\n", tag, formatTOMLValue(fv))
+		}
+	}
+
+	for _, m := range maps {
+		b.WriteString("\n")
+		fmt.Fprintf(b, "# [%s]\n", m)
+		b.WriteString("# 自定义键值对，按需配置\n")
+	}
+
+	for _, sub := range tables {
+		b.WriteString("\n")
+		fmt.Fprintf(b, "[%s]\n", sub.path)
+		writeStructDefaults(b, sub.v, sub.path)
+	}
+
+	for _, list := range lists {
+		// 有默认元素时按默认值输出，否则输出注释掉的字段骨架
+		if list.v.Len() > 0 {
+			for i := 0; i < list.v.Len(); i++ {
+				b.WriteString("\n")
+				fmt.Fprintf(b, "[[%s]]\n", list.path)
+				writeStructDefaults(b, list.v.Index(i), list.path)
+			}
+			continue
+		}
+		b.WriteString("\n")
+		fmt.Fprintf(b, "# [[%s]]\n", list.path)
+		elem := reflect.New(list.v.Type().Elem()).Elem()
+		et := elem.Type()
+		for i := 0; i < et.NumField(); i++ {
+			tag := tomlTagName(et.Field(i))
+			if tag == "" {
+				continue
+			}
+			fmt.Fprintf(b, "# %s = %s\n", tag, formatTOMLValue(elem.Field(i)))
+		}
+	}
+}
+
+// tomlTagName 提取字段的 toml 标签名，无标签或标记忽略时返回空
+func tomlTagName(f reflect.StructField) string {
+	tag, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+	if tag == "-" {
+		return ""
+	}
+	return tag
+}
+
+// formatTOMLValue 将标量默认值格式化为 TOML 字面量
+func formatTOMLValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.Quote(v.String())
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', 1, 64)
+	default:
+		return fmt.Sprintf("%v", v.Interface())
+	}
+}
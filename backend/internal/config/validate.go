@@ -0,0 +1,169 @@
+package config
+
+import (
+	"fmt"
+	"os"
+)
+
+// CheckResult 单项配置检查结果
+type CheckResult struct {
+	// Name 检查项名称（如 server.port）
+	Name string
+	// OK 是否通过
+	OK bool
+	// Message 失败原因（通过时为空）
+	Message string
+}
+
+// Validate 校验配置，返回全部检查项的结果
+// 任一检查失败不会中断，便于一次性看到所有问题
+func (c *Config) Validate() []CheckResult {
+	var results []CheckResult
+	check := func(name string, ok bool, format string, args ...any) {
+		r := CheckResult{Name: name, OK: ok}
+		if !ok {
+			r.Message = fmt.Sprintf(format, args...)
+		}
+		results = append(results, r)
+	}
+
+	// 服务器
+	check("server.host", c.Server.Host != "", "监听地址不能为空")
+	check("server.port", c.Server.Port > 0 && c.Server.Port < 65536,
+		"端口必须在 1-65535 之间，当前为 %d", c.Server.Port)
+	tlsPartial := (c.Server.TLS.CertFile == "") != (c.Server.TLS.KeyFile == "")
+	check("server.tls", !tlsPartial, "cert_file 与 key_file 必须同时配置")
+	if c.Server.TLS.Enabled() {
+		check("server.tls.cert_file", fileExists(c.Server.TLS.CertFile),
+			"证书文件不存在: %s", c.Server.TLS.CertFile)
+		check("server.tls.key_file", fileExists(c.Server.TLS.KeyFile),
+			"私钥文件不存在: %s", c.Server.TLS.KeyFile)
+	}
+
+	// 数据库
+	check("database.host", c.Database.Host != "", "数据库主机不能为空")
+	check("database.port", c.Database.Port > 0 && c.Database.Port < 65536,
+		"端口必须在 1-65535 之间，当前为 %d", c.Database.Port)
+	check("database.user", c.Database.User != "", "数据库用户不能为空")
+	check("database.dbname", c.Database.DBName != "", "数据库名不能为空")
+	sslModes := map[string]bool{
+		"disable": true, "allow": true, "prefer": true,
+		"require": true, "verify-ca": true, "verify-full": true,
+	}
+	check("database.sslmode", sslModes[c.Database.SSLMode],
+		"无效的 sslmode: %s", c.Database.SSLMode)
+
+	// JWT
+	check("jwt.secret", c.JWT.Secret != "", "JWT secret 不能为空")
+	if !c.App.DevMode {
+		check("jwt.secret.default", c.JWT.Secret != defaultJWTSecret,
+			"非开发模式请修改默认 JWT secret")
+	}
+	check("jwt.access_token_expire", c.JWT.AccessTokenExpire > 0,
+		"访问令牌有效期必须大于 0，当前为 %d", c.JWT.AccessTokenExpire)
+	check("jwt.refresh_token_expire", c.JWT.RefreshTokenExpire > 0,
+		"刷新令牌有效期必须大于 0，当前为 %d", c.JWT.RefreshTokenExpire)
+
+	// 初始管理员
+	check("admin", len(c.Admins) > 0, "至少需要配置一个初始管理员")
+	for i, admin := range c.Admins {
+		check(fmt.Sprintf("admin[%d]", i),
+			admin.Username != "" && admin.Email != "" && admin.Password != "",
+			"用户名、邮箱和密码均不能为空")
+	}
+
+	// 对象存储（启用时）
+	if c.Storage.Enabled {
+		check("storage.endpoint", c.Storage.Endpoint != "", "存储端点不能为空")
+		check("storage.access_key", c.Storage.AccessKey != "", "访问密钥不能为空")
+		check("storage.secret_key", c.Storage.SecretKey != "", "密钥不能为空")
+		check("storage.bucket", c.Storage.Bucket != "", "存储桶不能为空")
+	}
+
+	// 本地静态资源
+	check("static.max_upload_size", c.Static.MaxUploadSize > 0,
+		"最大上传大小必须大于 0，当前为 %d", c.Static.MaxUploadSize)
+
+	// 日志
+	logLevels := map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
+	check("log.level", logLevels[c.Log.Level], "无效的日志级别: %s", c.Log.Level)
+	logFormats := map[string]bool{"json": true, "text": true}
+	check("log.format", logFormats[c.Log.Format], "无效的日志格式: %s", c.Log.Format)
+
+	// 遥测（启用时）
+	if c.Telemetry.Enabled {
+		check("telemetry.endpoint", c.Telemetry.Endpoint != "", "OTLP 端点不能为空")
+		check("telemetry.protocol",
+			c.Telemetry.Protocol == "grpc" || c.Telemetry.Protocol == "http",
+			"无效的协议: %s（支持 grpc、http）", c.Telemetry.Protocol)
+		check("telemetry.trace_sample_rate",
+			c.Telemetry.TraceSampleRate >= 0 && c.Telemetry.TraceSampleRate <= 1,
+			"采样率必须在 0.0-1.0 之间，当前为 %v", c.Telemetry.TraceSampleRate)
+	}
+
+	// GeoIP（启用时）
+	if c.GeoIP.Enabled {
+		check("geoip.database_path", fileExists(c.GeoIP.DatabasePath),
+			"GeoIP 数据库文件不存在: %s", c.GeoIP.DatabasePath)
+	}
+
+	// 审计日志保留策略（启用时）
+	if c.AuditRetention.Enabled {
+		check("audit_retention.default_days", c.AuditRetention.DefaultDays > 0,
+			"默认保留天数必须大于 0，当前为 %d", c.AuditRetention.DefaultDays)
+		for i, rule := range c.AuditRetention.Rules {
+			check(fmt.Sprintf("audit_retention.rules[%d].days", i), rule.Days > 0,
+				"保留天数必须大于 0，当前为 %d", rule.Days)
+		}
+	}
+
+	// 邮件服务（启用时）
+	if c.Mail.Enabled {
+		check("mail.host", c.Mail.Host != "", "SMTP 服务器地址不能为空")
+		check("mail.port", c.Mail.Port > 0 && c.Mail.Port < 65536,
+			"端口必须在 1-65535 之间，当前为 %d", c.Mail.Port)
+		check("mail.from", c.Mail.From != "", "发件人地址不能为空")
+	}
+
+	return results
+}
+
+// Redacted 返回脱敏后的配置副本，用于打印生效配置
+func (c *Config) Redacted() *Config {
+	redacted := *c
+
+	redacted.Database.Password = maskSecret(c.Database.Password)
+	redacted.JWT.Secret = maskSecret(c.JWT.Secret)
+	redacted.Storage.SecretKey = maskSecret(c.Storage.SecretKey)
+	redacted.Mail.Password = maskSecret(c.Mail.Password)
+
+	admins := make([]AdminConfig, len(c.Admins))
+	copy(admins, c.Admins)
+	for i := range admins {
+		admins[i].Password = maskSecret(admins[i].Password)
+	}
+	redacted.Admins = admins
+
+	routes := make([]CasdoorRouteConfig, len(c.CasdoorRoutes))
+	copy(routes, c.CasdoorRoutes)
+	for i := range routes {
+		routes[i].ClientSecret = maskSecret(routes[i].ClientSecret)
+	}
+	redacted.CasdoorRoutes = routes
+
+	return &redacted
+}
+
+// maskSecret 脱敏密钥，空值保持为空
+func maskSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "******"
+}
+
+// fileExists 检查文件是否存在
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -2,18 +2,22 @@ package database
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
+	"database/sql"
 	"fmt"
+	"strings"
 
 	"zera/ent"
+	"zera/ent/auditlog"
 	"zera/ent/migrate"
 	"zera/ent/role"
 	"zera/ent/user"
+	"zera/internal/auth"
 	"zera/internal/config"
 	"zera/internal/logger"
 	"zera/internal/telemetry"
 
+	"entgo.io/ent/dialect"
+	entsql "entgo.io/ent/dialect/sql"
 	_ "github.com/lib/pq"
 )
 
@@ -28,20 +32,34 @@ type Database struct {
 	Client        *ent.Client
 	config        *config.Config
 	dbHook        *telemetry.DBHook
+	sqlDB         *sql.DB
+	health        *HealthMonitor
 }
 
 // New 创建数据库连接
 func New(cfg *config.Config) (*Database, error) {
-	client, err := ent.Open("postgres", cfg.Database.DSN())
+	drv, err := entsql.Open("postgres", cfg.Database.DSN())
 	if err != nil {
 		return nil, fmt.Errorf("failed opening connection to postgres: %w", err)
 	}
+	// 开发/遥测场景下包装驱动，按请求统计查询次数以发现疑似 N+1
+	var entDriver dialect.Driver = drv
+	if threshold := NPlusOneThreshold(cfg); threshold > 0 {
+		entDriver = newNPlusOneDriver(drv, threshold)
+		logger.Info("N+1 query detection enabled", "threshold", threshold)
+	}
+	client := ent.NewClient(ent.Driver(entDriver))
 
 	db := &Database{
 		Client: client,
 		config: cfg,
+		sqlDB:  drv.DB(),
+		health: newHealthMonitor(drv.DB()),
 	}
 
+	// 启动连接健康监控（失效时后台重连，期间请求快速失败）
+	db.health.Start()
+
 	// 如果启用了遥测，添加数据库日志钩子
 	if cfg.Telemetry.Enabled && cfg.Telemetry.Logs.DBEnabled {
 		loggerSet := telemetry.GetLoggerSet()
@@ -58,18 +76,47 @@ func New(cfg *config.Config) (*Database, error) {
 	return db, nil
 }
 
-// AutoMigrate 开发环境自动迁移
-// 仅在 dev_mode = true 时执行
+// ServerVersion 查询数据库服务器版本（用于启动自检报告）
+func (d *Database) ServerVersion(ctx context.Context) (string, error) {
+	db, err := sql.Open("postgres", d.config.Database.DSN())
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	var version string
+	if err := db.QueryRowContext(ctx, "SELECT version()").Scan(&version); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+// AdminUserExists 检查是否存在拥有管理员角色的用户
+func (d *Database) AdminUserExists(ctx context.Context) (bool, error) {
+	return d.Client.User.Query().
+		Where(user.HasRolesWith(role.Code(RoleCodeAdmin))).
+		Exist(ctx)
+}
+
+// AutoMigrate 启动时自动迁移
+// 仅在 dev_mode = true 或 database.auto_migrate = true 时执行
+// 生产环境默认跳过，检测到 schema 与代码不一致时打印警告，需用 zera migrate 手动迁移
 func (d *Database) AutoMigrate(ctx context.Context) error {
-	if !d.config.App.DevMode {
-		logger.InfoContext(ctx, "skipping auto migration (dev_mode is disabled)")
+	if !d.config.App.DevMode && !d.config.Database.AutoMigrate {
+		logger.InfoContext(ctx, "skipping auto migration (dev_mode and database.auto_migrate are disabled)")
+		d.warnSchemaDrift(ctx)
 		return nil
 	}
 
-	logger.InfoContext(ctx, "running auto migration in development mode")
+	logger.InfoContext(ctx, "running auto migration")
+	return d.Migrate(ctx)
+}
 
+// Migrate 按当前 ent schema 迁移数据库表结构
+// 供启动自动迁移和 zera migrate 命令共用
+func (d *Database) Migrate(ctx context.Context) error {
 	// 使用 ent 的自动迁移功能
-	// WithDropIndex 和 WithDropColumn 允许在开发环境中删除索引和列
+	// WithDropIndex 和 WithDropColumn 允许删除不再使用的索引和列
 	if err := d.Client.Schema.Create(
 		ctx,
 		migrate.WithDropIndex(true),
@@ -78,10 +125,43 @@ func (d *Database) AutoMigrate(ctx context.Context) error {
 		return fmt.Errorf("failed creating schema resources: %w", err)
 	}
 
-	logger.InfoContext(ctx, "auto migration completed successfully")
+	// 审计日志全文检索索引（ent 不支持表达式索引，单独创建）
+	if err := d.ensureAuditLogSearchIndex(ctx); err != nil {
+		return fmt.Errorf("failed creating audit log search index: %w", err)
+	}
+
+	logger.InfoContext(ctx, "migration completed successfully")
 	return nil
 }
 
+// warnSchemaDrift 检测 schema 与代码是否一致，不一致时打印警告
+// 通过 ent 的 WriteTo 做 dry-run，只生成待执行的 DDL 而不改动数据库
+func (d *Database) warnSchemaDrift(ctx context.Context) {
+	var pending strings.Builder
+	if err := d.Client.Schema.WriteTo(
+		ctx,
+		&pending,
+		migrate.WithDropIndex(true),
+		migrate.WithDropColumn(true),
+	); err != nil {
+		logger.WarnContext(ctx, "failed to check schema drift", "error", err)
+		return
+	}
+
+	if strings.TrimSpace(pending.String()) != "" {
+		logger.WarnContext(ctx, "database schema is out of sync with code, run 'zera migrate' to apply changes manually")
+	}
+}
+
+// ensureAuditLogSearchIndex 创建审计日志关键词搜索的 GIN 表达式索引
+// 表达式必须与查询侧 logger.AuditSearchVector 完全一致才能命中索引
+func (d *Database) ensureAuditLogSearchIndex(ctx context.Context) error {
+	stmt := "CREATE INDEX IF NOT EXISTS " + auditlog.Table + "_fts_idx ON " +
+		auditlog.Table + " USING GIN (" + logger.AuditSearchVector + ")"
+	_, err := d.sqlDB.ExecContext(ctx, stmt)
+	return err
+}
+
 // InitSystemRoles 初始化系统内置角色
 func (d *Database) InitSystemRoles(ctx context.Context) error {
 	logger.InfoContext(ctx, "initializing system roles")
@@ -127,10 +207,9 @@ func (d *Database) InitSystemRoles(ctx context.Context) error {
 }
 
 // InitAdminUser 初始化管理员用户
-// 如果管理员用户不存在则创建并分配管理员角色
+// 遍历配置的初始管理员列表，逐个创建不存在的账号并分配管理员角色
+// 已存在的账号跳过不覆盖
 func (d *Database) InitAdminUser(ctx context.Context) error {
-	adminCfg := d.config.Admin
-
 	// 获取管理员角色
 	adminRole, err := d.Client.Role.Query().
 		Where(role.Code(RoleCodeAdmin)).
@@ -139,66 +218,78 @@ func (d *Database) InitAdminUser(ctx context.Context) error {
 		return fmt.Errorf("failed to get admin role: %w", err)
 	}
 
-	// 检查是否已存在拥有管理员角色的用户
-	exists, err := d.Client.User.Query().
-		Where(user.HasRolesWith(role.Code(RoleCodeAdmin))).
-		Exist(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to check admin user: %w", err)
-	}
+	for _, adminCfg := range d.config.Admins {
+		if adminCfg.Username == "" {
+			continue
+		}
 
-	if exists {
-		logger.InfoContext(ctx, "admin user already exists, skipping initialization")
-		return nil
-	}
+		// 检查用户名是否已被占用
+		existingUser, err := d.Client.User.Query().
+			Where(user.Username(adminCfg.Username)).
+			Only(ctx)
+		if err != nil && !ent.IsNotFound(err) {
+			return fmt.Errorf("failed to check username: %w", err)
+		}
 
-	// 检查用户名是否已被占用
-	existingUser, err := d.Client.User.Query().
-		Where(user.Username(adminCfg.Username)).
-		Only(ctx)
-	if err != nil && !ent.IsNotFound(err) {
-		return fmt.Errorf("failed to check username: %w", err)
-	}
+		// 如果用户已存在，不覆盖账号信息，仅补齐管理员角色
+		if existingUser != nil {
+			hasRole, err := existingUser.QueryRoles().
+				Where(role.Code(RoleCodeAdmin)).
+				Exist(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to check admin role: %w", err)
+			}
+			if hasRole {
+				logger.InfoContext(ctx, "admin user already exists, skipping", "username", adminCfg.Username)
+				continue
+			}
 
-	// 如果用户已存在，只需分配角色
-	if existingUser != nil {
-		logger.InfoContext(ctx, "user exists, adding admin role", "username", adminCfg.Username)
-		_, err = existingUser.Update().
+			logger.InfoContext(ctx, "user exists, adding admin role", "username", adminCfg.Username)
+			_, err = existingUser.Update().
+				AddRoles(adminRole).
+				Save(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to add admin role to user: %w", err)
+			}
+			continue
+		}
+
+		// 创建管理员用户
+		logger.InfoContext(ctx, "creating admin user", "username", adminCfg.Username)
+
+		passwordHash, err := auth.HashPassword(adminCfg.Password)
+		if err != nil {
+			return fmt.Errorf("failed to hash admin password: %w", err)
+		}
+
+		_, err = d.Client.User.Create().
+			SetUsername(adminCfg.Username).
+			SetEmail(adminCfg.Email).
+			SetPasswordHash(passwordHash).
+			SetNickname("Administrator").
+			SetStatus(user.StatusActive).
 			AddRoles(adminRole).
 			Save(ctx)
 		if err != nil {
-			return fmt.Errorf("failed to add admin role to user: %w", err)
+			return fmt.Errorf("failed to create admin user: %w", err)
 		}
-		return nil
-	}
 
-	// 创建管理员用户
-	logger.InfoContext(ctx, "creating admin user", "username", adminCfg.Username)
-
-	_, err = d.Client.User.Create().
-		SetUsername(adminCfg.Username).
-		SetEmail(adminCfg.Email).
-		SetPasswordHash(hashPassword(adminCfg.Password)).
-		SetNickname("Administrator").
-		SetStatus(user.StatusActive).
-		AddRoles(adminRole).
-		Save(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to create admin user: %w", err)
+		logger.InfoContext(ctx, "admin user created successfully", "username", adminCfg.Username)
 	}
 
-	logger.InfoContext(ctx, "admin user created successfully")
 	return nil
 }
 
-// hashPassword 简单的密码哈希（生产环境应使用 bcrypt）
-func hashPassword(password string) string {
-	hash := sha256.Sum256([]byte(password))
-	return hex.EncodeToString(hash[:])
+// Healthy 数据库连接当前是否可用
+func (d *Database) Healthy() bool {
+	return d.health.Healthy()
 }
 
 // Close 关闭数据库连接
 func (d *Database) Close() error {
+	if d.health != nil {
+		d.health.Stop()
+	}
 	if d.Client != nil {
 		return d.Client.Close()
 	}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"time"
+
+	"zera/internal/logger"
+)
+
+// 健康检查参数
+const (
+	// healthCheckInterval 健康检查周期
+	healthCheckInterval = 15 * time.Second
+	// healthPingTimeout 单次 Ping 超时
+	healthPingTimeout = 5 * time.Second
+	// reconnectBaseDelay 重连退避起始间隔
+	reconnectBaseDelay = time.Second
+	// reconnectMaxDelay 重连退避最大间隔
+	reconnectMaxDelay = 30 * time.Second
+)
+
+// HealthMonitor 数据库连接健康监控
+// 周期性 Ping 数据库，连接失效时标记不可用并在后台按指数退避重连，
+// 失效期间请求由拦截器快速失败，恢复后自动放行并记录日志
+type HealthMonitor struct {
+	db      *sql.DB
+	healthy atomic.Bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// newHealthMonitor 创建健康监控，初始状态为可用
+func newHealthMonitor(db *sql.DB) *HealthMonitor {
+	m := &HealthMonitor{
+		db:   db,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	m.healthy.Store(true)
+	return m
+}
+
+// Healthy 当前连接是否可用
+func (m *HealthMonitor) Healthy() bool {
+	return m.healthy.Load()
+}
+
+// Start 启动后台健康检查
+func (m *HealthMonitor) Start() {
+	go m.run()
+}
+
+// Stop 停止健康检查并等待后台协程退出
+func (m *HealthMonitor) Stop() {
+	close(m.stop)
+	<-m.done
+}
+
+// run 健康检查主循环
+func (m *HealthMonitor) run() {
+	defer close(m.done)
+
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			if err := m.ping(); err != nil {
+				m.healthy.Store(false)
+				logger.Error("database connection lost, reconnecting in background", "error", err)
+				if !m.reconnect() {
+					return
+				}
+			}
+		}
+	}
+}
+
+// reconnect 按指数退避重试 Ping 直到连接恢复
+// 返回 false 表示监控已停止
+func (m *HealthMonitor) reconnect() bool {
+	delay := reconnectBaseDelay
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-m.stop:
+			return false
+		case <-time.After(delay):
+		}
+
+		if err := m.ping(); err != nil {
+			logger.Warn("database reconnect attempt failed",
+				"attempt", attempt,
+				"next_retry", delay.String(),
+				"error", err,
+			)
+			delay *= 2
+			if delay > reconnectMaxDelay {
+				delay = reconnectMaxDelay
+			}
+			continue
+		}
+
+		m.healthy.Store(true)
+		logger.Info("database connection restored", "attempts", attempt)
+		return true
+	}
+}
+
+// ping 带超时的连接检查
+// database/sql 在 Ping 失败时会丢弃失效连接，成功即代表连接池已恢复
+func (m *HealthMonitor) ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), healthPingTimeout)
+	defer cancel()
+	return m.db.PingContext(ctx)
+}
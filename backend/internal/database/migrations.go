@@ -0,0 +1,261 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"zera/internal/logger"
+	"zera/internal/pinyin"
+)
+
+// Migration 版本化迁移
+// 与 ent 的 schema diff 不同，版本化迁移带版本号、可回滚，
+// 主要承载数据迁移等无法用 schema diff 表达的自定义脚本
+type Migration struct {
+	// Version 迁移版本号，全局唯一且递增
+	Version int
+	// Name 迁移名称，用于日志和状态展示
+	Name string
+	// Up 前进脚本，在事务内执行
+	Up func(ctx context.Context, tx *sql.Tx) error
+	// Down 回退脚本，在事务内执行；为 nil 表示该迁移不可回滚
+	Down func(ctx context.Context, tx *sql.Tx) error
+}
+
+// migrations 全部版本化迁移，按 Version 递增排列
+// 新增迁移追加到末尾，版本号只增不改，已发布的迁移不允许修改
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "backfill user pinyin index",
+		Up:      backfillUserPinyinUp,
+		Down:    backfillUserPinyinDown,
+	},
+}
+
+// migrationsTable 记录已应用迁移版本的表名
+const migrationsTable = "migrations"
+
+// MigrationStatus 单个迁移的应用状态
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// ensureMigrationsTable 创建迁移版本记录表
+func (d *Database) ensureMigrationsTable(ctx context.Context) error {
+	_, err := d.sqlDB.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+migrationsTable+` (
+		version BIGINT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`)
+	return err
+}
+
+// appliedMigrations 查询已应用的迁移版本及应用时间
+func (d *Database) appliedMigrations(ctx context.Context) (map[int]time.Time, error) {
+	rows, err := d.sqlDB.QueryContext(ctx, "SELECT version, applied_at FROM "+migrationsTable)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// sortedMigrations 返回按版本号递增排序的迁移列表副本
+func sortedMigrations() []Migration {
+	list := make([]Migration, len(migrations))
+	copy(list, migrations)
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list
+}
+
+// MigrateUp 应用所有未执行的版本化迁移
+// 每个迁移在独立事务内执行并记录版本，失败时该迁移回滚且不再继续
+func (d *Database) MigrateUp(ctx context.Context) (int, error) {
+	if err := d.ensureMigrationsTable(ctx); err != nil {
+		return 0, fmt.Errorf("failed to ensure migrations table: %w", err)
+	}
+
+	applied, err := d.appliedMigrations(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+
+	count := 0
+	for _, m := range sortedMigrations() {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		logger.InfoContext(ctx, "applying migration", "version", m.Version, "name", m.Name)
+		if err := d.runMigration(ctx, m, true); err != nil {
+			return count, fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+// MigrateDown 按版本倒序回退最近应用的 steps 个迁移
+// 回退脚本与版本记录删除在同一事务内执行
+func (d *Database) MigrateDown(ctx context.Context, steps int) (int, error) {
+	if steps < 1 {
+		steps = 1
+	}
+
+	if err := d.ensureMigrationsTable(ctx); err != nil {
+		return 0, fmt.Errorf("failed to ensure migrations table: %w", err)
+	}
+
+	applied, err := d.appliedMigrations(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+
+	// 已应用版本按倒序回退
+	versions := make([]int, 0, len(applied))
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+	byVersion := make(map[int]Migration, len(migrations))
+	for _, m := range migrations {
+		byVersion[m.Version] = m
+	}
+
+	count := 0
+	for _, v := range versions {
+		if count >= steps {
+			break
+		}
+
+		m, ok := byVersion[v]
+		if !ok {
+			// 数据库记录的版本在当前代码中不存在，通常是用旧版本二进制回退新版本的迁移
+			return count, fmt.Errorf("migration %d is not defined in this build, use a newer binary to roll it back", v)
+		}
+		if m.Down == nil {
+			return count, fmt.Errorf("migration %d (%s) is irreversible", m.Version, m.Name)
+		}
+
+		logger.InfoContext(ctx, "rolling back migration", "version", m.Version, "name", m.Name)
+		if err := d.runMigration(ctx, m, false); err != nil {
+			return count, fmt.Errorf("rollback of migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+// runMigration 在事务内执行迁移脚本并同步版本记录
+func (d *Database) runMigration(ctx context.Context, m Migration, up bool) error {
+	tx, err := d.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if up {
+		err = m.Up(ctx, tx)
+		if err == nil {
+			_, err = tx.ExecContext(ctx,
+				"INSERT INTO "+migrationsTable+" (version, name) VALUES ($1, $2)", m.Version, m.Name)
+		}
+	} else {
+		err = m.Down(ctx, tx)
+		if err == nil {
+			_, err = tx.ExecContext(ctx,
+				"DELETE FROM "+migrationsTable+" WHERE version = $1", m.Version)
+		}
+	}
+
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// MigrationStatuses 返回全部迁移及其应用状态，按版本号递增排列
+func (d *Database) MigrationStatuses(ctx context.Context) ([]MigrationStatus, error) {
+	if err := d.ensureMigrationsTable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to ensure migrations table: %w", err)
+	}
+
+	applied, err := d.appliedMigrations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range sortedMigrations() {
+		appliedAt, ok := applied[m.Version]
+		statuses = append(statuses, MigrationStatus{
+			Version:   m.Version,
+			Name:      m.Name,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+	}
+	return statuses, nil
+}
+
+// backfillUserPinyinUp 为历史用户补齐昵称拼音索引
+// pinyin 字段引入前创建的用户该字段为空，无法被拼音搜索命中
+func backfillUserPinyinUp(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx,
+		"SELECT id, nickname FROM users WHERE (pinyin IS NULL OR pinyin = '') AND nickname <> ''")
+	if err != nil {
+		return err
+	}
+
+	type userRow struct {
+		id       int
+		nickname string
+	}
+	users := make([]userRow, 0)
+	for rows.Next() {
+		var u userRow
+		if err := rows.Scan(&u.id, &u.nickname); err != nil {
+			rows.Close()
+			return err
+		}
+		users = append(users, u)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE users SET pinyin = $1 WHERE id = $2", pinyin.Index(u.nickname), u.id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillUserPinyinDown 清空补齐的拼音索引
+func backfillUserPinyinDown(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "UPDATE users SET pinyin = ''")
+	return err
+}
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"context"
+	"regexp"
+	"sync"
+
+	"zera/internal/config"
+	"zera/internal/logger"
+
+	"entgo.io/ent/dialect"
+)
+
+// queryCounterKey 查询计数器上下文键
+type queryCounterKey struct{}
+
+// queryCounter 单个请求内按表累计的查询计数
+// 同一张表的查询次数超过阈值时视为疑似 N+1，每张表每个请求只告警一次
+type queryCounter struct {
+	mu        sync.Mutex
+	procedure string
+	counts    map[string]int
+	warned    map[string]bool
+}
+
+// WithQueryCounter 在请求上下文中挂载查询计数器
+// 由拦截器在请求入口调用，procedure 用于告警时定位接口
+func WithQueryCounter(ctx context.Context, procedure string) context.Context {
+	return context.WithValue(ctx, queryCounterKey{}, &queryCounter{
+		procedure: procedure,
+		counts:    make(map[string]int),
+		warned:    make(map[string]bool),
+	})
+}
+
+// NPlusOneThreshold 返回疑似 N+1 检测阈值，0 表示不启用
+// 仅在开发模式或启用数据库遥测日志时开启，避免影响生产性能
+func NPlusOneThreshold(cfg *config.Config) int {
+	if !cfg.App.DevMode && !(cfg.Telemetry.Enabled && cfg.Telemetry.Logs.DBEnabled) {
+		return 0
+	}
+	return int(cfg.Telemetry.Logs.DBNPlusOneThreshold)
+}
+
+// nPlusOneDriver 包装 ent 驱动，统计单个请求内各表的查询次数
+// 上下文中没有计数器时（如后台任务）不做任何统计
+type nPlusOneDriver struct {
+	dialect.Driver
+	threshold int
+}
+
+// newNPlusOneDriver 创建 N+1 检测驱动包装器
+func newNPlusOneDriver(drv dialect.Driver, threshold int) *nPlusOneDriver {
+	return &nPlusOneDriver{
+		Driver:    drv,
+		threshold: threshold,
+	}
+}
+
+// Exec 执行写操作并计入统计
+func (d *nPlusOneDriver) Exec(ctx context.Context, query string, args, v any) error {
+	d.record(ctx, query)
+	return d.Driver.Exec(ctx, query, args, v)
+}
+
+// Query 执行查询并计入统计
+func (d *nPlusOneDriver) Query(ctx context.Context, query string, args, v any) error {
+	d.record(ctx, query)
+	return d.Driver.Query(ctx, query, args, v)
+}
+
+// Tx 开启事务，事务内的查询同样计入统计
+func (d *nPlusOneDriver) Tx(ctx context.Context) (dialect.Tx, error) {
+	tx, err := d.Driver.Tx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &nPlusOneTx{Tx: tx, driver: d}, nil
+}
+
+// nPlusOneTx 事务包装器，转发统计到驱动
+type nPlusOneTx struct {
+	dialect.Tx
+	driver *nPlusOneDriver
+}
+
+// Exec 执行事务内写操作并计入统计
+func (t *nPlusOneTx) Exec(ctx context.Context, query string, args, v any) error {
+	t.driver.record(ctx, query)
+	return t.Tx.Exec(ctx, query, args, v)
+}
+
+// Query 执行事务内查询并计入统计
+func (t *nPlusOneTx) Query(ctx context.Context, query string, args, v any) error {
+	t.driver.record(ctx, query)
+	return t.Tx.Query(ctx, query, args, v)
+}
+
+// tablePattern 从 SQL 中提取首个表名（FROM/INTO/UPDATE 后的标识符）
+var tablePattern = regexp.MustCompile(`(?i)\b(?:FROM|INTO|UPDATE)\s+"?([a-zA-Z_][a-zA-Z0-9_]*)"?`)
+
+// tableFromSQL 提取 SQL 涉及的主表名，无法识别时返回空字符串
+func tableFromSQL(query string) string {
+	m := tablePattern.FindStringSubmatch(query)
+	if len(m) < 2 {
+		return ""
+	}
+	return m[1]
+}
+
+// record 累计表查询次数，超过阈值时记录疑似 N+1 告警
+func (d *nPlusOneDriver) record(ctx context.Context, query string) {
+	c, ok := ctx.Value(queryCounterKey{}).(*queryCounter)
+	if !ok {
+		return
+	}
+
+	table := tableFromSQL(query)
+	if table == "" {
+		return
+	}
+
+	c.mu.Lock()
+	c.counts[table]++
+	count := c.counts[table]
+	shouldWarn := count > d.threshold && !c.warned[table]
+	if shouldWarn {
+		c.warned[table] = true
+	}
+	c.mu.Unlock()
+
+	if shouldWarn {
+		logger.WarnContext(ctx, "suspected N+1 query pattern",
+			"procedure", c.procedure,
+			"table", table,
+			"count", count,
+			"threshold", d.threshold,
+			"sql", query,
+		)
+	}
+}
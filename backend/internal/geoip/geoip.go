@@ -0,0 +1,103 @@
+// Package geoip 提供可选的 IP 地理位置解析能力
+// 基于 MaxMind GeoIP2/GeoLite2 数据库文件，未配置数据库路径时解析被跳过
+package geoip
+
+import (
+	"fmt"
+	"net"
+
+	"zera/internal/config"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// Location IP 解析结果
+type Location struct {
+	// Country 国家名称（中文优先）
+	Country string
+	// City 城市名称（中文优先）
+	City string
+}
+
+// String 返回 "国家/城市" 形式的展示文本
+func (l *Location) String() string {
+	if l == nil {
+		return ""
+	}
+	if l.City != "" {
+		return l.Country + "/" + l.City
+	}
+	return l.Country
+}
+
+// Resolver IP 地理位置解析器
+type Resolver struct {
+	reader *geoip2.Reader
+}
+
+// 全局解析器，未初始化或初始化失败时保持 nil（解析被跳过）
+var defaultResolver *Resolver
+
+// Init 根据配置初始化全局解析器
+// 未启用或未配置数据库路径时返回 nil（不视为错误）
+func Init(cfg *config.GeoIPConfig) error {
+	if cfg == nil || !cfg.Enabled || cfg.DatabasePath == "" {
+		return nil
+	}
+
+	reader, err := geoip2.Open(cfg.DatabasePath)
+	if err != nil {
+		return fmt.Errorf("failed to open geoip database: %w", err)
+	}
+
+	defaultResolver = &Resolver{reader: reader}
+	return nil
+}
+
+// Close 关闭全局解析器
+func Close() {
+	if defaultResolver != nil {
+		_ = defaultResolver.reader.Close()
+		defaultResolver = nil
+	}
+}
+
+// Enabled 解析器是否可用
+func Enabled() bool {
+	return defaultResolver != nil
+}
+
+// Lookup 解析 IP 的地理位置
+// 解析器未启用、IP 无效或未命中时返回 nil
+func Lookup(ip string) *Location {
+	if defaultResolver == nil {
+		return nil
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return nil
+	}
+
+	record, err := defaultResolver.reader.City(parsed)
+	if err != nil {
+		return nil
+	}
+
+	loc := &Location{
+		Country: localizedName(record.Country.Names),
+		City:    localizedName(record.City.Names),
+	}
+	if loc.Country == "" && loc.City == "" {
+		return nil
+	}
+	return loc
+}
+
+// localizedName 优先返回中文名称，其次英文
+func localizedName(names map[string]string) string {
+	if name, ok := names["zh-CN"]; ok {
+		return name
+	}
+	return names["en"]
+}
@@ -4,11 +4,15 @@ import (
 	"context"
 	"errors"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"zera/gen/base"
 	"zera/gen/base/baseconnect"
 	"zera/internal/logger"
+	"zera/internal/pagination"
+	"zera/internal/permission"
 	"zera/internal/service"
 
 	"buf.build/go/protovalidate"
@@ -72,7 +76,7 @@ func (h *AuditLogHandler) ListAuditLogs(
 	}
 
 	// 调用服务层
-	result, err := h.auditLogService.ListAuditLogs(
+	result, nextCursor, err := h.auditLogService.ListAuditLogs(
 		ctx,
 		int(req.Msg.Page),
 		int(req.Msg.PageSize),
@@ -87,22 +91,44 @@ func (h *AuditLogHandler) ListAuditLogs(
 		req.Msg.Keyword,
 		req.Msg.SortBy,
 		req.Msg.Descending,
+		req.Msg.ExactTotal,
+		req.Msg.Cursor,
 	)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, errors.New("获取日志列表失败"))
+		if errors.Is(err, pagination.ErrInvalidCursor) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("无效的分页游标"))
+		}
+		return nil, WrapError(ctx, err, "获取日志列表失败")
 	}
 
-	// 转换为 proto 响应
+	// 转换为 proto 响应，请求/响应体按字段级权限决定是否返回
+	includeBody := canReadAuditBody(ctx)
 	logs := make([]*base.AuditLogEntry, 0, len(result.Entries))
 	for idx, entry := range result.Entries {
-		logs = append(logs, h.entryToProto(entry, idx))
+		logs = append(logs, h.entryToProto(entry, idx, includeBody))
+	}
+
+	// 关键字搜索时附带命中信息，供前端高亮
+	var keywordMatches []*base.AuditLogKeywordMatches
+	if req.Msg.Keyword != "" {
+		for idx, entry := range result.Entries {
+			positions := findKeywordMatches(entry, req.Msg.Keyword)
+			if len(positions) > 0 {
+				keywordMatches = append(keywordMatches, &base.AuditLogKeywordMatches{
+					LogIndex:  int32(idx),
+					Positions: positions,
+				})
+			}
+		}
 	}
 
 	return connect.NewResponse(&base.ListAuditLogsResponse{
-		Logs:     logs,
-		Total:    result.Total,
-		Page:     int32(result.Page),
-		PageSize: int32(result.PageSize),
+		Logs:           logs,
+		Total:          result.Total,
+		Page:           int32(result.Page),
+		PageSize:       int32(result.PageSize),
+		KeywordMatches: keywordMatches,
+		NextCursor:     nextCursor,
 	}), nil
 }
 
@@ -122,7 +148,7 @@ func (h *AuditLogHandler) GetAuditLog(
 	}
 
 	return connect.NewResponse(&base.GetAuditLogResponse{
-		Log: h.entryToProtoWithID(entry, req.Msg.Id),
+		Log: h.entryToProtoWithID(ctx, entry, req.Msg.Id),
 	}), nil
 }
 
@@ -148,7 +174,7 @@ func (h *AuditLogHandler) GetAuditLogStats(
 
 	stats, err := h.auditLogService.GetAuditLogStats(ctx, startTime, endTime)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, errors.New("获取日志统计失败"))
+		return nil, WrapError(ctx, err, "获取日志统计失败")
 	}
 
 	// 转换 level counts
@@ -165,6 +191,53 @@ func (h *AuditLogHandler) GetAuditLogStats(
 	}), nil
 }
 
+// GetTimeSeries 获取时间序列统计（按天/小时分桶）
+func (h *AuditLogHandler) GetTimeSeries(
+	ctx context.Context,
+	req *connect.Request[base.GetTimeSeriesRequest],
+) (*connect.Response[base.GetTimeSeriesResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	// 解析时间范围
+	opts := logger.TimeSeriesOptions{
+		Interval: req.Msg.Interval,
+		Level:    logger.Level(req.Msg.Level),
+		Module:   req.Msg.Module,
+	}
+	if req.Msg.StartTime != "" {
+		t, err := time.Parse(time.RFC3339, req.Msg.StartTime)
+		if err == nil {
+			opts.StartTime = t
+		}
+	}
+	if req.Msg.EndTime != "" {
+		t, err := time.Parse(time.RFC3339, req.Msg.EndTime)
+		if err == nil {
+			opts.EndTime = t
+		}
+	}
+
+	points, err := h.auditLogService.GetTimeSeries(ctx, opts)
+	if err != nil {
+		return nil, WrapError(ctx, err, "获取时间序列统计失败")
+	}
+
+	protoPoints := make([]*base.TimeSeriesPoint, 0, len(points))
+	for _, p := range points {
+		protoPoints = append(protoPoints, &base.TimeSeriesPoint{
+			Timestamp: p.Timestamp.Format(time.RFC3339),
+			Count:     p.Count,
+		})
+	}
+
+	return connect.NewResponse(&base.GetTimeSeriesResponse{
+		Points: protoPoints,
+	}), nil
+}
+
 // ListAuditLogModules 获取可用模块列表
 func (h *AuditLogHandler) ListAuditLogModules(
 	ctx context.Context,
@@ -172,7 +245,7 @@ func (h *AuditLogHandler) ListAuditLogModules(
 ) (*connect.Response[base.ListAuditLogModulesResponse], error) {
 	modules, err := h.auditLogService.GetModules(ctx)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, errors.New("获取模块列表失败"))
+		return nil, WrapError(ctx, err, "获取模块列表失败")
 	}
 
 	return connect.NewResponse(&base.ListAuditLogModulesResponse{
@@ -180,8 +253,125 @@ func (h *AuditLogHandler) ListAuditLogModules(
 	}), nil
 }
 
+// GetUserRecentLogs 获取用户最近操作
+func (h *AuditLogHandler) GetUserRecentLogs(
+	ctx context.Context,
+	req *connect.Request[base.GetUserRecentLogsRequest],
+) (*connect.Response[base.GetUserRecentLogsResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	entries, err := h.auditLogService.GetRecentByUser(ctx, int(req.Msg.UserId), int(req.Msg.Limit))
+	if err != nil {
+		return nil, WrapError(ctx, err, "获取用户最近操作失败")
+	}
+
+	// 转换为 proto 响应，使用数据库真实 ID
+	includeBody := canReadAuditBody(ctx)
+	logs := make([]*base.AuditLogEntry, 0, len(entries))
+	for _, entry := range entries {
+		protoEntry := h.entryToProto(entry.Entry, 0, includeBody)
+		protoEntry.Id = entry.ID
+		logs = append(logs, protoEntry)
+	}
+
+	return connect.NewResponse(&base.GetUserRecentLogsResponse{
+		Logs: logs,
+	}), nil
+}
+
+// GetResourceHistory 获取资源操作历史
+func (h *AuditLogHandler) GetResourceHistory(
+	ctx context.Context,
+	req *connect.Request[base.GetResourceHistoryRequest],
+) (*connect.Response[base.GetResourceHistoryResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	entries, err := h.auditLogService.GetResourceHistory(ctx, req.Msg.Resource, req.Msg.ResourceId, int(req.Msg.Limit))
+	if err != nil {
+		return nil, WrapError(ctx, err, "获取资源操作历史失败")
+	}
+
+	// 转换为 proto 响应，使用数据库真实 ID
+	includeBody := canReadAuditBody(ctx)
+	logs := make([]*base.AuditLogEntry, 0, len(entries))
+	for _, entry := range entries {
+		protoEntry := h.entryToProto(entry.Entry, 0, includeBody)
+		protoEntry.Id = entry.ID
+		logs = append(logs, protoEntry)
+	}
+
+	return connect.NewResponse(&base.GetResourceHistoryResponse{
+		Logs: logs,
+	}), nil
+}
+
+// keywordSearchFields 关键字模糊匹配涉及的字段（与 logger 查询实现保持一致）
+var keywordSearchFields = []string{"module", "action", "username", "details", "error_message"}
+
+// findKeywordMatches 在关键字搜索涉及的字段中查找命中位置
+// 位置按字符（rune）计数，与前端字符串下标对齐
+func findKeywordMatches(entry *logger.Entry, keyword string) []*base.KeywordMatchPosition {
+	fieldValues := map[string]string{
+		"module":        entry.Module,
+		"action":        entry.Action,
+		"username":      entry.Username,
+		"details":       entry.Details,
+		"error_message": entry.ErrorMessage,
+	}
+
+	keywordLen := utf8.RuneCountInString(keyword)
+	var positions []*base.KeywordMatchPosition
+	for _, field := range keywordSearchFields {
+		value := fieldValues[field]
+		if value == "" {
+			continue
+		}
+
+		// 查找字段内所有命中位置
+		offset := 0 // 已扫描部分的字节长度
+		for {
+			i := strings.Index(value[offset:], keyword)
+			if i < 0 {
+				break
+			}
+			start := utf8.RuneCountInString(value[:offset+i])
+			positions = append(positions, &base.KeywordMatchPosition{
+				Field:  field,
+				Start:  int32(start),
+				Length: int32(keywordLen),
+			})
+			offset += i + len(keyword)
+		}
+	}
+
+	return positions
+}
+
+// auditBodyPermission 查看请求/响应体所需的字段级权限
+const auditBodyPermission = "audit_log:read-body"
+
+// canReadAuditBody 判断调用者是否可以查看审计日志的请求/响应体
+// 字段级权限: 仅拥有 audit_log:read-body 权限（或管理员）时返回敏感字段
+func canReadAuditBody(ctx context.Context) bool {
+	roles, _ := ctx.Value(ContextKeyRoles).([]string)
+	for _, r := range roles {
+		if r == "admin" {
+			return true
+		}
+	}
+
+	permissions, _ := ctx.Value(ContextKeyPermissions).([]string)
+	return permission.Matches(permissions, auditBodyPermission)
+}
+
 // entryToProto 将 logger.Entry 转换为 proto 消息
-func (h *AuditLogHandler) entryToProto(entry *logger.Entry, idx int) *base.AuditLogEntry {
+func (h *AuditLogHandler) entryToProto(entry *logger.Entry, idx int, includeBody bool) *base.AuditLogEntry {
 	protoEntry := &base.AuditLogEntry{
 		Id:        strconv.Itoa(idx), // 临时 ID，实际应从数据库获取
 		Module:    entry.Module,
@@ -225,12 +415,63 @@ func (h *AuditLogHandler) entryToProto(entry *logger.Entry, idx int) *base.Audit
 		protoEntry.ErrorMessage = entry.ErrorMessage
 	}
 
+	// 敏感字段仅在调用者拥有字段级权限时返回
+	if includeBody {
+		protoEntry.RequestBody = entry.RequestBody
+		protoEntry.ResponseBody = entry.ResponseBody
+	}
+
 	return protoEntry
 }
 
 // entryToProtoWithID 将 logger.Entry 转换为带 ID 的 proto 消息
-func (h *AuditLogHandler) entryToProtoWithID(entry *logger.Entry, id string) *base.AuditLogEntry {
-	protoEntry := h.entryToProto(entry, 0)
+func (h *AuditLogHandler) entryToProtoWithID(ctx context.Context, entry *logger.Entry, id string) *base.AuditLogEntry {
+	protoEntry := h.entryToProto(entry, 0, canReadAuditBody(ctx))
 	protoEntry.Id = id
 	return protoEntry
 }
+
+// ExportAuditLogs 导出审计日志为 SIEM 格式（CEF/LEEF）
+func (h *AuditLogHandler) ExportAuditLogs(
+	ctx context.Context,
+	req *connect.Request[base.ExportAuditLogsRequest],
+) (*connect.Response[base.ExportAuditLogsResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	// 构建查询条件（按时间倒序取最近记录）
+	opts := logger.QueryOptions{
+		PageSize:   int(req.Msg.Limit),
+		Level:      logger.Level(req.Msg.Level),
+		Module:     req.Msg.Module,
+		SortBy:     "created_at",
+		Descending: true,
+	}
+	if req.Msg.StartTime != "" {
+		t, err := time.Parse(time.RFC3339, req.Msg.StartTime)
+		if err == nil {
+			opts.StartTime = &t
+		}
+	}
+	if req.Msg.EndTime != "" {
+		t, err := time.Parse(time.RFC3339, req.Msg.EndTime)
+		if err == nil {
+			opts.EndTime = &t
+		}
+	}
+
+	data, count, err := h.auditLogService.ExportAuditLogs(ctx, opts, req.Msg.Format)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidExportFormat) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		return nil, WrapError(ctx, err, "导出审计日志失败")
+	}
+
+	return connect.NewResponse(&base.ExportAuditLogsResponse{
+		Data:  data,
+		Count: int32(count),
+	}), nil
+}
@@ -3,12 +3,14 @@ package handler
 import (
 	"context"
 	"errors"
+	"net/http"
 	"strings"
 
 	"zera/gen/base"
 	"zera/gen/base/baseconnect"
 	"zera/internal/auth"
 	"zera/internal/logger"
+	"zera/internal/permission"
 	"zera/internal/service"
 
 	"buf.build/go/protovalidate"
@@ -34,19 +36,25 @@ type AuthHandler struct {
 	baseconnect.UnimplementedAuthServiceHandler
 	validator   protovalidate.Validator
 	authService *service.AuthService
+	userService *service.UserService
 	jwtManager  *auth.JWTManager
+	nonceStore  *auth.NonceStore
 }
 
 // NewAuthHandler 创建认证处理器
 func NewAuthHandler(
 	validator protovalidate.Validator,
 	authService *service.AuthService,
+	userService *service.UserService,
 	jwtManager *auth.JWTManager,
+	nonceStore *auth.NonceStore,
 ) *AuthHandler {
 	return &AuthHandler{
 		validator:   validator,
 		authService: authService,
+		userService: userService,
 		jwtManager:  jwtManager,
+		nonceStore:  nonceStore,
 	}
 }
 
@@ -63,7 +71,13 @@ func (h *AuthHandler) Login(
 	logger.InfoContext(ctx, "login attempt", "username", req.Msg.Username)
 
 	// 调用服务层
-	resp, err := h.authService.Login(ctx, req.Msg.Username, req.Msg.Password)
+	// 客户端类型优先取请求参数，为空时回退 X-Client-Type 请求头
+	clientType := req.Msg.ClientType
+	if clientType == "" {
+		clientType = req.Header().Get("X-Client-Type")
+	}
+
+	resp, err := h.authService.Login(ctx, req.Msg.Username, req.Msg.Password, clientIPFromHeader(req.Header()), req.Header().Get("User-Agent"), clientType)
 	if err != nil {
 		if errors.Is(err, service.ErrInvalidCredentials) {
 			logger.WarnContext(ctx, "login failed: invalid credentials", "username", req.Msg.Username)
@@ -73,8 +87,12 @@ func (h *AuthHandler) Login(
 			logger.WarnContext(ctx, "login failed: user inactive", "username", req.Msg.Username)
 			return nil, connect.NewError(connect.CodePermissionDenied, errors.New("用户已被禁用"))
 		}
+		if errors.Is(err, service.ErrTooManyLoginAttempts) {
+			logger.WarnContext(ctx, "login failed: rate limited", "username", req.Msg.Username)
+			return nil, connect.NewError(connect.CodeResourceExhausted, errors.New("登录尝试过于频繁，请稍后再试"))
+		}
 		logger.ErrorContext(ctx, "login error", "username", req.Msg.Username, "error", err)
-		return nil, connect.NewError(connect.CodeInternal, errors.New("登录失败"))
+		return nil, WrapError(ctx, err, "登录失败")
 	}
 
 	logger.InfoContext(ctx, "user logged in successfully", "username", req.Msg.Username)
@@ -117,6 +135,276 @@ func (h *AuthHandler) Register(
 	return connect.NewResponse(resp), nil
 }
 
+// SendLoginCode 发送邮箱登录验证码
+func (h *AuthHandler) SendLoginCode(
+	ctx context.Context,
+	req *connect.Request[base.SendLoginCodeRequest],
+) (*connect.Response[base.SendLoginCodeResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	resp, err := h.authService.SendLoginCode(ctx, req.Msg.Email)
+	if err != nil {
+		if errors.Is(err, service.ErrMailerDisabled) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("邮件服务未启用，无法发送验证码"))
+		}
+		if errors.Is(err, auth.ErrLoginCodeThrottled) {
+			return nil, connect.NewError(connect.CodeResourceExhausted, errors.New("验证码发送过于频繁，请稍后再试"))
+		}
+		return nil, WrapError(ctx, err, "发送验证码失败")
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+// LoginWithCode 邮箱验证码登录
+func (h *AuthHandler) LoginWithCode(
+	ctx context.Context,
+	req *connect.Request[base.LoginWithCodeRequest],
+) (*connect.Response[base.LoginResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	logger.InfoContext(ctx, "login with code attempt", "email", req.Msg.Email)
+
+	resp, err := h.authService.LoginWithCode(ctx, req.Msg.Email, req.Msg.Code, clientIPFromHeader(req.Header()), req.Header().Get("User-Agent"))
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidLoginCode) {
+			logger.WarnContext(ctx, "login with code failed: invalid code", "email", req.Msg.Email)
+			return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("验证码错误或已过期"))
+		}
+		if errors.Is(err, service.ErrRegistrationDisabled) {
+			logger.WarnContext(ctx, "login with code failed: registration disabled", "email", req.Msg.Email)
+			return nil, connect.NewError(connect.CodePermissionDenied, errors.New("邮箱未注册且系统未开放注册"))
+		}
+		if errors.Is(err, service.ErrUserInactive) {
+			logger.WarnContext(ctx, "login with code failed: user inactive", "email", req.Msg.Email)
+			return nil, connect.NewError(connect.CodePermissionDenied, errors.New("用户已被禁用"))
+		}
+		logger.ErrorContext(ctx, "login with code error", "email", req.Msg.Email, "error", err)
+		return nil, WrapError(ctx, err, "登录失败")
+	}
+
+	logger.InfoContext(ctx, "user logged in with email code", "email", req.Msg.Email)
+
+	return connect.NewResponse(resp), nil
+}
+
+// RequestPasswordReset 申请密码重置
+func (h *AuthHandler) RequestPasswordReset(
+	ctx context.Context,
+	req *connect.Request[base.RequestPasswordResetRequest],
+) (*connect.Response[base.RequestPasswordResetResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	resp, err := h.authService.RequestPasswordReset(ctx, req.Msg.Email)
+	if err != nil {
+		if errors.Is(err, service.ErrMailerDisabled) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("邮件服务未启用，无法发送重置邮件"))
+		}
+		if errors.Is(err, service.ErrResetThrottled) {
+			return nil, connect.NewError(connect.CodeResourceExhausted, errors.New("重置邮件发送过于频繁，请稍后再试"))
+		}
+		return nil, WrapError(ctx, err, "申请密码重置失败")
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+// ConfirmPasswordReset 确认密码重置
+func (h *AuthHandler) ConfirmPasswordReset(
+	ctx context.Context,
+	req *connect.Request[base.ConfirmPasswordResetRequest],
+) (*connect.Response[base.ConfirmPasswordResetResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	if err := h.authService.ConfirmPasswordReset(ctx, req.Msg.Token, req.Msg.NewPassword); err != nil {
+		if errors.Is(err, service.ErrInvalidResetToken) {
+			return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("重置令牌无效或已过期，请重新申请"))
+		}
+		if errors.Is(err, service.ErrPasswordTooShort) ||
+			errors.Is(err, service.ErrPasswordNoUppercase) ||
+			errors.Is(err, service.ErrPasswordNoNumber) ||
+			errors.Is(err, service.ErrPasswordNoSpecialChar) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		return nil, WrapError(ctx, err, "密码重置失败")
+	}
+
+	logger.InfoContext(ctx, "password reset via email confirmed")
+
+	return connect.NewResponse(&base.ConfirmPasswordResetResponse{Success: true}), nil
+}
+
+// LoginVerifyMFA 两步验证登录（第二步）
+func (h *AuthHandler) LoginVerifyMFA(
+	ctx context.Context,
+	req *connect.Request[base.LoginVerifyMFARequest],
+) (*connect.Response[base.LoginResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	resp, err := h.authService.LoginVerifyMFA(ctx, req.Msg.MfaToken, req.Msg.Code, clientIPFromHeader(req.Header()), req.Header().Get("User-Agent"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidToken) {
+			return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("两步验证会话无效或已过期，请重新登录"))
+		}
+		if errors.Is(err, service.ErrInvalidTOTPCode) {
+			logger.WarnContext(ctx, "mfa login failed: invalid code")
+			return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("验证码错误"))
+		}
+		if errors.Is(err, service.ErrTOTPNotEnabled) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("该账号未开启两步验证"))
+		}
+		if errors.Is(err, service.ErrUserNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("用户不存在"))
+		}
+		if errors.Is(err, service.ErrUserInactive) {
+			return nil, connect.NewError(connect.CodePermissionDenied, errors.New("用户已被禁用"))
+		}
+		logger.ErrorContext(ctx, "mfa login error", "error", err)
+		return nil, WrapError(ctx, err, "登录失败")
+	}
+
+	logger.InfoContext(ctx, "user logged in with mfa", "username", resp.User.GetUsername())
+
+	return connect.NewResponse(resp), nil
+}
+
+// LoginWithRecoveryCode 恢复码登录（两步验证兜底）
+// TOTP 设备丢失时使用一次性恢复码完成第二步验证
+func (h *AuthHandler) LoginWithRecoveryCode(
+	ctx context.Context,
+	req *connect.Request[base.LoginWithRecoveryCodeRequest],
+) (*connect.Response[base.LoginResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	resp, err := h.authService.LoginWithRecoveryCode(ctx, req.Msg.MfaToken, req.Msg.RecoveryCode, clientIPFromHeader(req.Header()), req.Header().Get("User-Agent"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidToken) {
+			return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("两步验证会话无效或已过期，请重新登录"))
+		}
+		if errors.Is(err, service.ErrInvalidRecoveryCode) {
+			logger.WarnContext(ctx, "recovery code login failed: invalid code")
+			return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("恢复码错误或已被使用"))
+		}
+		if errors.Is(err, service.ErrTOTPNotEnabled) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("该账号未开启两步验证"))
+		}
+		if errors.Is(err, service.ErrUserNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("用户不存在"))
+		}
+		if errors.Is(err, service.ErrUserInactive) {
+			return nil, connect.NewError(connect.CodePermissionDenied, errors.New("用户已被禁用"))
+		}
+		logger.ErrorContext(ctx, "recovery code login error", "error", err)
+		return nil, WrapError(ctx, err, "登录失败")
+	}
+
+	logger.InfoContext(ctx, "user logged in with recovery code", "username", resp.User.GetUsername())
+
+	return connect.NewResponse(resp), nil
+}
+
+// EnableTOTP 开启 TOTP 两步验证
+// 返回的密钥和恢复码仅本次可见，VerifyTOTP 校验后正式生效
+func (h *AuthHandler) EnableTOTP(
+	ctx context.Context,
+	req *connect.Request[base.EnableTOTPRequest],
+) (*connect.Response[base.EnableTOTPResponse], error) {
+	// 从上下文获取用户ID
+	userID, ok := ctx.Value(ContextKeyUserID).(int)
+	if !ok {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("未登录"))
+	}
+
+	resp, err := h.authService.EnableTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrTOTPAlreadyEnabled) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("两步验证已开启"))
+		}
+		if errors.Is(err, service.ErrUserNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("用户不存在"))
+		}
+		return nil, WrapError(ctx, err, "开启两步验证失败")
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+// VerifyTOTP 校验验证码并激活 TOTP
+func (h *AuthHandler) VerifyTOTP(
+	ctx context.Context,
+	req *connect.Request[base.VerifyTOTPRequest],
+) (*connect.Response[base.VerifyTOTPResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	// 从上下文获取用户ID
+	userID, ok := ctx.Value(ContextKeyUserID).(int)
+	if !ok {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("未登录"))
+	}
+
+	if err := h.authService.VerifyTOTP(ctx, userID, req.Msg.Code); err != nil {
+		if errors.Is(err, service.ErrInvalidTOTPCode) {
+			return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("验证码错误"))
+		}
+		if errors.Is(err, service.ErrTOTPNotEnabled) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("请先调用开启两步验证生成密钥"))
+		}
+		return nil, WrapError(ctx, err, "激活两步验证失败")
+	}
+
+	return connect.NewResponse(&base.VerifyTOTPResponse{Success: true}), nil
+}
+
+// DisableTOTP 关闭 TOTP 两步验证
+func (h *AuthHandler) DisableTOTP(
+	ctx context.Context,
+	req *connect.Request[base.DisableTOTPRequest],
+) (*connect.Response[base.DisableTOTPResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	// 从上下文获取用户ID
+	userID, ok := ctx.Value(ContextKeyUserID).(int)
+	if !ok {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("未登录"))
+	}
+
+	if err := h.authService.DisableTOTP(ctx, userID, req.Msg.Code); err != nil {
+		if errors.Is(err, service.ErrInvalidTOTPCode) {
+			return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("验证码错误"))
+		}
+		if errors.Is(err, service.ErrTOTPNotEnabled) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("两步验证未开启"))
+		}
+		return nil, WrapError(ctx, err, "关闭两步验证失败")
+	}
+
+	return connect.NewResponse(&base.DisableTOTPResponse{Success: true}), nil
+}
+
 // Logout 用户登出
 func (h *AuthHandler) Logout(
 	ctx context.Context,
@@ -137,7 +425,7 @@ func (h *AuthHandler) Logout(
 		if errors.Is(err, service.ErrInvalidToken) {
 			return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("无效的令牌"))
 		}
-		return nil, connect.NewError(connect.CodeInternal, errors.New("登出失败"))
+		return nil, WrapError(ctx, err, "登出失败")
 	}
 
 	return connect.NewResponse(&base.LogoutResponse{
@@ -160,13 +448,16 @@ func (h *AuthHandler) RefreshToken(
 		if errors.Is(err, service.ErrInvalidToken) {
 			return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("无效的刷新令牌"))
 		}
+		if errors.Is(err, service.ErrRefreshTokenReused) {
+			return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("刷新令牌已失效，请重新登录"))
+		}
 		if errors.Is(err, service.ErrUserNotFound) {
 			return nil, connect.NewError(connect.CodeNotFound, errors.New("用户不存在"))
 		}
 		if errors.Is(err, service.ErrUserInactive) {
 			return nil, connect.NewError(connect.CodePermissionDenied, errors.New("用户已被禁用"))
 		}
-		return nil, connect.NewError(connect.CodeInternal, errors.New("刷新令牌失败"))
+		return nil, WrapError(ctx, err, "刷新令牌失败")
 	}
 
 	return connect.NewResponse(resp), nil
@@ -188,7 +479,7 @@ func (h *AuthHandler) GetCurrentUser(
 		if errors.Is(err, service.ErrUserNotFound) {
 			return nil, connect.NewError(connect.CodeNotFound, errors.New("用户不存在"))
 		}
-		return nil, connect.NewError(connect.CodeInternal, errors.New("获取用户信息失败"))
+		return nil, WrapError(ctx, err, "获取用户信息失败")
 	}
 
 	return connect.NewResponse(&base.GetCurrentUserResponse{
@@ -196,6 +487,151 @@ func (h *AuthHandler) GetCurrentUser(
 	}), nil
 }
 
+// UpdateProfile 更新个人资料（当前用户自助）
+func (h *AuthHandler) UpdateProfile(
+	ctx context.Context,
+	req *connect.Request[base.UpdateProfileRequest],
+) (*connect.Response[base.UpdateProfileResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	// 从上下文获取用户ID
+	userID, ok := ctx.Value(ContextKeyUserID).(int)
+	if !ok {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("未登录"))
+	}
+
+	if err := h.userService.UpdateOwnProfile(ctx, userID, req.Msg.Nickname, req.Msg.Email, req.Msg.Avatar); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("用户不存在"))
+		}
+		if errors.Is(err, service.ErrUserExists) {
+			return nil, connect.NewError(connect.CodeAlreadyExists, errors.New("邮箱已被其他用户使用"))
+		}
+		return nil, WrapError(ctx, err, "更新个人资料失败")
+	}
+
+	// 返回更新后的用户信息
+	userInfo, err := h.authService.GetCurrentUser(ctx, userID)
+	if err != nil {
+		return nil, WrapError(ctx, err, "获取用户信息失败")
+	}
+
+	return connect.NewResponse(&base.UpdateProfileResponse{
+		User: userInfo,
+	}), nil
+}
+
+// ChangePassword 修改密码（当前用户自助，需校验旧密码）
+func (h *AuthHandler) ChangePassword(
+	ctx context.Context,
+	req *connect.Request[base.ChangePasswordRequest],
+) (*connect.Response[base.ChangePasswordResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	// 从上下文获取用户ID
+	userID, ok := ctx.Value(ContextKeyUserID).(int)
+	if !ok {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("未登录"))
+	}
+
+	if err := h.userService.ChangeOwnPassword(ctx, userID, req.Msg.OldPassword, req.Msg.NewPassword); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("用户不存在"))
+		}
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("旧密码错误"))
+		}
+		if errors.Is(err, service.ErrExternalAuthUser) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("外部认证用户请在身份提供方修改密码"))
+		}
+		if errors.Is(err, service.ErrPasswordTooShort) ||
+			errors.Is(err, service.ErrPasswordNoUppercase) ||
+			errors.Is(err, service.ErrPasswordNoNumber) ||
+			errors.Is(err, service.ErrPasswordNoSpecialChar) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		return nil, WrapError(ctx, err, "修改密码失败")
+	}
+
+	return connect.NewResponse(&base.ChangePasswordResponse{
+		Success: true,
+	}), nil
+}
+
+// AcceptTerms 同意当前版本的服务条款
+// 记录后用户需重新登录获取完整权限（未同意期间令牌中的权限已降级为只读）
+func (h *AuthHandler) AcceptTerms(
+	ctx context.Context,
+	req *connect.Request[base.AcceptTermsRequest],
+) (*connect.Response[base.AcceptTermsResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	userID, ok := ctx.Value(ContextKeyUserID).(int)
+	if !ok {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("未登录"))
+	}
+
+	if err := h.authService.AcceptTerms(ctx, userID, req.Msg.Version); err != nil {
+		if errors.Is(err, service.ErrTermsVersionMismatch) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("条款版本已更新，请刷新后重新确认"))
+		}
+		if errors.Is(err, service.ErrUserNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("用户不存在"))
+		}
+		return nil, WrapError(ctx, err, "记录条款同意失败")
+	}
+
+	return connect.NewResponse(&base.AcceptTermsResponse{
+		Success: true,
+	}), nil
+}
+
+// DeleteMyAccount 用户自助注销账号
+// 校验当前密码后删除账号并吊销全部会话，操作对象始终是当前用户自己
+func (h *AuthHandler) DeleteMyAccount(
+	ctx context.Context,
+	req *connect.Request[base.DeleteMyAccountRequest],
+) (*connect.Response[base.DeleteMyAccountResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	userID, ok := ctx.Value(ContextKeyUserID).(int)
+	if !ok {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("未登录"))
+	}
+
+	if err := h.authService.DeleteMyAccount(ctx, userID, req.Msg.Password); err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("密码错误"))
+		}
+		if errors.Is(err, service.ErrExternalAuthUser) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("外部认证用户请联系管理员删除账号"))
+		}
+		if errors.Is(err, service.ErrLastAdmin) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("系统至少需要保留一名管理员，无法注销"))
+		}
+		if errors.Is(err, service.ErrUserNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("用户不存在"))
+		}
+		return nil, WrapError(ctx, err, "注销账号失败")
+	}
+
+	return connect.NewResponse(&base.DeleteMyAccountResponse{
+		Success: true,
+	}), nil
+}
+
 // extractTokenFromHeader 从 Authorization 头提取令牌
 func extractTokenFromHeader(authHeader string) string {
 	if authHeader == "" {
@@ -209,3 +645,113 @@ func extractTokenFromHeader(authHeader string) string {
 
 	return parts[1]
 }
+
+// clientIPFromHeader 从请求头提取客户端 IP
+func clientIPFromHeader(header http.Header) string {
+	// 按优先级检查各种头
+	if ip := header.Get("X-Real-IP"); ip != "" {
+		return ip
+	}
+	if ip := header.Get("X-Forwarded-For"); ip != "" {
+		// X-Forwarded-For 可能包含多个 IP，取第一个
+		parts := strings.Split(ip, ",")
+		return strings.TrimSpace(parts[0])
+	}
+	if ip := header.Get("X-Client-IP"); ip != "" {
+		return ip
+	}
+	return ""
+}
+
+// VerifyPassword 二次密码确认，签发短时 sudo 令牌
+func (h *AuthHandler) VerifyPassword(
+	ctx context.Context,
+	req *connect.Request[base.VerifyPasswordRequest],
+) (*connect.Response[base.VerifyPasswordResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	// 从上下文获取用户ID
+	userID, ok := ctx.Value(ContextKeyUserID).(int)
+	if !ok {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("未登录"))
+	}
+
+	resp, err := h.authService.VerifyPassword(ctx, userID, req.Msg.Password)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("密码错误"))
+		}
+		if errors.Is(err, service.ErrUserNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("用户不存在"))
+		}
+		return nil, WrapError(ctx, err, "密码确认失败")
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+// GetNonce 获取一次性防重放 nonce
+// 敏感写接口要求通过 X-Nonce 请求头携带，nonce 用后作废
+func (h *AuthHandler) GetNonce(
+	ctx context.Context,
+	req *connect.Request[base.GetNonceRequest],
+) (*connect.Response[base.GetNonceResponse], error) {
+	// 从上下文获取用户ID
+	userID, ok := ctx.Value(ContextKeyUserID).(int)
+	if !ok {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("未登录"))
+	}
+
+	nonce, ttl, err := h.nonceStore.Issue(userID)
+	if err != nil {
+		return nil, WrapError(ctx, err, "生成 nonce 失败")
+	}
+
+	return connect.NewResponse(&base.GetNonceResponse{
+		Nonce:     nonce,
+		ExpiresIn: int64(ttl.Seconds()),
+	}), nil
+}
+
+// ListAccessibleProcedures 获取当前用户可访问的 procedure 列表
+// 基于权限注册表和 token 中的角色/权限计算，供前端/网关预判可调用的 RPC
+func (h *AuthHandler) ListAccessibleProcedures(
+	ctx context.Context,
+	req *connect.Request[base.ListAccessibleProceduresRequest],
+) (*connect.Response[base.ListAccessibleProceduresResponse], error) {
+	roles, _ := ctx.Value(ContextKeyRoles).([]string)
+	permissions, _ := ctx.Value(ContextKeyPermissions).([]string)
+
+	return connect.NewResponse(&base.ListAccessibleProceduresResponse{
+		Procedures: permission.AccessibleProcedures(roles, permissions),
+	}), nil
+}
+
+// CheckPermissions 批量校验当前用户是否拥有指定权限
+// 基于 token 中的权限快照匹配，通配符逻辑与权限拦截器一致，
+// 供前端做按钮级权限判断，免去各处自行解析 permissions 列表
+func (h *AuthHandler) CheckPermissions(
+	ctx context.Context,
+	req *connect.Request[base.CheckPermissionsRequest],
+) (*connect.Response[base.CheckPermissionsResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	permissions, _ := ctx.Value(ContextKeyPermissions).([]string)
+
+	// 管理员的权限快照为通配符 "*"，由 Matches 统一放行；
+	// 不按角色放行，与权限拦截器的判定保持一致
+	results := make(map[string]bool, len(req.Msg.Codes))
+	for _, code := range req.Msg.Codes {
+		results[code] = permission.Matches(permissions, code)
+	}
+
+	return connect.NewResponse(&base.CheckPermissionsResponse{
+		Results: results,
+	}), nil
+}
@@ -48,7 +48,7 @@ func (h *CASAuthHandler) GetCASLoginURL(
 	resp, err := h.casAuthService.GetCASLoginURL(ctx, req.Msg.RedirectUrl)
 	if err != nil {
 		logger.ErrorContext(ctx, "failed to get CAS login URL", "error", err)
-		return nil, connect.NewError(connect.CodeInternal, errors.New("获取 CAS 登录地址失败"))
+		return nil, WrapError(ctx, err, "获取 CAS 登录地址失败")
 	}
 
 	return connect.NewResponse(resp), nil
@@ -78,10 +78,10 @@ func (h *CASAuthHandler) CASCallback(
 		}
 		if errors.Is(err, service.ErrCASUserCreateFailed) {
 			logger.ErrorContext(ctx, "failed to create CAS user", "error", err)
-			return nil, connect.NewError(connect.CodeInternal, errors.New("创建用户失败，请联系管理员"))
+			return nil, WrapError(ctx, err, "创建用户失败，请联系管理员")
 		}
 		logger.ErrorContext(ctx, "CAS callback error", "error", err)
-		return nil, connect.NewError(connect.CodeInternal, errors.New("CAS 认证失败"))
+		return nil, WrapError(ctx, err, "CAS 认证失败")
 	}
 
 	if resp.IsNewUser {
@@ -103,7 +103,7 @@ func (h *CASAuthHandler) CASLogout(
 	resp, err := h.casAuthService.CASLogout(ctx, req.Msg.AccessToken)
 	if err != nil {
 		logger.ErrorContext(ctx, "CAS logout error", "error", err)
-		return nil, connect.NewError(connect.CodeInternal, errors.New("登出失败"))
+		return nil, WrapError(ctx, err, "登出失败")
 	}
 
 	return connect.NewResponse(resp), nil
@@ -117,7 +117,7 @@ func (h *CASAuthHandler) GetPublicCASSettings(
 	resp, err := h.casAuthService.GetPublicCASSettings(ctx)
 	if err != nil {
 		logger.ErrorContext(ctx, "failed to get public CAS settings", "error", err)
-		return nil, connect.NewError(connect.CodeInternal, errors.New("获取 CAS 设置失败"))
+		return nil, WrapError(ctx, err, "获取 CAS 设置失败")
 	}
 
 	return connect.NewResponse(resp), nil
@@ -131,7 +131,7 @@ func (h *CASAuthHandler) GetCASConfig(
 	config, err := h.casAuthService.GetCASConfig(ctx)
 	if err != nil {
 		logger.ErrorContext(ctx, "failed to get CAS config", "error", err)
-		return nil, connect.NewError(connect.CodeInternal, errors.New("获取 CAS 配置失败"))
+		return nil, WrapError(ctx, err, "获取 CAS 配置失败")
 	}
 
 	return connect.NewResponse(&base.GetCASConfigResponse{
@@ -155,7 +155,7 @@ func (h *CASAuthHandler) UpdateCASConfig(
 	err := h.casAuthService.UpdateCASConfig(ctx, config)
 	if err != nil {
 		logger.ErrorContext(ctx, "failed to update CAS config", "error", err)
-		return nil, connect.NewError(connect.CodeInternal, errors.New("更新 CAS 配置失败"))
+		return nil, WrapError(ctx, err, "更新 CAS 配置失败")
 	}
 
 	// 重新初始化 Casdoor 客户端以应用新配置
@@ -172,7 +172,7 @@ func (h *CASAuthHandler) UpdateCASConfig(
 	updatedConfig, err := h.casAuthService.GetCASConfig(ctx)
 	if err != nil {
 		logger.ErrorContext(ctx, "failed to get updated CAS config", "error", err)
-		return nil, connect.NewError(connect.CodeInternal, errors.New("获取更新后的配置失败"))
+		return nil, WrapError(ctx, err, "获取更新后的配置失败")
 	}
 
 	logger.InfoContext(ctx, "CAS config updated successfully", "enabled", updatedConfig.Enabled)
@@ -198,7 +198,7 @@ func (h *CASAuthHandler) TestCASConnection(
 	resp, err := h.casAuthService.TestCASConnection(ctx, config)
 	if err != nil {
 		logger.ErrorContext(ctx, "CAS connection test error", "error", err)
-		return nil, connect.NewError(connect.CodeInternal, errors.New("测试连接失败"))
+		return nil, WrapError(ctx, err, "测试连接失败")
 	}
 
 	if resp.Success {
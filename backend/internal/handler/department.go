@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"zera/gen/base"
+	"zera/gen/base/baseconnect"
+	"zera/internal/service"
+
+	"buf.build/go/protovalidate"
+	"connectrpc.com/connect"
+)
+
+// DepartmentHandler 部门管理处理器
+type DepartmentHandler struct {
+	baseconnect.UnimplementedDepartmentServiceHandler
+	validator         protovalidate.Validator
+	departmentService *service.DepartmentService
+}
+
+// NewDepartmentHandler 创建部门管理处理器
+func NewDepartmentHandler(
+	validator protovalidate.Validator,
+	departmentService *service.DepartmentService,
+) *DepartmentHandler {
+	return &DepartmentHandler{
+		validator:         validator,
+		departmentService: departmentService,
+	}
+}
+
+// ListDepartments 获取部门列表
+func (h *DepartmentHandler) ListDepartments(
+	ctx context.Context,
+	req *connect.Request[base.ListDepartmentsRequest],
+) (*connect.Response[base.ListDepartmentsResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	resp, err := h.departmentService.ListDepartments(ctx, req.Msg)
+	if err != nil {
+		return nil, WrapError(ctx, err, "获取部门列表失败")
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+// GetDepartmentTree 获取部门树
+func (h *DepartmentHandler) GetDepartmentTree(
+	ctx context.Context,
+	req *connect.Request[base.GetDepartmentTreeRequest],
+) (*connect.Response[base.GetDepartmentTreeResponse], error) {
+	resp, err := h.departmentService.GetDepartmentTree(ctx)
+	if err != nil {
+		return nil, WrapError(ctx, err, "获取部门树失败")
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+// GetDepartment 获取部门详情
+func (h *DepartmentHandler) GetDepartment(
+	ctx context.Context,
+	req *connect.Request[base.GetDepartmentRequest],
+) (*connect.Response[base.GetDepartmentResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	id, err := strconv.Atoi(req.Msg.Id)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("无效的部门ID"))
+	}
+
+	resp, err := h.departmentService.GetDepartment(ctx, id)
+	if err != nil {
+		if errors.Is(err, service.ErrDepartmentNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("部门不存在"))
+		}
+		return nil, WrapError(ctx, err, "获取部门详情失败")
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+// CreateDepartment 创建部门
+func (h *DepartmentHandler) CreateDepartment(
+	ctx context.Context,
+	req *connect.Request[base.CreateDepartmentRequest],
+) (*connect.Response[base.CreateDepartmentResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	resp, err := h.departmentService.CreateDepartment(ctx, req.Msg)
+	if err != nil {
+		if errors.Is(err, service.ErrDepartmentExists) {
+			return nil, connect.NewError(connect.CodeAlreadyExists, errors.New("部门代码已存在"))
+		}
+		if errors.Is(err, service.ErrDepartmentNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("父部门不存在"))
+		}
+		return nil, WrapError(ctx, err, "创建部门失败")
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+// UpdateDepartment 更新部门
+func (h *DepartmentHandler) UpdateDepartment(
+	ctx context.Context,
+	req *connect.Request[base.UpdateDepartmentRequest],
+) (*connect.Response[base.UpdateDepartmentResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	id, err := strconv.Atoi(req.Msg.Id)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("无效的部门ID"))
+	}
+
+	resp, err := h.departmentService.UpdateDepartment(ctx, id, req.Msg)
+	if err != nil {
+		if errors.Is(err, service.ErrDepartmentNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("部门不存在"))
+		}
+		if errors.Is(err, service.ErrDepartmentCycle) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("不能将部门移动到自身或其子部门下"))
+		}
+		return nil, WrapError(ctx, err, "更新部门失败")
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+// DeleteDepartment 删除部门
+func (h *DepartmentHandler) DeleteDepartment(
+	ctx context.Context,
+	req *connect.Request[base.DeleteDepartmentRequest],
+) (*connect.Response[base.DeleteDepartmentResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	id, err := strconv.Atoi(req.Msg.Id)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("无效的部门ID"))
+	}
+
+	err = h.departmentService.DeleteDepartment(ctx, id)
+	if err != nil {
+		if errors.Is(err, service.ErrDepartmentNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("部门不存在"))
+		}
+		if errors.Is(err, service.ErrDepartmentHasChildren) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("请先删除子部门"))
+		}
+		if errors.Is(err, service.ErrDepartmentHasUsers) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("请先移出部门下的用户"))
+		}
+		return nil, WrapError(ctx, err, "删除部门失败")
+	}
+
+	return connect.NewResponse(&base.DeleteDepartmentResponse{Success: true}), nil
+}
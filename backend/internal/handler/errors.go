@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"zera/internal/logger"
+
+	"connectrpc.com/connect"
+)
+
+// traceIDMetaKey 错误响应 metadata 中的追踪ID字段名
+const traceIDMetaKey = "X-Trace-ID"
+
+// WrapError 统一包装内部错误
+// 原始错误会记录到日志（带 trace 信息）便于排查，返回给调用方的是
+// 对用户友好的本地化消息，并在错误 metadata 中附带 trace_id
+func WrapError(ctx context.Context, err error, userMessage string) *connect.Error {
+	logger.ErrorContext(ctx, "request failed",
+		"error", err,
+		"message", userMessage,
+	)
+
+	connectErr := connect.NewError(connect.CodeInternal, errors.New(userMessage))
+	if traceID := logger.GetTraceID(ctx); traceID != "" {
+		connectErr.Meta().Set(traceIDMetaKey, traceID)
+	}
+	return connectErr
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userImportTemplateCSV 用户导入 CSV 模板内容
+// 列顺序与 BatchImportUsers 的 ImportUserItem 字段一致，
+// roles 多个角色代码用分号分隔，department_id 留空表示不分配部门
+const userImportTemplateCSV = "username,password,email,nickname,roles,department_id\n" +
+	"zhangsan,Passw0rd!,zhangsan@example.com,张三,user,\n" +
+	"lisi,Passw0rd!,lisi@example.com,李四,user;admin,1\n"
+
+// UserImportTemplate 下载用户导入 CSV 模板
+// 返回带表头和示例行的 CSV 文件，供前端"下载模板"按钮使用
+func UserImportTemplate(c *gin.Context) {
+	c.Header("Content-Disposition", `attachment; filename="user-import-template.csv"`)
+	// 前缀 UTF-8 BOM，避免 Excel 打开中文列乱码
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", append([]byte("\ufeff"), userImportTemplateCSV...))
+}
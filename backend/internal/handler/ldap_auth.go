@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"zera/gen/base"
+	"zera/gen/base/baseconnect"
+	"zera/internal/logger"
+	"zera/internal/service"
+
+	"buf.build/go/protovalidate"
+	"connectrpc.com/connect"
+)
+
+// LDAPAuthHandler LDAP/AD 认证处理器
+type LDAPAuthHandler struct {
+	baseconnect.UnimplementedLDAPAuthServiceHandler
+	validator       protovalidate.Validator
+	ldapAuthService *service.LDAPAuthService
+}
+
+// NewLDAPAuthHandler 创建 LDAP 认证处理器
+func NewLDAPAuthHandler(
+	validator protovalidate.Validator,
+	ldapAuthService *service.LDAPAuthService,
+) *LDAPAuthHandler {
+	return &LDAPAuthHandler{
+		validator:       validator,
+		ldapAuthService: ldapAuthService,
+	}
+}
+
+// LDAPLogin LDAP 登录
+func (h *LDAPAuthHandler) LDAPLogin(
+	ctx context.Context,
+	req *connect.Request[base.LDAPLoginRequest],
+) (*connect.Response[base.LDAPLoginResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	logger.InfoContext(ctx, "LDAP login attempt", "username", req.Msg.Username)
+
+	resp, err := h.ldapAuthService.Login(ctx, req.Msg.Username, req.Msg.Password)
+	if err != nil {
+		if errors.Is(err, service.ErrLDAPNotEnabled) {
+			logger.WarnContext(ctx, "LDAP authentication is not enabled")
+			return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("LDAP 认证未启用"))
+		}
+		if errors.Is(err, service.ErrLDAPInvalidCredential) {
+			logger.WarnContext(ctx, "LDAP credential validation failed", "username", req.Msg.Username)
+			return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("用户名或密码错误"))
+		}
+		if errors.Is(err, service.ErrLDAPConnectionFailed) {
+			logger.ErrorContext(ctx, "LDAP connection failed", "error", err)
+			return nil, connect.NewError(connect.CodeUnavailable, errors.New("无法连接 LDAP 服务器，请联系管理员"))
+		}
+		if errors.Is(err, service.ErrLDAPUserCreateFailed) {
+			logger.ErrorContext(ctx, "failed to create LDAP user", "error", err)
+			return nil, WrapError(ctx, err, "创建用户失败，请联系管理员")
+		}
+		logger.ErrorContext(ctx, "LDAP login error", "error", err)
+		return nil, WrapError(ctx, err, "LDAP 认证失败")
+	}
+
+	if resp.IsNewUser {
+		logger.InfoContext(ctx, "new LDAP user created", "username", resp.User.Username)
+	} else {
+		logger.InfoContext(ctx, "LDAP user logged in", "username", resp.User.Username)
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+// GetPublicLDAPSettings 获取公开的 LDAP 设置
+func (h *LDAPAuthHandler) GetPublicLDAPSettings(
+	ctx context.Context,
+	req *connect.Request[base.GetPublicLDAPSettingsRequest],
+) (*connect.Response[base.GetPublicLDAPSettingsResponse], error) {
+	resp, err := h.ldapAuthService.GetPublicLDAPSettings(ctx)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to get public LDAP settings", "error", err)
+		return nil, WrapError(ctx, err, "获取 LDAP 设置失败")
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+// GetLDAPConfig 获取 LDAP 配置 (管理员)
+func (h *LDAPAuthHandler) GetLDAPConfig(
+	ctx context.Context,
+	req *connect.Request[base.GetLDAPConfigRequest],
+) (*connect.Response[base.GetLDAPConfigResponse], error) {
+	config, err := h.ldapAuthService.GetLDAPConfig(ctx)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to get LDAP config", "error", err)
+		return nil, WrapError(ctx, err, "获取 LDAP 配置失败")
+	}
+
+	return connect.NewResponse(&base.GetLDAPConfigResponse{
+		Config: service.ConvertToLDAPConfigProto(config),
+	}), nil
+}
+
+// UpdateLDAPConfig 更新 LDAP 配置 (管理员)
+func (h *LDAPAuthHandler) UpdateLDAPConfig(
+	ctx context.Context,
+	req *connect.Request[base.UpdateLDAPConfigRequest],
+) (*connect.Response[base.UpdateLDAPConfigResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	logger.InfoContext(ctx, "updating LDAP config")
+
+	config := service.ConvertFromLDAPConfigProto(req.Msg.Config)
+	if err := h.ldapAuthService.UpdateLDAPConfig(ctx, config); err != nil {
+		logger.ErrorContext(ctx, "failed to update LDAP config", "error", err)
+		return nil, WrapError(ctx, err, "更新 LDAP 配置失败")
+	}
+
+	// 获取更新后的配置
+	updatedConfig, err := h.ldapAuthService.GetLDAPConfig(ctx)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to get updated LDAP config", "error", err)
+		return nil, WrapError(ctx, err, "获取更新后的配置失败")
+	}
+
+	logger.InfoContext(ctx, "LDAP config updated successfully", "enabled", updatedConfig.Enabled)
+
+	return connect.NewResponse(&base.UpdateLDAPConfigResponse{
+		Success: true,
+		Config:  service.ConvertToLDAPConfigProto(updatedConfig),
+	}), nil
+}
+
+// TestLDAPConnection 测试 LDAP 连接 (管理员)
+func (h *LDAPAuthHandler) TestLDAPConnection(
+	ctx context.Context,
+	req *connect.Request[base.TestLDAPConnectionRequest],
+) (*connect.Response[base.TestLDAPConnectionResponse], error) {
+	logger.InfoContext(ctx, "testing LDAP connection")
+
+	var config *service.LDAPConfig
+	if req.Msg.Config != nil {
+		config = service.ConvertFromLDAPConfigProto(req.Msg.Config)
+	}
+
+	resp, err := h.ldapAuthService.TestLDAPConnection(ctx, config)
+	if err != nil {
+		logger.ErrorContext(ctx, "LDAP connection test error", "error", err)
+		return nil, WrapError(ctx, err, "测试连接失败")
+	}
+
+	if resp.Success {
+		logger.InfoContext(ctx, "LDAP connection test successful")
+	} else {
+		logger.WarnContext(ctx, "LDAP connection test failed", "error", resp.ErrorMessage)
+	}
+
+	return connect.NewResponse(resp), nil
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"zera/gen/base"
+	"zera/gen/base/baseconnect"
+	"zera/internal/logger"
+	"zera/internal/service"
+
+	"buf.build/go/protovalidate"
+	"connectrpc.com/connect"
+)
+
+// OIDCAuthHandler OIDC / OAuth2 认证处理器
+type OIDCAuthHandler struct {
+	baseconnect.UnimplementedOIDCAuthServiceHandler
+	validator       protovalidate.Validator
+	oidcAuthService *service.OIDCAuthService
+}
+
+// NewOIDCAuthHandler 创建 OIDC 认证处理器
+func NewOIDCAuthHandler(
+	validator protovalidate.Validator,
+	oidcAuthService *service.OIDCAuthService,
+) *OIDCAuthHandler {
+	return &OIDCAuthHandler{
+		validator:       validator,
+		oidcAuthService: oidcAuthService,
+	}
+}
+
+// GetOIDCAuthURL 获取 OIDC 授权地址
+func (h *OIDCAuthHandler) GetOIDCAuthURL(
+	ctx context.Context,
+	req *connect.Request[base.GetOIDCAuthURLRequest],
+) (*connect.Response[base.GetOIDCAuthURLResponse], error) {
+	resp, err := h.oidcAuthService.GetAuthURL(ctx, req.Msg.RedirectUri)
+	if err != nil {
+		if errors.Is(err, service.ErrOIDCNotEnabled) {
+			logger.WarnContext(ctx, "OIDC authentication is not enabled")
+			return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("OIDC 认证未启用"))
+		}
+		if errors.Is(err, service.ErrOIDCDiscoveryFailed) {
+			logger.ErrorContext(ctx, "OIDC discovery failed", "error", err)
+			return nil, connect.NewError(connect.CodeUnavailable, errors.New("无法连接身份提供方，请联系管理员"))
+		}
+		logger.ErrorContext(ctx, "failed to build OIDC auth URL", "error", err)
+		return nil, WrapError(ctx, err, "获取授权地址失败")
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+// OIDCCallback OIDC 回调 - 用授权码换取令牌并登录
+func (h *OIDCAuthHandler) OIDCCallback(
+	ctx context.Context,
+	req *connect.Request[base.OIDCCallbackRequest],
+) (*connect.Response[base.OIDCCallbackResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	logger.InfoContext(ctx, "OIDC callback received")
+
+	resp, err := h.oidcAuthService.HandleCallback(ctx, req.Msg.Code, req.Msg.State, req.Msg.RedirectUri)
+	if err != nil {
+		if errors.Is(err, service.ErrOIDCNotEnabled) {
+			logger.WarnContext(ctx, "OIDC authentication is not enabled")
+			return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("OIDC 认证未启用"))
+		}
+		if errors.Is(err, service.ErrOIDCStateInvalid) {
+			logger.WarnContext(ctx, "OIDC state validation failed")
+			return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("state 无效或已过期，请重新登录"))
+		}
+		if errors.Is(err, service.ErrOIDCExchangeFailed) || errors.Is(err, service.ErrOIDCDiscoveryFailed) {
+			logger.ErrorContext(ctx, "OIDC code exchange failed", "error", err)
+			return nil, connect.NewError(connect.CodeUnavailable, errors.New("与身份提供方通信失败，请联系管理员"))
+		}
+		if errors.Is(err, service.ErrOIDCTokenInvalid) {
+			logger.WarnContext(ctx, "OIDC id_token validation failed", "error", err)
+			return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("身份令牌校验失败"))
+		}
+		if errors.Is(err, service.ErrOIDCUserCreateFailed) {
+			logger.ErrorContext(ctx, "failed to create OIDC user", "error", err)
+			return nil, WrapError(ctx, err, "创建用户失败，请联系管理员")
+		}
+		if errors.Is(err, service.ErrUserInactive) {
+			logger.WarnContext(ctx, "OIDC user is inactive")
+			return nil, connect.NewError(connect.CodePermissionDenied, errors.New("账号已被禁用"))
+		}
+		logger.ErrorContext(ctx, "OIDC callback error", "error", err)
+		return nil, WrapError(ctx, err, "OIDC 认证失败")
+	}
+
+	if resp.IsNewUser {
+		logger.InfoContext(ctx, "new OIDC user created", "username", resp.User.Username)
+	} else {
+		logger.InfoContext(ctx, "OIDC user logged in", "username", resp.User.Username)
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+// GetPublicOIDCSettings 获取公开的 OIDC 设置
+func (h *OIDCAuthHandler) GetPublicOIDCSettings(
+	ctx context.Context,
+	req *connect.Request[base.GetPublicOIDCSettingsRequest],
+) (*connect.Response[base.GetPublicOIDCSettingsResponse], error) {
+	resp, err := h.oidcAuthService.GetPublicOIDCSettings(ctx)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to get public OIDC settings", "error", err)
+		return nil, WrapError(ctx, err, "获取 OIDC 设置失败")
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+// GetOIDCConfig 获取 OIDC 配置 (管理员)
+func (h *OIDCAuthHandler) GetOIDCConfig(
+	ctx context.Context,
+	req *connect.Request[base.GetOIDCConfigRequest],
+) (*connect.Response[base.GetOIDCConfigResponse], error) {
+	config, err := h.oidcAuthService.GetOIDCConfig(ctx)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to get OIDC config", "error", err)
+		return nil, WrapError(ctx, err, "获取 OIDC 配置失败")
+	}
+
+	return connect.NewResponse(&base.GetOIDCConfigResponse{
+		Config: service.ConvertToOIDCConfigProto(config),
+	}), nil
+}
+
+// UpdateOIDCConfig 更新 OIDC 配置 (管理员)
+func (h *OIDCAuthHandler) UpdateOIDCConfig(
+	ctx context.Context,
+	req *connect.Request[base.UpdateOIDCConfigRequest],
+) (*connect.Response[base.UpdateOIDCConfigResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	logger.InfoContext(ctx, "updating OIDC config")
+
+	config := service.ConvertFromOIDCConfigProto(req.Msg.Config)
+	if err := h.oidcAuthService.UpdateOIDCConfig(ctx, config); err != nil {
+		logger.ErrorContext(ctx, "failed to update OIDC config", "error", err)
+		return nil, WrapError(ctx, err, "更新 OIDC 配置失败")
+	}
+
+	// 获取更新后的配置
+	updatedConfig, err := h.oidcAuthService.GetOIDCConfig(ctx)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to get updated OIDC config", "error", err)
+		return nil, WrapError(ctx, err, "获取更新后的配置失败")
+	}
+
+	logger.InfoContext(ctx, "OIDC config updated successfully", "enabled", updatedConfig.Enabled)
+
+	return connect.NewResponse(&base.UpdateOIDCConfigResponse{
+		Success: true,
+		Config:  service.ConvertToOIDCConfigProto(updatedConfig),
+	}), nil
+}
@@ -43,7 +43,7 @@ func (h *RoleHandler) ListRoles(
 
 	resp, err := h.roleService.ListRoles(ctx, req.Msg)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, errors.New("获取角色列表失败"))
+		return nil, WrapError(ctx, err, "获取角色列表失败")
 	}
 
 	return connect.NewResponse(resp), nil
@@ -69,7 +69,7 @@ func (h *RoleHandler) GetRole(
 		if errors.Is(err, service.ErrRoleNotFound) {
 			return nil, connect.NewError(connect.CodeNotFound, errors.New("角色不存在"))
 		}
-		return nil, connect.NewError(connect.CodeInternal, errors.New("获取角色详情失败"))
+		return nil, WrapError(ctx, err, "获取角色详情失败")
 	}
 
 	return connect.NewResponse(resp), nil
@@ -90,7 +90,22 @@ func (h *RoleHandler) CreateRole(
 		if errors.Is(err, service.ErrRoleExists) {
 			return nil, connect.NewError(connect.CodeAlreadyExists, errors.New("角色代码已存在"))
 		}
-		return nil, connect.NewError(connect.CodeInternal, errors.New("创建角色失败"))
+		if errors.Is(err, service.ErrInvalidPermissionCodes) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		if errors.Is(err, service.ErrSensitivePermission) {
+			return nil, connect.NewError(connect.CodePermissionDenied, err)
+		}
+		if errors.Is(err, service.ErrRoleTemplateNotFound) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		if errors.Is(err, service.ErrInvalidDataScope) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		if errors.Is(err, service.ErrRoleNotFound) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("父角色不存在"))
+		}
+		return nil, WrapError(ctx, err, "创建角色失败")
 	}
 
 	return connect.NewResponse(resp), nil
@@ -116,7 +131,32 @@ func (h *RoleHandler) UpdateRole(
 		if errors.Is(err, service.ErrRoleNotFound) {
 			return nil, connect.NewError(connect.CodeNotFound, errors.New("角色不存在"))
 		}
-		return nil, connect.NewError(connect.CodeInternal, errors.New("更新角色失败"))
+		if errors.Is(err, service.ErrInvalidPermissionCodes) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		if errors.Is(err, service.ErrSensitivePermission) {
+			return nil, connect.NewError(connect.CodePermissionDenied, err)
+		}
+		if errors.Is(err, service.ErrInvalidDataScope) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		if errors.Is(err, service.ErrRoleCycle) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("父角色设置会形成环形继承"))
+		}
+		return nil, WrapError(ctx, err, "更新角色失败")
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+// ListRoleTemplates 获取角色模板列表
+func (h *RoleHandler) ListRoleTemplates(
+	ctx context.Context,
+	req *connect.Request[base.ListRoleTemplatesRequest],
+) (*connect.Response[base.ListRoleTemplatesResponse], error) {
+	resp, err := h.roleService.ListRoleTemplates(ctx, req.Msg)
+	if err != nil {
+		return nil, WrapError(ctx, err, "获取角色模板列表失败")
 	}
 
 	return connect.NewResponse(resp), nil
@@ -137,7 +177,7 @@ func (h *RoleHandler) DeleteRole(
 		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("无效的角色ID"))
 	}
 
-	err = h.roleService.DeleteRole(ctx, id)
+	err = h.roleService.DeleteRole(ctx, id, req.Msg.Force)
 	if err != nil {
 		if errors.Is(err, service.ErrRoleNotFound) {
 			return nil, connect.NewError(connect.CodeNotFound, errors.New("角色不存在"))
@@ -145,12 +185,76 @@ func (h *RoleHandler) DeleteRole(
 		if errors.Is(err, service.ErrRoleIsSystem) {
 			return nil, connect.NewError(connect.CodePermissionDenied, errors.New("系统角色不可删除"))
 		}
-		return nil, connect.NewError(connect.CodeInternal, errors.New("删除角色失败"))
+		if errors.Is(err, service.ErrRoleHasChildren) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("该角色存在子角色，请先调整子角色的继承关系"))
+		}
+		if errors.Is(err, service.ErrRoleInUse) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, err)
+		}
+		return nil, WrapError(ctx, err, "删除角色失败")
 	}
 
 	return connect.NewResponse(&base.DeleteRoleResponse{Success: true}), nil
 }
 
+// RestoreRole 恢复已软删除的角色
+func (h *RoleHandler) RestoreRole(
+	ctx context.Context,
+	req *connect.Request[base.RestoreRoleRequest],
+) (*connect.Response[base.RestoreRoleResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	id, err := strconv.Atoi(req.Msg.Id)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("无效的角色ID"))
+	}
+
+	resp, err := h.roleService.RestoreRole(ctx, id)
+	if err != nil {
+		if errors.Is(err, service.ErrRoleNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("角色不存在或未被删除"))
+		}
+		return nil, WrapError(ctx, err, "恢复角色失败")
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+// CloneRole 克隆角色
+func (h *RoleHandler) CloneRole(
+	ctx context.Context,
+	req *connect.Request[base.CloneRoleRequest],
+) (*connect.Response[base.CloneRoleResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	sourceID, err := strconv.Atoi(req.Msg.SourceId)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("无效的角色ID"))
+	}
+
+	resp, err := h.roleService.CloneRole(ctx, sourceID, req.Msg.Code, req.Msg.Name)
+	if err != nil {
+		if errors.Is(err, service.ErrRoleNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("源角色不存在"))
+		}
+		if errors.Is(err, service.ErrRoleExists) {
+			return nil, connect.NewError(connect.CodeAlreadyExists, errors.New("角色代码已存在"))
+		}
+		if errors.Is(err, service.ErrSensitivePermission) {
+			return nil, connect.NewError(connect.CodePermissionDenied, err)
+		}
+		return nil, WrapError(ctx, err, "克隆角色失败")
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
 // ListPermissions 获取权限列表
 func (h *RoleHandler) ListPermissions(
 	ctx context.Context,
@@ -158,7 +262,7 @@ func (h *RoleHandler) ListPermissions(
 ) (*connect.Response[base.ListPermissionsResponse], error) {
 	resp, err := h.roleService.ListPermissions(ctx, req.Msg)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, errors.New("获取权限列表失败"))
+		return nil, WrapError(ctx, err, "获取权限列表失败")
 	}
 
 	return connect.NewResponse(resp), nil
@@ -184,7 +288,7 @@ func (h *RoleHandler) GetRolePermissions(
 		if errors.Is(err, service.ErrRoleNotFound) {
 			return nil, connect.NewError(connect.CodeNotFound, errors.New("角色不存在"))
 		}
-		return nil, connect.NewError(connect.CodeInternal, errors.New("获取角色权限失败"))
+		return nil, WrapError(ctx, err, "获取角色权限失败")
 	}
 
 	return connect.NewResponse(resp), nil
@@ -205,13 +309,157 @@ func (h *RoleHandler) UpdateRolePermissions(
 		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("无效的角色ID"))
 	}
 
-	err = h.roleService.UpdateRolePermissions(ctx, roleID, req.Msg.Permissions)
+	operatorID, _ := ctx.Value(ContextKeyUserID).(int)
+
+	requestID, err := h.roleService.UpdateRolePermissions(ctx, operatorID, roleID, req.Msg.Permissions)
 	if err != nil {
 		if errors.Is(err, service.ErrRoleNotFound) {
 			return nil, connect.NewError(connect.CodeNotFound, errors.New("角色不存在"))
 		}
-		return nil, connect.NewError(connect.CodeInternal, errors.New("更新角色权限失败"))
+		if errors.Is(err, service.ErrInvalidPermissionCodes) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		if errors.Is(err, service.ErrSensitivePermission) {
+			return nil, connect.NewError(connect.CodePermissionDenied, err)
+		}
+		return nil, WrapError(ctx, err, "更新角色权限失败")
+	}
+
+	// 系统角色的变更进入审批流程，等待另一管理员审批
+	if requestID > 0 {
+		return connect.NewResponse(&base.UpdateRolePermissionsResponse{
+			Success:         true,
+			PendingApproval: true,
+			ChangeRequestId: strconv.Itoa(requestID),
+		}), nil
 	}
 
 	return connect.NewResponse(&base.UpdateRolePermissionsResponse{Success: true}), nil
 }
+
+// BatchUpdateRolePermissions 批量更新多个角色的权限
+// 整批在一个事务内执行，任一角色失败时全部回滚并在结果中标明原因
+func (h *RoleHandler) BatchUpdateRolePermissions(
+	ctx context.Context,
+	req *connect.Request[base.BatchUpdateRolePermissionsRequest],
+) (*connect.Response[base.BatchUpdateRolePermissionsResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	rolePermissions := make(map[string][]string, len(req.Msg.Items))
+	for _, item := range req.Msg.Items {
+		rolePermissions[item.RoleCode] = item.Permissions
+	}
+
+	results, err := h.roleService.BatchUpdateRolePermissions(ctx, rolePermissions)
+	if err != nil {
+		return nil, WrapError(ctx, err, "批量更新角色权限失败")
+	}
+
+	success := true
+	for _, r := range results {
+		if !r.Success {
+			success = false
+			break
+		}
+	}
+
+	return connect.NewResponse(&base.BatchUpdateRolePermissionsResponse{
+		Success: success,
+		Results: results,
+	}), nil
+}
+
+// ListRoleChangeRequests 获取角色权限变更请求列表
+func (h *RoleHandler) ListRoleChangeRequests(
+	ctx context.Context,
+	req *connect.Request[base.ListRoleChangeRequestsRequest],
+) (*connect.Response[base.ListRoleChangeRequestsResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	resp, err := h.roleService.ListRoleChangeRequests(ctx, req.Msg)
+	if err != nil {
+		return nil, WrapError(ctx, err, "获取变更请求列表失败")
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+// ReviewRoleChangeRequest 审批角色权限变更请求
+func (h *RoleHandler) ReviewRoleChangeRequest(
+	ctx context.Context,
+	req *connect.Request[base.ReviewRoleChangeRequestRequest],
+) (*connect.Response[base.ReviewRoleChangeRequestResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	requestID, err := strconv.Atoi(req.Msg.Id)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("无效的变更请求ID"))
+	}
+
+	reviewerID, _ := ctx.Value(ContextKeyUserID).(int)
+
+	err = h.roleService.ReviewRoleChangeRequest(ctx, reviewerID, requestID, req.Msg.Approve, req.Msg.Comment)
+	if err != nil {
+		if errors.Is(err, service.ErrChangeRequestNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("变更请求不存在"))
+		}
+		if errors.Is(err, service.ErrChangeRequestReviewed) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("该变更请求已处理"))
+		}
+		if errors.Is(err, service.ErrSelfReview) {
+			return nil, connect.NewError(connect.CodePermissionDenied, errors.New("不能审批自己发起的变更请求"))
+		}
+		if errors.Is(err, service.ErrRoleNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("角色不存在"))
+		}
+		if errors.Is(err, service.ErrInvalidPermissionCodes) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		return nil, WrapError(ctx, err, "审批变更请求失败")
+	}
+
+	return connect.NewResponse(&base.ReviewRoleChangeRequestResponse{Success: true}), nil
+}
+
+// ExportRoles 导出角色配置
+func (h *RoleHandler) ExportRoles(
+	ctx context.Context,
+	req *connect.Request[base.ExportRolesRequest],
+) (*connect.Response[base.ExportRolesResponse], error) {
+	resp, err := h.roleService.ExportRoles(ctx)
+	if err != nil {
+		return nil, WrapError(ctx, err, "导出角色配置失败")
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+// ImportRoles 导入角色配置
+func (h *RoleHandler) ImportRoles(
+	ctx context.Context,
+	req *connect.Request[base.ImportRolesRequest],
+) (*connect.Response[base.ImportRolesResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	resp, err := h.roleService.ImportRoles(ctx, req.Msg.Data, req.Msg.Mode)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidImportData) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("导入数据格式无效"))
+		}
+		return nil, WrapError(ctx, err, "导入角色配置失败")
+	}
+
+	return connect.NewResponse(resp), nil
+}
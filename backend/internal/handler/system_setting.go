@@ -37,7 +37,7 @@ func (h *SystemSettingHandler) GetSystemSettings(
 ) (*connect.Response[base.GetSystemSettingsResponse], error) {
 	resp, err := h.settingService.GetAllSettings(ctx)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, errors.New("获取系统设置失败"))
+		return nil, WrapError(ctx, err, "获取系统设置失败")
 	}
 
 	return connect.NewResponse(resp), nil
@@ -59,7 +59,10 @@ func (h *SystemSettingHandler) UpdateSystemSettings(
 
 	resp, err := h.settingService.UpdateSettings(ctx, req.Msg.Settings)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, errors.New("更新系统设置失败"))
+		if errors.Is(err, service.ErrInvalidSettingValue) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		return nil, WrapError(ctx, err, "更新系统设置失败")
 	}
 
 	return connect.NewResponse(resp), nil
@@ -72,7 +75,7 @@ func (h *SystemSettingHandler) GetPublicSettings(
 ) (*connect.Response[base.GetPublicSettingsResponse], error) {
 	resp, err := h.settingService.GetPublicSettings(ctx)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, errors.New("获取公开设置失败"))
+		return nil, WrapError(ctx, err, "获取公开设置失败")
 	}
 
 	return connect.NewResponse(resp), nil
@@ -41,9 +41,11 @@ func (h *UserHandler) ListUsers(
 		return nil, connect.NewError(connect.CodeInvalidArgument, err)
 	}
 
-	resp, err := h.userService.ListUsers(ctx, req.Msg)
+	// 按操作者角色的数据范围过滤可见用户
+	operatorID, _ := ctx.Value(ContextKeyUserID).(int)
+	resp, err := h.userService.ListUsers(ctx, operatorID, req.Msg)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, errors.New("获取用户列表失败"))
+		return nil, WrapError(ctx, err, "获取用户列表失败")
 	}
 
 	return connect.NewResponse(resp), nil
@@ -64,12 +66,16 @@ func (h *UserHandler) GetUser(
 		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("无效的用户ID"))
 	}
 
-	resp, err := h.userService.GetUser(ctx, id)
+	operatorID, _ := ctx.Value(ContextKeyUserID).(int)
+	resp, err := h.userService.GetUser(ctx, operatorID, id)
 	if err != nil {
 		if errors.Is(err, service.ErrUserNotFound) {
 			return nil, connect.NewError(connect.CodeNotFound, errors.New("用户不存在"))
 		}
-		return nil, connect.NewError(connect.CodeInternal, errors.New("获取用户详情失败"))
+		if errors.Is(err, service.ErrUserOutOfScope) {
+			return nil, connect.NewError(connect.CodePermissionDenied, errors.New("该用户不在您的数据范围内"))
+		}
+		return nil, WrapError(ctx, err, "获取用户详情失败")
 	}
 
 	return connect.NewResponse(resp), nil
@@ -90,7 +96,7 @@ func (h *UserHandler) CreateUser(
 		if errors.Is(err, service.ErrUserExists) {
 			return nil, connect.NewError(connect.CodeAlreadyExists, errors.New("用户名或邮箱已存在"))
 		}
-		return nil, connect.NewError(connect.CodeInternal, errors.New("创建用户失败"))
+		return nil, WrapError(ctx, err, "创建用户失败")
 	}
 
 	return connect.NewResponse(resp), nil
@@ -111,15 +117,19 @@ func (h *UserHandler) UpdateUser(
 		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("无效的用户ID"))
 	}
 
-	resp, err := h.userService.UpdateUser(ctx, id, req.Msg)
+	operatorID, _ := ctx.Value(ContextKeyUserID).(int)
+	resp, err := h.userService.UpdateUser(ctx, operatorID, id, req.Msg)
 	if err != nil {
 		if errors.Is(err, service.ErrUserNotFound) {
 			return nil, connect.NewError(connect.CodeNotFound, errors.New("用户不存在"))
 		}
+		if errors.Is(err, service.ErrUserOutOfScope) {
+			return nil, connect.NewError(connect.CodePermissionDenied, errors.New("该用户不在您的数据范围内"))
+		}
 		if errors.Is(err, service.ErrUserExists) {
 			return nil, connect.NewError(connect.CodeAlreadyExists, errors.New("邮箱已被其他用户使用"))
 		}
-		return nil, connect.NewError(connect.CodeInternal, errors.New("更新用户失败"))
+		return nil, WrapError(ctx, err, "更新用户失败")
 	}
 
 	return connect.NewResponse(resp), nil
@@ -140,12 +150,22 @@ func (h *UserHandler) DeleteUser(
 		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("无效的用户ID"))
 	}
 
-	err = h.userService.DeleteUser(ctx, id)
+	operatorID, _ := ctx.Value(ContextKeyUserID).(int)
+	err = h.userService.DeleteUser(ctx, operatorID, id)
 	if err != nil {
 		if errors.Is(err, service.ErrUserNotFound) {
 			return nil, connect.NewError(connect.CodeNotFound, errors.New("用户不存在"))
 		}
-		return nil, connect.NewError(connect.CodeInternal, errors.New("删除用户失败"))
+		if errors.Is(err, service.ErrUserOutOfScope) {
+			return nil, connect.NewError(connect.CodePermissionDenied, errors.New("该用户不在您的数据范围内"))
+		}
+		if errors.Is(err, service.ErrSelfOperation) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("不能删除自己的账号"))
+		}
+		if errors.Is(err, service.ErrLastAdmin) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("系统至少需要保留一名处于启用状态的管理员"))
+		}
+		return nil, WrapError(ctx, err, "删除用户失败")
 	}
 
 	return connect.NewResponse(&base.DeleteUserResponse{Success: true}), nil
@@ -171,7 +191,7 @@ func (h *UserHandler) ResetUserPassword(
 		if errors.Is(err, service.ErrUserNotFound) {
 			return nil, connect.NewError(connect.CodeNotFound, errors.New("用户不存在"))
 		}
-		return nil, connect.NewError(connect.CodeInternal, errors.New("重置密码失败"))
+		return nil, WrapError(ctx, err, "重置密码失败")
 	}
 
 	return connect.NewResponse(&base.ResetUserPasswordResponse{Success: true}), nil
@@ -206,7 +226,8 @@ func (h *UserHandler) BatchDeleteUsers(
 	}
 
 	// 调用 service 层批量删除
-	serviceResults, successCount, failedCount := h.userService.BatchDeleteUsers(ctx, ids)
+	operatorID, _ := ctx.Value(ContextKeyUserID).(int)
+	serviceResults, successCount, failedCount := h.userService.BatchDeleteUsers(ctx, operatorID, ids)
 
 	// 转换 service 结果为 proto 结果
 	protoResults := make([]*base.BatchOperationResult, 0, len(serviceResults)+len(invalidResults))
@@ -257,7 +278,8 @@ func (h *UserHandler) BatchUpdateUserStatus(
 	}
 
 	// 调用 service 层批量更新状态
-	serviceResults, successCount, failedCount := h.userService.BatchUpdateStatus(ctx, ids, req.Msg.Status)
+	operatorID, _ := ctx.Value(ContextKeyUserID).(int)
+	serviceResults, successCount, failedCount := h.userService.BatchUpdateStatus(ctx, operatorID, ids, req.Msg.Status)
 
 	// 转换 service 结果为 proto 结果
 	protoResults := make([]*base.BatchOperationResult, 0, len(serviceResults)+len(invalidResults))
@@ -278,3 +300,371 @@ func (h *UserHandler) BatchUpdateUserStatus(
 		FailedCount:  int32(failedCount + len(invalidResults)),
 	}), nil
 }
+
+// CheckUserExistence 批量检查用户名/邮箱是否已被占用
+func (h *UserHandler) CheckUserExistence(
+	ctx context.Context,
+	req *connect.Request[base.CheckUserExistenceRequest],
+) (*connect.Response[base.CheckUserExistenceResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	usernames, emails, err := h.userService.CheckExistence(ctx, req.Msg.Usernames, req.Msg.Emails)
+	if err != nil {
+		return nil, WrapError(ctx, err, "检查用户是否存在失败")
+	}
+
+	return connect.NewResponse(&base.CheckUserExistenceResponse{
+		Usernames: usernames,
+		Emails:    emails,
+	}), nil
+}
+
+// UnlockUser 解锁被登录失败锁定的用户
+func (h *UserHandler) UnlockUser(
+	ctx context.Context,
+	req *connect.Request[base.UnlockUserRequest],
+) (*connect.Response[base.UnlockUserResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	id, err := strconv.Atoi(req.Msg.Id)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("无效的用户ID"))
+	}
+
+	wasLocked, err := h.userService.UnlockUser(ctx, id)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("用户不存在"))
+		}
+		return nil, WrapError(ctx, err, "解锁用户失败")
+	}
+
+	return connect.NewResponse(&base.UnlockUserResponse{
+		Success:   true,
+		WasLocked: wasLocked,
+	}), nil
+}
+
+// BatchUnlockUsers 批量解锁用户
+func (h *UserHandler) BatchUnlockUsers(
+	ctx context.Context,
+	req *connect.Request[base.BatchUnlockUsersRequest],
+) (*connect.Response[base.BatchUnlockUsersResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	// 转换 ID 并记录无效的 ID
+	ids := make([]int, 0, len(req.Msg.Ids))
+	invalidResults := make([]*base.BatchOperationResult, 0)
+
+	for _, idStr := range req.Msg.Ids {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			invalidResults = append(invalidResults, &base.BatchOperationResult{
+				Id:           idStr,
+				Success:      false,
+				ErrorCode:    service.ErrCodeInvalidID,
+				ErrorMessage: "无效的用户ID格式",
+			})
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	// 调用 service 层批量解锁
+	operatorID, _ := ctx.Value(ContextKeyUserID).(int)
+	serviceResults, successCount, failedCount := h.userService.BatchUnlockUsers(ctx, operatorID, ids)
+
+	// 转换 service 结果为 proto 结果
+	protoResults := make([]*base.BatchOperationResult, 0, len(serviceResults)+len(invalidResults))
+	protoResults = append(protoResults, invalidResults...)
+
+	for _, r := range serviceResults {
+		protoResults = append(protoResults, &base.BatchOperationResult{
+			Id:           r.ID,
+			Success:      r.Success,
+			ErrorCode:    r.ErrorCode,
+			ErrorMessage: r.ErrorMessage,
+		})
+	}
+
+	return connect.NewResponse(&base.BatchUnlockUsersResponse{
+		Results:      protoResults,
+		SuccessCount: int32(successCount),
+		FailedCount:  int32(failedCount + len(invalidResults)),
+	}), nil
+}
+
+// MergeUsers 合并用户账号
+func (h *UserHandler) MergeUsers(
+	ctx context.Context,
+	req *connect.Request[base.MergeUsersRequest],
+) (*connect.Response[base.MergeUsersResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	primaryID, err := strconv.Atoi(req.Msg.PrimaryId)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("无效的主账号ID"))
+	}
+	secondaryID, err := strconv.Atoi(req.Msg.SecondaryId)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("无效的次账号ID"))
+	}
+
+	resp, err := h.userService.MergeUsers(ctx, primaryID, secondaryID)
+	if err != nil {
+		if errors.Is(err, service.ErrMergeSameUser) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("主账号与次账号不能相同"))
+		}
+		if errors.Is(err, service.ErrUserNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("用户不存在"))
+		}
+		return nil, WrapError(ctx, err, "合并用户失败")
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+// BatchImportUsers 批量导入用户（流式返回进度）
+func (h *UserHandler) BatchImportUsers(
+	ctx context.Context,
+	req *connect.Request[base.BatchImportUsersRequest],
+	stream *connect.ServerStream[base.BatchProgress],
+) error {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	// 每处理一批通过回调推送一次进度
+	var sendErr error
+	onProgress := func(p service.BatchProgress) {
+		if sendErr != nil {
+			return
+		}
+		sendErr = stream.Send(&base.BatchProgress{
+			Processed: int32(p.Processed),
+			Total:     int32(p.Total),
+			Failed:    int32(p.Failed),
+		})
+	}
+
+	results, _, failedCount := h.userService.BatchImportUsers(ctx, req.Msg.Users, onProgress)
+	if sendErr != nil {
+		return sendErr
+	}
+
+	// 最终汇总消息，携带详细结果
+	return stream.Send(&base.BatchProgress{
+		Processed: int32(len(results)),
+		Total:     int32(len(results)),
+		Failed:    int32(failedCount),
+		Done:      true,
+		Results:   batchResultsToProto(results),
+	})
+}
+
+// BatchResetPasswords 批量重置密码（流式返回进度）
+func (h *UserHandler) BatchResetPasswords(
+	ctx context.Context,
+	req *connect.Request[base.BatchResetPasswordsRequest],
+	stream *connect.ServerStream[base.BatchProgress],
+) error {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	// 转换 ID 并记录无效的 ID
+	ids := make([]int, 0, len(req.Msg.Ids))
+	invalidResults := make([]service.BatchOperationResult, 0)
+
+	for _, idStr := range req.Msg.Ids {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			invalidResults = append(invalidResults, service.BatchOperationResult{
+				ID:           idStr,
+				Success:      false,
+				ErrorCode:    service.ErrCodeInvalidID,
+				ErrorMessage: "无效的用户ID格式",
+			})
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	// 每处理一批通过回调推送一次进度
+	var sendErr error
+	onProgress := func(p service.BatchProgress) {
+		if sendErr != nil {
+			return
+		}
+		sendErr = stream.Send(&base.BatchProgress{
+			Processed: int32(p.Processed),
+			Total:     int32(p.Total + len(invalidResults)),
+			Failed:    int32(p.Failed + len(invalidResults)),
+		})
+	}
+
+	operatorID, _ := ctx.Value(ContextKeyUserID).(int)
+	results, _, failedCount, err := h.userService.BatchResetPasswords(ctx, operatorID, ids, req.Msg.NewPassword, onProgress)
+	if err != nil {
+		// 密码策略不满足等前置校验失败
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+
+	// 最终汇总消息，携带详细结果（含无效 ID）
+	allResults := append(invalidResults, results...)
+	return stream.Send(&base.BatchProgress{
+		Processed: int32(len(allResults)),
+		Total:     int32(len(allResults)),
+		Failed:    int32(failedCount + len(invalidResults)),
+		Done:      true,
+		Results:   batchResultsToProto(allResults),
+	})
+}
+
+// batchResultsToProto 将 service 批量结果转换为 proto 结果
+func batchResultsToProto(results []service.BatchOperationResult) []*base.BatchOperationResult {
+	protoResults := make([]*base.BatchOperationResult, 0, len(results))
+	for _, r := range results {
+		protoResults = append(protoResults, &base.BatchOperationResult{
+			Id:           r.ID,
+			Success:      r.Success,
+			ErrorCode:    r.ErrorCode,
+			ErrorMessage: r.ErrorMessage,
+		})
+	}
+	return protoResults
+}
+
+// GetOnlineUsers 获取在线用户列表
+func (h *UserHandler) GetOnlineUsers(
+	ctx context.Context,
+	req *connect.Request[base.GetOnlineUsersRequest],
+) (*connect.Response[base.GetOnlineUsersResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	resp, err := h.userService.GetOnlineUsers(ctx, req.Msg)
+	if err != nil {
+		return nil, WrapError(ctx, err, "获取在线用户失败")
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+// ListTags 获取标签列表
+func (h *UserHandler) ListTags(
+	ctx context.Context,
+	req *connect.Request[base.ListTagsRequest],
+) (*connect.Response[base.ListTagsResponse], error) {
+	tags, err := h.userService.ListTags(ctx)
+	if err != nil {
+		return nil, WrapError(ctx, err, "获取标签列表失败")
+	}
+
+	return connect.NewResponse(&base.ListTagsResponse{
+		Tags: tags,
+	}), nil
+}
+
+// AddUserTags 给用户打标签
+func (h *UserHandler) AddUserTags(
+	ctx context.Context,
+	req *connect.Request[base.AddUserTagsRequest],
+) (*connect.Response[base.AddUserTagsResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	id, err := strconv.Atoi(req.Msg.Id)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("无效的用户ID"))
+	}
+
+	detail, err := h.userService.AddUserTags(ctx, id, req.Msg.Tags)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("用户不存在"))
+		}
+		return nil, WrapError(ctx, err, "添加用户标签失败")
+	}
+
+	return connect.NewResponse(&base.AddUserTagsResponse{
+		User: detail,
+	}), nil
+}
+
+// RemoveUserTags 移除用户标签
+func (h *UserHandler) RemoveUserTags(
+	ctx context.Context,
+	req *connect.Request[base.RemoveUserTagsRequest],
+) (*connect.Response[base.RemoveUserTagsResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	id, err := strconv.Atoi(req.Msg.Id)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("无效的用户ID"))
+	}
+
+	detail, err := h.userService.RemoveUserTags(ctx, id, req.Msg.Tags)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("用户不存在"))
+		}
+		return nil, WrapError(ctx, err, "移除用户标签失败")
+	}
+
+	return connect.NewResponse(&base.RemoveUserTagsResponse{
+		User: detail,
+	}), nil
+}
+
+// UpdateUserMetadata 更新用户扩展属性（整体替换）
+func (h *UserHandler) UpdateUserMetadata(
+	ctx context.Context,
+	req *connect.Request[base.UpdateUserMetadataRequest],
+) (*connect.Response[base.UpdateUserMetadataResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	id, err := strconv.Atoi(req.Msg.UserId)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("无效的用户ID"))
+	}
+
+	detail, err := h.userService.UpdateUserMetadata(ctx, id, req.Msg.Metadata)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("用户不存在"))
+		}
+		return nil, WrapError(ctx, err, "更新用户扩展属性失败")
+	}
+
+	return connect.NewResponse(&base.UpdateUserMetadataResponse{
+		User: detail,
+	}), nil
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"zera/gen/base"
+	"zera/internal/logger"
+	"zera/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserExportHandler 用户列表导出处理器
+// 认证和权限校验由路由上的 Gin 认证中间件统一处理
+type UserExportHandler struct {
+	userService *service.UserService
+	auditLogger logger.Writer
+}
+
+// NewUserExportHandler 创建用户导出处理器
+func NewUserExportHandler(userService *service.UserService, auditLogger logger.Writer) *UserExportHandler {
+	return &UserExportHandler{
+		userService: userService,
+		auditLogger: auditLogger,
+	}
+}
+
+// ExportUsersCSV 处理 GET /api/users/export 请求
+// 复用 ListUsers 的筛选条件（keyword/status/role/department_id/tags 查询参数）但不分页，
+// 分批查询后流式写出 CSV 附件。fields 参数用逗号分隔选择导出字段，缺省导出全部字段。
+func (h *UserExportHandler) ExportUsersCSV(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	req := &base.ListUsersRequest{
+		Keyword:               c.Query("keyword"),
+		Role:                  c.Query("role"),
+		DepartmentId:          c.Query("department_id"),
+		IncludeSubDepartments: c.Query("include_sub_departments") == "true",
+		MatchAllTags:          c.Query("match_all_tags") == "true",
+	}
+	if tags := c.Query("tags"); tags != "" {
+		req.Tags = strings.Split(tags, ",")
+	}
+	if status := c.Query("status"); status != "" {
+		req.Status = exportStatusFromQuery(status)
+		if req.Status == base.UserStatus_USER_STATUS_UNSPECIFIED {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "无效的 status 参数，支持 active/inactive/banned/softban",
+			})
+			return
+		}
+	}
+
+	var fields []string
+	if raw := c.Query("fields"); raw != "" {
+		for _, f := range strings.Split(raw, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				fields = append(fields, f)
+			}
+		}
+	}
+
+	// 字段校验须在写出任何响应字节前完成，
+	// 否则 400 错误会混入已提交的 200 + BOM 响应
+	if err := service.ValidateExportFields(fields); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	operatorID, _ := ctx.Value(ContextKeyUserID).(int)
+
+	filename := "users-" + time.Now().Format("20060102-150405") + ".csv"
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	// 前缀 UTF-8 BOM，避免 Excel 打开中文列乱码
+	if _, err := c.Writer.Write([]byte("\ufeff")); err != nil {
+		return
+	}
+
+	writer := csv.NewWriter(c.Writer)
+	count, err := h.userService.ExportUsers(ctx, operatorID, req, fields, writer.Write)
+	if err != nil {
+		// 字段错误已在上方预校验拦截，此处仅作兜底；
+		// 响应已开始流式写出，只能记日志后中断
+		logger.ErrorContext(ctx, "export users failed", "error", err, "exported", count)
+		return
+	}
+	writer.Flush()
+
+	// 导出涉及批量用户数据外流，记录审计日志便于追溯
+	userID := operatorID
+	details, _ := json.Marshal(map[string]any{"count": count, "filename": filename})
+	if err := h.auditLogger.Write(ctx, &logger.Entry{
+		Level:    logger.LevelInfo,
+		Module:   "user",
+		Action:   "export",
+		Resource: "user",
+		UserID:   &userID,
+		Username: usernameFromContext(c),
+		IP:       c.ClientIP(),
+		Method:   http.MethodGet,
+		Path:     c.Request.URL.Path,
+		Details:  string(details),
+	}); err != nil {
+		logger.WarnContext(ctx, "failed to write export audit log", "error", err)
+	}
+}
+
+// exportStatusFromQuery 将查询参数中的状态名转换为 proto 枚举
+func exportStatusFromQuery(status string) base.UserStatus {
+	switch strings.ToLower(status) {
+	case "active":
+		return base.UserStatus_USER_STATUS_ACTIVE
+	case "inactive":
+		return base.UserStatus_USER_STATUS_INACTIVE
+	case "banned":
+		return base.UserStatus_USER_STATUS_BANNED
+	case "softban":
+		return base.UserStatus_USER_STATUS_SOFTBAN
+	}
+	return base.UserStatus_USER_STATUS_UNSPECIFIED
+}
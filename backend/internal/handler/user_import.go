@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"zera/gen/base"
+	"zera/internal/logger"
+	"zera/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userImportMaxRows 单次 CSV 导入的最大行数（不含表头），防止误传超大文件拖垮服务
+const userImportMaxRows = 10000
+
+// UserImportRowResult 单行导入结果，字段含义与 BatchOperationResult 对齐，
+// 额外带 CSV 行号（含表头，从 2 开始）便于运维定位问题行
+type UserImportRowResult struct {
+	Row          int    `json:"row"`
+	Username     string `json:"username"`
+	Success      bool   `json:"success"`
+	ErrorCode    string `json:"error_code,omitempty"`
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// UserImportResponse CSV 导入响应
+type UserImportResponse struct {
+	Success      bool                  `json:"success"`
+	DryRun       bool                  `json:"dry_run"`
+	Total        int                   `json:"total"`
+	SuccessCount int                   `json:"success_count"`
+	FailedCount  int                   `json:"failed_count"`
+	Results      []UserImportRowResult `json:"results"`
+	Error        string                `json:"error,omitempty"`
+}
+
+// UserImportHandler CSV 批量导入用户处理器
+// 认证和权限校验由路由上的 Gin 认证中间件统一处理
+type UserImportHandler struct {
+	userService *service.UserService
+}
+
+// NewUserImportHandler 创建用户导入处理器
+func NewUserImportHandler(userService *service.UserService) *UserImportHandler {
+	return &UserImportHandler{userService: userService}
+}
+
+// ImportUsersCSV 处理 POST /api/import/users 请求
+// 流式解析上传的 CSV 文件（列与下载模板一致，按表头名匹配、列顺序不限），
+// 逐行创建用户并校验密码策略与唯一性，返回每行成功/失败及原因。
+// 查询参数 dry_run=true 时只校验不写入，便于导入前预检。
+//
+// 回滚策略：与 BatchImportUsers 保持一致，每行独立提交、不包整批事务，
+// 部分失败时已成功的行保留，失败行修正后可重新导入（唯一性校验会跳过已导入的行）。
+func (h *UserImportHandler) ImportUsersCSV(c *gin.Context) {
+	ctx := c.Request.Context()
+	dryRun := c.Query("dry_run") == "true"
+
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, UserImportResponse{
+			Error: "请选择要上传的 CSV 文件",
+		})
+		return
+	}
+	defer file.Close()
+
+	// 直接从上传流中逐行读取，不把整个文件载入内存
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1 // 列数在表头解析后按名取值，允许行尾列缺省
+
+	header, err := reader.Read()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, UserImportResponse{
+			Error: "CSV 文件为空或表头解析失败",
+		})
+		return
+	}
+	columns, err := parseImportHeader(header)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, UserImportResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	var (
+		results      []UserImportRowResult
+		successCount int
+		failedCount  int
+		capExceeded  bool
+	)
+	row := 1 // 表头占第 1 行，数据行从第 2 行起
+	for {
+		row++
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			results = append(results, UserImportRowResult{
+				Row:          row,
+				Success:      false,
+				ErrorCode:    service.ErrCodeImportFailed,
+				ErrorMessage: "CSV 行解析失败: " + err.Error(),
+			})
+			failedCount++
+			continue
+		}
+
+		if len(results) >= userImportMaxRows {
+			// 每行独立提交，超限时不能丢弃已写入行的结果，
+			// 返回已处理的逐行结果并告知后续行未处理
+			results = append(results, UserImportRowResult{
+				Row:          row,
+				Success:      false,
+				ErrorCode:    service.ErrCodeImportFailed,
+				ErrorMessage: "超出单次导入最大行数，该行及后续行未处理",
+			})
+			failedCount++
+			capExceeded = true
+			break
+		}
+
+		// 客户端断开或请求取消时不再继续处理
+		if ctx.Err() != nil {
+			results = append(results, UserImportRowResult{
+				Row:          row,
+				Success:      false,
+				ErrorCode:    service.ErrCodeCancelled,
+				ErrorMessage: "请求已取消，该行及后续行未处理",
+			})
+			failedCount++
+			break
+		}
+
+		item := columns.toItem(record)
+		if item.Username == "" {
+			results = append(results, UserImportRowResult{
+				Row:          row,
+				Success:      false,
+				ErrorCode:    service.ErrCodeImportFailed,
+				ErrorMessage: "username 不能为空",
+			})
+			failedCount++
+			continue
+		}
+
+		if dryRun {
+			err = h.userService.ValidateImportUser(ctx, item)
+		} else {
+			_, err = h.userService.CreateUser(ctx, &base.CreateUserRequest{
+				Username:     item.Username,
+				Password:     item.Password,
+				Email:        item.Email,
+				Nickname:     item.Nickname,
+				Roles:        item.Roles,
+				DepartmentId: item.DepartmentId,
+			})
+		}
+		if err != nil {
+			results = append(results, UserImportRowResult{
+				Row:          row,
+				Username:     item.Username,
+				Success:      false,
+				ErrorCode:    service.ErrCodeImportFailed,
+				ErrorMessage: service.ImportErrorMessage(err),
+			})
+			failedCount++
+		} else {
+			results = append(results, UserImportRowResult{
+				Row:      row,
+				Username: item.Username,
+				Success:  true,
+			})
+			successCount++
+		}
+	}
+
+	if !dryRun {
+		logger.InfoContext(ctx, "users imported from csv",
+			"user", usernameFromContext(c),
+			"total", len(results),
+			"success", successCount,
+			"failed", failedCount,
+		)
+	}
+
+	resp := UserImportResponse{
+		Success:      failedCount == 0,
+		DryRun:       dryRun,
+		Total:        len(results),
+		SuccessCount: successCount,
+		FailedCount:  failedCount,
+		Results:      results,
+	}
+	if capExceeded {
+		resp.Error = "单次导入最多 10000 行，已处理的行见逐行结果，请拆分文件后导入剩余行"
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// importColumns 表头各列的下标，-1 表示该列缺失
+type importColumns struct {
+	username   int
+	password   int
+	email      int
+	nickname   int
+	roles      int
+	department int
+}
+
+// parseImportHeader 按列名解析 CSV 表头，列名与下载模板一致，顺序不限
+// username 和 password 为必需列，其余列可省略
+func parseImportHeader(header []string) (*importColumns, error) {
+	cols := &importColumns{username: -1, password: -1, email: -1, nickname: -1, roles: -1, department: -1}
+	for i, name := range header {
+		// 首列可能带 UTF-8 BOM（模板文件为兼容 Excel 添加）
+		switch strings.TrimPrefix(strings.TrimSpace(strings.ToLower(name)), "\ufeff") {
+		case "username":
+			cols.username = i
+		case "password":
+			cols.password = i
+		case "email":
+			cols.email = i
+		case "nickname":
+			cols.nickname = i
+		case "roles":
+			cols.roles = i
+		case "department_id":
+			cols.department = i
+		}
+	}
+	if cols.username < 0 || cols.password < 0 {
+		return nil, errors.New("CSV 表头缺少必需列 username/password，请参照导入模板")
+	}
+	return cols, nil
+}
+
+// toItem 按表头下标从 CSV 行中取值，roles 多个角色代码用分号分隔
+func (cols *importColumns) toItem(record []string) *base.ImportUserItem {
+	field := func(idx int) string {
+		if idx < 0 || idx >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[idx])
+	}
+
+	var roles []string
+	for _, r := range strings.Split(field(cols.roles), ";") {
+		if r = strings.TrimSpace(r); r != "" {
+			roles = append(roles, r)
+		}
+	}
+
+	return &base.ImportUserItem{
+		Username:     field(cols.username),
+		Password:     field(cols.password),
+		Email:        field(cols.email),
+		Nickname:     field(cols.nickname),
+		Roles:        roles,
+		DepartmentId: field(cols.department),
+	}
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"zera/gen/base"
+	"zera/internal/service"
+
+	"connectrpc.com/connect"
+)
+
+// GrantUserPermission 授予用户直接权限（绕过角色的一次性授权）
+func (h *UserHandler) GrantUserPermission(
+	ctx context.Context,
+	req *connect.Request[base.GrantUserPermissionRequest],
+) (*connect.Response[base.GrantUserPermissionResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	id, err := strconv.Atoi(req.Msg.UserId)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("无效的用户ID"))
+	}
+
+	if err := h.userService.GrantUserPermission(ctx, id, req.Msg.PermissionCode); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("用户不存在"))
+		}
+		if errors.Is(err, service.ErrInvalidPermissionCodes) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		return nil, WrapError(ctx, err, "授予用户权限失败")
+	}
+
+	return connect.NewResponse(&base.GrantUserPermissionResponse{
+		Success: true,
+	}), nil
+}
+
+// RevokeUserPermission 撤销用户的直接权限
+func (h *UserHandler) RevokeUserPermission(
+	ctx context.Context,
+	req *connect.Request[base.RevokeUserPermissionRequest],
+) (*connect.Response[base.RevokeUserPermissionResponse], error) {
+	// 验证请求
+	if err := h.validator.Validate(req.Msg); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	id, err := strconv.Atoi(req.Msg.UserId)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("无效的用户ID"))
+	}
+
+	if err := h.userService.RevokeUserPermission(ctx, id, req.Msg.PermissionCode); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("用户不存在"))
+		}
+		if errors.Is(err, service.ErrInvalidPermissionCodes) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		return nil, WrapError(ctx, err, "撤销用户权限失败")
+	}
+
+	return connect.NewResponse(&base.RevokeUserPermissionResponse{
+		Success: true,
+	}), nil
+}
@@ -0,0 +1,43 @@
+// Package instanceid 提供服务实例的唯一标识
+// 多实例部署时日志与遥测数据带上实例标识，便于聚合后按实例过滤和定位问题实例
+package instanceid
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"sync"
+)
+
+var (
+	once sync.Once
+	id   string
+)
+
+// ID 返回当前进程的实例标识
+// 优先读取 INSTANCE_ID 环境变量（容器编排场景可注入 pod 名等），
+// 未设置时生成 "hostname-随机后缀"，进程生命周期内保持不变
+func ID() string {
+	once.Do(func() {
+		id = generate()
+	})
+	return id
+}
+
+func generate() string {
+	if v := os.Getenv("INSTANCE_ID"); v != "" {
+		return v
+	}
+
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+
+	// 随机后缀区分同一主机上的多个进程
+	buf := make([]byte, 3)
+	if _, err := rand.Read(buf); err != nil {
+		return host
+	}
+	return host + "-" + hex.EncodeToString(buf)
+}
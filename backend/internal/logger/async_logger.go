@@ -79,6 +79,12 @@ func (l *AsyncLogger) Write(ctx context.Context, entry *Entry) error {
 	}
 }
 
+// Backlog 返回缓冲区中等待写入的日志条数
+// 供业务健康指标读取，持续接近缓冲区上限说明写入能力不足
+func (l *AsyncLogger) Backlog() int {
+	return len(l.entryChan)
+}
+
 // WriteBatch 批量写入（直接调用底层 writer）
 func (l *AsyncLogger) WriteBatch(ctx context.Context, entries []*Entry) error {
 	return l.writer.WriteBatch(ctx, entries)
@@ -116,6 +122,16 @@ func (l *AsyncLogger) GetModules(ctx context.Context) ([]string, error) {
 	return l.reader.GetModules(ctx)
 }
 
+// GetRecentByUser 获取指定用户最近的操作日志
+func (l *AsyncLogger) GetRecentByUser(ctx context.Context, userID, limit int) ([]*EntryWithID, error) {
+	return l.reader.GetRecentByUser(ctx, userID, limit)
+}
+
+// GetResourceHistory 获取针对某个具体资源的操作记录
+func (l *AsyncLogger) GetResourceHistory(ctx context.Context, resource, resourceID string, limit int) ([]*EntryWithID, error) {
+	return l.reader.GetResourceHistory(ctx, resource, resourceID, limit)
+}
+
 // processEntries 后台处理日志条目
 func (l *AsyncLogger) processEntries() {
 	defer l.wg.Done()
@@ -158,3 +174,8 @@ func (l *AsyncLogger) processEntries() {
 		}
 	}
 }
+
+// GetTimeSeries 按天或小时分桶统计日志数量
+func (l *AsyncLogger) GetTimeSeries(ctx context.Context, opts TimeSeriesOptions) ([]TimeSeriesPoint, error) {
+	return l.reader.GetTimeSeries(ctx, opts)
+}
@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"math/rand/v2"
+)
+
+// BodySampler 请求体记录采样器
+// 决定一次请求是否记录完整请求体/响应体，由审计日志拦截器与 telemetry
+// API 日志共用。慢请求与错误请求不受采样比例限制，始终记录完整 body，
+// 正常的快请求按比例采样，降低高频接口的序列化开销与日志体积。
+type BodySampler struct {
+	// sampleRate 正常请求的记录比例 (0.0-1.0)
+	sampleRate float64
+	// slowThresholdMs 慢请求阈值（毫秒），0 表示关闭
+	slowThresholdMs int64
+	// alwaysOnError 出错请求始终记录
+	alwaysOnError bool
+}
+
+// NewBodySampler 创建请求体采样器
+func NewBodySampler(sampleRate float64, slowThresholdMs int64, alwaysOnError bool) *BodySampler {
+	return &BodySampler{
+		sampleRate:      sampleRate,
+		slowThresholdMs: slowThresholdMs,
+		alwaysOnError:   alwaysOnError,
+	}
+}
+
+// ShouldRecord 判断本次请求是否记录完整请求体
+// 采样决策在请求完成后进行，因此可以参考耗时和错误结果
+// nil 采样器等价于全量记录，保持未配置时的原有行为
+func (s *BodySampler) ShouldRecord(durationMs int64, err error) bool {
+	if s == nil {
+		return true
+	}
+	if s.alwaysOnError && err != nil {
+		return true
+	}
+	if s.slowThresholdMs > 0 && durationMs >= s.slowThresholdMs {
+		return true
+	}
+	if s.sampleRate >= 1 {
+		return true
+	}
+	if s.sampleRate <= 0 {
+		return false
+	}
+	return rand.Float64() < s.sampleRate
+}
@@ -2,13 +2,42 @@ package logger
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 	"time"
 
 	"zera/ent"
 	"zera/ent/auditlog"
+	"zera/ent/predicate"
+	"zera/internal/cache"
+
+	entsql "entgo.io/ent/dialect/sql"
 )
 
+// auditCountCache 审计日志查询总数的短 TTL 缓存
+var auditCountCache = cache.NewCountCache(30 * time.Second)
+
+// AuditSearchVector 审计日志全文检索的 tsvector 表达式
+// 覆盖关键词搜索涉及的字段（含 request_body/error_message 等大字段）；
+// 必须与 GIN 表达式索引中的表达式完全一致才能命中索引
+const AuditSearchVector = "to_tsvector('simple', " +
+	"coalesce(module, '') || ' ' || coalesce(action, '') || ' ' || coalesce(username, '') || ' ' || " +
+	"coalesce(error_message, '') || ' ' || coalesce(details, '') || ' ' || coalesce(request_body, ''))"
+
+// auditKeywordPredicate 关键词全文检索条件
+// 使用 tsvector @@ plainto_tsquery 走 GIN 表达式索引，
+// 替代多列 LIKE '%kw%' 拼 OR 在大表上的全表扫描
+func auditKeywordPredicate(keyword string) predicate.AuditLog {
+	return func(s *entsql.Selector) {
+		s.Where(entsql.P(func(b *entsql.Builder) {
+			b.WriteString(AuditSearchVector)
+			b.WriteString(" @@ plainto_tsquery('simple', ")
+			b.Arg(keyword)
+			b.WriteString(")")
+		}))
+	}
+}
+
 // EntLogger 基于 Ent ORM 的数据库日志实现
 type EntLogger struct {
 	client *ent.Client
@@ -118,23 +147,44 @@ func (l *EntLogger) Query(ctx context.Context, opts QueryOptions) (*QueryResult,
 		query = query.Where(auditlog.CreatedAtLTE(*opts.EndTime))
 	}
 	if opts.Keyword != "" {
+		query = query.Where(auditKeywordPredicate(opts.Keyword))
+	}
+
+	// 获取总数
+	// 审计日志表持续增长，count 开销高；
+	// 关键词和时间范围的筛选组合不可枚举，不参与缓存
+	countKey := "audit|" + string(opts.Level) + "|" + opts.Module + "|" + opts.Action +
+		"|" + opts.Username + "|" + opts.IP + "|" + opts.Resource
+	cacheable := opts.Keyword == "" && opts.StartTime == nil && opts.EndTime == nil && !opts.ExactTotal
+	total, cached := 0, false
+	if cacheable {
+		total, cached = auditCountCache.Get(countKey)
+	}
+	if !cached {
+		var err error
+		total, err = query.Clone().Count(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if cacheable {
+			auditCountCache.Set(countKey, total)
+		}
+	}
+
+	// 游标分页：按 (created_at, id) 键集过滤，创建时间相同的按 ID 断开
+	// 仅配合默认的创建时间降序使用，不经过 offset
+	if opts.AfterTime != nil {
 		query = query.Where(
 			auditlog.Or(
-				auditlog.ModuleContains(opts.Keyword),
-				auditlog.ActionContains(opts.Keyword),
-				auditlog.UsernameContains(opts.Keyword),
-				auditlog.DetailsContains(opts.Keyword),
-				auditlog.ErrorMessageContains(opts.Keyword),
+				auditlog.CreatedAtLT(*opts.AfterTime),
+				auditlog.And(
+					auditlog.CreatedAtEQ(*opts.AfterTime),
+					auditlog.IDLT(opts.AfterID),
+				),
 			),
 		)
 	}
 
-	// 获取总数
-	total, err := query.Clone().Count(ctx)
-	if err != nil {
-		return nil, err
-	}
-
 	// 应用排序
 	if opts.SortBy != "" {
 		if opts.Descending {
@@ -165,12 +215,19 @@ func (l *EntLogger) Query(ctx context.Context, opts QueryOptions) (*QueryResult,
 		entries = append(entries, l.toEntry(log))
 	}
 
-	return &QueryResult{
+	result := &QueryResult{
 		Entries:  entries,
 		Total:    int64(total),
 		Page:     opts.Page,
 		PageSize: opts.PageSize,
-	}, nil
+	}
+	if len(logs) > 0 {
+		last := logs[len(logs)-1]
+		result.LastTime = last.CreatedAt
+		result.LastID = last.ID
+	}
+
+	return result, nil
 }
 
 // Get 获取单条日志
@@ -263,6 +320,50 @@ func (l *EntLogger) GetModules(ctx context.Context) ([]string, error) {
 	return modules, nil
 }
 
+// GetRecentByUser 获取指定用户最近的操作日志
+func (l *EntLogger) GetRecentByUser(ctx context.Context, userID, limit int) ([]*EntryWithID, error) {
+	logs, err := l.client.AuditLog.Query().
+		Where(auditlog.UserIDEQ(userID)).
+		Order(ent.Desc(auditlog.FieldCreatedAt)).
+		Limit(limit).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*EntryWithID, 0, len(logs))
+	for _, log := range logs {
+		entries = append(entries, l.ToEntryWithID(log))
+	}
+	return entries, nil
+}
+
+// GetResourceHistory 获取针对某个具体资源的操作记录
+// 按 (resource, resource_id) 组合索引精确匹配；
+// 批量操作记录的 resource_id 形如 "42 (+3 more)"，用前缀匹配一并命中
+func (l *EntLogger) GetResourceHistory(ctx context.Context, resource, resourceID string, limit int) ([]*EntryWithID, error) {
+	logs, err := l.client.AuditLog.Query().
+		Where(
+			auditlog.ResourceEQ(resource),
+			auditlog.Or(
+				auditlog.ResourceIDEQ(resourceID),
+				auditlog.ResourceIDHasPrefix(resourceID+" ("),
+			),
+		).
+		Order(ent.Desc(auditlog.FieldCreatedAt)).
+		Limit(limit).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*EntryWithID, 0, len(logs))
+	for _, log := range logs {
+		entries = append(entries, l.ToEntryWithID(log))
+	}
+	return entries, nil
+}
+
 // toEntry 将 ent.AuditLog 转换为 Entry
 func (l *EntLogger) toEntry(log *ent.AuditLog) *Entry {
 	entry := &Entry{
@@ -334,3 +435,53 @@ func nilIfEmpty(s string) *string {
 	}
 	return &s
 }
+
+// GetTimeSeries 按天或小时分桶统计日志数量
+// 使用 PostgreSQL 的 date_trunc 在数据库侧完成聚合
+func (l *EntLogger) GetTimeSeries(ctx context.Context, opts TimeSeriesOptions) ([]TimeSeriesPoint, error) {
+	// 粒度白名单，防止非法值拼接进 SQL
+	interval := opts.Interval
+	if interval != TimeSeriesIntervalHour {
+		interval = TimeSeriesIntervalDay
+	}
+
+	query := l.client.AuditLog.Query()
+	if !opts.StartTime.IsZero() {
+		query = query.Where(auditlog.CreatedAtGTE(opts.StartTime))
+	}
+	if !opts.EndTime.IsZero() {
+		query = query.Where(auditlog.CreatedAtLTE(opts.EndTime))
+	}
+	if opts.Level != "" {
+		query = query.Where(auditlog.LevelEQ(auditlog.Level(opts.Level)))
+	}
+	if opts.Module != "" {
+		query = query.Where(auditlog.ModuleEQ(opts.Module))
+	}
+
+	var rows []struct {
+		Bucket time.Time `sql:"bucket"`
+		Count  int64     `sql:"count"`
+	}
+	err := query.
+		Modify(func(s *entsql.Selector) {
+			s.Select(
+				entsql.As(fmt.Sprintf("date_trunc('%s', %s)", interval, s.C(auditlog.FieldCreatedAt)), "bucket"),
+				entsql.As(entsql.Count("*"), "count"),
+			).GroupBy("bucket").OrderBy("bucket")
+		}).
+		Scan(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]TimeSeriesPoint, 0, len(rows))
+	for _, row := range rows {
+		points = append(points, TimeSeriesPoint{
+			Timestamp: row.Bucket,
+			Count:     row.Count,
+		})
+	}
+
+	return points, nil
+}
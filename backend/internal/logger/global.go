@@ -10,6 +10,8 @@ import (
 	"runtime"
 	"sync"
 	"time"
+
+	"zera/internal/instanceid"
 )
 
 // LogFormat 日志格式类型
@@ -223,6 +225,8 @@ func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
 	if h.config.Environment != "" {
 		r.AddAttrs(slog.String("env", h.config.Environment))
 	}
+	// 实例标识，多实例部署时按实例过滤日志
+	r.AddAttrs(slog.String("instance", instanceid.ID()))
 
 	// 从上下文提取追踪信息
 	if ctx != nil {
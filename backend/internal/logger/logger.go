@@ -78,6 +78,42 @@ type Reader interface {
 	GetStats(ctx context.Context, startTime, endTime time.Time) (*Stats, error)
 	// GetModules 获取所有模块列表
 	GetModules(ctx context.Context) ([]string, error)
+	// GetRecentByUser 获取指定用户最近的操作日志（按时间倒序，带真实 ID）
+	GetRecentByUser(ctx context.Context, userID, limit int) ([]*EntryWithID, error)
+	// GetResourceHistory 获取针对某个具体资源的操作记录（按时间倒序，带真实 ID）
+	GetResourceHistory(ctx context.Context, resource, resourceID string, limit int) ([]*EntryWithID, error)
+	// GetTimeSeries 按天或小时分桶统计日志数量（按时间升序）
+	GetTimeSeries(ctx context.Context, opts TimeSeriesOptions) ([]TimeSeriesPoint, error)
+}
+
+// TimeSeriesInterval 时间序列分桶粒度
+const (
+	// TimeSeriesIntervalDay 按天分桶
+	TimeSeriesIntervalDay = "day"
+	// TimeSeriesIntervalHour 按小时分桶
+	TimeSeriesIntervalHour = "hour"
+)
+
+// TimeSeriesOptions 时间序列统计选项
+type TimeSeriesOptions struct {
+	// StartTime 开始时间（零值表示不限制）
+	StartTime time.Time
+	// EndTime 结束时间（零值表示不限制）
+	EndTime time.Time
+	// Interval 分桶粒度: day、hour
+	Interval string
+	// Level 日志级别筛选（可选）
+	Level Level
+	// Module 模块筛选（可选）
+	Module string
+}
+
+// TimeSeriesPoint 时间序列数据点
+type TimeSeriesPoint struct {
+	// Timestamp 时间桶起点
+	Timestamp time.Time
+	// Count 该时间桶内的日志数量
+	Count int64
 }
 
 // QueryOptions 查询选项
@@ -108,6 +144,12 @@ type QueryOptions struct {
 	SortBy string
 	// Descending 是否降序
 	Descending bool
+	// ExactTotal 要求精确总数（跳过总数缓存）
+	ExactTotal bool
+	// AfterTime 游标分页：仅返回创建时间早于该值的记录（配合默认的创建时间降序）
+	AfterTime *time.Time
+	// AfterID 游标分页：创建时间相同时按 ID 断开，避免跨页重复
+	AfterID int
 }
 
 // QueryResult 查询结果
@@ -120,6 +162,10 @@ type QueryResult struct {
 	Page int
 	// PageSize 每页数量
 	PageSize int
+	// LastTime 本页最后一条记录的创建时间（用于构造下一页游标）
+	LastTime time.Time
+	// LastID 本页最后一条记录的 ID（用于构造下一页游标）
+	LastID int
 }
 
 // Stats 日志统计
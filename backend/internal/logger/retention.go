@@ -0,0 +1,163 @@
+package logger
+
+import (
+	"context"
+	"time"
+
+	"zera/ent"
+	"zera/ent/auditlog"
+	"zera/ent/predicate"
+)
+
+// RetentionRule 审计日志保留规则
+// Module/Action 为空表示不限制该维度
+type RetentionRule struct {
+	// Module 匹配的模块，为空匹配所有模块
+	Module string
+	// Action 匹配的操作，为空匹配所有操作
+	Action string
+	// Days 保留天数
+	Days int
+}
+
+// RetentionConfig 审计日志保留策略
+type RetentionConfig struct {
+	// DefaultDays 全局默认保留天数，未命中任何规则的日志按此清理
+	DefaultDays int
+	// Interval 清理任务执行间隔
+	Interval time.Duration
+	// Rules 按模块/操作匹配的保留规则
+	Rules []RetentionRule
+}
+
+// RetentionWorker 审计日志保留期清理任务
+// 定期按规则删除过期日志：命中规则的按规则保留天数清理，
+// 未命中任何规则的按全局默认保留天数清理
+type RetentionWorker struct {
+	client *ent.Client
+	cfg    RetentionConfig
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewRetentionWorker 创建保留期清理任务
+func NewRetentionWorker(client *ent.Client, cfg RetentionConfig) *RetentionWorker {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 24 * time.Hour
+	}
+	return &RetentionWorker{
+		client: client,
+		cfg:    cfg,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Start 启动后台清理循环
+// 启动时立即执行一次，之后按配置间隔执行
+func (w *RetentionWorker) Start() {
+	go func() {
+		defer close(w.done)
+
+		w.runCleanup(context.Background())
+
+		ticker := time.NewTicker(w.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				w.runCleanup(context.Background())
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止清理任务并等待当前轮次结束
+func (w *RetentionWorker) Stop() {
+	close(w.stop)
+	<-w.done
+}
+
+// runCleanup 执行一轮清理
+func (w *RetentionWorker) runCleanup(ctx context.Context) {
+	now := time.Now()
+	totalDeleted := 0
+
+	// 命中规则的日志按各自保留天数清理
+	for _, rule := range w.cfg.Rules {
+		if rule.Days <= 0 {
+			continue
+		}
+
+		preds := []predicate.AuditLog{
+			auditlog.CreatedAtLT(now.AddDate(0, 0, -rule.Days)),
+		}
+		preds = append(preds, rule.matchPredicates()...)
+
+		n, err := w.client.AuditLog.Delete().
+			Where(auditlog.And(preds...)).
+			Exec(ctx)
+		if err != nil {
+			Error("审计日志规则清理失败",
+				"module", rule.Module,
+				"action", rule.Action,
+				"error", err,
+			)
+			continue
+		}
+		totalDeleted += n
+		if n > 0 {
+			Info("审计日志规则清理完成",
+				"module", rule.Module,
+				"action", rule.Action,
+				"retention_days", rule.Days,
+				"deleted", n,
+			)
+		}
+	}
+
+	// 未命中任何规则的日志按全局默认保留天数清理
+	// 排除规则命中的记录，避免保留期更长的规则被默认策略提前删除
+	if w.cfg.DefaultDays > 0 {
+		preds := []predicate.AuditLog{
+			auditlog.CreatedAtLT(now.AddDate(0, 0, -w.cfg.DefaultDays)),
+		}
+		for _, rule := range w.cfg.Rules {
+			if match := rule.matchPredicates(); len(match) > 0 {
+				preds = append(preds, auditlog.Not(auditlog.And(match...)))
+			}
+		}
+
+		n, err := w.client.AuditLog.Delete().
+			Where(auditlog.And(preds...)).
+			Exec(ctx)
+		if err != nil {
+			Error("审计日志默认清理失败", "error", err)
+		} else {
+			totalDeleted += n
+			if n > 0 {
+				Info("审计日志默认清理完成",
+					"retention_days", w.cfg.DefaultDays,
+					"deleted", n,
+				)
+			}
+		}
+	}
+
+	Debug("审计日志保留期清理执行完毕", "total_deleted", totalDeleted)
+}
+
+// matchPredicates 构建规则的匹配条件
+func (r RetentionRule) matchPredicates() []predicate.AuditLog {
+	preds := make([]predicate.AuditLog, 0, 2)
+	if r.Module != "" {
+		preds = append(preds, auditlog.ModuleEQ(r.Module))
+	}
+	if r.Action != "" {
+		preds = append(preds, auditlog.ActionEQ(r.Action))
+	}
+	return preds
+}
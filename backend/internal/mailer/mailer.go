@@ -0,0 +1,74 @@
+// Package mailer 提供基于 SMTP 的邮件发送能力
+// 用于登录提醒等系统通知邮件，未启用时发送接口直接返回错误
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strings"
+)
+
+// ErrNotEnabled 邮件服务未启用
+var ErrNotEnabled = errors.New("mailer is not enabled")
+
+// Config 邮件服务配置
+type Config struct {
+	// Enabled 是否启用邮件发送
+	Enabled bool
+	// Host SMTP 服务器地址
+	Host string
+	// Port SMTP 服务器端口
+	Port int
+	// Username SMTP 认证用户名，为空时不认证
+	Username string
+	// Password SMTP 认证密码
+	Password string
+	// From 发件人地址
+	From string
+}
+
+// Mailer SMTP 邮件发送器
+type Mailer struct {
+	cfg Config
+}
+
+// New 创建邮件发送器
+func New(cfg Config) *Mailer {
+	return &Mailer{cfg: cfg}
+}
+
+// Enabled 是否可用（已启用且必要配置完整）
+func (m *Mailer) Enabled() bool {
+	return m.cfg.Enabled && m.cfg.Host != "" && m.cfg.From != ""
+}
+
+// Send 发送纯文本邮件
+// 主题按 RFC 2047 编码，支持中文
+func (m *Mailer) Send(to, subject, body string) error {
+	if !m.Enabled() {
+		return ErrNotEnabled
+	}
+
+	addr := fmt.Sprintf("%s:%d", m.cfg.Host, m.cfg.Port)
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+
+	return smtp.SendMail(addr, auth, m.cfg.From, []string{to}, buildMessage(m.cfg.From, to, subject, body))
+}
+
+// buildMessage 构建 RFC 5322 邮件内容
+func buildMessage(from, to, subject, body string) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("UTF-8", subject) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(body)
+	return []byte(b.String())
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"time"
+
+	"zera/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessLogMiddleware Gin 结构化访问日志中间件
+// 无论 telemetry 是否启用都记录每个 HTTP 请求，替代 gin 默认的文本日志
+// trace_id 由 logger 的上下文处理器自动附加（需在 TraceMiddleware 之后注册）
+// 日志级别按状态码区分: 5xx 为 Error，4xx 为 Warn，其余为 Info
+func AccessLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		startTime := time.Now()
+
+		// 处理请求
+		c.Next()
+
+		ctx := c.Request.Context()
+		status := c.Writer.Status()
+		attrs := []any{
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", status,
+			"duration_ms", time.Since(startTime).Milliseconds(),
+			"client_ip", c.ClientIP(),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "error", c.Errors.String())
+		}
+
+		switch {
+		case status >= 500:
+			logger.ErrorContext(ctx, "http request", attrs...)
+		case status >= 400:
+			logger.WarnContext(ctx, "http request", attrs...)
+		default:
+			logger.InfoContext(ctx, "http request", attrs...)
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"zera/internal/geoip"
 	"zera/internal/handler"
 	"zera/internal/logger"
 	"zera/internal/permission"
@@ -25,12 +26,15 @@ const auditLogModule = "audit_log"
 // 自动记录所有 API 请求的审计日志
 type AuditLogInterceptor struct {
 	logger logger.Writer
+	// bodySampler 请求体记录采样器，nil 表示全量记录
+	bodySampler *logger.BodySampler
 }
 
 // NewAuditLogInterceptor 创建审计日志拦截器
-func NewAuditLogInterceptor(logger logger.Writer) *AuditLogInterceptor {
+func NewAuditLogInterceptor(auditLogger logger.Writer, bodySampler *logger.BodySampler) *AuditLogInterceptor {
 	return &AuditLogInterceptor{
-		logger: logger,
+		logger:      auditLogger,
+		bodySampler: bodySampler,
 	}
 }
 
@@ -49,18 +53,15 @@ func (i *AuditLogInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFun
 		// 构建日志条目
 		entry := i.buildEntry(ctx, req.Header(), procedure, req.Any(), duration, err)
 
-		// 异步写入日志
-		go func() {
-			writeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			if writeErr := i.logger.Write(writeCtx, entry); writeErr != nil {
-				// 日志写入失败不影响请求，但记录到系统日志
-				logger.ErrorContext(ctx, "failed to write audit log",
-					"error", writeErr,
-					"procedure", procedure,
-				)
-			}
-		}()
+		// 送入 AsyncLogger 的缓冲通道，由后台攒批或定时统一刷库
+		// Write 只做非阻塞的通道投递，无需再为每条日志单独起 goroutine
+		if writeErr := i.writeEntry(entry); writeErr != nil {
+			// 日志写入失败不影响请求，但记录到系统日志
+			logger.ErrorContext(ctx, "failed to write audit log",
+				"error", writeErr,
+				"procedure", procedure,
+			)
+		}
 
 		return resp, err
 	}
@@ -86,23 +87,29 @@ func (i *AuditLogInterceptor) WrapStreamingHandler(next connect.StreamingHandler
 		// 构建日志条目
 		entry := i.buildEntry(ctx, conn.RequestHeader(), procedure, nil, duration, err)
 
-		// 异步写入日志
-		go func() {
-			writeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			if writeErr := i.logger.Write(writeCtx, entry); writeErr != nil {
-				// 日志写入失败不影响请求，但记录到系统日志
-				logger.ErrorContext(ctx, "failed to write audit log",
-					"error", writeErr,
-					"procedure", procedure,
-				)
-			}
-		}()
+		// 送入 AsyncLogger 的缓冲通道，由后台攒批或定时统一刷库
+		// Write 只做非阻塞的通道投递，无需再为每条日志单独起 goroutine
+		if writeErr := i.writeEntry(entry); writeErr != nil {
+			// 日志写入失败不影响请求，但记录到系统日志
+			logger.ErrorContext(ctx, "failed to write audit log",
+				"error", writeErr,
+				"procedure", procedure,
+			)
+		}
 
 		return err
 	}
 }
 
+// writeEntry 写入单条审计日志
+// 底层为 AsyncLogger 时仅是通道投递；仅在缓冲满的降级路径才会同步落库，
+// 超时上限兜底，避免数据库异常时拖住请求
+func (i *AuditLogInterceptor) writeEntry(entry *logger.Entry) error {
+	writeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return i.logger.Write(writeCtx, entry)
+}
+
 // buildEntry 构建日志条目
 func (i *AuditLogInterceptor) buildEntry(
 	ctx context.Context,
@@ -148,12 +155,22 @@ func (i *AuditLogInterceptor) buildEntry(
 	}
 
 	// 处理请求体（脱敏）并提取资源 ID
+	// 完整请求体受采样控制（慢请求与错误请求始终记录）；
+	// 资源 ID 属于元数据，不受采样影响
 	if requestBody != nil {
-		entry.RequestBody = sanitizeRequestBody(requestBody)
+		if i.bodySampler.ShouldRecord(durationMs, err) {
+			entry.RequestBody = sanitizeRequestBody(requestBody)
+		}
 		// 从请求体中提取资源 ID
 		entry.ResourceID = extractResourceID(requestBody)
 	}
 
+	// IP 地理位置解析（GeoIP 未启用时跳过）
+	if loc := geoip.Lookup(entry.IP); loc != nil {
+		details, _ := json.Marshal(map[string]string{"ip_region": loc.String()})
+		entry.Details = string(details)
+	}
+
 	// 处理错误
 	if err != nil {
 		entry.Level = logger.LevelError
@@ -6,11 +6,34 @@ import (
 )
 
 // CORS 返回 CORS 中间件配置
+// 除常规头外放行 Connect/gRPC-Web 协议所需的请求头，
+// 并暴露 gRPC-Web 状态头与自定义响应头，浏览器端才能读取流式和错误信息
 func CORS() gin.HandlerFunc {
 	return cors.New(cors.Config{
-		AllowOrigins:  []string{"*"},
-		AllowMethods:  []string{"POST", "GET", "OPTIONS", "PUT", "DELETE"},
-		AllowHeaders:  []string{"Content-Type", "Connect-Protocol-Version", "Authorization"},
-		ExposeHeaders: []string{"Connect-Protocol-Version"},
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{"POST", "GET", "OPTIONS", "PUT", "DELETE"},
+		AllowHeaders: []string{
+			"Content-Type",
+			"Connect-Protocol-Version",
+			"Connect-Timeout-Ms",
+			"Authorization",
+			// gRPC-Web 协议头
+			"X-Grpc-Web",
+			"X-User-Agent",
+			"Grpc-Timeout",
+			// 高危操作的二次确认令牌
+			"X-Sudo-Token",
+		},
+		ExposeHeaders: []string{
+			"Connect-Protocol-Version",
+			// gRPC-Web 状态头，浏览器端读取调用结果必需
+			"Grpc-Status",
+			"Grpc-Message",
+			"Grpc-Status-Details-Bin",
+			"Grpc-Encoding",
+			// 令牌临近过期提醒
+			"X-Token-Expiring",
+			"X-Token-Expires-In",
+		},
 	})
 }
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	"connectrpc.com/connect"
+)
+
+// HealthChecker 数据库连接健康状态查询接口
+type HealthChecker interface {
+	Healthy() bool
+}
+
+// DBHealthInterceptor 数据库健康拦截器
+// 数据库连接失效期间让请求快速失败返回 CodeUnavailable，
+// 避免请求在失效连接上长时间挂起；后台重连成功后自动放行
+type DBHealthInterceptor struct {
+	checker HealthChecker
+}
+
+// NewDBHealthInterceptor 创建数据库健康拦截器
+func NewDBHealthInterceptor(checker HealthChecker) *DBHealthInterceptor {
+	return &DBHealthInterceptor{
+		checker: checker,
+	}
+}
+
+// errDBUnavailable 数据库不可用时的统一错误消息
+var errDBUnavailable = errors.New("数据库连接暂不可用，请稍后重试")
+
+// WrapUnary 包装一元调用
+func (i *DBHealthInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if !i.checker.Healthy() {
+			return nil, connect.NewError(connect.CodeUnavailable, errDBUnavailable)
+		}
+		return next(ctx, req)
+	}
+}
+
+// WrapStreamingClient 包装流式客户端
+func (i *DBHealthInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler 包装流式处理器
+func (i *DBHealthInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		if !i.checker.Healthy() {
+			return connect.NewError(connect.CodeUnavailable, errDBUnavailable)
+		}
+		return next(ctx, conn)
+	}
+}
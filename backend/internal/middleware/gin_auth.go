@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"context"
+
+	"zera/internal/auth"
+	"zera/internal/handler"
+	"zera/internal/permission"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ginContextClaims gin.Context 中存放用户声明的键
+const ginContextClaims = "auth_claims"
+
+// GinAuthMiddleware Gin 认证中间件
+// 与 Connect 权限拦截器复用同一套令牌解析与权限匹配逻辑，
+// 用于上传等未走 Connect-RPC 的 Gin 端点，保证两条路径认证行为一致
+type GinAuthMiddleware struct {
+	jwtManager        *auth.JWTManager
+	permissionChecker *permission.Checker
+	tokenRevoker      auth.TokenRevoker
+}
+
+// NewGinAuthMiddleware 创建 Gin 认证中间件
+// tokenRevoker 用于查询登出令牌黑名单，可为 nil（此时跳过查询）
+func NewGinAuthMiddleware(
+	jwtManager *auth.JWTManager,
+	checker *permission.Checker,
+	tokenRevoker auth.TokenRevoker,
+) *GinAuthMiddleware {
+	return &GinAuthMiddleware{
+		jwtManager:        jwtManager,
+		permissionChecker: checker,
+		tokenRevoker:      tokenRevoker,
+	}
+}
+
+// RequireAuth 要求请求携带有效的访问令牌
+// 校验通过后将用户声明注入 gin.Context，并同步写入请求上下文供下游读取
+func (m *GinAuthMiddleware) RequireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := extractToken(c.Request.Header)
+		if token == "" {
+			c.AbortWithStatusJSON(401, gin.H{
+				"success": false,
+				"error":   "未授权访问",
+			})
+			return
+		}
+
+		claims, err := m.jwtManager.ValidateAccessToken(token)
+		if err != nil {
+			c.AbortWithStatusJSON(401, gin.H{
+				"success": false,
+				"error":   "未授权访问",
+			})
+			return
+		}
+
+		// 已登出的令牌在黑名单中，过期前拒绝访问
+		if m.tokenRevoker != nil && m.tokenRevoker.IsRevoked(token) {
+			c.AbortWithStatusJSON(401, gin.H{
+				"success": false,
+				"error":   "令牌已失效，请重新登录",
+			})
+			return
+		}
+
+		// 注入 gin.Context
+		c.Set(ginContextClaims, claims)
+
+		// 同步注入请求上下文，与 Connect 拦截器保持相同的键
+		ctx := c.Request.Context()
+		ctx = context.WithValue(ctx, handler.ContextKeyUserID, claims.UserID)
+		ctx = context.WithValue(ctx, handler.ContextKeyUsername, claims.Username)
+		ctx = context.WithValue(ctx, handler.ContextKeyRoles, claims.Roles)
+		ctx = context.WithValue(ctx, handler.ContextKeyPermissions, claims.Permissions)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// RequirePermission 要求当前用户拥有指定权限
+// 需要在 RequireAuth 之后使用，权限匹配规则与 Connect 拦截器一致
+func (m *GinAuthMiddleware) RequirePermission(code string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := GinClaims(c)
+		if claims == nil {
+			c.AbortWithStatusJSON(401, gin.H{
+				"success": false,
+				"error":   "未授权访问",
+			})
+			return
+		}
+
+		// 管理员的权限快照为通配符 "*"，由 containsPermission 放行；
+		// 不按角色放行，保证降级权限（softban、未同意条款）同样生效
+		if containsPermission(claims.Permissions, code) {
+			c.Next()
+			return
+		}
+
+		c.AbortWithStatusJSON(403, gin.H{
+			"success": false,
+			"error":   "缺少权限: " + code,
+		})
+	}
+}
+
+// GinClaims 获取认证中间件注入的用户声明，未认证时返回 nil
+func GinClaims(c *gin.Context) *auth.Claims {
+	v, ok := c.Get(ginContextClaims)
+	if !ok {
+		return nil
+	}
+	claims, ok := v.(*auth.Claims)
+	if !ok {
+		return nil
+	}
+	return claims
+}
@@ -5,9 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"zera/internal/auth"
+	"zera/internal/cache"
 	"zera/internal/handler"
 	"zera/internal/permission"
 
@@ -19,16 +22,28 @@ import (
 type PermissionInterceptor struct {
 	jwtManager        *auth.JWTManager
 	permissionChecker *permission.Checker
+	onlineTracker     *cache.OnlineTracker
+	nonceStore        *auth.NonceStore
+	tokenRevoker      auth.TokenRevoker
 }
 
 // NewPermissionInterceptor 创建权限拦截器
+// onlineTracker 用于记录认证用户的最近活跃时间，可为 nil
+// nonceStore 用于校验敏感写接口的防重放 nonce，可为 nil（此时跳过校验）
+// tokenRevoker 用于查询登出令牌黑名单，可为 nil（此时跳过查询）
 func NewPermissionInterceptor(
 	jwtManager *auth.JWTManager,
 	checker *permission.Checker,
+	onlineTracker *cache.OnlineTracker,
+	nonceStore *auth.NonceStore,
+	tokenRevoker auth.TokenRevoker,
 ) *PermissionInterceptor {
 	return &PermissionInterceptor{
 		jwtManager:        jwtManager,
 		permissionChecker: checker,
+		onlineTracker:     onlineTracker,
+		nonceStore:        nonceStore,
+		tokenRevoker:      tokenRevoker,
 	}
 }
 
@@ -73,33 +88,85 @@ func (i *PermissionInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryF
 				)
 			}
 
+			// 已登出的令牌在黑名单中，过期前拒绝访问
+			if i.tokenRevoker != nil && i.tokenRevoker.IsRevoked(token) {
+				return nil, connect.NewError(
+					connect.CodeUnauthenticated,
+					errors.New("令牌已失效，请重新登录"),
+				)
+			}
+
 			// 将用户信息存入上下文
 			ctx = context.WithValue(ctx, handler.ContextKeyUserID, claims.UserID)
 			ctx = context.WithValue(ctx, handler.ContextKeyUsername, claims.Username)
 			ctx = context.WithValue(ctx, handler.ContextKeyRoles, claims.Roles)
 			ctx = context.WithValue(ctx, handler.ContextKeyPermissions, claims.Permissions)
 
-			// 5. 检查具体权限
-			if apiPerm.Code != "" {
-				// 管理员拥有所有权限
-				if containsRole(claims.Roles, "admin") {
-					return next(ctx, req)
+			// 记录用户最近活跃时间（用于在线用户查询）
+			if i.onlineTracker != nil {
+				i.onlineTracker.Touch(claims.UserID)
+			}
+
+			// 5. 高危操作需要二次密码确认（sudo 模式），管理员也不例外
+			if apiPerm.RequireSudo {
+				if err := i.checkSudoToken(req.Header(), claims.UserID); err != nil {
+					return nil, err
 				}
+			}
+
+			// 敏感写接口要求一次性防重放 nonce，用后作废
+			if apiPerm.RequireNonce {
+				if err := i.checkNonce(req.Header(), claims.UserID); err != nil {
+					return nil, err
+				}
+			}
 
-				// 检查用户是否拥有所需权限
-				if !containsPermission(claims.Permissions, apiPerm.Code) {
+			// 6. 检查具体权限
+			// 管理员的权限快照为通配符 "*"，由 containsPermission 放行；
+			// 不按角色放行，否则 softban/未同意条款的降级权限对管理员形同虚设
+			if apiPerm.Code != "" {
+				if apiPerm.StrictCheck {
+					// 严格模式：不信任令牌内嵌的权限快照，实时查库确认当前权限
+					if err := i.checkFreshPermission(ctx, claims.UserID, apiPerm); err != nil {
+						return nil, err
+					}
+				} else if !containsPermission(claims.Permissions, apiPerm.Code) {
 					return nil, connect.NewError(
 						connect.CodePermissionDenied,
 						fmt.Errorf("缺少权限: %s (%s)", apiPerm.Name, apiPerm.Code),
 					)
 				}
 			}
+
+			// 7. 令牌临近过期时在响应头提醒前端刷新
+			resp, err := next(ctx, req)
+			if err == nil && resp != nil {
+				setTokenExpiringHeader(resp.Header(), claims)
+			}
+			return resp, err
 		}
 
 		return next(ctx, req)
 	}
 }
 
+// tokenExpiringThreshold 令牌剩余有效期低于该阈值时提醒前端刷新
+const tokenExpiringThreshold = 5 * time.Minute
+
+// setTokenExpiringHeader 令牌临近过期时设置提醒响应头
+// 不做自动续期（与滑动过期区分），仅提示前端主动调用 RefreshToken
+func setTokenExpiringHeader(header http.Header, claims *auth.Claims) {
+	if claims.ExpiresAt == nil {
+		return
+	}
+	remaining := time.Until(claims.ExpiresAt.Time)
+	if remaining <= 0 || remaining > tokenExpiringThreshold {
+		return
+	}
+	header.Set("X-Token-Expiring", "true")
+	header.Set("X-Token-Expires-In", strconv.Itoa(int(remaining.Seconds())))
+}
+
 // WrapStreamingClient 包装流式客户端
 func (i *PermissionInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
 	return next
@@ -144,26 +211,134 @@ func (i *PermissionInterceptor) WrapStreamingHandler(next connect.StreamingHandl
 				)
 			}
 
+			// 已登出的令牌在黑名单中，过期前拒绝访问
+			if i.tokenRevoker != nil && i.tokenRevoker.IsRevoked(token) {
+				return connect.NewError(
+					connect.CodeUnauthenticated,
+					errors.New("令牌已失效，请重新登录"),
+				)
+			}
+
 			ctx = context.WithValue(ctx, handler.ContextKeyUserID, claims.UserID)
 			ctx = context.WithValue(ctx, handler.ContextKeyUsername, claims.Username)
 			ctx = context.WithValue(ctx, handler.ContextKeyRoles, claims.Roles)
 			ctx = context.WithValue(ctx, handler.ContextKeyPermissions, claims.Permissions)
 
-			// 检查权限
+			// 记录用户最近活跃时间（用于在线用户查询）
+			if i.onlineTracker != nil {
+				i.onlineTracker.Touch(claims.UserID)
+			}
+
+			// 高危操作需要二次密码确认（sudo 模式）
+			if apiPerm.RequireSudo {
+				if err := i.checkSudoToken(conn.RequestHeader(), claims.UserID); err != nil {
+					return err
+				}
+			}
+
+			// 敏感写接口要求一次性防重放 nonce，用后作废
+			if apiPerm.RequireNonce {
+				if err := i.checkNonce(conn.RequestHeader(), claims.UserID); err != nil {
+					return err
+				}
+			}
+
+			// 检查权限（管理员经通配符 "*" 放行，不按角色放行，与一元调用一致）
 			if apiPerm.Code != "" {
-				if !containsRole(claims.Roles, "admin") && !containsPermission(claims.Permissions, apiPerm.Code) {
+				if apiPerm.StrictCheck {
+					// 严格模式：不信任令牌内嵌的权限快照，实时查库确认当前权限
+					if err := i.checkFreshPermission(ctx, claims.UserID, apiPerm); err != nil {
+						return err
+					}
+				} else if !containsPermission(claims.Permissions, apiPerm.Code) {
 					return connect.NewError(
 						connect.CodePermissionDenied,
 						fmt.Errorf("缺少权限: %s", apiPerm.Code),
 					)
 				}
 			}
+
+			// 令牌临近过期时在响应头提醒前端刷新
+			setTokenExpiringHeader(conn.ResponseHeader(), claims)
 		}
 
 		return next(ctx, conn)
 	}
 }
 
+// checkFreshPermission 严格模式的实时权限校验
+// 不信任令牌内嵌的权限快照，直接查库确认用户当前权限，
+// 避免权限被撤销后旧令牌在过期前仍可执行高危操作；
+// 管理员判定同样实时查库，而不是采信令牌中的角色列表
+func (i *PermissionInterceptor) checkFreshPermission(ctx context.Context, userID int, apiPerm *permission.APIPermission) error {
+	isAdmin, err := i.permissionChecker.IsAdmin(ctx, userID)
+	if err != nil {
+		return connect.NewError(connect.CodeInternal, errors.New("权限校验失败"))
+	}
+	if isAdmin {
+		return nil
+	}
+
+	has, err := i.permissionChecker.HasPermission(ctx, userID, apiPerm.Code)
+	if err != nil {
+		return connect.NewError(connect.CodeInternal, errors.New("权限校验失败"))
+	}
+	if !has {
+		return connect.NewError(
+			connect.CodePermissionDenied,
+			fmt.Errorf("缺少权限: %s (%s)", apiPerm.Name, apiPerm.Code),
+		)
+	}
+	return nil
+}
+
+// checkSudoToken 校验请求头中的 sudo 令牌
+// 高危接口需要先通过 VerifyPassword 获取短时 sudo 令牌，并通过 X-Sudo-Token 请求头携带
+func (i *PermissionInterceptor) checkSudoToken(header http.Header, userID int) error {
+	sudoToken := header.Get("X-Sudo-Token")
+	if sudoToken == "" {
+		return connect.NewError(
+			connect.CodePermissionDenied,
+			errors.New("该操作需要二次密码确认"),
+		)
+	}
+
+	sudoClaims, err := i.jwtManager.ValidateSudoToken(sudoToken)
+	if err != nil || sudoClaims.UserID != userID {
+		return connect.NewError(
+			connect.CodePermissionDenied,
+			errors.New("二次密码确认已过期，请重新验证"),
+		)
+	}
+
+	return nil
+}
+
+// checkNonce 校验请求头中的一次性防重放 nonce
+// 敏感写接口需要先通过 GetNonce 获取 nonce，并通过 X-Nonce 请求头携带，用后作废
+func (i *PermissionInterceptor) checkNonce(header http.Header, userID int) error {
+	if i.nonceStore == nil {
+		return nil
+	}
+
+	nonce := header.Get("X-Nonce")
+	if nonce == "" {
+		return connect.NewError(
+			connect.CodePermissionDenied,
+			errors.New("该操作需要携带防重放 nonce，请先调用 GetNonce 获取"),
+		)
+	}
+
+	if err := i.nonceStore.Consume(userID, nonce); err != nil {
+		return connect.NewError(
+			connect.CodePermissionDenied,
+			errors.New("nonce 无效、已使用或已过期，请重新获取"),
+		)
+	}
+
+	return nil
+}
+
 // extractToken 从请求头提取令牌
 func extractToken(header http.Header) string {
 	authHeader := header.Get("Authorization")
@@ -180,21 +355,9 @@ func extractToken(header http.Header) string {
 }
 
 // containsPermission 检查权限列表中是否包含指定权限
+// 通配符匹配逻辑统一收敛在 permission.Matches
 func containsPermission(permissions []string, target string) bool {
-	for _, p := range permissions {
-		// 支持通配符权限
-		if p == "*" || p == target {
-			return true
-		}
-		// 支持资源级通配符，如 user:* 匹配所有 user 相关权限
-		if strings.HasSuffix(p, ":*") {
-			prefix := strings.TrimSuffix(p, "*")
-			if strings.HasPrefix(target, prefix) {
-				return true
-			}
-		}
-	}
-	return false
+	return permission.Matches(permissions, target)
 }
 
 // containsRole 检查角色列表中是否包含指定角色
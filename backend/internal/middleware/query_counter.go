@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+
+	"connectrpc.com/connect"
+)
+
+// QueryCounterInterceptor 查询计数拦截器
+// 在请求上下文中挂载按表的查询计数器，配合数据库驱动包装器做疑似 N+1 检测
+type QueryCounterInterceptor struct {
+	seed func(ctx context.Context, procedure string) context.Context
+}
+
+// NewQueryCounterInterceptor 创建查询计数拦截器
+// seed 负责在上下文中挂载计数器（由 database 包提供）
+func NewQueryCounterInterceptor(seed func(ctx context.Context, procedure string) context.Context) *QueryCounterInterceptor {
+	return &QueryCounterInterceptor{
+		seed: seed,
+	}
+}
+
+// WrapUnary 包装一元调用
+func (i *QueryCounterInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		ctx = i.seed(ctx, req.Spec().Procedure)
+		return next(ctx, req)
+	}
+}
+
+// WrapStreamingClient 包装流式客户端
+func (i *QueryCounterInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler 包装流式处理器
+func (i *QueryCounterInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		ctx = i.seed(ctx, conn.Spec().Procedure)
+		return next(ctx, conn)
+	}
+}
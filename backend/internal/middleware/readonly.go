@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	"zera/ent"
+	"zera/internal/permission"
+	"zera/internal/service"
+
+	"connectrpc.com/connect"
+)
+
+// ReadOnlyInterceptor 只读模式拦截器
+// 只读模式开启时（升级或备份期间）拒绝所有写操作，读操作正常放行
+// 与维护模式的区别: 维护模式阻止普通用户的所有请求，只读模式仅阻止写入
+type ReadOnlyInterceptor struct {
+	client *ent.Client
+}
+
+// NewReadOnlyInterceptor 创建只读模式拦截器
+func NewReadOnlyInterceptor(client *ent.Client) *ReadOnlyInterceptor {
+	return &ReadOnlyInterceptor{
+		client: client,
+	}
+}
+
+// WrapUnary 包装一元调用
+func (i *ReadOnlyInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if err := i.checkReadOnly(ctx, req.Spec().Procedure); err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+// WrapStreamingClient 包装流式客户端
+func (i *ReadOnlyInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler 包装流式处理器
+func (i *ReadOnlyInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		if err := i.checkReadOnly(ctx, conn.Spec().Procedure); err != nil {
+			return err
+		}
+		return next(ctx, conn)
+	}
+}
+
+// checkReadOnly 只读模式下拒绝写操作
+func (i *ReadOnlyInterceptor) checkReadOnly(ctx context.Context, procedure string) error {
+	// 非写操作直接放行，避免无谓的数据库查询
+	if !isWriteProcedure(procedure) {
+		return nil
+	}
+
+	// 更新系统设置始终放行，否则只读模式开启后将无法关闭
+	if procedure == updateSystemSettingsProcedure {
+		return nil
+	}
+
+	settingService := service.NewSystemSettingService(i.client)
+	readOnly, err := settingService.IsReadOnlyMode(ctx)
+	if err != nil {
+		// 如果获取失败，放行请求
+		return nil
+	}
+
+	if readOnly {
+		return connect.NewError(
+			connect.CodeFailedPrecondition,
+			errors.New("系统只读维护中，暂不接受写入操作"),
+		)
+	}
+
+	return nil
+}
+
+// updateSystemSettingsProcedure 更新系统设置的过程名（只读模式下的逃生口）
+const updateSystemSettingsProcedure = "/base.SystemSettingService/UpdateSystemSettings"
+
+// readActions 视为读操作的权限 action
+// 维护的是读清单而非写清单：新增的写类 action（如 merge/grant/unlock/approve）
+// 无需登记即可在只读模式下被拦截，避免清单遗漏导致写操作漏网
+var readActions = map[string]bool{
+	"read":      true,
+	"read-body": true,
+	"export":    true,
+}
+
+// isWriteProcedure 根据权限注册表的 action 判断是否为写操作
+// 未设置 action 的条目为登录、登出、刷新令牌等认证流程接口，
+// 按读操作放行，避免只读模式把用户挡在登录之外
+func isWriteProcedure(procedure string) bool {
+	apiPerm := permission.GetByProcedure(procedure)
+	if apiPerm == nil || apiPerm.Action == "" {
+		return false
+	}
+	return !readActions[apiPerm.Action]
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+// TimeoutInterceptor RPC 超时拦截器
+// 为一元调用设置独立于通用 HTTP 超时的上下文超时；
+// 流式调用不设超时，避免长连接的流式响应被切断
+type TimeoutInterceptor struct {
+	timeout time.Duration
+}
+
+// NewTimeoutInterceptor 创建 RPC 超时拦截器
+// timeout <= 0 时不限制
+func NewTimeoutInterceptor(timeout time.Duration) *TimeoutInterceptor {
+	return &TimeoutInterceptor{
+		timeout: timeout,
+	}
+}
+
+// WrapUnary 包装一元调用
+func (i *TimeoutInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	if i.timeout <= 0 {
+		return next
+	}
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		ctx, cancel := context.WithTimeout(ctx, i.timeout)
+		defer cancel()
+		return next(ctx, req)
+	}
+}
+
+// WrapStreamingClient 包装流式客户端
+func (i *TimeoutInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler 包装流式处理器（流式调用不设超时）
+func (i *TimeoutInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next
+}
@@ -0,0 +1,83 @@
+// Package pagination 提供加密的分页游标编解码。
+// 游标内容经 AES-GCM 加密后 base64 编码，客户端既无法读取
+// 游标内部的 id/时间等字段，也无法篡改或伪造；
+// 服务端解码时校验认证标签，被篡改的游标返回 ErrInvalidCursor。
+package pagination
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidCursor 游标无效（格式错误、密钥不匹配或被篡改）
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// CursorCodec 加密游标编解码器
+// 同一密钥的实例间编码结果互通，密钥变更后旧游标全部失效
+type CursorCodec struct {
+	aead cipher.AEAD
+}
+
+// NewCursorCodec 创建游标编解码器
+// 密钥经 SHA-256 派生为 AES-256 密钥，任意长度的 secret 均可用
+func NewCursorCodec(secret string) *CursorCodec {
+	key := sha256.Sum256([]byte(secret))
+	// AES-256 + GCM 的参数固定合法，构造不会失败
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		panic(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	return &CursorCodec{
+		aead: aead,
+	}
+}
+
+// Encode 将游标内容编码为不透明 token
+// 格式: base64url(nonce || AES-GCM 密文)，GCM 认证标签同时防篡改
+func (c *CursorCodec) Encode(payload any) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, data, nil)
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decode 解码并解密游标 token，结果写入 out
+// 格式错误、密钥不匹配或被篡改时返回 ErrInvalidCursor
+func (c *CursorCodec) Decode(token string, out any) error {
+	sealed, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if len(sealed) < c.aead.NonceSize() {
+		return ErrInvalidCursor
+	}
+
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	data, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return ErrInvalidCursor
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package permission
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// accessibleCache 按角色+权限集合缓存可访问 procedure 列表
+// Registry 在进程生命周期内不变，缓存无需失效
+var (
+	accessibleMu    sync.RWMutex
+	accessibleCache = make(map[string][]string)
+)
+
+// AccessibleProcedures 返回给定角色与权限集合可访问的所有 procedure
+// 包含公开 API 和仅需认证的 API；拥有 "*" 权限（管理员）时返回全部
+func AccessibleProcedures(roles, permissions []string) []string {
+	key := accessibleCacheKey(roles, permissions)
+
+	accessibleMu.RLock()
+	cached, ok := accessibleCache[key]
+	accessibleMu.RUnlock()
+	if ok {
+		return cached
+	}
+
+	result := computeAccessibleProcedures(roles, permissions)
+
+	accessibleMu.Lock()
+	accessibleCache[key] = result
+	accessibleMu.Unlock()
+
+	return result
+}
+
+// computeAccessibleProcedures 遍历注册表过滤出可访问的 procedure
+func computeAccessibleProcedures(_, permissions []string) []string {
+	// 管理员的权限列表为通配符 "*"，由 Matches 放行；
+	// 不按角色放行，降级用户（softban、未同意条款）只看到只读接口
+	procedures := make([]string, 0, len(Registry))
+	for _, p := range Registry {
+		// 公开 API 和仅需认证的 API 对所有人可见
+		if p.IsPublic || p.Code == "" || Matches(permissions, p.Code) {
+			procedures = append(procedures, p.Procedure)
+		}
+	}
+
+	return procedures
+}
+
+// accessibleCacheKey 生成缓存键，排序后拼接以避免顺序影响命中
+func accessibleCacheKey(roles, permissions []string) string {
+	sortedRoles := append([]string(nil), roles...)
+	sortedPerms := append([]string(nil), permissions...)
+	sort.Strings(sortedRoles)
+	sort.Strings(sortedPerms)
+	return strings.Join(sortedRoles, ",") + "|" + strings.Join(sortedPerms, ",")
+}
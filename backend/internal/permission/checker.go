@@ -10,6 +10,7 @@ import (
 )
 
 // Checker 权限检查器 - 用于运行时检查用户权限
+// 已软删除的角色不参与权限判定
 type Checker struct {
 	client *ent.Client
 }
@@ -20,7 +21,7 @@ func NewChecker(client *ent.Client) *Checker {
 }
 
 // HasPermission 检查用户是否拥有指定权限
-// 通过用户的角色间接获取权限
+// 依次检查角色直接挂载的权限、直接授予用户的权限以及父角色链上继承的权限
 func (c *Checker) HasPermission(ctx context.Context, userID int, permissionCode string) (bool, error) {
 	if permissionCode == "" {
 		return true, nil
@@ -31,11 +32,79 @@ func (c *Checker) HasPermission(ctx context.Context, userID int, permissionCode
 		Query().
 		Where(user.ID(userID)).
 		QueryRoles().
+		Where(role.DeletedAtIsNil()).
 		QueryPermissions().
 		Where(permission.Code(permissionCode)).
 		Exist(ctx)
+	if err != nil || exists {
+		return exists, err
+	}
 
-	return exists, err
+	// 直接授予用户的权限（绕过角色的一次性授权）
+	exists, err = c.client.User.
+		Query().
+		Where(user.ID(userID)).
+		QueryDirectPermissions().
+		Where(permission.Code(permissionCode)).
+		Exist(ctx)
+	if err != nil || exists {
+		return exists, err
+	}
+
+	// 父角色链上继承的权限
+	ancestors, err := c.ancestorRoleIDs(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if len(ancestors) == 0 {
+		return false, nil
+	}
+	return c.client.Role.
+		Query().
+		Where(role.IDIn(ancestors...)).
+		QueryPermissions().
+		Where(permission.Code(permissionCode)).
+		Exist(ctx)
+}
+
+// ancestorRoleIDs 收集用户各角色沿父链向上的全部祖先角色 ID
+// visited 集合兜底防止环形继承导致死循环，已软删除的角色中断继承链
+func (c *Checker) ancestorRoleIDs(ctx context.Context, userID int) ([]int, error) {
+	roles, err := c.client.User.
+		Query().
+		Where(user.ID(userID)).
+		QueryRoles().
+		Where(role.DeletedAtIsNil()).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	visited := make(map[int]bool, len(roles))
+	for _, r := range roles {
+		visited[r.ID] = true
+	}
+
+	var ancestors []int
+	for _, r := range roles {
+		parentID := r.ParentID
+		for parentID != nil && !visited[*parentID] {
+			visited[*parentID] = true
+			parent, err := c.client.Role.
+				Query().
+				Where(role.ID(*parentID), role.DeletedAtIsNil()).
+				Only(ctx)
+			if err != nil {
+				if ent.IsNotFound(err) {
+					break
+				}
+				return nil, err
+			}
+			ancestors = append(ancestors, parent.ID)
+			parentID = parent.ParentID
+		}
+	}
+	return ancestors, nil
 }
 
 // HasAnyPermission 检查用户是否拥有任意一个指定权限
@@ -48,6 +117,7 @@ func (c *Checker) HasAnyPermission(ctx context.Context, userID int, permissionCo
 		Query().
 		Where(user.ID(userID)).
 		QueryRoles().
+		Where(role.DeletedAtIsNil()).
 		QueryPermissions().
 		Where(permission.CodeIn(permissionCodes...)).
 		Exist(ctx)
@@ -79,6 +149,7 @@ func (c *Checker) GetUserPermissions(ctx context.Context, userID int) ([]string,
 		Query().
 		Where(user.ID(userID)).
 		QueryRoles().
+		Where(role.DeletedAtIsNil()).
 		QueryPermissions().
 		All(ctx)
 
@@ -105,6 +176,7 @@ func (c *Checker) GetUserRoles(ctx context.Context, userID int) ([]string, error
 		Query().
 		Where(user.ID(userID)).
 		QueryRoles().
+		Where(role.DeletedAtIsNil()).
 		All(ctx)
 
 	if err != nil {
@@ -125,7 +197,7 @@ func (c *Checker) IsAdmin(ctx context.Context, userID int) (bool, error) {
 		Query().
 		Where(user.ID(userID)).
 		QueryRoles().
-		Where(role.Code("admin")).
+		Where(role.Code("admin"), role.DeletedAtIsNil()).
 		Exist(ctx)
 }
 
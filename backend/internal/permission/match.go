@@ -0,0 +1,19 @@
+package permission
+
+import "strings"
+
+// Matches 检查权限列表是否覆盖目标权限代码
+// 支持 "*" 全局通配符和 user:* 资源级通配符，
+// 权限拦截器、字段级权限判断与 CheckPermissions 接口共用该逻辑
+func Matches(permissions []string, target string) bool {
+	for _, p := range permissions {
+		if p == "*" || p == target {
+			return true
+		}
+		// 资源级通配符，如 user:* 匹配所有 user 相关权限
+		if strings.HasSuffix(p, ":*") && strings.HasPrefix(target, strings.TrimSuffix(p, "*")) {
+			return true
+		}
+	}
+	return false
+}
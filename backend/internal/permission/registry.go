@@ -23,6 +23,31 @@ type APIPermission struct {
 	RequireAuth bool
 	// IsPublic 是否为公开 API（无需认证和权限）
 	IsPublic bool
+	// RequireSudo 是否需要二次密码确认（sudo 模式）
+	// 标记后请求必须携带有效的 X-Sudo-Token 请求头才会执行
+	RequireSudo bool
+	// Sensitive 是否为敏感权限（高危操作）
+	// 标记后仅允许分配给系统角色，防止误配给低级角色
+	Sensitive bool
+	// RequireNonce 是否要求一次性防重放 nonce
+	// 标记后请求必须通过 X-Nonce 请求头携带从 GetNonce 获取的 nonce，用后作废
+	RequireNonce bool
+	// StrictCheck 是否启用严格模式的实时权限校验
+	// 标记后不信任令牌内嵌的权限快照，改为实时查库确认用户当前权限，
+	// 避免权限被撤销后旧令牌在过期前仍可执行高危操作；仅用于少量敏感接口
+	StrictCheck bool
+}
+
+// ExtraPermissions 未绑定具体 API 的字段级权限
+// 这些权限不参与请求拦截，由 handler 在返回数据时自行检查
+var ExtraPermissions = []APIPermission{
+	{
+		Code:        "audit_log:read-body",
+		Name:        "查看审计日志请求/响应体",
+		Description: "查看审计日志中的请求体和响应体等敏感字段",
+		Resource:    "audit_log",
+		Action:      "read-body",
+	},
 }
 
 // ResourceGroup 资源分组定义
@@ -54,6 +79,36 @@ var Registry = []APIPermission{
 		IsPublic:    true,
 		RequireAuth: false,
 	},
+	{
+		Procedure:   baseconnect.AuthServiceSendLoginCodeProcedure,
+		IsPublic:    true,
+		RequireAuth: false,
+	},
+	{
+		Procedure:   baseconnect.AuthServiceLoginWithCodeProcedure,
+		IsPublic:    true,
+		RequireAuth: false,
+	},
+	{
+		Procedure:   baseconnect.AuthServiceRequestPasswordResetProcedure,
+		IsPublic:    true,
+		RequireAuth: false,
+	},
+	{
+		Procedure:   baseconnect.AuthServiceConfirmPasswordResetProcedure,
+		IsPublic:    true,
+		RequireAuth: false,
+	},
+	{
+		Procedure:   baseconnect.AuthServiceLoginVerifyMFAProcedure,
+		IsPublic:    true,
+		RequireAuth: false,
+	},
+	{
+		Procedure:   baseconnect.AuthServiceLoginWithRecoveryCodeProcedure,
+		IsPublic:    true,
+		RequireAuth: false,
+	},
 	{
 		Procedure:   baseconnect.AuthServiceRefreshTokenProcedure,
 		IsPublic:    true,
@@ -71,6 +126,72 @@ var Registry = []APIPermission{
 		IsPublic:    false,
 		// 获取当前用户只需要认证，不需要特定权限
 	},
+	{
+		Procedure:   baseconnect.AuthServiceUpdateProfileProcedure,
+		RequireAuth: true,
+		IsPublic:    false,
+		// 更新个人资料只修改当前用户自己，无需 user:update 权限
+	},
+	{
+		Procedure:   baseconnect.AuthServiceChangePasswordProcedure,
+		RequireAuth: true,
+		IsPublic:    false,
+		// 修改密码需校验旧密码，只作用于当前用户自己
+	},
+	{
+		Procedure:   baseconnect.AuthServiceDeleteMyAccountProcedure,
+		RequireAuth: true,
+		IsPublic:    false,
+		// 注销账号需校验当前密码，只作用于当前用户自己
+	},
+	{
+		Procedure:   baseconnect.AuthServiceAcceptTermsProcedure,
+		RequireAuth: true,
+		IsPublic:    false,
+		// 同意服务条款只作用于当前用户自己，未同意期间权限已降级也要能调用
+	},
+	{
+		Procedure:   baseconnect.AuthServiceVerifyPasswordProcedure,
+		RequireAuth: true,
+		IsPublic:    false,
+		// 二次密码确认只需要认证，不需要特定权限
+	},
+	{
+		Procedure:   baseconnect.AuthServiceGetNonceProcedure,
+		RequireAuth: true,
+		IsPublic:    false,
+		// 获取防重放 nonce 只需要认证，不需要特定权限
+	},
+	{
+		Procedure:   baseconnect.AuthServiceEnableTOTPProcedure,
+		RequireAuth: true,
+		IsPublic:    false,
+		// 管理自己的两步验证只需要认证，不需要特定权限
+	},
+	{
+		Procedure:   baseconnect.AuthServiceVerifyTOTPProcedure,
+		RequireAuth: true,
+		IsPublic:    false,
+		// 管理自己的两步验证只需要认证，不需要特定权限
+	},
+	{
+		Procedure:   baseconnect.AuthServiceDisableTOTPProcedure,
+		RequireAuth: true,
+		IsPublic:    false,
+		// 管理自己的两步验证只需要认证，不需要特定权限
+	},
+	{
+		Procedure:   baseconnect.AuthServiceListAccessibleProceduresProcedure,
+		RequireAuth: true,
+		IsPublic:    false,
+		// 查询自己可访问的 procedure 只需要认证，不需要特定权限
+	},
+	{
+		Procedure:   baseconnect.AuthServiceCheckPermissionsProcedure,
+		RequireAuth: true,
+		IsPublic:    false,
+		// 校验自己的权限只需要认证，不需要特定权限
+	},
 
 	// ============================================
 	// CAS 认证服务
@@ -123,6 +244,84 @@ var Registry = []APIPermission{
 		RequireAuth: true,
 	},
 
+	// ============================================
+	// LDAP 认证服务
+	// ============================================
+	{
+		Procedure:   baseconnect.LDAPAuthServiceLDAPLoginProcedure,
+		IsPublic:    true,
+		RequireAuth: false,
+	},
+	{
+		Procedure:   baseconnect.LDAPAuthServiceGetPublicLDAPSettingsProcedure,
+		IsPublic:    true,
+		RequireAuth: false,
+	},
+	{
+		Procedure:   baseconnect.LDAPAuthServiceGetLDAPConfigProcedure,
+		Code:        "system_setting:read",
+		Name:        "查看 LDAP 配置",
+		Description: "获取 LDAP 认证配置",
+		Resource:    "system_setting",
+		Action:      "read",
+		RequireAuth: true,
+	},
+	{
+		Procedure:   baseconnect.LDAPAuthServiceUpdateLDAPConfigProcedure,
+		Code:        "system_setting:update",
+		Name:        "更新 LDAP 配置",
+		Description: "修改 LDAP 认证配置",
+		Resource:    "system_setting",
+		Action:      "update",
+		RequireAuth: true,
+	},
+	{
+		Procedure:   baseconnect.LDAPAuthServiceTestLDAPConnectionProcedure,
+		Code:        "system_setting:update",
+		Name:        "测试 LDAP 连接",
+		Description: "测试 LDAP 服务器连接",
+		Resource:    "system_setting",
+		Action:      "update",
+		RequireAuth: true,
+	},
+
+	// ============================================
+	// OIDC 认证服务
+	// ============================================
+	{
+		Procedure:   baseconnect.OIDCAuthServiceGetOIDCAuthURLProcedure,
+		IsPublic:    true,
+		RequireAuth: false,
+	},
+	{
+		Procedure:   baseconnect.OIDCAuthServiceOIDCCallbackProcedure,
+		IsPublic:    true,
+		RequireAuth: false,
+	},
+	{
+		Procedure:   baseconnect.OIDCAuthServiceGetPublicOIDCSettingsProcedure,
+		IsPublic:    true,
+		RequireAuth: false,
+	},
+	{
+		Procedure:   baseconnect.OIDCAuthServiceGetOIDCConfigProcedure,
+		Code:        "system_setting:read",
+		Name:        "查看 OIDC 配置",
+		Description: "获取 OIDC 认证配置",
+		Resource:    "system_setting",
+		Action:      "read",
+		RequireAuth: true,
+	},
+	{
+		Procedure:   baseconnect.OIDCAuthServiceUpdateOIDCConfigProcedure,
+		Code:        "system_setting:update",
+		Name:        "更新 OIDC 配置",
+		Description: "修改 OIDC 认证配置",
+		Resource:    "system_setting",
+		Action:      "update",
+		RequireAuth: true,
+	},
+
 	// ============================================
 	// 用户管理服务
 	// ============================================
@@ -163,13 +362,17 @@ var Registry = []APIPermission{
 		RequireAuth: true,
 	},
 	{
-		Procedure:   baseconnect.UserServiceDeleteUserProcedure,
-		Code:        "user:delete",
-		Name:        "删除用户",
-		Description: "删除用户账号",
-		Resource:    "user",
-		Action:      "delete",
-		RequireAuth: true,
+		Procedure:    baseconnect.UserServiceDeleteUserProcedure,
+		Code:         "user:delete",
+		Name:         "删除用户",
+		Description:  "删除用户账号",
+		Resource:     "user",
+		Action:       "delete",
+		RequireAuth:  true,
+		RequireSudo:  true,
+		Sensitive:    true,
+		StrictCheck:  true,
+		RequireNonce: true,
 	},
 	{
 		Procedure:   baseconnect.UserServiceResetUserPasswordProcedure,
@@ -181,14 +384,125 @@ var Registry = []APIPermission{
 		RequireAuth: true,
 	},
 	{
-		Procedure:   baseconnect.UserServiceBatchDeleteUsersProcedure,
-		Code:        "user:delete",
-		Name:        "批量删除用户",
-		Description: "批量删除多个用户账号",
+		Procedure:    baseconnect.UserServiceMergeUsersProcedure,
+		Code:         "user:merge",
+		Name:         "合并用户账号",
+		Description:  "将次账号数据迁移至主账号并删除次账号",
+		Resource:     "user",
+		Action:       "merge",
+		RequireAuth:  true,
+		RequireSudo:  true,
+		Sensitive:    true,
+		StrictCheck:  true,
+		RequireNonce: true,
+	},
+	{
+		Procedure:   baseconnect.UserServiceGetOnlineUsersProcedure,
+		Code:        "user:read",
+		Name:        "查看在线用户",
+		Description: "获取最近活跃的在线用户列表",
 		Resource:    "user",
-		Action:      "delete",
+		Action:      "read",
+		RequireAuth: true,
+	},
+	{
+		Procedure:   baseconnect.UserServiceListTagsProcedure,
+		Code:        "user:read",
+		Name:        "查看用户标签",
+		Description: "获取所有用户标签列表",
+		Resource:    "user",
+		Action:      "read",
+		RequireAuth: true,
+	},
+	{
+		Procedure:   baseconnect.UserServiceAddUserTagsProcedure,
+		Code:        "user:update",
+		Name:        "添加用户标签",
+		Description: "给用户打标签",
+		Resource:    "user",
+		Action:      "update",
+		RequireAuth: true,
+	},
+	{
+		Procedure:   baseconnect.UserServiceRemoveUserTagsProcedure,
+		Code:        "user:update",
+		Name:        "移除用户标签",
+		Description: "移除用户的标签",
+		Resource:    "user",
+		Action:      "update",
 		RequireAuth: true,
 	},
+	{
+		Procedure:   baseconnect.UserServiceUpdateUserMetadataProcedure,
+		Code:        "user:update",
+		Name:        "更新用户扩展属性",
+		Description: "更新用户的自定义扩展属性",
+		Resource:    "user",
+		Action:      "update",
+		RequireAuth: true,
+	},
+	{
+		Procedure:   baseconnect.UserServiceGrantUserPermissionProcedure,
+		Code:        "user:grant",
+		Name:        "授予用户直接权限",
+		Description: "绕过角色直接为用户授予权限（一次性授权）",
+		Resource:    "user",
+		Action:      "grant",
+		RequireAuth: true,
+		Sensitive:   true,
+		StrictCheck: true,
+	},
+	{
+		Procedure:   baseconnect.UserServiceRevokeUserPermissionProcedure,
+		Code:        "user:grant",
+		Name:        "撤销用户直接权限",
+		Description: "撤销直接授予用户的权限",
+		Resource:    "user",
+		Action:      "grant",
+		RequireAuth: true,
+		Sensitive:   true,
+		StrictCheck: true,
+	},
+	{
+		Procedure:   baseconnect.UserServiceCheckUserExistenceProcedure,
+		Code:        "user:read",
+		Name:        "检查用户是否存在",
+		Description: "批量检查用户名或邮箱是否已被占用",
+		Resource:    "user",
+		Action:      "read",
+		RequireAuth: true,
+	},
+	{
+		Procedure:   baseconnect.UserServiceBatchImportUsersProcedure,
+		Code:        "user:create",
+		Name:        "批量导入用户",
+		Description: "批量导入用户并流式返回进度",
+		Resource:    "user",
+		Action:      "create",
+		RequireAuth: true,
+	},
+	{
+		Procedure:   baseconnect.UserServiceBatchResetPasswordsProcedure,
+		Code:        "user:reset-password",
+		Name:        "批量重置密码",
+		Description: "批量重置用户密码并流式返回进度",
+		Resource:    "user",
+		Action:      "reset-password",
+		RequireAuth: true,
+	},
+	{
+		Procedure:    baseconnect.UserServiceBatchDeleteUsersProcedure,
+		Code:         "user:delete",
+		Name:         "批量删除用户",
+		Description:  "批量删除多个用户账号",
+		Resource:     "user",
+		Action:       "delete",
+		RequireAuth:  true,
+		RequireSudo:  true,
+		Sensitive:    true,
+		StrictCheck:  true,
+		RequireNonce: true,
+	},
 	{
 		Procedure:   baseconnect.UserServiceBatchUpdateUserStatusProcedure,
 		Code:        "user:update",
@@ -198,6 +512,24 @@ var Registry = []APIPermission{
 		Action:      "update",
 		RequireAuth: true,
 	},
+	{
+		Procedure:   baseconnect.UserServiceUnlockUserProcedure,
+		Code:        "user:unlock",
+		Name:        "解锁用户",
+		Description: "解锁因多次登录失败被锁定的用户",
+		Resource:    "user",
+		Action:      "unlock",
+		RequireAuth: true,
+	},
+	{
+		Procedure:   baseconnect.UserServiceBatchUnlockUsersProcedure,
+		Code:        "user:unlock",
+		Name:        "批量解锁用户",
+		Description: "批量解锁因多次登录失败被锁定的用户",
+		Resource:    "user",
+		Action:      "unlock",
+		RequireAuth: true,
+	},
 
 	// ============================================
 	// 角色管理服务
@@ -229,6 +561,15 @@ var Registry = []APIPermission{
 		Action:      "create",
 		RequireAuth: true,
 	},
+	{
+		Procedure:   baseconnect.RoleServiceCloneRoleProcedure,
+		Code:        "role:create",
+		Name:        "克隆角色",
+		Description: "复制源角色的全部权限创建新角色",
+		Resource:    "role",
+		Action:      "create",
+		RequireAuth: true,
+	},
 	{
 		Procedure:   baseconnect.RoleServiceUpdateRoleProcedure,
 		Code:        "role:update",
@@ -239,14 +580,54 @@ var Registry = []APIPermission{
 		RequireAuth: true,
 	},
 	{
-		Procedure:   baseconnect.RoleServiceDeleteRoleProcedure,
+		Procedure:    baseconnect.RoleServiceDeleteRoleProcedure,
+		Code:         "role:delete",
+		Name:         "删除角色",
+		Description:  "删除角色",
+		Resource:     "role",
+		Action:       "delete",
+		RequireAuth:  true,
+		RequireSudo:  true,
+		Sensitive:    true,
+		StrictCheck:  true,
+		RequireNonce: true,
+	},
+	{
+		Procedure:   baseconnect.RoleServiceRestoreRoleProcedure,
 		Code:        "role:delete",
-		Name:        "删除角色",
-		Description: "删除角色",
+		Name:        "恢复角色",
+		Description: "恢复已软删除的角色",
 		Resource:    "role",
 		Action:      "delete",
 		RequireAuth: true,
 	},
+	{
+		Procedure:   baseconnect.RoleServiceListRoleChangeRequestsProcedure,
+		Code:        "role:read",
+		Name:        "查看权限变更请求",
+		Description: "获取角色权限变更请求列表",
+		Resource:    "role",
+		Action:      "read",
+		RequireAuth: true,
+	},
+	{
+		Procedure:   baseconnect.RoleServiceReviewRoleChangeRequestProcedure,
+		Code:        "role:approve",
+		Name:        "审批权限变更",
+		Description: "审批系统角色的权限变更请求",
+		Resource:    "role",
+		Action:      "approve",
+		RequireAuth: true,
+	},
+	{
+		Procedure:   baseconnect.RoleServiceListRoleTemplatesProcedure,
+		Code:        "role:read",
+		Name:        "查看角色模板",
+		Description: "获取内置角色模板列表",
+		Resource:    "role",
+		Action:      "read",
+		RequireAuth: true,
+	},
 	{
 		Procedure:   baseconnect.RoleServiceListPermissionsProcedure,
 		Code:        "permission:read",
@@ -274,6 +655,91 @@ var Registry = []APIPermission{
 		Action:      "assign",
 		RequireAuth: true,
 	},
+	{
+		Procedure:   baseconnect.RoleServiceBatchUpdateRolePermissionsProcedure,
+		Code:        "role:assign",
+		Name:        "批量分配角色权限",
+		Description: "在一个事务内批量更新多个角色的权限",
+		Resource:    "role",
+		Action:      "assign",
+		RequireAuth: true,
+	},
+	{
+		Procedure:   baseconnect.RoleServiceExportRolesProcedure,
+		Code:        "role:read",
+		Name:        "导出角色配置",
+		Description: "导出非系统角色及其权限配置",
+		Resource:    "role",
+		Action:      "read",
+		RequireAuth: true,
+	},
+	{
+		Procedure:   baseconnect.RoleServiceImportRolesProcedure,
+		Code:        "role:assign",
+		Name:        "导入角色配置",
+		Description: "从 JSON 配置导入角色及其权限",
+		Resource:    "role",
+		Action:      "assign",
+		RequireAuth: true,
+	},
+
+	// ============================================
+	// 部门管理服务
+	// ============================================
+	{
+		Procedure:   baseconnect.DepartmentServiceListDepartmentsProcedure,
+		Code:        "department:read",
+		Name:        "查看部门列表",
+		Description: "获取部门列表",
+		Resource:    "department",
+		Action:      "read",
+		RequireAuth: true,
+	},
+	{
+		Procedure:   baseconnect.DepartmentServiceGetDepartmentTreeProcedure,
+		Code:        "department:read",
+		Name:        "查看部门树",
+		Description: "获取部门树形结构",
+		Resource:    "department",
+		Action:      "read",
+		RequireAuth: true,
+	},
+	{
+		Procedure:   baseconnect.DepartmentServiceGetDepartmentProcedure,
+		Code:        "department:read",
+		Name:        "查看部门详情",
+		Description: "获取单个部门详细信息",
+		Resource:    "department",
+		Action:      "read",
+		RequireAuth: true,
+	},
+	{
+		Procedure:   baseconnect.DepartmentServiceCreateDepartmentProcedure,
+		Code:        "department:create",
+		Name:        "创建部门",
+		Description: "创建新部门",
+		Resource:    "department",
+		Action:      "create",
+		RequireAuth: true,
+	},
+	{
+		Procedure:   baseconnect.DepartmentServiceUpdateDepartmentProcedure,
+		Code:        "department:update",
+		Name:        "编辑部门",
+		Description: "修改部门信息",
+		Resource:    "department",
+		Action:      "update",
+		RequireAuth: true,
+	},
+	{
+		Procedure:   baseconnect.DepartmentServiceDeleteDepartmentProcedure,
+		Code:        "department:delete",
+		Name:        "删除部门",
+		Description: "删除部门",
+		Resource:    "department",
+		Action:      "delete",
+		RequireAuth: true,
+	},
 
 	// ============================================
 	// 审计日志服务
@@ -305,6 +771,15 @@ var Registry = []APIPermission{
 		Action:      "read",
 		RequireAuth: true,
 	},
+	{
+		Procedure:   baseconnect.AuditLogServiceGetTimeSeriesProcedure,
+		Code:        "audit_log:read",
+		Name:        "查看日志时间序列",
+		Description: "按天或小时分桶统计审计日志数量",
+		Resource:    "audit_log",
+		Action:      "read",
+		RequireAuth: true,
+	},
 	{
 		Procedure:   baseconnect.AuditLogServiceListAuditLogModulesProcedure,
 		Code:        "audit_log:read",
@@ -314,6 +789,33 @@ var Registry = []APIPermission{
 		Action:      "read",
 		RequireAuth: true,
 	},
+	{
+		Procedure:   baseconnect.AuditLogServiceGetUserRecentLogsProcedure,
+		Code:        "audit_log:read",
+		Name:        "查看用户最近操作",
+		Description: "获取指定用户最近的操作日志",
+		Resource:    "audit_log",
+		Action:      "read",
+		RequireAuth: true,
+	},
+	{
+		Procedure:   baseconnect.AuditLogServiceGetResourceHistoryProcedure,
+		Code:        "audit_log:read",
+		Name:        "查看资源操作历史",
+		Description: "按资源类型和资源 ID 追溯操作记录",
+		Resource:    "audit_log",
+		Action:      "read",
+		RequireAuth: true,
+	},
+	{
+		Procedure:   baseconnect.AuditLogServiceExportAuditLogsProcedure,
+		Code:        "audit_log:export",
+		Name:        "导出审计日志",
+		Description: "导出审计日志为 SIEM 格式（CEF/LEEF）",
+		Resource:    "audit_log",
+		Action:      "export",
+		RequireAuth: true,
+	},
 
 	// ============================================
 	// 系统设置服务
@@ -334,13 +836,17 @@ var Registry = []APIPermission{
 		RequireAuth: true,
 	},
 	{
-		Procedure:   baseconnect.SystemSettingServiceUpdateSystemSettingsProcedure,
-		Code:        "system_setting:update",
-		Name:        "更新系统设置",
-		Description: "修改系统设置",
-		Resource:    "system_setting",
-		Action:      "update",
-		RequireAuth: true,
+		Procedure:    baseconnect.SystemSettingServiceUpdateSystemSettingsProcedure,
+		Code:         "system_setting:update",
+		Name:         "更新系统设置",
+		Description:  "修改系统设置",
+		Resource:     "system_setting",
+		Action:       "update",
+		RequireAuth:  true,
+		RequireSudo:  true,
+		Sensitive:    true,
+		StrictCheck:  true,
+		RequireNonce: true,
 	},
 }
 
@@ -367,19 +873,26 @@ var ResourceGroups = []ResourceGroup{
 		Icon:        "SafetyOutlined",
 		Order:       3,
 	},
+	{
+		Resource:    "department",
+		Name:        "部门管理",
+		Description: "部门组织架构管理",
+		Icon:        "ApartmentOutlined",
+		Order:       4,
+	},
 	{
 		Resource:    "audit_log",
 		Name:        "审计日志",
 		Description: "系统操作日志查看",
 		Icon:        "FileTextOutlined",
-		Order:       4,
+		Order:       5,
 	},
 	{
 		Resource:    "system_setting",
 		Name:        "系统设置",
 		Description: "系统配置管理",
 		Icon:        "SettingOutlined",
-		Order:       5,
+		Order:       6,
 	},
 }
 
@@ -389,6 +902,7 @@ var (
 	codeToPermission      map[string]*APIPermission
 	uniquePermissions     []*APIPermission
 	resourceGroupMap      map[string]*ResourceGroup
+	sensitiveCodes        map[string]bool
 )
 
 func init() {
@@ -400,6 +914,7 @@ func initMaps() {
 	procedureToPermission = make(map[string]*APIPermission)
 	codeToPermission = make(map[string]*APIPermission)
 	resourceGroupMap = make(map[string]*ResourceGroup)
+	sensitiveCodes = make(map[string]bool)
 	seen := make(map[string]bool)
 
 	// 构建 API 权限映射
@@ -407,6 +922,11 @@ func initMaps() {
 		p := &Registry[i]
 		procedureToPermission[p.Procedure] = p
 
+		// 任一入口标记为敏感，该权限代码即视为敏感
+		if p.Code != "" && p.Sensitive {
+			sensitiveCodes[p.Code] = true
+		}
+
 		// 去重收集唯一权限（用于同步到数据库）
 		if p.Code != "" && !seen[p.Code] {
 			seen[p.Code] = true
@@ -415,6 +935,16 @@ func initMaps() {
 		}
 	}
 
+	// 收集未绑定 API 的字段级权限
+	for i := range ExtraPermissions {
+		p := &ExtraPermissions[i]
+		if p.Code != "" && !seen[p.Code] {
+			seen[p.Code] = true
+			codeToPermission[p.Code] = p
+			uniquePermissions = append(uniquePermissions, p)
+		}
+	}
+
 	// 构建资源分组映射
 	for i := range ResourceGroups {
 		g := &ResourceGroups[i]
@@ -432,6 +962,12 @@ func GetByCode(code string) *APIPermission {
 	return codeToPermission[code]
 }
 
+// IsSensitiveCode 判断权限代码是否为敏感权限
+// 敏感权限仅允许分配给系统角色
+func IsSensitiveCode(code string) bool {
+	return sensitiveCodes[code]
+}
+
 // GetUniquePermissions 获取所有唯一权限（用于同步到数据库）
 func GetUniquePermissions() []*APIPermission {
 	return uniquePermissions
@@ -0,0 +1,38 @@
+// Package pinyin 提供中文转拼音索引的工具函数
+package pinyin
+
+import (
+	"strings"
+
+	gopinyin "github.com/mozillazg/go-pinyin"
+)
+
+// Index 生成拼音索引字符串
+// 返回 "全拼 首字母" 形式的小写字符串（如 "张三" → "zhangsan zs"），
+// 非中文字符原样保留并转为小写
+func Index(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	args := gopinyin.NewArgs()
+	var full, initials strings.Builder
+	for _, r := range s {
+		py := gopinyin.SinglePinyin(r, args)
+		if len(py) > 0 && py[0] != "" {
+			full.WriteString(py[0])
+			initials.WriteString(py[0][:1])
+			continue
+		}
+		// 非中文字符原样保留
+		lower := strings.ToLower(string(r))
+		full.WriteString(lower)
+		initials.WriteString(lower)
+	}
+
+	// 无中文字符时全拼与首字母一致，只保留一份
+	if full.String() == initials.String() {
+		return full.String()
+	}
+	return full.String() + " " + initials.String()
+}
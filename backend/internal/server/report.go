@@ -0,0 +1,70 @@
+package server
+
+import (
+	"fmt"
+)
+
+// StartupReport 服务启动自检报告
+// 汇总初始化链路各环节的结果，启动完成后统一打印
+type StartupReport struct {
+	// DatabaseVersion 数据库服务器版本
+	DatabaseVersion string
+	// MigrationRan 是否执行了自动迁移（仅开发模式）
+	MigrationRan bool
+	// PermissionsCreated 本次同步新建的权限数
+	PermissionsCreated int
+	// PermissionsUpdated 本次同步更新的权限数
+	PermissionsUpdated int
+	// AdminExists 是否存在管理员用户
+	AdminExists bool
+	// StorageEnabled 对象存储是否启用
+	StorageEnabled bool
+	// StorageConnected 对象存储是否连通（桶检查通过）
+	StorageConnected bool
+	// CasdoorEnabled Casdoor 同步是否启用
+	CasdoorEnabled bool
+	// CasdoorConnected Casdoor 客户端是否初始化成功
+	CasdoorConnected bool
+	// TelemetryEnabled OpenTelemetry 是否启用
+	TelemetryEnabled bool
+}
+
+// Print 打印自检报告
+func (r *StartupReport) Print() {
+	fmt.Println("========== 启动自检报告 ==========")
+	fmt.Printf("  数据库版本: %s\n", valueOrDash(r.DatabaseVersion))
+	fmt.Printf("  自动迁移:   %s\n", boolLabel(r.MigrationRan, "已执行", "已跳过（非开发模式）"))
+	fmt.Printf("  权限同步:   新建 %d / 更新 %d\n", r.PermissionsCreated, r.PermissionsUpdated)
+	fmt.Printf("  管理员用户: %s\n", boolLabel(r.AdminExists, "已存在", "不存在"))
+	fmt.Printf("  对象存储:   %s\n", statusLabel(r.StorageEnabled, r.StorageConnected))
+	fmt.Printf("  Casdoor:    %s\n", statusLabel(r.CasdoorEnabled, r.CasdoorConnected))
+	fmt.Printf("  Telemetry:  %s\n", boolLabel(r.TelemetryEnabled, "已启用", "未启用"))
+	fmt.Println("==================================")
+}
+
+// valueOrDash 空值显示为 "-"
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// boolLabel 根据布尔值选择显示文案
+func boolLabel(ok bool, yes, no string) string {
+	if ok {
+		return yes
+	}
+	return no
+}
+
+// statusLabel 组合启用状态与连通状态的文案
+func statusLabel(enabled, connected bool) string {
+	if !enabled {
+		return "未启用"
+	}
+	if connected {
+		return "已启用，连接正常"
+	}
+	return "已启用，连接异常"
+}
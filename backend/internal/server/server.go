@@ -3,14 +3,21 @@ package server
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"time"
 
 	"zera/gen/base/baseconnect"
 	"zera/internal/auth"
+	"zera/internal/cache"
+	"zera/internal/casdoor"
 	"zera/internal/config"
 	"zera/internal/database"
+	"zera/internal/geoip"
 	"zera/internal/handler"
 	"zera/internal/logger"
+	"zera/internal/mailer"
 	"zera/internal/middleware"
+	"zera/internal/pagination"
 	"zera/internal/permission"
 	"zera/internal/service"
 	"zera/internal/static"
@@ -20,6 +27,8 @@ import (
 	"buf.build/go/protovalidate"
 	"connectrpc.com/connect"
 	"github.com/gin-gonic/gin"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 // Server HTTP 服务器
@@ -30,9 +39,17 @@ type Server struct {
 	storage       *storage.Storage
 	localStorage  *static.LocalStorage
 	auditLogger   *logger.AsyncLogger
+	retention     *logger.RetentionWorker
 	globalLogger  *logger.GlobalLogger
 	otelProvider  *telemetry.Provider
 	otelLoggerSet *telemetry.LoggerSet
+	userService   *service.UserService
+	report        *StartupReport
+}
+
+// Report 返回启动自检报告
+func (s *Server) Report() *StartupReport {
+	return s.report
 }
 
 // New 创建服务器实例
@@ -61,6 +78,15 @@ func New(cfg *config.Config) (*Server, error) {
 		"environment", cfg.Log.Environment,
 	)
 
+	// 配置 bcrypt 成本因子（需在创建管理员用户之前设置）
+	auth.SetBcryptCost(cfg.Password.BcryptCost)
+
+	// 从 JWT 密钥派生 TOTP 密钥的加密密钥
+	auth.SetTOTPCipherKey(cfg.JWT.Secret)
+
+	// 启动自检报告，初始化链路各环节填充结果
+	report := &StartupReport{}
+
 	// 初始化 OpenTelemetry 提供者
 	var otelProvider *telemetry.Provider
 	var otelLoggerSet *telemetry.LoggerSet
@@ -81,10 +107,18 @@ func New(cfg *config.Config) (*Server, error) {
 			"app_logs", cfg.Telemetry.Logs.AppEnabled,
 			"db_logs", cfg.Telemetry.Logs.DBEnabled,
 		)
+		report.TelemetryEnabled = true
 	} else {
 		logger.Info("OpenTelemetry is disabled")
 	}
 
+	// 初始化 GeoIP 解析器（可选，未配置时跳过）
+	if err := geoip.Init(&cfg.GeoIP); err != nil {
+		logger.Warn("failed to initialize geoip resolver, ip region resolution disabled", "error", err)
+	} else if geoip.Enabled() {
+		logger.Info("geoip resolver initialized", "database", cfg.GeoIP.DatabasePath)
+	}
+
 	// 初始化数据库连接
 	logger.Info("connecting to database", "host", cfg.Database.Host, "port", cfg.Database.Port)
 	db, err := database.New(cfg)
@@ -92,12 +126,16 @@ func New(cfg *config.Config) (*Server, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 	logger.Info("database connected successfully")
+	if version, err := db.ServerVersion(context.Background()); err == nil {
+		report.DatabaseVersion = version
+	}
 
 	// 开发模式下自动迁移
 	if err := db.AutoMigrate(context.Background()); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to run auto migration: %w", err)
 	}
+	report.MigrationRan = cfg.App.DevMode || cfg.Database.AutoMigrate
 
 	// 初始化系统角色
 	if err := db.InitSystemRoles(context.Background()); err != nil {
@@ -107,16 +145,22 @@ func New(cfg *config.Config) (*Server, error) {
 
 	// 同步权限到数据库
 	permSyncer := permission.NewSyncer(db.Client, slogger)
-	if _, err := permSyncer.SyncPermissions(context.Background()); err != nil {
+	syncResult, err := permSyncer.SyncPermissions(context.Background())
+	if err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to sync permissions: %w", err)
 	}
+	report.PermissionsCreated = syncResult.Created
+	report.PermissionsUpdated = syncResult.Updated
 
 	// 初始化管理员用户
 	if err := db.InitAdminUser(context.Background()); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to init admin user: %w", err)
 	}
+	if exists, err := db.AdminUserExists(context.Background()); err == nil {
+		report.AdminExists = exists
+	}
 
 	// 初始化对象存储服务
 	storageClient, err := storage.New(&cfg.Storage, slogger)
@@ -131,6 +175,8 @@ func New(cfg *config.Config) (*Server, error) {
 		storageClient.Close()
 		return nil, fmt.Errorf("failed to ensure storage bucket: %w", err)
 	}
+	report.StorageEnabled = storageClient.IsEnabled()
+	report.StorageConnected = storageClient.IsEnabled()
 
 	// 初始化本地静态资源存储
 	localStorage, err := static.NewLocalStorage(&cfg.Static)
@@ -145,6 +191,11 @@ func New(cfg *config.Config) (*Server, error) {
 		logger.Warn("failed to ensure logo directory", "error", err)
 	}
 
+	// 确保登录页背景图目录存在
+	if err := localStorage.EnsureBackgroundDir(); err != nil {
+		logger.Warn("failed to ensure background directory", "error", err)
+	}
+
 	// 创建验证器
 	validator, err := protovalidate.New()
 	if err != nil {
@@ -153,27 +204,108 @@ func New(cfg *config.Config) (*Server, error) {
 	}
 
 	// 初始化 JWT 管理器
-	jwtManager := auth.NewJWTManager(&cfg.JWT)
+	jwtManager, err := auth.NewJWTManager(&cfg.JWT)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWT manager: %w", err)
+	}
 
 	// 初始化权限检查器
 	permChecker := permission.NewChecker(db.Client)
 
+	// 一次性防重放 nonce 存储（敏感写接口要求携带）
+	nonceStore := auth.NewNonceStore()
+
+	// 令牌黑名单（登出的 access token 在自然过期前拒绝访问）
+	tokenRevoker := auth.NewMemoryTokenRevoker()
+
 	// 初始化审计日志记录器
 	entLogger := logger.NewEntLogger(db.Client)
 	asyncLogger := logger.NewAsyncLogger(entLogger, entLogger, slogger, nil)
 
+	// 启动审计日志保留期清理任务（可选）
+	var retentionWorker *logger.RetentionWorker
+	if cfg.AuditRetention.Enabled {
+		retentionWorker = logger.NewRetentionWorker(db.Client, auditRetentionConfig(&cfg.AuditRetention))
+		retentionWorker.Start()
+		logger.Info("audit log retention worker started",
+			"default_days", cfg.AuditRetention.DefaultDays,
+			"rules", len(cfg.AuditRetention.Rules),
+		)
+	}
+
+	// 初始化邮件发送器（用于登录提醒等通知邮件）
+	mailSender := mailer.New(mailer.Config{
+		Enabled:  cfg.Mail.Enabled,
+		Host:     cfg.Mail.Host,
+		Port:     cfg.Mail.Port,
+		Username: cfg.Mail.Username,
+		Password: cfg.Mail.Password,
+		From:     cfg.Mail.From,
+	})
+
 	// 初始化服务层
-	authService := service.NewAuthService(db.Client, jwtManager)
+	authService := service.NewAuthService(db.Client, jwtManager, mailSender)
+	authService.SetTokenRevoker(tokenRevoker)
+	if cfg.LoginRateLimit.Enabled {
+		authService.SetLoginRateLimiter(auth.NewMemoryLoginRateLimiter(
+			time.Duration(cfg.LoginRateLimit.Window)*time.Second,
+			cfg.LoginRateLimit.MaxFailures,
+		))
+		logger.Info("login rate limiting enabled",
+			"window_seconds", cfg.LoginRateLimit.Window,
+			"max_failures", cfg.LoginRateLimit.MaxFailures,
+		)
+	}
+	if cfg.LoginAnomaly.Enabled {
+		anomalyDetector := auth.NewLoginAnomalyDetector(
+			time.Duration(cfg.LoginAnomaly.Window)*time.Second,
+			cfg.LoginAnomaly.GlobalThreshold,
+			cfg.LoginAnomaly.IPThreshold,
+			cfg.LoginAnomaly.AccountThreshold,
+			cfg.LoginAnomaly.WebhookURL,
+		)
+		// 告警事件落结构化日志，便于告警平台采集
+		anomalyDetector.SetAlertFunc(func(alert auth.LoginAnomalyAlert) {
+			logger.Warn("login anomaly detected",
+				"scope", alert.Scope,
+				"key", alert.Key,
+				"failures", alert.Failures,
+				"threshold", alert.Threshold,
+				"window_seconds", alert.WindowSeconds,
+			)
+		})
+		authService.SetLoginAnomalyDetector(anomalyDetector)
+		logger.Info("login anomaly detection enabled",
+			"window_seconds", cfg.LoginAnomaly.Window,
+			"global_threshold", cfg.LoginAnomaly.GlobalThreshold,
+			"ip_threshold", cfg.LoginAnomaly.IPThreshold,
+			"account_threshold", cfg.LoginAnomaly.AccountThreshold,
+		)
+	}
 	userService := service.NewUserService(db.Client)
+	userService.SetCursorCodec(pagination.NewCursorCodec(cfg.JWT.Secret))
 	roleService := service.NewRoleService(db.Client)
-	auditLogService := service.NewAuditLogService(asyncLogger)
+	departmentService := service.NewDepartmentService(db.Client)
+	auditLogService := service.NewAuditLogService(asyncLogger, pagination.NewCursorCodec(cfg.JWT.Secret))
 	systemSettingService := service.NewSystemSettingService(db.Client)
 	casAuthService := service.NewCASAuthService(db.Client, jwtManager)
+	ldapAuthService := service.NewLDAPAuthService(db.Client, jwtManager)
+	oidcAuthService := service.NewOIDCAuthService(db.Client, jwtManager)
 
 	// 初始化 Casdoor 客户端（用于用户同步）
 	if err := userService.InitCasdoorClient(context.Background()); err != nil {
 		slogger.Warn("failed to initialize casdoor client for user sync", "error", err)
 	}
+	report.CasdoorEnabled, report.CasdoorConnected = userService.CasdoorStatus()
+
+	// 多 Casdoor 组织路由（可选，按用户部门路由到不同组织）
+	if len(cfg.CasdoorRoutes) > 0 {
+		if err := userService.SetCasdoorRoutes(casdoorRoutesFromConfig(cfg.CasdoorRoutes)); err != nil {
+			slogger.Warn("failed to initialize casdoor routes", "error", err)
+		} else {
+			logger.Info("casdoor routes initialized", "routes", len(cfg.CasdoorRoutes))
+		}
+	}
 
 	// 初始化默认系统设置
 	if err := systemSettingService.InitDefaultSettings(context.Background()); err != nil {
@@ -182,22 +314,52 @@ func New(cfg *config.Config) (*Server, error) {
 	}
 
 	// 初始化处理器
-	authHandler := handler.NewAuthHandler(validator, authService, jwtManager)
+	authHandler := handler.NewAuthHandler(validator, authService, userService, jwtManager, nonceStore)
 	userHandler := handler.NewUserHandler(validator, userService)
 	roleHandler := handler.NewRoleHandler(validator, roleService)
+	departmentHandler := handler.NewDepartmentHandler(validator, departmentService)
 	auditLogHandler := handler.NewAuditLogHandler(validator, auditLogService)
 	systemSettingHandler := handler.NewSystemSettingHandler(validator, systemSettingService)
-	uploadHandler := handler.NewUploadHandler(localStorage, &cfg.Static, jwtManager, permChecker, systemSettingService)
+	uploadHandler := handler.NewUploadHandler(localStorage, &cfg.Static, systemSettingService)
 	casAuthHandler := handler.NewCASAuthHandler(validator, casAuthService, userService, jwtManager)
+	ldapAuthHandler := handler.NewLDAPAuthHandler(validator, ldapAuthService)
+	oidcAuthHandler := handler.NewOIDCAuthHandler(validator, oidcAuthService)
+
+	// 在线用户追踪器（权限拦截器记录活跃时间，在线用户查询读取）
+	onlineTracker := cache.NewOnlineTracker()
+	userService.SetOnlineTracker(onlineTracker)
+
+	// 注册业务健康指标（用户总数、活跃会话、登录失败率、审计积压、Casdoor 同步队列）
+	if otelProvider != nil && otelProvider.IsEnabled() {
+		otelProvider.RegisterBusinessMetrics(&telemetry.BusinessMetricsSource{
+			TotalUsers:       userService.TotalUsers,
+			ActiveSessions:   userService.ActiveSessionCount,
+			LoginFailureRate: authService.LoginFailureRate,
+			AuditLogBacklog: func() int64 {
+				return int64(asyncLogger.Backlog())
+			},
+			CasdoorSyncQueue: userService.CasdoorSyncPending,
+		})
+	}
 
 	// 创建权限拦截器（替代原来的认证拦截器）
-	permInterceptor := middleware.NewPermissionInterceptor(jwtManager, permChecker)
+	permInterceptor := middleware.NewPermissionInterceptor(jwtManager, permChecker, onlineTracker, nonceStore, tokenRevoker)
 
 	// 创建维护模式拦截器
 	maintenanceInterceptor := middleware.NewMaintenanceInterceptor(db.Client)
 
+	// 创建只读模式拦截器
+	readOnlyInterceptor := middleware.NewReadOnlyInterceptor(db.Client)
+
+	// 创建请求体记录采样器（审计日志与 telemetry API 日志共用）
+	bodySampler := logger.NewBodySampler(
+		cfg.Log.BodySampling.SampleRate,
+		cfg.Log.BodySampling.SlowThresholdMs,
+		cfg.Log.BodySampling.AlwaysOnError,
+	)
+
 	// 创建审计日志拦截器
-	auditLogInterceptor := middleware.NewAuditLogInterceptor(asyncLogger)
+	auditLogInterceptor := middleware.NewAuditLogInterceptor(asyncLogger, bodySampler)
 
 	// 创建 Gin 引擎
 	// 根据环境设置 Gin 模式
@@ -213,18 +375,19 @@ func New(cfg *config.Config) (*Server, error) {
 	}
 	// 2. 追踪中间件（生成 TraceID，保持向后兼容）
 	engine.Use(middleware.TraceMiddleware())
-	// 3. CORS 中间件
+	// 3. 结构化访问日志（不依赖 telemetry，需在追踪中间件之后以携带 trace_id）
+	engine.Use(middleware.AccessLogMiddleware())
+	// 4. CORS 中间件
 	engine.Use(middleware.CORS())
-	// 4. 恢复中间件
+	// 5. 恢复中间件
 	engine.Use(gin.Recovery())
-	// 注意：RPC 请求日志由 LoggingInterceptor 记录，避免重复
 
 	// 创建拦截器列表
 	var interceptorList []connect.Interceptor
 
 	// 1. OpenTelemetry 追踪拦截器（如果启用）
 	if otelProvider != nil && otelProvider.IsEnabled() {
-		otelTraceInterceptor := telemetry.NewOtelTraceInterceptor(otelProvider, otelLoggerSet)
+		otelTraceInterceptor := telemetry.NewOtelTraceInterceptor(otelProvider, otelLoggerSet, bodySampler)
 		interceptorList = append(interceptorList, otelTraceInterceptor)
 	}
 
@@ -236,13 +399,32 @@ func New(cfg *config.Config) (*Server, error) {
 	loggingInterceptor := middleware.NewLoggingInterceptor()
 	interceptorList = append(interceptorList, loggingInterceptor)
 
-	// 4. 权限拦截器
+	// 4. RPC 超时拦截器（可选，仅限制一元调用，流式不受限）
+	if cfg.Server.RPCTimeout > 0 {
+		timeoutInterceptor := middleware.NewTimeoutInterceptor(time.Duration(cfg.Server.RPCTimeout) * time.Second)
+		interceptorList = append(interceptorList, timeoutInterceptor)
+	}
+
+	// 5. 数据库健康拦截器（连接失效期间快速失败，避免请求挂起）
+	dbHealthInterceptor := middleware.NewDBHealthInterceptor(db)
+	interceptorList = append(interceptorList, dbHealthInterceptor)
+
+	// 6. 查询计数拦截器（疑似 N+1 检测，仅开发/遥测场景启用）
+	if database.NPlusOneThreshold(cfg) > 0 {
+		queryCounterInterceptor := middleware.NewQueryCounterInterceptor(database.WithQueryCounter)
+		interceptorList = append(interceptorList, queryCounterInterceptor)
+	}
+
+	// 7. 权限拦截器
 	interceptorList = append(interceptorList, permInterceptor)
 
-	// 5. 维护模式拦截器
+	// 8. 维护模式拦截器
 	interceptorList = append(interceptorList, maintenanceInterceptor)
 
-	// 6. 审计日志拦截器
+	// 9. 只读模式拦截器（只读维护期间拒绝写操作）
+	interceptorList = append(interceptorList, readOnlyInterceptor)
+
+	// 10. 审计日志拦截器
 	interceptorList = append(interceptorList, auditLogInterceptor)
 
 	// 创建拦截器链
@@ -269,6 +451,13 @@ func New(cfg *config.Config) (*Server, error) {
 	)
 	engine.Any(rolePath+"*action", gin.WrapH(roleH))
 
+	// 注册部门管理服务路由
+	departmentPath, departmentH := baseconnect.NewDepartmentServiceHandler(
+		departmentHandler,
+		interceptors,
+	)
+	engine.Any(departmentPath+"*action", gin.WrapH(departmentH))
+
 	// 注册审计日志服务路由
 	auditLogPath, auditLogH := baseconnect.NewAuditLogServiceHandler(
 		auditLogHandler,
@@ -290,13 +479,45 @@ func New(cfg *config.Config) (*Server, error) {
 	)
 	engine.Any(casAuthPath+"*action", gin.WrapH(casAuthH))
 
+	// 注册 LDAP 认证服务路由
+	ldapAuthPath, ldapAuthH := baseconnect.NewLDAPAuthServiceHandler(
+		ldapAuthHandler,
+		interceptors,
+	)
+	engine.Any(ldapAuthPath+"*action", gin.WrapH(ldapAuthH))
+
+	// 注册 OIDC 认证服务路由
+	oidcAuthPath, oidcAuthH := baseconnect.NewOIDCAuthServiceHandler(
+		oidcAuthHandler,
+		interceptors,
+	)
+	engine.Any(oidcAuthPath+"*action", gin.WrapH(oidcAuthH))
+
 	// 注册本地静态资源路由 (用于 Logo 等上传文件)
 	engine.Static("/uploads/static", cfg.Static.UploadsDir)
 
 	// 注册上传 API 路由
+	// 认证与权限检查通过 Gin 中间件统一处理，与 Connect 拦截器共用一套逻辑
+	ginAuth := middleware.NewGinAuthMiddleware(jwtManager, permChecker, tokenRevoker)
 	api := engine.Group("/api")
+	api.Use(ginAuth.RequireAuth(), ginAuth.RequirePermission("system_setting:update"))
 	api.POST("/upload/logo", uploadHandler.UploadLogo)
 	api.DELETE("/upload/logo", uploadHandler.DeleteLogo)
+	api.POST("/upload/background", uploadHandler.UploadBackground)
+	api.DELETE("/upload/background", uploadHandler.DeleteBackground)
+
+	// 用户导入模板下载与 CSV 上传导入，权限与批量导入接口保持一致
+	userImportHandler := handler.NewUserImportHandler(userService)
+	importAPI := engine.Group("/api/import")
+	importAPI.Use(ginAuth.RequireAuth(), ginAuth.RequirePermission("user:create"))
+	importAPI.GET("/users/template", handler.UserImportTemplate)
+	importAPI.POST("/users", userImportHandler.ImportUsersCSV)
+
+	// 用户清单导出（给审计用），权限与用户列表查询保持一致
+	userExportHandler := handler.NewUserExportHandler(userService, asyncLogger)
+	exportAPI := engine.Group("/api/users")
+	exportAPI.Use(ginAuth.RequireAuth(), ginAuth.RequirePermission("user:read"))
+	exportAPI.GET("/export", userExportHandler.ExportUsersCSV)
 
 	// 注册 SPA 静态资源（生产环境）
 	// 开发环境下 dist 目录可能不存在或为空，会优雅降级
@@ -308,6 +529,9 @@ func New(cfg *config.Config) (*Server, error) {
 
 	logger.Info("server initialized successfully")
 
+	// 启动完成，打印自检报告
+	report.Print()
+
 	return &Server{
 		config:        cfg,
 		engine:        engine,
@@ -315,21 +539,81 @@ func New(cfg *config.Config) (*Server, error) {
 		storage:       storageClient,
 		localStorage:  localStorage,
 		auditLogger:   asyncLogger,
+		retention:     retentionWorker,
 		globalLogger:  globalLogger,
 		otelProvider:  otelProvider,
 		otelLoggerSet: otelLoggerSet,
+		userService:   userService,
+		report:        report,
 	}, nil
 }
 
 // Run 启动服务器
+// 配置了 TLS 证书时以 HTTPS 提供服务（net/http 自动协商 HTTP/2）；
+// 配置了 h2c 时支持明文 HTTP/2（内网 gRPC 传输场景）；
+// 两者均未配置时保持明文 HTTP/1.1
 func (s *Server) Run() error {
 	addr := fmt.Sprintf("%s:%d", s.config.Server.Host, s.config.Server.Port)
-	logger.Info("server starting",
-		"address", addr,
-		"host", s.config.Server.Host,
-		"port", s.config.Server.Port,
-	)
-	return s.engine.Run(addr)
+	tlsCfg := &s.config.Server.TLS
+
+	switch {
+	case tlsCfg.Enabled():
+		logger.Info("server starting with TLS",
+			"address", addr,
+			"cert_file", tlsCfg.CertFile,
+		)
+		return s.engine.RunTLS(addr, tlsCfg.CertFile, tlsCfg.KeyFile)
+	case s.config.Server.H2C:
+		logger.Info("server starting with h2c",
+			"address", addr,
+		)
+		return http.ListenAndServe(addr, h2c.NewHandler(s.engine.Handler(), &http2.Server{}))
+	default:
+		logger.Info("server starting",
+			"address", addr,
+			"host", s.config.Server.Host,
+			"port", s.config.Server.Port,
+		)
+		return s.engine.Run(addr)
+	}
+}
+
+// auditRetentionConfig 将配置转换为 logger 包的保留策略
+func auditRetentionConfig(cfg *config.AuditRetentionConfig) logger.RetentionConfig {
+	rules := make([]logger.RetentionRule, 0, len(cfg.Rules))
+	for _, r := range cfg.Rules {
+		rules = append(rules, logger.RetentionRule{
+			Module: r.Module,
+			Action: r.Action,
+			Days:   r.Days,
+		})
+	}
+
+	return logger.RetentionConfig{
+		DefaultDays: cfg.DefaultDays,
+		Interval:    time.Duration(cfg.IntervalHours) * time.Hour,
+		Rules:       rules,
+	}
+}
+
+// casdoorRoutesFromConfig 将配置转换为 casdoor 包的路由规则
+func casdoorRoutesFromConfig(routes []config.CasdoorRouteConfig) []casdoor.Route {
+	result := make([]casdoor.Route, 0, len(routes))
+	for _, r := range routes {
+		result = append(result, casdoor.Route{
+			Department: r.Department,
+			Config: &casdoor.Config{
+				ServerURL:    r.ServerURL,
+				ClientID:     r.ClientID,
+				ClientSecret: r.ClientSecret,
+				JwtPublicKey: r.JwtPublicKey,
+				Organization: r.Organization,
+				Application:  r.Application,
+				SyncEnabled:  true,
+			},
+		})
+	}
+	return result
 }
 
 // Close 关闭服务器资源
@@ -344,6 +628,17 @@ func (s *Server) Close() error {
 		}
 	}
 
+	// 停止审计日志保留期清理任务
+	if s.retention != nil {
+		s.retention.Stop()
+	}
+
+	// 等待在途的异步 Casdoor 同步完成，避免关闭时丢失同步
+	if s.userService != nil && !s.userService.WaitCasdoorSyncs(10*time.Second) {
+		logger.Warn("timed out waiting for pending casdoor syncs",
+			"pending", s.userService.CasdoorSyncPending())
+	}
+
 	// 关闭审计日志记录器
 	if s.auditLogger != nil {
 		if err := s.auditLogger.Close(); err != nil {
@@ -358,6 +653,9 @@ func (s *Server) Close() error {
 		}
 	}
 
+	// 关闭 GeoIP 解析器
+	geoip.Close()
+
 	// 关闭全局日志
 	if s.globalLogger != nil {
 		if err := s.globalLogger.Close(); err != nil {
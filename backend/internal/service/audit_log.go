@@ -2,24 +2,37 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"zera/internal/logger"
+	"zera/internal/pagination"
 )
 
 // AuditLogService 审计日志服务
 type AuditLogService struct {
-	logger logger.Logger
+	logger      logger.Logger
+	cursorCodec *pagination.CursorCodec
 }
 
 // NewAuditLogService 创建审计日志服务
-func NewAuditLogService(l logger.Logger) *AuditLogService {
+func NewAuditLogService(l logger.Logger, cursorCodec *pagination.CursorCodec) *AuditLogService {
 	return &AuditLogService{
-		logger: l,
+		logger:      l,
+		cursorCodec: cursorCodec,
 	}
 }
 
+// auditLogCursor 审计日志游标载荷
+// 经 CursorCodec 加密编码后对客户端不透明
+type auditLogCursor struct {
+	Time time.Time `json:"t"`
+	ID   int       `json:"id"`
+}
+
 // ListAuditLogs 获取日志列表
+// cursor 非空时走游标分页（固定按创建时间降序，忽略 page 和排序参数），
+// 返回的 nextCursor 为空表示没有更多数据
 func (s *AuditLogService) ListAuditLogs(
 	ctx context.Context,
 	page, pageSize int,
@@ -27,8 +40,9 @@ func (s *AuditLogService) ListAuditLogs(
 	module, action, username, ip, resource string,
 	startTime, endTime *time.Time,
 	keyword, sortBy string,
-	descending bool,
-) (*logger.QueryResult, error) {
+	descending, exactTotal bool,
+	cursor string,
+) (*logger.QueryResult, string, error) {
 	opts := logger.QueryOptions{
 		Page:       page,
 		PageSize:   pageSize,
@@ -43,9 +57,41 @@ func (s *AuditLogService) ListAuditLogs(
 		Keyword:    keyword,
 		SortBy:     sortBy,
 		Descending: descending,
+		ExactTotal: exactTotal,
 	}
 
-	return s.logger.Query(ctx, opts)
+	if cursor != "" && s.cursorCodec != nil {
+		var c auditLogCursor
+		if err := s.cursorCodec.Decode(cursor, &c); err != nil {
+			return nil, "", err
+		}
+		opts.AfterTime = &c.Time
+		opts.AfterID = c.ID
+		// 游标分页固定按创建时间降序，不经过 offset
+		opts.Page = 1
+		opts.SortBy = ""
+		opts.Descending = false
+	}
+
+	result, err := s.logger.Query(ctx, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 默认排序下满页时生成下一页游标
+	nextCursor := ""
+	if s.cursorCodec != nil && opts.SortBy == "" &&
+		opts.PageSize > 0 && len(result.Entries) == opts.PageSize && result.LastID > 0 {
+		nextCursor, err = s.cursorCodec.Encode(auditLogCursor{
+			Time: result.LastTime,
+			ID:   result.LastID,
+		})
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	return result, nextCursor, nil
 }
 
 // GetAuditLog 获取单条日志
@@ -61,11 +107,83 @@ func (s *AuditLogService) GetAuditLogStats(
 	return s.logger.GetStats(ctx, startTime, endTime)
 }
 
+// GetTimeSeries 按天或小时分桶统计日志数量
+// interval 为空时默认按天分桶
+func (s *AuditLogService) GetTimeSeries(
+	ctx context.Context,
+	opts logger.TimeSeriesOptions,
+) ([]logger.TimeSeriesPoint, error) {
+	if opts.Interval == "" {
+		opts.Interval = logger.TimeSeriesIntervalDay
+	}
+	return s.logger.GetTimeSeries(ctx, opts)
+}
+
+// ExportAuditLogs 导出审计日志为 SIEM 格式（CEF/LEEF）
+// limit 为 0 时默认导出 1000 条，最多 10000 条
+func (s *AuditLogService) ExportAuditLogs(
+	ctx context.Context,
+	opts logger.QueryOptions,
+	format string,
+) (string, int, error) {
+	if format == "" {
+		format = SIEMFormatCEF
+	}
+	if format != SIEMFormatCEF && format != SIEMFormatLEEF {
+		return "", 0, fmt.Errorf("%w: %s", ErrInvalidExportFormat, format)
+	}
+
+	if opts.PageSize <= 0 {
+		opts.PageSize = 1000
+	}
+	if opts.PageSize > 10000 {
+		opts.PageSize = 10000
+	}
+	opts.Page = 1
+
+	result, err := s.logger.Query(ctx, opts)
+	if err != nil {
+		return "", 0, err
+	}
+
+	data, err := FormatSIEM(result.Entries, format)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return data, len(result.Entries), nil
+}
+
 // GetModules 获取所有模块列表
 func (s *AuditLogService) GetModules(ctx context.Context) ([]string, error) {
 	return s.logger.GetModules(ctx)
 }
 
+// GetRecentByUser 获取指定用户最近的操作日志
+// limit 为 0 时默认返回 20 条，最多 100 条
+func (s *AuditLogService) GetRecentByUser(ctx context.Context, userID, limit int) ([]*logger.EntryWithID, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	return s.logger.GetRecentByUser(ctx, userID, limit)
+}
+
+// GetResourceHistory 获取针对某个具体资源的操作记录
+// 按 resource + resourceID 反向追溯（如"用户 42 被谁改过"），按时间倒序
+// limit 为 0 时默认返回 50 条，最多 200 条
+func (s *AuditLogService) GetResourceHistory(ctx context.Context, resource, resourceID string, limit int) ([]*logger.EntryWithID, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+	return s.logger.GetResourceHistory(ctx, resource, resourceID, limit)
+}
+
 // WriteLog 写入日志（供其他服务调用）
 func (s *AuditLogService) WriteLog(ctx context.Context, entry *logger.Entry) error {
 	return s.logger.Write(ctx, entry)
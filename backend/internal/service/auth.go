@@ -5,15 +5,22 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"zera/ent"
+	"zera/ent/refreshtoken"
 	"zera/ent/role"
 	"zera/ent/user"
 	"zera/gen/base"
 	"zera/internal/auth"
+	"zera/internal/geoip"
+	"zera/internal/logger"
+	"zera/internal/mailer"
 	"zera/internal/permission"
+	"zera/internal/pinyin"
 )
 
 var (
@@ -27,6 +34,10 @@ var (
 	ErrInvalidToken = errors.New("invalid token")
 	// ErrAccountLocked 账号已锁定
 	ErrAccountLocked = errors.New("account is locked")
+	// ErrRefreshTokenReused 刷新令牌被重复使用（疑似重放攻击）
+	ErrRefreshTokenReused = errors.New("refresh token has already been used")
+	// ErrTooManyLoginAttempts 登录尝试过于频繁（触发 IP+用户名 限流）
+	ErrTooManyLoginAttempts = errors.New("too many login attempts")
 )
 
 // AuthService 认证服务
@@ -34,19 +45,50 @@ type AuthService struct {
 	client            *ent.Client
 	jwtManager        *auth.JWTManager
 	permissionChecker *permission.Checker
+	mailer            *mailer.Mailer
+	loginCodes        *auth.LoginCodeStore
+	tokenRevoker      auth.TokenRevoker
+	loginLimiter      auth.LoginRateLimiter
+	anomalyDetector   *auth.LoginAnomalyDetector
+	loginStats        loginStats
+}
+
+// SetLoginRateLimiter 设置登录失败限流器，未设置时不限流
+func (s *AuthService) SetLoginRateLimiter(limiter auth.LoginRateLimiter) {
+	s.loginLimiter = limiter
+}
+
+// SetLoginAnomalyDetector 设置登录异常检测器，未设置时不检测
+func (s *AuthService) SetLoginAnomalyDetector(detector *auth.LoginAnomalyDetector) {
+	s.anomalyDetector = detector
+}
+
+// SetTokenRevoker 设置令牌吊销器，登出的令牌在自然过期前加入黑名单
+func (s *AuthService) SetTokenRevoker(revoker auth.TokenRevoker) {
+	s.tokenRevoker = revoker
 }
 
 // NewAuthService 创建认证服务
-func NewAuthService(client *ent.Client, jwtManager *auth.JWTManager) *AuthService {
+func NewAuthService(client *ent.Client, jwtManager *auth.JWTManager, m *mailer.Mailer) *AuthService {
 	return &AuthService{
 		client:            client,
 		jwtManager:        jwtManager,
 		permissionChecker: permission.NewChecker(client),
+		mailer:            m,
+		loginCodes:        auth.NewLoginCodeStore(),
 	}
 }
 
 // Login 用户登录
-func (s *AuthService) Login(ctx context.Context, username, password string) (*base.LoginResponse, error) {
+// username 同时接受用户名或邮箱作为登录标识；
+// clientType 为客户端类型标识（如 web、app），用于签发差异化有效期的令牌
+func (s *AuthService) Login(ctx context.Context, username, password, clientIP, userAgent, clientType string) (*base.LoginResponse, error) {
+	// IP+用户名 滑动窗口限流，在查库和密码校验之前快速拒绝
+	if s.loginLimiter != nil && !s.loginLimiter.Allow(clientIP, username) {
+		logger.WarnContext(ctx, "login rate limited", "username", username, "ip", clientIP)
+		return nil, ErrTooManyLoginAttempts
+	}
+
 	// 获取安全设置
 	settingService := NewSystemSettingService(s.client)
 	securitySettings, err := s.getSecuritySettings(ctx, settingService)
@@ -54,15 +96,18 @@ func (s *AuthService) Login(ctx context.Context, username, password string) (*ba
 		return nil, err
 	}
 
-	// 查询用户
-	u, err := s.client.User.Query().
-		Where(user.Username(username)).
-		WithRoles(func(q *ent.RoleQuery) {
-			q.WithPermissions()
-		}).
-		Only(ctx)
+	// 查询用户：登录标识同时支持用户名和邮箱，两者都命中时以用户名优先
+	u, err := s.lookupLoginUser(ctx, username)
 	if err != nil {
-		if ent.IsNotFound(err) {
+		if errors.Is(err, ErrUserNotFound) {
+			// 未知登录标识同样计入失败，防止通过枚举用户名或邮箱探测
+			if s.loginLimiter != nil {
+				s.loginLimiter.RecordFailure(clientIP, username)
+			}
+			if s.anomalyDetector != nil {
+				s.anomalyDetector.RecordFailure(clientIP, username)
+			}
+			s.loginStats.record(false)
 			return nil, ErrInvalidCredentials
 		}
 		return nil, err
@@ -89,6 +134,14 @@ func (s *AuthService) Login(ctx context.Context, username, password string) (*ba
 
 	// 验证密码
 	if !s.verifyPassword(password, u.PasswordHash) {
+		if s.loginLimiter != nil {
+			s.loginLimiter.RecordFailure(clientIP, username)
+		}
+		if s.anomalyDetector != nil {
+			s.anomalyDetector.RecordFailure(clientIP, username)
+		}
+		s.loginStats.record(false)
+
 		// 增加登录失败次数
 		newAttempts := u.LoginAttempts + 1
 		update := u.Update().SetLoginAttempts(newAttempts)
@@ -115,46 +168,245 @@ func (s *AuthService) Login(ctx context.Context, username, password string) (*ba
 		return nil, ErrInvalidCredentials
 	}
 
-	// 检查用户状态
-	if u.Status != user.StatusActive {
+	// 密码校验通过，清除该 IP+用户名 组合的限流计数
+	if s.loginLimiter != nil {
+		s.loginLimiter.Reset(clientIP, username)
+	}
+	s.loginStats.record(true)
+
+	// 旧版 SHA256 哈希校验通过后自动升级为 bcrypt，用户无需重置密码
+	if auth.IsLegacyHash(u.PasswordHash) {
+		s.rehashPassword(ctx, u, password)
+	}
+
+	// 检查用户状态，softban 用户仍允许登录但权限会被降级
+	if u.Status != user.StatusActive && u.Status != user.StatusSoftban {
 		return nil, ErrUserInactive
 	}
 
+	// 开启 TOTP 的账号密码校验通过后进入第二步，
+	// 签发短时 challenge 令牌，由 LoginVerifyMFA 校验验证码后再发正式令牌
+	if u.TotpEnabled {
+		mfaToken, err := s.jwtManager.GenerateMFAToken(u.ID, u.Username, clientType)
+		if err != nil {
+			return nil, err
+		}
+		return &base.LoginResponse{
+			MfaRequired: true,
+			MfaToken:    mfaToken,
+		}, nil
+	}
+
+	return s.finishLogin(ctx, u, clientIP, userAgent, clientType, securitySettings.sessionTimeout)
+}
+
+// lookupLoginUser 按登录标识查询用户
+// 先按用户名精确匹配，未命中再按邮箱匹配（大小写不敏感）；
+// 未找到时返回 ErrUserNotFound，由调用方统一转为凭证错误避免枚举
+func (s *AuthService) lookupLoginUser(ctx context.Context, identifier string) (*ent.User, error) {
+	u, err := s.client.User.Query().
+		Where(user.Username(identifier)).
+		WithRoles(withActiveRolesAndPermissions).
+		Only(ctx)
+	if err == nil {
+		return u, nil
+	}
+	if !ent.IsNotFound(err) {
+		return nil, err
+	}
+
+	u, err = s.client.User.Query().
+		Where(user.EmailEqualFold(identifier)).
+		WithRoles(withActiveRolesAndPermissions).
+		Only(ctx)
+	if err != nil {
+		// 大小写不敏感匹配到多条邮箱记录时同样视为未找到
+		if ent.IsNotFound(err) || ent.IsNotSingular(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return u, nil
+}
+
+// finishLogin 完成登录的收尾流程：登录地区/设备检测、更新登录记录、签发令牌
+// 调用前须已完成凭证校验和用户状态检查
+func (s *AuthService) finishLogin(ctx context.Context, u *ent.User, clientIP, userAgent, clientType string, sessionTimeout int) (*base.LoginResponse, error) {
+	// 解析登录地区（GeoIP 未启用时为空），检测异地登录
+	region := geoip.Lookup(clientIP).String()
+	newRegion := region != "" && u.LastLoginRegion != nil && *u.LastLoginRegion != "" && *u.LastLoginRegion != region
+	if newRegion {
+		logger.Warn("login from unusual region",
+			"userID", u.ID,
+			"username", u.Username,
+			"ip", clientIP,
+			"lastRegion", *u.LastLoginRegion,
+			"currentRegion", region,
+		)
+	}
+
+	// 计算设备指纹，检测新设备（首次登录仅记录基线，不视为新设备）
+	device := deviceFingerprint(userAgent)
+	newDevice := device != "" && u.LastLoginDevice != nil && *u.LastLoginDevice != "" && *u.LastLoginDevice != device
+
 	// 登录成功，重置登录尝试次数并更新最后登录时间
-	_, err = u.Update().
+	update := u.Update().
 		SetLoginAttempts(0).
 		ClearLockedUntil().
-		SetLastLoginAt(time.Now()).
-		Save(ctx)
-	if err != nil {
+		SetLastLoginAt(time.Now())
+	if region != "" {
+		update = update.SetLastLoginRegion(region)
+	}
+	if device != "" {
+		update = update.SetLastLoginDevice(device)
+	}
+	if _, err := update.Save(ctx); err != nil {
 		return nil, err
 	}
 
+	// 检测到新设备或异地登录时向用户发送提醒邮件，用户可在偏好设置中关闭
+	if (newDevice || newRegion) && u.LoginNotify {
+		s.sendLoginNotification(u, clientIP, region, newDevice, newRegion)
+	}
+
 	// 获取用户角色和权限
-	roles, permissions := s.extractRolesAndPermissions(u)
+	roles, permissions, err := s.extractRolesAndPermissions(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	// 条款合规检查：未同意最新条款时权限降级为只读
+	permissions, needAcceptTerms, termsVersion, err := applyTermsDowngrade(ctx, s.client, u, permissions)
+	if err != nil {
+		return nil, err
+	}
 
-	// 生成令牌（使用系统设置的会话超时时间）
-	accessToken, err := s.jwtManager.GenerateAccessTokenWithExpire(u.ID, u.Username, roles, permissions, securitySettings.sessionTimeout)
+	// 生成令牌（客户端未配置专属有效期时使用系统设置的会话超时时间）
+	accessToken, err := s.jwtManager.GenerateClientAccessToken(u.ID, u.Username, roles, permissions, clientType, sessionTimeout)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := s.jwtManager.GenerateRefreshToken(u.ID, u.Username)
+	refreshToken, jti, err := s.jwtManager.GenerateClientRefreshToken(u.ID, u.Username, clientType)
 	if err != nil {
 		return nil, err
 	}
 
+	// 记录刷新令牌，用于轮换和重放检测
+	if err := StoreRefreshTokenForClient(ctx, s.client, s.jwtManager, u.ID, jti, jti, clientType); err != nil {
+		return nil, err
+	}
+
 	// 构建用户信息
 	userInfo := s.buildUserInfo(u, permissions)
 
 	return &base.LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    int64(securitySettings.sessionTimeout * 60), // 转换为秒
-		User:         userInfo,
+		AccessToken:     accessToken,
+		RefreshToken:    refreshToken,
+		ExpiresIn:       int64(s.jwtManager.ClientAccessTokenExpire(clientType, sessionTimeout).Seconds()),
+		User:            userInfo,
+		NeedAcceptTerms: needAcceptTerms,
+		TermsVersion:    termsVersion,
 	}, nil
 }
 
+// ErrTermsVersionMismatch 同意的条款版本与当前版本不一致
+var ErrTermsVersionMismatch = errors.New("terms version mismatch")
+
+// ErrLastAdmin 系统至少需要保留一名管理员
+var ErrLastAdmin = errors.New("cannot delete the last administrator")
+
+// DeleteMyAccount 用户自助注销账号（GDPR 自助删除）
+// 校验当前密码后物理删除账号，吊销全部会话并异步同步删除到 Casdoor。
+// 持有管理员角色的用户若是系统中最后一名管理员则拒绝，避免系统失管
+func (s *AuthService) DeleteMyAccount(ctx context.Context, userID int, password string) error {
+	u, err := s.client.User.Query().Where(user.ID(userID)).WithRoles().Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	// 外部认证用户没有本地密码，无法二次确认，由管理员代为删除
+	if u.AuthProvider != user.AuthProviderLocal {
+		return ErrExternalAuthUser
+	}
+	if !s.verifyPassword(password, u.PasswordHash) {
+		return ErrInvalidCredentials
+	}
+
+	// 最后一个管理员保护：删除后系统不能没有管理员
+	isAdmin := false
+	for _, r := range u.Edges.Roles {
+		if r.Code == "admin" || r.Code == "super_admin" {
+			isAdmin = true
+			break
+		}
+	}
+	if isAdmin {
+		others, err := s.client.User.Query().
+			Where(
+				user.IDNEQ(userID),
+				user.StatusEQ(user.StatusActive),
+				user.HasRolesWith(role.CodeIn("admin", "super_admin")),
+			).
+			Count(ctx)
+		if err != nil {
+			return err
+		}
+		if others == 0 {
+			return ErrLastAdmin
+		}
+	}
+
+	// 清理会话：删除刷新令牌后删除账号
+	// 先删除账号以释放 (auth_provider, external_id) 唯一索引
+	_, err = s.client.RefreshToken.Delete().
+		Where(refreshtoken.HasUserWith(user.ID(userID))).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.client.User.DeleteOneID(userID).Exec(ctx); err != nil {
+		return err
+	}
+
+	// 同步删除到 Casdoor（异步，不影响本地操作）
+	us := NewUserService(s.client)
+	us.goCasdoorSync(func(ctx context.Context) { us.syncUserDeleteToCasdoor(ctx, u) })
+
+	// 用户数量变化，失效总数缓存
+	userCountCache.Clear()
+
+	logger.InfoContext(ctx, "user deleted own account", "userID", userID, "username", u.Username)
+	return nil
+}
+
+// AcceptTerms 记录用户同意当前版本的服务条款
+// version 必须与系统设置中的当前版本一致，防止前端缓存的旧版本误写入
+func (s *AuthService) AcceptTerms(ctx context.Context, userID int, version string) error {
+	current, err := NewSystemSettingService(s.client).GetTermsVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if current == "" || version != current {
+		return ErrTermsVersionMismatch
+	}
+
+	if err := s.client.User.UpdateOneID(userID).
+		SetTermsAcceptedVersion(version).
+		Exec(ctx); err != nil {
+		if ent.IsNotFound(err) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	logger.InfoContext(ctx, "terms accepted", "userID", userID, "version", version)
+	return nil
+}
+
 // securitySettingsData 安全设置数据
 type securitySettingsData struct {
 	maxLoginAttempts int
@@ -270,11 +522,16 @@ func (s *AuthService) Register(ctx context.Context, req *base.RegisterRequest) (
 	}
 
 	// 创建用户
+	passwordHash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
 	userCreate := s.client.User.Create().
 		SetUsername(req.Username).
 		SetEmail(req.Email).
-		SetPasswordHash(hashPassword(req.Password)).
+		SetPasswordHash(passwordHash).
 		SetNickname(nickname).
+		SetPinyin(pinyin.Index(nickname)).
 		SetStatus(user.StatusActive)
 
 	// 分配默认角色
@@ -309,19 +566,28 @@ func (s *AuthService) Register(ctx context.Context, req *base.RegisterRequest) (
 }
 
 // Logout 用户登出
+// 被登出的 access token 加入黑名单直到自然过期，期间无法再通过认证
 func (s *AuthService) Logout(ctx context.Context, accessToken string) (bool, error) {
 	// 验证令牌
-	_, err := s.jwtManager.ValidateAccessToken(accessToken)
+	claims, err := s.jwtManager.ValidateAccessToken(accessToken)
 	if err != nil {
 		return false, ErrInvalidToken
 	}
 
-	// TODO: 可以将令牌加入黑名单（需要 Redis 支持）
-	// 目前简单返回成功
+	// 将令牌加入黑名单
+	if s.tokenRevoker != nil && claims.ExpiresAt != nil {
+		if err := s.tokenRevoker.Revoke(accessToken, claims.ExpiresAt.Time); err != nil {
+			return false, err
+		}
+		logger.InfoContext(ctx, "access token revoked", "userID", claims.UserID)
+	}
+
 	return true, nil
 }
 
 // RefreshToken 刷新令牌
+// 刷新令牌为一次性使用：每次刷新作废旧令牌并签发新令牌，
+// 已使用的令牌被再次使用时吊销该令牌所在的整个令牌家族
 func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*base.RefreshTokenResponse, error) {
 	// 验证刷新令牌
 	claims, err := s.jwtManager.ValidateRefreshToken(refreshToken)
@@ -329,12 +595,16 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*b
 		return nil, ErrInvalidToken
 	}
 
+	// 消费令牌（标记已使用，检测重放），新令牌沿用旧令牌的家族
+	familyID, err := ConsumeRefreshToken(ctx, s.client, claims.UserID, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	// 查询用户确保用户仍然有效，并获取最新的角色和权限
 	u, err := s.client.User.Query().
 		Where(user.ID(claims.UserID)).
-		WithRoles(func(q *ent.RoleQuery) {
-			q.WithPermissions()
-		}).
+		WithRoles(withActiveRolesAndPermissions).
 		Only(ctx)
 	if err != nil {
 		if ent.IsNotFound(err) {
@@ -343,29 +613,45 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*b
 		return nil, err
 	}
 
-	// 检查用户状态
-	if u.Status != user.StatusActive {
+	// 检查用户状态，softban 用户仍允许登录但权限会被降级
+	if u.Status != user.StatusActive && u.Status != user.StatusSoftban {
 		return nil, ErrUserInactive
 	}
 
 	// 获取用户角色和权限
-	roles, permissions := s.extractRolesAndPermissions(u)
+	roles, permissions, err := s.extractRolesAndPermissions(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	// 条款合规检查：未同意最新条款时新令牌同样降级为只读，
+	// 防止通过刷新令牌绕过登录时的降级
+	permissions, _, _, err = applyTermsDowngrade(ctx, s.client, u, permissions)
+	if err != nil {
+		return nil, err
+	}
 
-	// 生成新的令牌
-	newAccessToken, err := s.jwtManager.GenerateAccessToken(u.ID, u.Username, roles, permissions)
+	// 生成新的令牌，沿用旧令牌记录的客户端类型
+	clientType := claims.ClientType
+	newAccessToken, err := s.jwtManager.GenerateClientAccessToken(u.ID, u.Username, roles, permissions, clientType, 0)
 	if err != nil {
 		return nil, err
 	}
 
-	newRefreshToken, err := s.jwtManager.GenerateRefreshToken(u.ID, u.Username)
+	newRefreshToken, jti, err := s.jwtManager.GenerateClientRefreshToken(u.ID, u.Username, clientType)
 	if err != nil {
 		return nil, err
 	}
 
+	// 记录新的刷新令牌
+	if err := StoreRefreshTokenForClient(ctx, s.client, s.jwtManager, u.ID, jti, familyID, clientType); err != nil {
+		return nil, err
+	}
+
 	return &base.RefreshTokenResponse{
 		AccessToken:  newAccessToken,
 		RefreshToken: newRefreshToken,
-		ExpiresIn:    s.jwtManager.GetAccessTokenExpire(),
+		ExpiresIn:    int64(s.jwtManager.ClientAccessTokenExpire(clientType, 0).Seconds()),
 	}, nil
 }
 
@@ -373,9 +659,7 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*b
 func (s *AuthService) GetCurrentUser(ctx context.Context, userID int) (*base.UserInfo, error) {
 	u, err := s.client.User.Query().
 		Where(user.ID(userID)).
-		WithRoles(func(q *ent.RoleQuery) {
-			q.WithPermissions()
-		}).
+		WithRoles(withActiveRolesAndPermissions).
 		Only(ctx)
 	if err != nil {
 		if ent.IsNotFound(err) {
@@ -384,12 +668,26 @@ func (s *AuthService) GetCurrentUser(ctx context.Context, userID int) (*base.Use
 		return nil, err
 	}
 
-	_, permissions := s.extractRolesAndPermissions(u)
-	return s.buildUserInfo(u, permissions), nil
+	_, permissions, err := s.extractRolesAndPermissions(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	info := s.buildUserInfo(u, permissions)
+
+	// 标注直接授予的权限，便于前端区分权限来源（角色 vs 直接授权）
+	direct, err := directUserPermissionCodes(ctx, s.client, userID)
+	if err != nil {
+		return nil, err
+	}
+	info.DirectPermissions = direct
+
+	return info, nil
 }
 
 // extractRolesAndPermissions 从用户实体中提取角色和权限
-func (s *AuthService) extractRolesAndPermissions(u *ent.User) ([]string, []string) {
+// 沿父角色链递归合并继承的权限；softban 用户的权限会被降级为只读
+func (s *AuthService) extractRolesAndPermissions(ctx context.Context, u *ent.User) ([]string, []string, error) {
 	roles := make([]string, 0, len(u.Edges.Roles))
 	permissionSet := make(map[string]bool)
 	isAdmin := false
@@ -406,9 +704,24 @@ func (s *AuthService) extractRolesAndPermissions(u *ent.User) ([]string, []strin
 		}
 	}
 
+	// 角色继承：递归合并父角色的权限
+	if err := mergeInheritedRolePermissions(ctx, s.client, u.Edges.Roles, permissionSet); err != nil {
+		return nil, nil, err
+	}
+
+	// 用户直接授予的权限（绕过角色的一次性授权）并入最终权限集合
+	if err := mergeDirectUserPermissions(ctx, s.client, u.ID, permissionSet); err != nil {
+		return nil, nil, err
+	}
+
+	// softban 用户仅保留只读权限，管理员也不例外
+	if u.Status == user.StatusSoftban {
+		return roles, filterReadOnlyPermissions(permissionSet), nil
+	}
+
 	// 管理员拥有所有权限
 	if isAdmin {
-		return roles, []string{"*"}
+		return roles, []string{"*"}, nil
 	}
 
 	// 转换为切片
@@ -417,7 +730,58 @@ func (s *AuthService) extractRolesAndPermissions(u *ent.User) ([]string, []strin
 		permissions = append(permissions, code)
 	}
 
-	return roles, permissions
+	return roles, permissions, nil
+}
+
+// applyTermsDowngrade 条款合规检查，供本地、CAS、LDAP、OIDC 各登录流程
+// 及令牌刷新共用：条款版本更新后用户需重新同意，未同意期间权限降级为只读
+// （与 softban 同一套降级规则）。返回（可能已降级的）权限列表、
+// 是否需要重新同意以及当前条款版本
+func applyTermsDowngrade(ctx context.Context, client *ent.Client, u *ent.User, permissions []string) ([]string, bool, string, error) {
+	termsVersion, err := NewSystemSettingService(client).GetTermsVersion(ctx)
+	if err != nil {
+		return nil, false, "", err
+	}
+	needAcceptTerms := termsVersion != "" && u.TermsAcceptedVersion != termsVersion
+	if needAcceptTerms {
+		permissionSet := make(map[string]bool, len(permissions))
+		for _, code := range permissions {
+			permissionSet[code] = true
+		}
+		permissions = filterReadOnlyPermissions(permissionSet)
+	}
+	return permissions, needAcceptTerms, termsVersion, nil
+}
+
+// filterReadOnlyPermissions 过滤出只读权限，用于 softban 和未同意条款用户的权限降级
+// 通配符授权（管理员的 "*"、资源级的 "user:*"）展开为注册表中对应的只读权限，
+// 否则持有通配符的用户降级后会得到空权限列表而非只读权限
+func filterReadOnlyPermissions(permissionSet map[string]bool) []string {
+	readOnly := make(map[string]bool, len(permissionSet))
+	for code := range permissionSet {
+		switch {
+		case strings.HasSuffix(code, ":read"):
+			readOnly[code] = true
+		case code == "*":
+			for _, p := range permission.GetUniquePermissions() {
+				if strings.HasSuffix(p.Code, ":read") {
+					readOnly[p.Code] = true
+				}
+			}
+		case strings.HasSuffix(code, ":*"):
+			prefix := strings.TrimSuffix(code, "*")
+			for _, p := range permission.GetUniquePermissions() {
+				if strings.HasPrefix(p.Code, prefix) && strings.HasSuffix(p.Code, ":read") {
+					readOnly[p.Code] = true
+				}
+			}
+		}
+	}
+	result := make([]string, 0, len(readOnly))
+	for code := range readOnly {
+		result = append(result, code)
+	}
+	return result
 }
 
 // buildUserInfo 构建用户信息
@@ -428,28 +792,131 @@ func (s *AuthService) buildUserInfo(u *ent.User, permissions []string) *base.Use
 	}
 
 	return &base.UserInfo{
-		Id:          intToString(u.ID),
-		Username:    u.Username,
-		Nickname:    u.Nickname,
-		Avatar:      u.Avatar,
-		Email:       u.Email,
-		Roles:       roles,
-		Permissions: permissions,
+		Id:           intToString(u.ID),
+		Username:     u.Username,
+		Nickname:     u.Nickname,
+		Avatar:       u.Avatar,
+		Email:        u.Email,
+		Roles:        roles,
+		Permissions:  permissions,
+		DefaultRoute: defaultRouteForUser(u),
 	}
 }
 
-// verifyPassword 验证密码
+// systemDefaultRoute 未配置角色默认路由时的系统默认首页
+const systemDefaultRoute = "/admin"
+
+// defaultRouteForUser 返回用户主要角色配置的默认首页路由
+// 主要角色取 sort_order 最小的角色；该角色未配置时回退到系统默认
+func defaultRouteForUser(u *ent.User) string {
+	var primary *ent.Role
+	for _, r := range u.Edges.Roles {
+		if primary == nil || r.SortOrder < primary.SortOrder {
+			primary = r
+		}
+	}
+	if primary != nil && primary.DefaultRoute != "" {
+		return primary.DefaultRoute
+	}
+	return systemDefaultRoute
+}
+
+// deviceFingerprint 根据 User-Agent 计算设备指纹
+// User-Agent 为空时返回空串，不参与新设备检测
+func deviceFingerprint(userAgent string) string {
+	if userAgent == "" {
+		return ""
+	}
+	h := sha256.Sum256([]byte(userAgent))
+	return hex.EncodeToString(h[:16])
+}
+
+// sendLoginNotification 异步发送新登录提醒邮件
+// 邮件服务未启用或用户无邮箱时静默跳过，发送失败仅记录日志，不影响登录流程
+func (s *AuthService) sendLoginNotification(u *ent.User, clientIP, region string, newDevice, newRegion bool) {
+	if s.mailer == nil || !s.mailer.Enabled() || u.Email == "" {
+		return
+	}
+
+	reasons := make([]string, 0, 2)
+	if newDevice {
+		reasons = append(reasons, "新设备")
+	}
+	if newRegion {
+		reasons = append(reasons, "异地")
+	}
+	location := region
+	if location == "" {
+		location = "未知"
+	}
+
+	body := fmt.Sprintf(
+		"您好 %s：\n\n您的账号于 %s 检测到%s登录。\n\nIP 地址: %s\n大致位置: %s\n\n如果这是您本人的操作，请忽略本邮件；否则请尽快修改密码。\n如不希望接收此类提醒，可在个人偏好设置中关闭登录通知。",
+		u.Username,
+		time.Now().Format("2006-01-02 15:04:05"),
+		strings.Join(reasons, "、"),
+		clientIP,
+		location,
+	)
+
+	go func() {
+		if err := s.mailer.Send(u.Email, "新登录提醒", body); err != nil {
+			logger.Error("failed to send login notification",
+				"userID", u.ID,
+				"email", u.Email,
+				"error", err,
+			)
+		}
+	}()
+}
+
+// verifyPassword 验证密码，兼容旧版 SHA256 哈希
 func (s *AuthService) verifyPassword(password, hash string) bool {
-	return hashPassword(password) == hash
+	return auth.VerifyPassword(password, hash)
 }
 
-// hashPassword 密码哈希
-func hashPassword(password string) string {
-	h := sha256.Sum256([]byte(password))
-	return hex.EncodeToString(h[:])
+// rehashPassword 将旧版 SHA256 哈希升级为 bcrypt 并写回数据库
+// 升级失败只记录日志不影响登录，下次登录会再次尝试
+func (s *AuthService) rehashPassword(ctx context.Context, u *ent.User, password string) {
+	newHash, err := auth.HashPassword(password)
+	if err != nil {
+		logger.WarnContext(ctx, "failed to rehash password", "userID", u.ID, "error", err)
+		return
+	}
+	if err := u.Update().SetPasswordHash(newHash).Exec(ctx); err != nil {
+		logger.WarnContext(ctx, "failed to save rehashed password", "userID", u.ID, "error", err)
+		return
+	}
+	logger.InfoContext(ctx, "password hash upgraded to bcrypt", "userID", u.ID)
 }
 
 // intToString 整数转字符串
 func intToString(i int) string {
 	return strconv.Itoa(i)
 }
+
+// VerifyPassword 二次密码确认（sudo 模式）
+// 验证当前用户密码，通过后签发短时 sudo 令牌用于高危操作
+func (s *AuthService) VerifyPassword(ctx context.Context, userID int, password string) (*base.VerifyPasswordResponse, error) {
+	u, err := s.client.User.Query().Where(user.ID(userID)).Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if !s.verifyPassword(password, u.PasswordHash) {
+		return nil, ErrInvalidCredentials
+	}
+
+	sudoToken, err := s.jwtManager.GenerateSudoToken(u.ID, u.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	return &base.VerifyPasswordResponse{
+		SudoToken: sudoToken,
+		ExpiresIn: s.jwtManager.GetSudoTokenExpire(),
+	}, nil
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"zera/ent"
+	"zera/ent/role"
+	"zera/ent/user"
+	"zera/gen/base"
+	"zera/internal/auth"
+	"zera/internal/logger"
+	"zera/internal/pinyin"
+)
+
+// ErrMailerDisabled 邮件服务未启用，无法发送验证码
+var ErrMailerDisabled = errors.New("mailer is not enabled")
+
+// SendLoginCode 发送邮箱登录验证码
+// 为防止邮箱枚举，邮箱未注册且未开启注册时同样返回成功但不发送邮件
+func (s *AuthService) SendLoginCode(ctx context.Context, email string) (*base.SendLoginCodeResponse, error) {
+	if s.mailer == nil || !s.mailer.Enabled() {
+		return nil, ErrMailerDisabled
+	}
+
+	// 邮箱未注册且未开启注册时不发送邮件，响应与正常流程一致
+	exists, err := s.client.User.Query().Where(user.Email(email)).Exist(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		settingService := NewSystemSettingService(s.client)
+		registrationEnabled, err := settingService.IsRegistrationEnabled(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !registrationEnabled {
+			logger.InfoContext(ctx, "login code requested for unknown email, skipping", "email", email)
+			return &base.SendLoginCodeResponse{
+				Success:   true,
+				ExpiresIn: int64(auth.LoginCodeTTL.Seconds()),
+			}, nil
+		}
+	}
+
+	code, ttl, err := s.loginCodes.Issue(email)
+	if err != nil {
+		return nil, err
+	}
+
+	// 异步发送验证码邮件，发送失败仅记录日志，用户可重新获取
+	go func() {
+		subject := "【Zera】登录验证码"
+		body := fmt.Sprintf("您的登录验证码为：%s\n\n验证码 %d 分钟内有效，请勿泄露给他人。如非本人操作请忽略本邮件。",
+			code, int(ttl.Minutes()))
+		if err := s.mailer.Send(email, subject, body); err != nil {
+			logger.Warn("failed to send login code email", "email", email, "error", err)
+		}
+	}()
+
+	logger.InfoContext(ctx, "login code issued", "email", email)
+
+	return &base.SendLoginCodeResponse{
+		Success:   true,
+		ExpiresIn: int64(ttl.Seconds()),
+	}, nil
+}
+
+// LoginWithCode 邮箱验证码登录（无密码）
+// 验证码校验通过后签发令牌；邮箱未注册且系统开启注册时自动创建账号
+func (s *AuthService) LoginWithCode(ctx context.Context, email, code, clientIP, userAgent string) (*base.LoginResponse, error) {
+	// 获取安全设置
+	settingService := NewSystemSettingService(s.client)
+	securitySettings, err := s.getSecuritySettings(ctx, settingService)
+	if err != nil {
+		return nil, err
+	}
+
+	// 校验验证码（次数超限自动作废，防止爆破）
+	if err := s.loginCodes.Verify(email, code); err != nil {
+		return nil, err
+	}
+
+	// 查询用户，未注册时按配置决定是否自动建号
+	u, err := s.client.User.Query().
+		Where(user.Email(email)).
+		WithRoles(withActiveRolesAndPermissions).
+		Only(ctx)
+	if err != nil {
+		if !ent.IsNotFound(err) {
+			return nil, err
+		}
+		u, err = s.createUserForEmail(ctx, settingService, email)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 检查用户状态，softban 用户仍允许登录但权限会被降级
+	if u.Status != user.StatusActive && u.Status != user.StatusSoftban {
+		return nil, ErrUserInactive
+	}
+
+	// 开启 TOTP 的账号与密码登录一致进入第二步，
+	// 防止仅凭邮箱验证码绕过两步验证
+	if u.TotpEnabled {
+		mfaToken, err := s.jwtManager.GenerateMFAToken(u.ID, u.Username, "")
+		if err != nil {
+			return nil, err
+		}
+		return &base.LoginResponse{
+			MfaRequired: true,
+			MfaToken:    mfaToken,
+		}, nil
+	}
+
+	logger.InfoContext(ctx, "user logged in with email code", "userID", u.ID, "email", email)
+
+	// 复用统一的登录收尾流程（登录记录、条款降级、签发令牌）
+	return s.finishLogin(ctx, u, clientIP, userAgent, "", securitySettings.sessionTimeout)
+}
+
+// createUserForEmail 验证码登录时为未注册邮箱自动创建账号
+// 仅在系统开启注册时允许，用户名取邮箱前缀并保证唯一，密码随机生成
+func (s *AuthService) createUserForEmail(ctx context.Context, settingService *SystemSettingService, email string) (*ent.User, error) {
+	registrationEnabled, err := settingService.IsRegistrationEnabled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !registrationEnabled {
+		return nil, ErrRegistrationDisabled
+	}
+
+	username, err := s.uniqueUsernameFromEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	// 随机密码占位，用户后续可通过重置密码设置本地密码
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	passwordHash, err := auth.HashPassword(hex.EncodeToString(buf))
+	if err != nil {
+		return nil, err
+	}
+
+	// 获取默认注册角色
+	defaultRoleCode, err := settingService.GetDefaultRegisterRole(ctx)
+	if err != nil {
+		defaultRoleCode = "user"
+	}
+	defaultRole, err := s.client.Role.Query().
+		Where(role.Code(defaultRoleCode)).
+		Only(ctx)
+	if err != nil {
+		defaultRole = nil
+	}
+
+	create := s.client.User.Create().
+		SetUsername(username).
+		SetEmail(email).
+		SetPasswordHash(passwordHash).
+		SetNickname(username).
+		SetPinyin(pinyin.Index(username)).
+		SetStatus(user.StatusActive)
+	if defaultRole != nil {
+		create = create.AddRoles(defaultRole)
+	}
+
+	u, err := create.Save(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "user auto-created via email code login", "userID", u.ID, "email", email)
+
+	// 重新加载角色和权限，保持与登录查询一致
+	return s.client.User.Query().
+		Where(user.ID(u.ID)).
+		WithRoles(withActiveRolesAndPermissions).
+		Only(ctx)
+}
+
+// uniqueUsernameFromEmail 从邮箱前缀派生唯一用户名
+func (s *AuthService) uniqueUsernameFromEmail(ctx context.Context, email string) (string, error) {
+	stem := sanitizeUsername(strings.SplitN(email, "@", 2)[0])
+
+	candidate := stem
+	for i := 0; ; i++ {
+		if i > 0 {
+			candidate = fmt.Sprintf("%s_%d", stem, i)
+		}
+		exists, err := s.client.User.Query().Where(user.Username(candidate)).Exist(ctx)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+	}
+}
+
+// sanitizeUsername 将邮箱前缀清洗为合法用户名（字母开头，仅字母/数字/下划线）
+func sanitizeUsername(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_':
+			b.WriteRune(r)
+		}
+	}
+	cleaned := b.String()
+	if cleaned == "" || !((cleaned[0] >= 'a' && cleaned[0] <= 'z') || (cleaned[0] >= 'A' && cleaned[0] <= 'Z')) {
+		cleaned = "user" + cleaned
+	}
+	return cleaned
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"zera/ent"
+	"zera/ent/user"
+)
+
+// createLoginTestUser 创建登录查询测试用户，用例结束后自动清理
+func createLoginTestUser(t *testing.T, client *ent.Client, username, email string) *ent.User {
+	t.Helper()
+	u, err := client.User.Create().
+		SetUsername(username).
+		SetEmail(email).
+		SetPasswordHash("$2a$04$placeholderplaceholderplace").
+		SetNickname(username).
+		SetStatus(user.StatusActive).
+		Save(context.Background())
+	if err != nil {
+		t.Fatalf("创建测试用户失败: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = client.User.DeleteOneID(u.ID).Exec(context.Background())
+	})
+	return u
+}
+
+// TestLookupLoginUserEmailCaseInsensitive 邮箱登录标识应大小写不敏感：
+// 存库为混合大小写、输入为任意大小写时都要命中同一用户
+func TestLookupLoginUserEmailCaseInsensitive(t *testing.T) {
+	client := openTestClient(t)
+	svc := NewAuthService(client, nil, nil)
+	ctx := context.Background()
+
+	suffix := fmt.Sprintf("%d", time.Now().UnixNano())
+	username := "login_case_" + suffix
+	email := "Login.Case+" + suffix + "@Example.COM"
+	created := createLoginTestUser(t, client, username, email)
+
+	tests := []struct {
+		name       string
+		identifier string
+	}{
+		{"用户名精确匹配", username},
+		{"邮箱原样输入", email},
+		{"邮箱全小写输入", "login.case+" + suffix + "@example.com"},
+		{"邮箱全大写输入", "LOGIN.CASE+" + suffix + "@EXAMPLE.COM"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := svc.lookupLoginUser(ctx, tt.identifier)
+			if err != nil {
+				t.Fatalf("lookupLoginUser(%q): %v", tt.identifier, err)
+			}
+			if u.ID != created.ID {
+				t.Errorf("命中了错误的用户: got %d, want %d", u.ID, created.ID)
+			}
+		})
+	}
+}
+
+// TestLookupLoginUserUsernamePriority 用户名和邮箱同时命中时以用户名优先
+func TestLookupLoginUserUsernamePriority(t *testing.T) {
+	client := openTestClient(t)
+	svc := NewAuthService(client, nil, nil)
+	ctx := context.Background()
+
+	suffix := fmt.Sprintf("%d", time.Now().UnixNano())
+	shared := "dup_" + suffix + "@example.com"
+	// byEmail 的邮箱与 byName 的用户名相同，标识同时命中两个用户
+	byEmail := createLoginTestUser(t, client, "login_dup_"+suffix, shared)
+	byName := createLoginTestUser(t, client, shared, "other_"+suffix+"@example.com")
+
+	u, err := svc.lookupLoginUser(ctx, shared)
+	if err != nil {
+		t.Fatalf("lookupLoginUser(%q): %v", shared, err)
+	}
+	if u.ID != byName.ID {
+		t.Errorf("未按用户名优先命中: got %d, want %d (email 命中的为 %d)", u.ID, byName.ID, byEmail.ID)
+	}
+}
+
+// TestLookupLoginUserNotFound 未知标识应统一返回 ErrUserNotFound，
+// 由调用方转为凭证错误避免枚举
+func TestLookupLoginUserNotFound(t *testing.T) {
+	client := openTestClient(t)
+	svc := NewAuthService(client, nil, nil)
+
+	_, err := svc.lookupLoginUser(context.Background(),
+		fmt.Sprintf("nobody_%d@example.com", time.Now().UnixNano()))
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("未知标识应返回 ErrUserNotFound, got %v", err)
+	}
+}
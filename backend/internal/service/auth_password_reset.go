@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"zera/ent"
+	"zera/ent/passwordresettoken"
+	"zera/ent/user"
+	"zera/gen/base"
+	"zera/internal/auth"
+	"zera/internal/logger"
+)
+
+var (
+	// ErrInvalidResetToken 重置令牌无效、已使用或已过期
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+	// ErrResetThrottled 重置邮件发送过于频繁
+	ErrResetThrottled = errors.New("password reset requested too frequently")
+)
+
+const (
+	// passwordResetTokenTTL 重置令牌有效期
+	passwordResetTokenTTL = 30 * time.Minute
+	// passwordResetResendInterval 重置邮件最小发送间隔
+	passwordResetResendInterval = time.Minute
+)
+
+// RequestPasswordReset 申请密码重置
+// 生成一次性重置令牌存库（仅保存哈希）并通过邮件发送；
+// 为防止邮箱枚举，邮箱未注册时同样返回成功但不发送邮件
+func (s *AuthService) RequestPasswordReset(ctx context.Context, email string) (*base.RequestPasswordResetResponse, error) {
+	if s.mailer == nil || !s.mailer.Enabled() {
+		return nil, ErrMailerDisabled
+	}
+
+	resp := &base.RequestPasswordResetResponse{
+		Success:   true,
+		ExpiresIn: int64(passwordResetTokenTTL.Seconds()),
+	}
+
+	u, err := s.client.User.Query().Where(user.Email(email)).Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			logger.InfoContext(ctx, "password reset requested for unknown email, skipping", "email", email)
+			return resp, nil
+		}
+		return nil, err
+	}
+
+	// 限制发送频率，窗口内已有未过期令牌时拒绝重发
+	recent, err := s.client.PasswordResetToken.Query().
+		Where(
+			passwordresettoken.UserID(u.ID),
+			passwordresettoken.UsedAtIsNil(),
+			passwordresettoken.CreatedAtGT(time.Now().Add(-passwordResetResendInterval)),
+		).
+		Exist(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if recent {
+		return nil, ErrResetThrottled
+	}
+
+	// 作废该用户此前未使用的令牌，同一时刻只有最新一封邮件有效
+	if _, err := s.client.PasswordResetToken.Delete().
+		Where(
+			passwordresettoken.UserID(u.ID),
+			passwordresettoken.UsedAtIsNil(),
+		).
+		Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	if _, err := s.client.PasswordResetToken.Create().
+		SetUserID(u.ID).
+		SetTokenHash(hashResetToken(token)).
+		SetExpiresAt(time.Now().Add(passwordResetTokenTTL)).
+		Save(ctx); err != nil {
+		return nil, err
+	}
+
+	// 异步发送重置邮件，发送失败仅记录日志，用户可重新申请
+	go func() {
+		subject := "【Zera】密码重置"
+		body := fmt.Sprintf(
+			"您好 %s：\n\n我们收到了您的密码重置申请。请在重置密码页面输入以下令牌完成重置：\n\n%s\n\n令牌 %d 分钟内有效且仅可使用一次。如非本人操作请忽略本邮件，您的密码不会被修改。",
+			u.Username, token, int(passwordResetTokenTTL.Minutes()))
+		if err := s.mailer.Send(u.Email, subject, body); err != nil {
+			logger.Error("failed to send password reset email",
+				"userID", u.ID,
+				"email", u.Email,
+				"error", err,
+			)
+		}
+	}()
+
+	logger.InfoContext(ctx, "password reset token issued", "userID", u.ID)
+	return resp, nil
+}
+
+// ConfirmPasswordReset 确认密码重置
+// 校验一次性令牌并设置新密码，新密码须满足系统密码策略；
+// 重置成功后清空登录失败计数和锁定状态
+func (s *AuthService) ConfirmPasswordReset(ctx context.Context, token, newPassword string) error {
+	prt, err := s.client.PasswordResetToken.Query().
+		Where(passwordresettoken.TokenHash(hashResetToken(token))).
+		Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+	if prt.UsedAt != nil || prt.ExpiresAt.Before(time.Now()) {
+		return ErrInvalidResetToken
+	}
+
+	u, err := s.client.User.Query().Where(user.ID(prt.UserID)).Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	// 新密码复用系统密码策略校验
+	settingService := NewSystemSettingService(s.client)
+	policy, err := GetPasswordPolicy(ctx, settingService)
+	if err != nil {
+		return err
+	}
+	if err := ValidatePassword(newPassword, policy); err != nil {
+		return err
+	}
+
+	passwordHash, err := auth.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	// 令牌标记已使用，单次有效
+	if err := prt.Update().SetUsedAt(time.Now()).Exec(ctx); err != nil {
+		return err
+	}
+
+	if err := u.Update().
+		SetPasswordHash(passwordHash).
+		SetLoginAttempts(0).
+		ClearLockedUntil().
+		Exec(ctx); err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "password reset completed", "userID", u.ID)
+	return nil
+}
+
+// hashResetToken 计算重置令牌哈希，数据库不保存令牌明文
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
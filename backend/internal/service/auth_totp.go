@@ -0,0 +1,335 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"zera/ent"
+	"zera/ent/user"
+	"zera/gen/base"
+	"zera/internal/auth"
+	"zera/internal/logger"
+)
+
+var (
+	// ErrTOTPAlreadyEnabled TOTP 已开启
+	ErrTOTPAlreadyEnabled = errors.New("totp is already enabled")
+	// ErrTOTPNotEnabled TOTP 未开启或未初始化
+	ErrTOTPNotEnabled = errors.New("totp is not enabled")
+	// ErrInvalidTOTPCode TOTP 验证码错误
+	ErrInvalidTOTPCode = errors.New("invalid totp code")
+	// ErrInvalidRecoveryCode 恢复码错误或已被使用
+	ErrInvalidRecoveryCode = errors.New("invalid or used recovery code")
+)
+
+// totpRecoveryCodeCount 开启 TOTP 时生成的一次性恢复码数量
+const totpRecoveryCodeCount = 8
+
+// EnableTOTP 开启 TOTP 两步验证
+// 生成密钥和恢复码并加密落库，但 totp_enabled 保持 false，
+// 用户在认证器中添加后须调用 VerifyTOTP 校验一次验证码才正式生效
+func (s *AuthService) EnableTOTP(ctx context.Context, userID int) (*base.EnableTOTPResponse, error) {
+	u, err := s.client.User.Query().Where(user.ID(userID)).Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if u.TotpEnabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encSecret, err := auth.EncryptTOTPSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	// 恢复码明文仅本次返回，数据库只保存哈希
+	recoveryCodes, err := generateRecoveryCodes(totpRecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, 0, len(recoveryCodes))
+	for _, code := range recoveryCodes {
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	if err := u.Update().
+		SetTotpSecret(encSecret).
+		SetTotpRecoveryCodes(hashes).
+		Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	// 二维码标签使用站点名称作为 issuer，获取失败时回退默认值
+	settingService := NewSystemSettingService(s.client)
+	issuer, err := settingService.GetSiteName(ctx)
+	if err != nil || issuer == "" {
+		issuer = "Zera"
+	}
+
+	return &base.EnableTOTPResponse{
+		Secret:        secret,
+		OtpauthUrl:    auth.TOTPURL(secret, u.Username, issuer),
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+// VerifyTOTP 校验验证码并激活 TOTP
+func (s *AuthService) VerifyTOTP(ctx context.Context, userID int, code string) error {
+	u, err := s.client.User.Query().Where(user.ID(userID)).Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if u.TotpSecret == "" {
+		return ErrTOTPNotEnabled
+	}
+
+	secret, err := auth.DecryptTOTPSecret(u.TotpSecret)
+	if err != nil {
+		return err
+	}
+	if !auth.ValidateTOTP(secret, code) {
+		return ErrInvalidTOTPCode
+	}
+
+	if err := u.Update().SetTotpEnabled(true).Exec(ctx); err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "totp enabled", "userID", u.ID)
+	return nil
+}
+
+// DisableTOTP 关闭 TOTP 两步验证
+// 须提供有效的 TOTP 验证码或恢复码，防止会话被盗后直接解除两步验证
+func (s *AuthService) DisableTOTP(ctx context.Context, userID int, code string) error {
+	u, err := s.client.User.Query().Where(user.ID(userID)).Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if !u.TotpEnabled && u.TotpSecret == "" {
+		return ErrTOTPNotEnabled
+	}
+
+	ok, _, err := s.verifyTOTPOrRecoveryCode(u, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidTOTPCode
+	}
+
+	if err := u.Update().
+		SetTotpEnabled(false).
+		ClearTotpSecret().
+		ClearTotpRecoveryCodes().
+		Exec(ctx); err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "totp disabled", "userID", u.ID)
+	return nil
+}
+
+// LoginVerifyMFA 两步验证登录（第二步）
+// 校验 Login 签发的 challenge 令牌和 TOTP 验证码/恢复码，通过后签发正式令牌
+func (s *AuthService) LoginVerifyMFA(ctx context.Context, mfaToken, code, clientIP, userAgent string) (*base.LoginResponse, error) {
+	claims, err := s.jwtManager.ValidateMFAToken(mfaToken)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	settingService := NewSystemSettingService(s.client)
+	securitySettings, err := s.getSecuritySettings(ctx, settingService)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := s.client.User.Query().
+		Where(user.ID(claims.UserID)).
+		WithRoles(withActiveRolesAndPermissions).
+		Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	// 检查用户状态，softban 用户仍允许登录但权限会被降级
+	if u.Status != user.StatusActive && u.Status != user.StatusSoftban {
+		return nil, ErrUserInactive
+	}
+	if !u.TotpEnabled {
+		return nil, ErrTOTPNotEnabled
+	}
+
+	ok, usedRecovery, err := s.verifyTOTPOrRecoveryCode(u, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		logger.WarnContext(ctx, "mfa verification failed", "userID", u.ID, "ip", clientIP)
+		return nil, ErrInvalidTOTPCode
+	}
+
+	// 恢复码一次性使用，消费后从列表中移除
+	remainingCount := 0
+	if usedRecovery {
+		remaining := removeRecoveryCode(u.TotpRecoveryCodes, hashRecoveryCode(code))
+		if err := u.Update().SetTotpRecoveryCodes(remaining).Exec(ctx); err != nil {
+			return nil, err
+		}
+		remainingCount = len(remaining)
+		logger.InfoContext(ctx, "totp recovery code used",
+			"userID", u.ID,
+			"remaining", remainingCount,
+		)
+	}
+
+	// 客户端类型沿用 challenge 令牌中记录的值
+	resp, err := s.finishLogin(ctx, u, clientIP, userAgent, claims.ClientType, securitySettings.sessionTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if usedRecovery {
+		resp.RecoveryCodeUsed = true
+		resp.RecoveryCodesRemaining = int32(remainingCount)
+	}
+	return resp, nil
+}
+
+// LoginWithRecoveryCode 恢复码登录（两步验证兜底）
+// TOTP 设备丢失时使用一次性恢复码完成第二步验证，恢复码用后作废；
+// 响应携带剩余恢复码数量，用完时前端应提示用户重新生成
+func (s *AuthService) LoginWithRecoveryCode(ctx context.Context, mfaToken, recoveryCode, clientIP, userAgent string) (*base.LoginResponse, error) {
+	claims, err := s.jwtManager.ValidateMFAToken(mfaToken)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	settingService := NewSystemSettingService(s.client)
+	securitySettings, err := s.getSecuritySettings(ctx, settingService)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := s.client.User.Query().
+		Where(user.ID(claims.UserID)).
+		WithRoles(withActiveRolesAndPermissions).
+		Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	// 检查用户状态，softban 用户仍允许登录但权限会被降级
+	if u.Status != user.StatusActive && u.Status != user.StatusSoftban {
+		return nil, ErrUserInactive
+	}
+	if !u.TotpEnabled {
+		return nil, ErrTOTPNotEnabled
+	}
+
+	// 仅匹配恢复码，不回退 TOTP 验证码校验
+	hash := hashRecoveryCode(recoveryCode)
+	matched := false
+	for _, h := range u.TotpRecoveryCodes {
+		if h == hash {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		logger.WarnContext(ctx, "recovery code login failed", "userID", u.ID, "ip", clientIP)
+		return nil, ErrInvalidRecoveryCode
+	}
+
+	// 恢复码一次性使用，消费后从列表中移除
+	remaining := removeRecoveryCode(u.TotpRecoveryCodes, hash)
+	if err := u.Update().SetTotpRecoveryCodes(remaining).Exec(ctx); err != nil {
+		return nil, err
+	}
+	logger.InfoContext(ctx, "totp recovery code used",
+		"userID", u.ID,
+		"remaining", len(remaining),
+	)
+
+	// 客户端类型沿用 challenge 令牌中记录的值
+	resp, err := s.finishLogin(ctx, u, clientIP, userAgent, claims.ClientType, securitySettings.sessionTimeout)
+	if err != nil {
+		return nil, err
+	}
+	resp.RecoveryCodeUsed = true
+	resp.RecoveryCodesRemaining = int32(len(remaining))
+	return resp, nil
+}
+
+// verifyTOTPOrRecoveryCode 校验 TOTP 验证码，失败时尝试匹配一次性恢复码
+// 返回是否通过以及是否使用了恢复码
+func (s *AuthService) verifyTOTPOrRecoveryCode(u *ent.User, code string) (bool, bool, error) {
+	if u.TotpSecret != "" {
+		secret, err := auth.DecryptTOTPSecret(u.TotpSecret)
+		if err != nil {
+			return false, false, err
+		}
+		if auth.ValidateTOTP(secret, code) {
+			return true, false, nil
+		}
+	}
+
+	hash := hashRecoveryCode(code)
+	for _, h := range u.TotpRecoveryCodes {
+		if h == hash {
+			return true, true, nil
+		}
+	}
+	return false, false, nil
+}
+
+// generateRecoveryCodes 生成一次性恢复码（10 位十六进制）
+func generateRecoveryCodes(count int) ([]string, error) {
+	codes := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		codes = append(codes, hex.EncodeToString(buf))
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode 计算恢复码哈希，数据库不保存恢复码明文
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// removeRecoveryCode 从恢复码哈希列表中移除已使用的条目
+func removeRecoveryCode(hashes []string, used string) []string {
+	remaining := make([]string, 0, len(hashes))
+	for _, h := range hashes {
+		if h != used {
+			remaining = append(remaining, h)
+		}
+	}
+	return remaining
+}
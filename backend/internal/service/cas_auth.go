@@ -11,6 +11,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"zera/ent"
@@ -22,6 +23,7 @@ import (
 	"zera/internal/casdoor"
 	"zera/internal/logger"
 	"zera/internal/permission"
+	"zera/internal/pinyin"
 )
 
 // CAS 配置键名常量
@@ -97,6 +99,60 @@ type CASAuthService struct {
 	permissionChecker *permission.Checker
 	httpClient        *http.Client
 	casdoorClient     *casdoor.Client
+	ticketCache       *ticketResultCache
+}
+
+// ticketCacheTTL 已验证 ticket 结果的缓存时长
+// ticket 是一次性的，重复回调通常紧跟首次请求，窗口很短即可
+const ticketCacheTTL = 60 * time.Second
+
+// ticketResultCache 已成功验证 ticket 的回调结果短时缓存
+// 部分前端会对同一 ticket 重复发起回调请求，第二次验证会因
+// ticket 已被 CAS 服务器消费而失败，缓存首次结果后重复回调直接返回
+type ticketResultCache struct {
+	mu      sync.Mutex
+	entries map[string]ticketResultEntry
+}
+
+type ticketResultEntry struct {
+	resp      *base.CASCallbackResponse
+	expiresAt time.Time
+}
+
+func newTicketResultCache() *ticketResultCache {
+	return &ticketResultCache{
+		entries: make(map[string]ticketResultEntry),
+	}
+}
+
+// Get 获取缓存的回调结果，过期或不存在时返回 false
+func (c *ticketResultCache) Get(ticket string) (*base.CASCallbackResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[ticket]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.resp, true
+}
+
+// Set 写入回调结果缓存
+func (c *ticketResultCache) Set(ticket string, resp *base.CASCallbackResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 顺带清理已过期的条目，避免缓存无限增长
+	now := time.Now()
+	for k, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+
+	c.entries[ticket] = ticketResultEntry{
+		resp:      resp,
+		expiresAt: now.Add(ticketCacheTTL),
+	}
 }
 
 // NewCASAuthService 创建 CAS 认证服务
@@ -109,6 +165,7 @@ func NewCASAuthService(client *ent.Client, jwtManager *auth.JWTManager) *CASAuth
 			Timeout: 10 * time.Second,
 		},
 		casdoorClient: casdoor.NewClient(),
+		ticketCache:   newTicketResultCache(),
 	}
 }
 
@@ -122,6 +179,7 @@ func NewCASAuthServiceWithCasdoor(client *ent.Client, jwtManager *auth.JWTManage
 			Timeout: 10 * time.Second,
 		},
 		casdoorClient: casdoorClient,
+		ticketCache:   newTicketResultCache(),
 	}
 }
 
@@ -392,6 +450,13 @@ func (s *CASAuthService) ValidateTicket(ctx context.Context, ticket, service str
 
 // CASCallback 处理 CAS 回调
 func (s *CASAuthService) CASCallback(ctx context.Context, ticket, service string) (*base.CASCallbackResponse, error) {
+	// 同一 ticket 的重复回调直接返回首次结果，
+	// 避免 ticket 已被消费导致二次验证失败
+	if cached, ok := s.ticketCache.Get(ticket); ok {
+		logger.Info("CAS ticket 重复回调，返回缓存结果", "ticket", ticket)
+		return cached, nil
+	}
+
 	// 验证票据
 	casUser, err := s.ValidateTicket(ctx, ticket, service)
 	if err != nil {
@@ -412,7 +477,16 @@ func (s *CASAuthService) CASCallback(ctx context.Context, ticket, service string
 	}
 
 	// 获取用户角色和权限
-	roles, permissions := s.extractRolesAndPermissions(u)
+	roles, permissions, err := s.extractRolesAndPermissions(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	// 条款合规检查：与本地登录同一套降级规则，SSO 用户同样不能绕过
+	permissions, needAcceptTerms, termsVersion, err := applyTermsDowngrade(ctx, s.client, u, permissions)
+	if err != nil {
+		return nil, err
+	}
 
 	// 生成令牌
 	accessToken, err := s.jwtManager.GenerateAccessTokenWithExpire(u.ID, u.Username, roles, permissions, securitySettings.sessionTimeout)
@@ -420,11 +494,16 @@ func (s *CASAuthService) CASCallback(ctx context.Context, ticket, service string
 		return nil, err
 	}
 
-	refreshToken, err := s.jwtManager.GenerateRefreshToken(u.ID, u.Username)
+	refreshToken, jti, err := s.jwtManager.GenerateRefreshToken(u.ID, u.Username)
 	if err != nil {
 		return nil, err
 	}
 
+	// 记录刷新令牌，用于轮换和重放检测
+	if err := StoreRefreshToken(ctx, s.client, s.jwtManager, u.ID, jti); err != nil {
+		return nil, err
+	}
+
 	// 更新最后登录时间
 	_, err = u.Update().
 		SetLastLoginAt(time.Now()).
@@ -436,13 +515,20 @@ func (s *CASAuthService) CASCallback(ctx context.Context, ticket, service string
 	// 构建用户信息
 	userInfo := s.buildUserInfo(u, permissions)
 
-	return &base.CASCallbackResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    int64(securitySettings.sessionTimeout * 60),
-		User:         userInfo,
-		IsNewUser:    isNewUser,
-	}, nil
+	resp := &base.CASCallbackResponse{
+		AccessToken:     accessToken,
+		RefreshToken:    refreshToken,
+		ExpiresIn:       int64(securitySettings.sessionTimeout * 60),
+		User:            userInfo,
+		IsNewUser:       isNewUser,
+		NeedAcceptTerms: needAcceptTerms,
+		TermsVersion:    termsVersion,
+	}
+
+	// 缓存成功结果，短时间内的重复回调复用
+	s.ticketCache.Set(ticket, resp)
+
+	return resp, nil
 }
 
 // CreateOrUpdateUser 根据 CAS 用户信息创建或更新本地用户
@@ -466,9 +552,7 @@ func (s *CASAuthService) CreateOrUpdateUser(ctx context.Context, casUser *CASUse
 			user.AuthProviderEQ(user.AuthProviderCas),
 			user.ExternalIDEQ(casUser.ExternalID),
 		).
-		WithRoles(func(q *ent.RoleQuery) {
-			q.WithPermissions()
-		}).
+		WithRoles(withActiveRolesAndPermissions).
 		Only(ctx)
 
 	if err == nil {
@@ -489,7 +573,8 @@ func (s *CASAuthService) CreateOrUpdateUser(ctx context.Context, casUser *CASUse
 			}
 		}
 		if casUser.DisplayName != "" {
-			update = update.SetNickname(casUser.DisplayName)
+			update = update.SetNickname(casUser.DisplayName).
+				SetPinyin(pinyin.Index(casUser.DisplayName))
 		}
 
 		u, err = update.Save(ctx)
@@ -500,9 +585,7 @@ func (s *CASAuthService) CreateOrUpdateUser(ctx context.Context, casUser *CASUse
 		// 重新加载用户（带角色和权限）
 		u, err = s.client.User.Query().
 			Where(user.ID(u.ID)).
-			WithRoles(func(q *ent.RoleQuery) {
-				q.WithPermissions()
-			}).
+			WithRoles(withActiveRolesAndPermissions).
 			Only(ctx)
 		if err != nil {
 			return nil, false, err
@@ -552,7 +635,8 @@ func (s *CASAuthService) CreateOrUpdateUser(ctx context.Context, casUser *CASUse
 				update := existingUser.Update().
 					SetAuthProvider(user.AuthProviderCas)
 				if casUser.DisplayName != "" {
-					update = update.SetNickname(casUser.DisplayName)
+					update = update.SetNickname(casUser.DisplayName).
+						SetPinyin(pinyin.Index(casUser.DisplayName))
 				}
 				u, err = update.Save(ctx)
 				if err != nil {
@@ -562,9 +646,7 @@ func (s *CASAuthService) CreateOrUpdateUser(ctx context.Context, casUser *CASUse
 				// 重新加载用户（带角色和权限）
 				u, err = s.client.User.Query().
 					Where(user.ID(u.ID)).
-					WithRoles(func(q *ent.RoleQuery) {
-						q.WithPermissions()
-					}).
+					WithRoles(withActiveRolesAndPermissions).
 					Only(ctx)
 				if err != nil {
 					return nil, false, err
@@ -597,7 +679,8 @@ func (s *CASAuthService) CreateOrUpdateUser(ctx context.Context, casUser *CASUse
 						SetAuthProvider(user.AuthProviderCas).
 						SetExternalID(casUser.ExternalID)
 					if casUser.DisplayName != "" {
-						update = update.SetNickname(casUser.DisplayName)
+						update = update.SetNickname(casUser.DisplayName).
+							SetPinyin(pinyin.Index(casUser.DisplayName))
 					}
 					u, err = update.Save(ctx)
 					if err != nil {
@@ -607,9 +690,7 @@ func (s *CASAuthService) CreateOrUpdateUser(ctx context.Context, casUser *CASUse
 					// 重新加载用户（带角色和权限）
 					u, err = s.client.User.Query().
 						Where(user.ID(u.ID)).
-						WithRoles(func(q *ent.RoleQuery) {
-							q.WithPermissions()
-						}).
+						WithRoles(withActiveRolesAndPermissions).
 						Only(ctx)
 					if err != nil {
 						return nil, false, err
@@ -678,6 +759,7 @@ func (s *CASAuthService) CreateOrUpdateUser(ctx context.Context, casUser *CASUse
 		SetEmail(email).
 		SetPasswordHash("CAS_USER_NO_PASSWORD"). // CAS 用户没有本地密码
 		SetNickname(casUser.DisplayName).
+		SetPinyin(pinyin.Index(casUser.DisplayName)).
 		SetStatus(user.StatusActive).
 		SetAuthProvider(user.AuthProviderCas).
 		SetExternalID(casUser.ExternalID)
@@ -700,9 +782,7 @@ func (s *CASAuthService) CreateOrUpdateUser(ctx context.Context, casUser *CASUse
 	// 重新加载用户（带角色和权限）
 	u, err = s.client.User.Query().
 		Where(user.ID(u.ID)).
-		WithRoles(func(q *ent.RoleQuery) {
-			q.WithPermissions()
-		}).
+		WithRoles(withActiveRolesAndPermissions).
 		Only(ctx)
 	if err != nil {
 		return nil, false, err
@@ -840,7 +920,8 @@ func (s *CASAuthService) getSecuritySettings(ctx context.Context, settingService
 }
 
 // extractRolesAndPermissions 从用户实体中提取角色和权限
-func (s *CASAuthService) extractRolesAndPermissions(u *ent.User) ([]string, []string) {
+// 沿父角色链递归合并继承的权限；softban 用户的权限会被降级为只读
+func (s *CASAuthService) extractRolesAndPermissions(ctx context.Context, u *ent.User) ([]string, []string, error) {
 	roles := make([]string, 0, len(u.Edges.Roles))
 	permissionSet := make(map[string]bool)
 	isAdmin := false
@@ -855,8 +936,23 @@ func (s *CASAuthService) extractRolesAndPermissions(u *ent.User) ([]string, []st
 		}
 	}
 
+	// 角色继承：递归合并父角色的权限
+	if err := mergeInheritedRolePermissions(ctx, s.client, u.Edges.Roles, permissionSet); err != nil {
+		return nil, nil, err
+	}
+
+	// 用户直接授予的权限（绕过角色的一次性授权）并入最终权限集合
+	if err := mergeDirectUserPermissions(ctx, s.client, u.ID, permissionSet); err != nil {
+		return nil, nil, err
+	}
+
+	// softban 用户仅保留只读权限，管理员也不例外
+	if u.Status == user.StatusSoftban {
+		return roles, filterReadOnlyPermissions(permissionSet), nil
+	}
+
 	if isAdmin {
-		return roles, []string{"*"}
+		return roles, []string{"*"}, nil
 	}
 
 	permissions := make([]string, 0, len(permissionSet))
@@ -864,7 +960,7 @@ func (s *CASAuthService) extractRolesAndPermissions(u *ent.User) ([]string, []st
 		permissions = append(permissions, code)
 	}
 
-	return roles, permissions
+	return roles, permissions, nil
 }
 
 // buildUserInfo 构建用户信息
@@ -875,13 +971,14 @@ func (s *CASAuthService) buildUserInfo(u *ent.User, permissions []string) *base.
 	}
 
 	return &base.UserInfo{
-		Id:          strconv.Itoa(u.ID),
-		Username:    u.Username,
-		Nickname:    u.Nickname,
-		Avatar:      u.Avatar,
-		Email:       u.Email,
-		Roles:       roles,
-		Permissions: permissions,
+		Id:           strconv.Itoa(u.ID),
+		Username:     u.Username,
+		Nickname:     u.Nickname,
+		Avatar:       u.Avatar,
+		Email:        u.Email,
+		Roles:        roles,
+		Permissions:  permissions,
+		DefaultRoute: defaultRouteForUser(u),
 	}
 }
 
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestGoCasdoorSyncDecoupledFromRequestContext 模拟请求 ctx 取消后异步同步仍应成功：
+// goCasdoorSync 必须使用独立的 background context，不随请求取消
+func TestGoCasdoorSyncDecoupledFromRequestContext(t *testing.T) {
+	svc := NewUserService(nil)
+
+	// 模拟请求 context：提交同步任务后立即取消（HTTP 请求返回）
+	reqCtx, cancel := context.WithCancel(context.Background())
+
+	syncErr := make(chan error, 1)
+	svc.goCasdoorSync(func(ctx context.Context) {
+		// 等待请求 ctx 被取消后再检查同步 ctx 是否仍然可用
+		<-reqCtx.Done()
+		syncErr <- ctx.Err()
+	})
+	cancel()
+
+	select {
+	case err := <-syncErr:
+		if err != nil {
+			t.Errorf("请求 ctx 取消后同步 ctx 也被取消: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("同步任务未执行完成")
+	}
+}
+
+// TestGoCasdoorSyncTimeout 同步 ctx 应带超时兜底，避免 Casdoor 不可达时 goroutine 悬挂
+func TestGoCasdoorSyncTimeout(t *testing.T) {
+	svc := NewUserService(nil)
+
+	deadlineOK := make(chan bool, 1)
+	svc.goCasdoorSync(func(ctx context.Context) {
+		_, ok := ctx.Deadline()
+		deadlineOK <- ok
+	})
+
+	select {
+	case ok := <-deadlineOK:
+		if !ok {
+			t.Error("同步 ctx 未设置超时")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("同步任务未执行完成")
+	}
+}
+
+// TestWaitCasdoorSyncs 优雅关闭时应能等待在途同步任务 drain 完成
+func TestWaitCasdoorSyncs(t *testing.T) {
+	svc := NewUserService(nil)
+
+	release := make(chan struct{})
+	svc.goCasdoorSync(func(ctx context.Context) {
+		<-release
+	})
+
+	// 任务未完成时等待应超时返回 false
+	if svc.WaitCasdoorSyncs(50 * time.Millisecond) {
+		t.Error("在途任务未完成时 WaitCasdoorSyncs 应返回 false")
+	}
+
+	close(release)
+	if !svc.WaitCasdoorSyncs(5 * time.Second) {
+		t.Error("任务完成后 WaitCasdoorSyncs 应返回 true")
+	}
+}
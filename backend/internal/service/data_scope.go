@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"zera/ent"
+	"zera/ent/department"
+	"zera/ent/predicate"
+	"zera/ent/role"
+	"zera/ent/user"
+)
+
+var (
+	// ErrInvalidDataScope 无效的数据范围代码
+	ErrInvalidDataScope = errors.New("invalid data scope")
+	// ErrUserOutOfScope 目标用户超出操作者的数据范围
+	ErrUserOutOfScope = errors.New("user is out of operator data scope")
+)
+
+// parseDataScope 解析数据范围代码，空值默认为全部
+func parseDataScope(s string) (role.DataScope, error) {
+	switch s {
+	case "":
+		return role.DataScopeAll, nil
+	case string(role.DataScopeAll), string(role.DataScopeDepartment),
+		string(role.DataScopeDepartmentAndChildren), string(role.DataScopeSelf):
+		return role.DataScope(s), nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrInvalidDataScope, s)
+	}
+}
+
+// userScopePredicate 计算操作者可管理用户范围的过滤条件
+// 组合操作者所有角色的数据范围取并集：任一角色为全部时返回 nil（不限制）；
+// 操作者始终可以管理自己
+func (s *UserService) userScopePredicate(ctx context.Context, operatorID int) (predicate.User, error) {
+	// 无操作者上下文的场景（如内部调用）不做限制
+	if operatorID <= 0 {
+		return nil, nil
+	}
+
+	// 已软删除的角色不参与数据范围计算
+	op, err := s.client.User.Query().
+		Where(user.ID(operatorID)).
+		WithRoles(func(q *ent.RoleQuery) {
+			q.Where(role.DeletedAtIsNil())
+		}).
+		WithDepartment().
+		Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	preds := []predicate.User{user.ID(operatorID)}
+	for _, r := range op.Edges.Roles {
+		switch r.DataScope {
+		case role.DataScopeAll:
+			return nil, nil
+		case role.DataScopeSelf:
+			// 已始终包含自己
+		case role.DataScopeDepartment, role.DataScopeDepartmentAndChildren:
+			// 操作者本身无部门时，该范围不扩大可见集合
+			if op.Edges.Department == nil {
+				continue
+			}
+			deptService := NewDepartmentService(s.client)
+			deptIDs, err := deptService.CollectDepartmentIDs(ctx, op.Edges.Department.ID,
+				r.DataScope == role.DataScopeDepartmentAndChildren)
+			if err != nil {
+				return nil, err
+			}
+			preds = append(preds, user.HasDepartmentWith(department.IDIn(deptIDs...)))
+		}
+	}
+
+	return user.Or(preds...), nil
+}
+
+// scopedIDSet 返回 ids 中处于操作者数据范围内的用户 ID 集合
+// 返回 nil 集合表示不受限制（全部在范围内）
+func (s *UserService) scopedIDSet(ctx context.Context, operatorID int, ids []int) (map[int]bool, error) {
+	pred, err := s.userScopePredicate(ctx, operatorID)
+	if err != nil {
+		return nil, err
+	}
+	if pred == nil {
+		return nil, nil
+	}
+
+	inScope, err := s.client.User.Query().
+		Where(user.IDIn(ids...), pred).
+		IDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(map[int]bool, len(inScope))
+	for _, id := range inScope {
+		set[id] = true
+	}
+	return set, nil
+}
+
+// filterIDsInScope 批量操作前的数据范围校验
+// 将超出操作者数据范围的目标标记为 PERMISSION_DENIED 失败并追加到 results，
+// 返回允许操作的 ID 列表；failCode 为范围校验自身失败时使用的错误码，
+// 第二个返回值为 false 表示校验失败、批量操作应整体终止
+func (s *UserService) filterIDsInScope(ctx context.Context, operatorID int, ids []int,
+	results *[]BatchOperationResult, failedCount *int, failCode string) ([]int, bool) {
+	scopeSet, err := s.scopedIDSet(ctx, operatorID, ids)
+	if err != nil {
+		for _, id := range ids {
+			*results = append(*results, BatchOperationResult{
+				ID:           strconv.Itoa(id),
+				Success:      false,
+				ErrorCode:    failCode,
+				ErrorMessage: "校验数据范围失败",
+			})
+			*failedCount++
+		}
+		return nil, false
+	}
+	if scopeSet == nil {
+		return ids, true
+	}
+
+	allowed := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if scopeSet[id] {
+			allowed = append(allowed, id)
+			continue
+		}
+		*results = append(*results, BatchOperationResult{
+			ID:           strconv.Itoa(id),
+			Success:      false,
+			ErrorCode:    ErrCodePermissionDenied,
+			ErrorMessage: "该用户不在您的数据范围内",
+		})
+		*failedCount++
+	}
+	return allowed, true
+}
+
+// checkUserInScope 校验目标用户是否在操作者的数据范围内
+// 超出范围时返回 ErrUserOutOfScope
+func (s *UserService) checkUserInScope(ctx context.Context, operatorID, targetID int) error {
+	pred, err := s.userScopePredicate(ctx, operatorID)
+	if err != nil {
+		return err
+	}
+	if pred == nil {
+		return nil
+	}
+
+	ok, err := s.client.User.Query().
+		Where(user.ID(targetID), pred).
+		Exist(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrUserOutOfScope
+	}
+	return nil
+}
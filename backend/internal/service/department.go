@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"zera/ent"
+	"zera/ent/department"
+	"zera/gen/base"
+)
+
+var (
+	// ErrDepartmentNotFound 部门不存在
+	ErrDepartmentNotFound = errors.New("department not found")
+	// ErrDepartmentExists 部门已存在
+	ErrDepartmentExists = errors.New("department already exists")
+	// ErrDepartmentHasChildren 部门存在子部门
+	ErrDepartmentHasChildren = errors.New("department has children")
+	// ErrDepartmentHasUsers 部门下仍有用户
+	ErrDepartmentHasUsers = errors.New("department has users")
+	// ErrDepartmentCycle 部门层级出现循环
+	ErrDepartmentCycle = errors.New("department parent would create a cycle")
+)
+
+// DepartmentService 部门管理服务
+type DepartmentService struct {
+	client *ent.Client
+}
+
+// NewDepartmentService 创建部门管理服务
+func NewDepartmentService(client *ent.Client) *DepartmentService {
+	return &DepartmentService{
+		client: client,
+	}
+}
+
+// ListDepartments 获取部门列表（平铺）
+func (s *DepartmentService) ListDepartments(ctx context.Context, req *base.ListDepartmentsRequest) (*base.ListDepartmentsResponse, error) {
+	query := s.client.Department.Query().
+		WithParent().
+		WithUsers()
+
+	// 搜索条件
+	if req.Keyword != "" {
+		query = query.Where(
+			department.Or(
+				department.NameContains(req.Keyword),
+				department.CodeContains(req.Keyword),
+			),
+		)
+	}
+
+	// 排序
+	query = query.Order(ent.Asc(department.FieldSortOrder), ent.Asc(department.FieldID))
+
+	departments, err := query.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 转换为响应
+	infos := make([]*base.DepartmentInfo, 0, len(departments))
+	for _, d := range departments {
+		infos = append(infos, s.toDepartmentInfo(d))
+	}
+
+	return &base.ListDepartmentsResponse{
+		Departments: infos,
+		Total:       int64(len(infos)),
+	}, nil
+}
+
+// GetDepartmentTree 获取部门树
+func (s *DepartmentService) GetDepartmentTree(ctx context.Context) (*base.GetDepartmentTreeResponse, error) {
+	departments, err := s.client.Department.Query().
+		WithParent().
+		WithUsers().
+		Order(ent.Asc(department.FieldSortOrder), ent.Asc(department.FieldID)).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先构建所有节点，再按父子关系挂载
+	nodeMap := make(map[int]*base.DepartmentInfo, len(departments))
+	for _, d := range departments {
+		nodeMap[d.ID] = s.toDepartmentInfo(d)
+	}
+
+	roots := make([]*base.DepartmentInfo, 0)
+	for _, d := range departments {
+		node := nodeMap[d.ID]
+		if d.Edges.Parent != nil {
+			if parent, ok := nodeMap[d.Edges.Parent.ID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	return &base.GetDepartmentTreeResponse{
+		Departments: roots,
+	}, nil
+}
+
+// GetDepartment 获取部门详情
+func (s *DepartmentService) GetDepartment(ctx context.Context, id int) (*base.GetDepartmentResponse, error) {
+	d, err := s.client.Department.Query().
+		Where(department.ID(id)).
+		WithParent().
+		WithUsers().
+		Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, ErrDepartmentNotFound
+		}
+		return nil, err
+	}
+
+	return &base.GetDepartmentResponse{
+		Department: s.toDepartmentInfo(d),
+	}, nil
+}
+
+// CreateDepartment 创建部门
+func (s *DepartmentService) CreateDepartment(ctx context.Context, req *base.CreateDepartmentRequest) (*base.CreateDepartmentResponse, error) {
+	// 检查部门代码是否已存在
+	exists, err := s.client.Department.Query().Where(department.Code(req.Code)).Exist(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrDepartmentExists
+	}
+
+	create := s.client.Department.Create().
+		SetCode(req.Code).
+		SetName(req.Name).
+		SetDescription(req.Description).
+		SetSortOrder(int(req.SortOrder))
+
+	// 设置父部门
+	if req.ParentId != "" {
+		parentID, err := strconv.Atoi(req.ParentId)
+		if err != nil {
+			return nil, ErrDepartmentNotFound
+		}
+		exists, err := s.client.Department.Query().Where(department.ID(parentID)).Exist(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrDepartmentNotFound
+		}
+		create = create.SetParentID(parentID)
+	}
+
+	d, err := create.Save(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 重新查询以获取关联数据
+	d, err = s.client.Department.Query().
+		Where(department.ID(d.ID)).
+		WithParent().
+		WithUsers().
+		Only(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &base.CreateDepartmentResponse{
+		Department: s.toDepartmentInfo(d),
+	}, nil
+}
+
+// UpdateDepartment 更新部门
+func (s *DepartmentService) UpdateDepartment(ctx context.Context, id int, req *base.UpdateDepartmentRequest) (*base.UpdateDepartmentResponse, error) {
+	d, err := s.client.Department.Query().Where(department.ID(id)).Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, ErrDepartmentNotFound
+		}
+		return nil, err
+	}
+
+	update := d.Update()
+
+	if req.Name != nil {
+		update = update.SetName(*req.Name)
+	}
+	if req.Description != nil {
+		update = update.SetDescription(*req.Description)
+	}
+	if req.SortOrder != nil {
+		update = update.SetSortOrder(int(*req.SortOrder))
+	}
+
+	// 调整父部门
+	if req.ParentId != nil {
+		if *req.ParentId == "" {
+			// 移动为顶级部门
+			update = update.ClearParent()
+		} else {
+			parentID, err := strconv.Atoi(*req.ParentId)
+			if err != nil {
+				return nil, ErrDepartmentNotFound
+			}
+			// 不允许将部门挂到自身或其子孙部门下
+			if parentID == id {
+				return nil, ErrDepartmentCycle
+			}
+			descendantIDs, err := s.collectDescendantIDs(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			for _, did := range descendantIDs {
+				if did == parentID {
+					return nil, ErrDepartmentCycle
+				}
+			}
+			exists, err := s.client.Department.Query().Where(department.ID(parentID)).Exist(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				return nil, ErrDepartmentNotFound
+			}
+			update = update.SetParentID(parentID)
+		}
+	}
+
+	_, err = update.Save(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 重新查询以获取更新后的数据
+	d, err = s.client.Department.Query().
+		Where(department.ID(id)).
+		WithParent().
+		WithUsers().
+		Only(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &base.UpdateDepartmentResponse{
+		Department: s.toDepartmentInfo(d),
+	}, nil
+}
+
+// DeleteDepartment 删除部门
+func (s *DepartmentService) DeleteDepartment(ctx context.Context, id int) error {
+	d, err := s.client.Department.Query().Where(department.ID(id)).Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return ErrDepartmentNotFound
+		}
+		return err
+	}
+
+	// 存在子部门时不允许删除
+	hasChildren, err := d.QueryChildren().Exist(ctx)
+	if err != nil {
+		return err
+	}
+	if hasChildren {
+		return ErrDepartmentHasChildren
+	}
+
+	// 部门下仍有用户时不允许删除
+	hasUsers, err := d.QueryUsers().Exist(ctx)
+	if err != nil {
+		return err
+	}
+	if hasUsers {
+		return ErrDepartmentHasUsers
+	}
+
+	return s.client.Department.DeleteOneID(id).Exec(ctx)
+}
+
+// CollectDepartmentIDs 收集部门及其所有子孙部门的 ID
+// includeSub 为 false 时仅返回自身 ID
+func (s *DepartmentService) CollectDepartmentIDs(ctx context.Context, id int, includeSub bool) ([]int, error) {
+	ids := []int{id}
+	if !includeSub {
+		return ids, nil
+	}
+
+	descendantIDs, err := s.collectDescendantIDs(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return append(ids, descendantIDs...), nil
+}
+
+// collectDescendantIDs 递归收集子孙部门 ID（逐层查询）
+func (s *DepartmentService) collectDescendantIDs(ctx context.Context, id int) ([]int, error) {
+	var result []int
+	frontier := []int{id}
+
+	for len(frontier) > 0 {
+		children, err := s.client.Department.Query().
+			Where(department.HasParentWith(department.IDIn(frontier...))).
+			Select(department.FieldID).
+			All(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		frontier = frontier[:0]
+		for _, c := range children {
+			result = append(result, c.ID)
+			frontier = append(frontier, c.ID)
+		}
+	}
+
+	return result, nil
+}
+
+// toDepartmentInfo 将 ent.Department 转换为 base.DepartmentInfo
+func (s *DepartmentService) toDepartmentInfo(d *ent.Department) *base.DepartmentInfo {
+	info := &base.DepartmentInfo{
+		Id:          strconv.Itoa(d.ID),
+		Code:        d.Code,
+		Name:        d.Name,
+		Description: d.Description,
+		SortOrder:   int32(d.SortOrder),
+		UserCount:   int32(len(d.Edges.Users)),
+		CreatedAt:   d.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   d.UpdatedAt.Format(time.RFC3339),
+	}
+
+	if d.Edges.Parent != nil {
+		info.ParentId = strconv.Itoa(d.Edges.Parent.ID)
+	}
+
+	return info
+}
@@ -0,0 +1,619 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"zera/ent"
+	"zera/ent/role"
+	"zera/ent/systemsetting"
+	"zera/ent/user"
+	"zera/gen/base"
+	"zera/internal/auth"
+	"zera/internal/logger"
+	"zera/internal/pinyin"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// LDAP 配置键名常量
+const (
+	SettingKeyLDAPConfig = "ldap.config"
+)
+
+// LDAP 相关错误
+var (
+	ErrLDAPNotEnabled        = errors.New("LDAP authentication is not enabled")
+	ErrLDAPInvalidCredential = errors.New("LDAP credential validation failed")
+	ErrLDAPUserCreateFailed  = errors.New("failed to create user from LDAP")
+	ErrLDAPConnectionFailed  = errors.New("failed to connect to LDAP server")
+)
+
+// LDAPConfig LDAP 配置结构
+type LDAPConfig struct {
+	Enabled         bool   `json:"enabled"`
+	ServerURL       string `json:"serverUrl"`       // ldap:// 或 ldaps:// 地址
+	BindDN          string `json:"bindDn"`          // 搜索用户的服务账号 DN
+	BindPassword    string `json:"bindPassword"`    // 服务账号密码
+	BaseDN          string `json:"baseDn"`          // 用户搜索起点
+	UserFilter      string `json:"userFilter"`      // 用户过滤器，%s 替换为用户名
+	UsernameAttr    string `json:"usernameAttr"`    // 用户名属性
+	EmailAttr       string `json:"emailAttr"`       // 邮箱属性
+	DisplayNameAttr string `json:"displayNameAttr"` // 显示名属性
+	DefaultRole     string `json:"defaultRole"`     // 默认角色
+	AutoCreateUser  bool   `json:"autoCreateUser"`  // 是否自动创建用户
+	SkipTLSVerify   bool   `json:"skipTlsVerify"`   // 是否跳过 TLS 证书校验
+}
+
+// applyDefaults 填充未配置的属性映射默认值
+func (c *LDAPConfig) applyDefaults() {
+	if c.UserFilter == "" {
+		c.UserFilter = "(uid=%s)"
+	}
+	if c.UsernameAttr == "" {
+		c.UsernameAttr = "uid"
+	}
+	if c.EmailAttr == "" {
+		c.EmailAttr = "mail"
+	}
+	if c.DisplayNameAttr == "" {
+		c.DisplayNameAttr = "displayName"
+	}
+}
+
+// LDAPUserInfo LDAP 用户信息 (从目录条目解析)
+type LDAPUserInfo struct {
+	Username    string
+	Email       string
+	DisplayName string
+	DN          string
+}
+
+// LDAPAuthService LDAP/AD 认证服务
+type LDAPAuthService struct {
+	client     *ent.Client
+	jwtManager *auth.JWTManager
+}
+
+// NewLDAPAuthService 创建 LDAP 认证服务
+func NewLDAPAuthService(client *ent.Client, jwtManager *auth.JWTManager) *LDAPAuthService {
+	return &LDAPAuthService{
+		client:     client,
+		jwtManager: jwtManager,
+	}
+}
+
+// ============================================
+// LDAP 配置管理
+// ============================================
+
+// GetLDAPConfig 获取 LDAP 配置
+func (s *LDAPAuthService) GetLDAPConfig(ctx context.Context) (*LDAPConfig, error) {
+	setting, err := s.client.SystemSetting.Query().
+		Where(systemsetting.Key(SettingKeyLDAPConfig)).
+		Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			// 返回默认配置
+			config := &LDAPConfig{
+				Enabled:        false,
+				DefaultRole:    "user",
+				AutoCreateUser: true,
+			}
+			config.applyDefaults()
+			return config, nil
+		}
+		return nil, err
+	}
+
+	var config LDAPConfig
+	if err := json.Unmarshal([]byte(setting.Value), &config); err != nil {
+		return nil, fmt.Errorf("failed to parse LDAP config: %w", err)
+	}
+	config.applyDefaults()
+
+	return &config, nil
+}
+
+// UpdateLDAPConfig 更新 LDAP 配置
+func (s *LDAPAuthService) UpdateLDAPConfig(ctx context.Context, config *LDAPConfig) error {
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to serialize LDAP config: %w", err)
+	}
+
+	// 尝试更新
+	n, err := s.client.SystemSetting.Update().
+		Where(systemsetting.Key(SettingKeyLDAPConfig)).
+		SetValue(string(configJSON)).
+		Save(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 如果没有更新任何记录，则创建新记录
+	if n == 0 {
+		_, err = s.client.SystemSetting.Create().
+			SetKey(SettingKeyLDAPConfig).
+			SetValue(string(configJSON)).
+			SetType("json").
+			SetGroup(SettingGroupSecurity).
+			SetDescription("LDAP/AD 认证配置").
+			Save(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ============================================
+// LDAP 认证流程
+// ============================================
+
+// Login 使用 LDAP 凭证登录
+// 先用服务账号搜索用户 DN，再用用户凭证 bind 校验，
+// 成功后按属性映射创建/更新本地用户并签发令牌
+func (s *LDAPAuthService) Login(ctx context.Context, username, password string) (*base.LDAPLoginResponse, error) {
+	config, err := s.GetLDAPConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !config.Enabled {
+		return nil, ErrLDAPNotEnabled
+	}
+
+	ldapUser, err := s.authenticate(config, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	// 创建或更新用户
+	u, isNewUser, err := s.CreateOrUpdateUser(ctx, config, ldapUser)
+	if err != nil {
+		return nil, err
+	}
+
+	// 检查用户状态，softban 用户仍允许登录但权限会被降级
+	if u.Status != user.StatusActive && u.Status != user.StatusSoftban {
+		return nil, ErrUserInactive
+	}
+
+	// 获取安全设置
+	settingService := NewSystemSettingService(s.client)
+	securitySettings, err := s.getSecuritySettings(ctx, settingService)
+	if err != nil {
+		return nil, err
+	}
+
+	// 获取用户角色和权限
+	roles, permissions, err := s.extractRolesAndPermissions(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	// 条款合规检查：与本地登录同一套降级规则，LDAP 用户同样不能绕过
+	permissions, needAcceptTerms, termsVersion, err := applyTermsDowngrade(ctx, s.client, u, permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	// 生成令牌
+	accessToken, err := s.jwtManager.GenerateAccessTokenWithExpire(u.ID, u.Username, roles, permissions, securitySettings.sessionTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, jti, err := s.jwtManager.GenerateRefreshToken(u.ID, u.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	// 记录刷新令牌，用于轮换和重放检测
+	if err := StoreRefreshToken(ctx, s.client, s.jwtManager, u.ID, jti); err != nil {
+		return nil, err
+	}
+
+	// 更新最后登录时间
+	_, err = u.Update().
+		SetLastLoginAt(time.Now()).
+		Save(ctx)
+	if err != nil {
+		// 非致命错误，继续
+	}
+
+	return &base.LDAPLoginResponse{
+		AccessToken:     accessToken,
+		RefreshToken:    refreshToken,
+		ExpiresIn:       int64(securitySettings.sessionTimeout * 60),
+		User:            s.buildUserInfo(u, permissions),
+		IsNewUser:       isNewUser,
+		NeedAcceptTerms: needAcceptTerms,
+		TermsVersion:    termsVersion,
+	}, nil
+}
+
+// authenticate 向 LDAP 服务器校验用户凭证
+// 返回按属性映射解析后的用户信息
+func (s *LDAPAuthService) authenticate(config *LDAPConfig, username, password string) (*LDAPUserInfo, error) {
+	conn, err := s.dial(config)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// 用服务账号 bind，搜索用户 DN
+	if config.BindDN != "" {
+		if err := conn.Bind(config.BindDN, config.BindPassword); err != nil {
+			return nil, fmt.Errorf("%w: bind DN 认证失败: %v", ErrLDAPConnectionFailed, err)
+		}
+	}
+
+	// 按过滤器搜索用户，用户名做转义防止过滤器注入
+	filter := strings.ReplaceAll(config.UserFilter, "%s", ldap.EscapeFilter(username))
+	searchReq := ldap.NewSearchRequest(
+		config.BaseDN,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		2, 10, false,
+		filter,
+		[]string{config.UsernameAttr, config.EmailAttr, config.DisplayNameAttr},
+		nil,
+	)
+
+	result, err := conn.Search(searchReq)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 搜索用户失败: %v", ErrLDAPConnectionFailed, err)
+	}
+	if len(result.Entries) == 0 {
+		return nil, ErrLDAPInvalidCredential
+	}
+	if len(result.Entries) > 1 {
+		logger.Warn("LDAP 用户过滤器匹配到多个条目", "username", username, "filter", filter)
+		return nil, ErrLDAPInvalidCredential
+	}
+
+	entry := result.Entries[0]
+
+	// 用用户自身的 DN 和密码 bind 校验凭证
+	if err := conn.Bind(entry.DN, password); err != nil {
+		return nil, ErrLDAPInvalidCredential
+	}
+
+	ldapUsername := entry.GetAttributeValue(config.UsernameAttr)
+	if ldapUsername == "" {
+		ldapUsername = username
+	}
+	displayName := entry.GetAttributeValue(config.DisplayNameAttr)
+	if displayName == "" {
+		displayName = ldapUsername
+	}
+
+	return &LDAPUserInfo{
+		Username:    ldapUsername,
+		Email:       entry.GetAttributeValue(config.EmailAttr),
+		DisplayName: displayName,
+		DN:          entry.DN,
+	}, nil
+}
+
+// dial 连接 LDAP 服务器
+func (s *LDAPAuthService) dial(config *LDAPConfig) (*ldap.Conn, error) {
+	opts := []ldap.DialOpt{}
+	if config.SkipTLSVerify {
+		opts = append(opts, ldap.DialWithTLSConfig(&tls.Config{InsecureSkipVerify: true}))
+	}
+
+	conn, err := ldap.DialURL(config.ServerURL, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLDAPConnectionFailed, err)
+	}
+	conn.SetTimeout(10 * time.Second)
+
+	return conn, nil
+}
+
+// CreateOrUpdateUser 根据 LDAP 用户信息创建或更新本地用户
+// 以 DN 作为 external_id 关联，复用 CAS 的创建/更新模式
+func (s *LDAPAuthService) CreateOrUpdateUser(ctx context.Context, config *LDAPConfig, ldapUser *LDAPUserInfo) (*ent.User, bool, error) {
+	// 先尝试通过 external_id 查找 LDAP 用户
+	u, err := s.client.User.Query().
+		Where(
+			user.AuthProviderEQ(user.AuthProviderLdap),
+			user.ExternalIDEQ(ldapUser.DN),
+		).
+		WithRoles(withActiveRolesAndPermissions).
+		Only(ctx)
+
+	if err == nil {
+		// 用户已存在，更新信息
+		update := u.Update()
+		if ldapUser.Email != "" && ldapUser.Email != u.Email {
+			// 检查邮箱是否被其他用户使用
+			exists, _ := s.client.User.Query().
+				Where(user.Email(ldapUser.Email), user.IDNEQ(u.ID)).
+				Exist(ctx)
+			if !exists {
+				update = update.SetEmail(ldapUser.Email)
+			}
+		}
+		if ldapUser.DisplayName != "" {
+			update = update.SetNickname(ldapUser.DisplayName).
+				SetPinyin(pinyin.Index(ldapUser.DisplayName))
+		}
+
+		u, err = update.Save(ctx)
+		if err != nil {
+			return nil, false, err
+		}
+
+		return s.reloadUser(ctx, u.ID, false)
+	}
+
+	if !ent.IsNotFound(err) {
+		return nil, false, err
+	}
+
+	// 用户不存在，检查是否允许自动创建
+	if !config.AutoCreateUser {
+		return nil, false, errors.New("user does not exist and auto-creation is disabled")
+	}
+
+	// 检查用户名冲突，已被其他认证来源占用时添加前缀
+	username := ldapUser.Username
+	exists, err := s.client.User.Query().
+		Where(user.Username(username)).
+		Exist(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	if exists {
+		logger.Info("LDAP 用户名已被占用，添加前缀",
+			"ldapUsername", ldapUser.Username,
+			"dn", ldapUser.DN,
+		)
+		username = "ldap_" + username
+	}
+
+	// 检查邮箱冲突
+	email := ldapUser.Email
+	if email == "" {
+		email = fmt.Sprintf("%s@ldap.local", username)
+	} else {
+		exists, _ := s.client.User.Query().
+			Where(user.Email(email)).
+			Exist(ctx)
+		if exists {
+			// 邮箱已被使用，生成一个唯一的邮箱
+			email = fmt.Sprintf("%s@ldap.local", username)
+		}
+	}
+
+	// 创建新用户
+	userCreate := s.client.User.Create().
+		SetUsername(username).
+		SetEmail(email).
+		SetPasswordHash("LDAP_USER_NO_PASSWORD"). // LDAP 用户没有本地密码
+		SetNickname(ldapUser.DisplayName).
+		SetPinyin(pinyin.Index(ldapUser.DisplayName)).
+		SetStatus(user.StatusActive).
+		SetAuthProvider(user.AuthProviderLdap).
+		SetExternalID(ldapUser.DN)
+
+	// 分配默认角色
+	if config.DefaultRole != "" {
+		defaultRole, err := s.client.Role.Query().
+			Where(role.Code(config.DefaultRole)).
+			Only(ctx)
+		if err == nil {
+			userCreate = userCreate.AddRoles(defaultRole)
+		}
+	}
+
+	u, err = userCreate.Save(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", ErrLDAPUserCreateFailed, err)
+	}
+
+	return s.reloadUser(ctx, u.ID, true)
+}
+
+// reloadUser 重新加载用户（带角色和权限）
+func (s *LDAPAuthService) reloadUser(ctx context.Context, id int, isNewUser bool) (*ent.User, bool, error) {
+	u, err := s.client.User.Query().
+		Where(user.ID(id)).
+		WithRoles(withActiveRolesAndPermissions).
+		Only(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	return u, isNewUser, nil
+}
+
+// GetPublicLDAPSettings 获取公开的 LDAP 设置
+func (s *LDAPAuthService) GetPublicLDAPSettings(ctx context.Context) (*base.GetPublicLDAPSettingsResponse, error) {
+	config, err := s.GetLDAPConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &base.GetPublicLDAPSettingsResponse{
+		LdapEnabled:     config.Enabled,
+		LoginButtonText: "使用域账号登录",
+	}, nil
+}
+
+// TestLDAPConnection 测试 LDAP 连接
+func (s *LDAPAuthService) TestLDAPConnection(ctx context.Context, config *LDAPConfig) (*base.TestLDAPConnectionResponse, error) {
+	if config == nil {
+		var err error
+		config, err = s.GetLDAPConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	config.applyDefaults()
+
+	if config.ServerURL == "" {
+		return &base.TestLDAPConnectionResponse{
+			Success:      false,
+			ErrorMessage: "LDAP server URL is not configured",
+		}, nil
+	}
+
+	conn, err := s.dial(config)
+	if err != nil {
+		return &base.TestLDAPConnectionResponse{
+			Success:      false,
+			ErrorMessage: fmt.Sprintf("failed to connect: %v", err),
+		}, nil
+	}
+	defer conn.Close()
+
+	// 配置了 Bind DN 时同时校验服务账号凭证
+	if config.BindDN != "" {
+		if err := conn.Bind(config.BindDN, config.BindPassword); err != nil {
+			return &base.TestLDAPConnectionResponse{
+				Success:      false,
+				ErrorMessage: fmt.Sprintf("bind failed: %v", err),
+			}, nil
+		}
+	}
+
+	return &base.TestLDAPConnectionResponse{
+		Success: true,
+	}, nil
+}
+
+// ============================================
+// 辅助方法
+// ============================================
+
+// getSecuritySettings 获取安全设置 (复用 CAS 的数据结构)
+func (s *LDAPAuthService) getSecuritySettings(ctx context.Context, settingService *SystemSettingService) (*casSecuritySettingsData, error) {
+	resp, err := settingService.GetAllSettings(ctx)
+	if err != nil {
+		return &casSecuritySettingsData{
+			sessionTimeout: 60,
+		}, nil
+	}
+
+	settings := &casSecuritySettingsData{
+		sessionTimeout: 60,
+	}
+
+	if resp.Settings != nil && resp.Settings.Security != nil {
+		if resp.Settings.Security.SessionTimeout > 0 {
+			settings.sessionTimeout = int(resp.Settings.Security.SessionTimeout)
+		}
+	}
+
+	return settings, nil
+}
+
+// extractRolesAndPermissions 从用户实体中提取角色和权限
+// 沿父角色链递归合并继承的权限；softban 用户的权限会被降级为只读
+func (s *LDAPAuthService) extractRolesAndPermissions(ctx context.Context, u *ent.User) ([]string, []string, error) {
+	roles := make([]string, 0, len(u.Edges.Roles))
+	permissionSet := make(map[string]bool)
+	isAdmin := false
+
+	for _, r := range u.Edges.Roles {
+		roles = append(roles, r.Code)
+		if r.Code == "admin" || r.Code == "super_admin" {
+			isAdmin = true
+		}
+		for _, p := range r.Edges.Permissions {
+			permissionSet[p.Code] = true
+		}
+	}
+
+	// 角色继承：递归合并父角色的权限
+	if err := mergeInheritedRolePermissions(ctx, s.client, u.Edges.Roles, permissionSet); err != nil {
+		return nil, nil, err
+	}
+
+	// 用户直接授予的权限（绕过角色的一次性授权）并入最终权限集合
+	if err := mergeDirectUserPermissions(ctx, s.client, u.ID, permissionSet); err != nil {
+		return nil, nil, err
+	}
+
+	// softban 用户仅保留只读权限，管理员也不例外
+	if u.Status == user.StatusSoftban {
+		return roles, filterReadOnlyPermissions(permissionSet), nil
+	}
+
+	if isAdmin {
+		return roles, []string{"*"}, nil
+	}
+
+	permissions := make([]string, 0, len(permissionSet))
+	for code := range permissionSet {
+		permissions = append(permissions, code)
+	}
+
+	return roles, permissions, nil
+}
+
+// buildUserInfo 构建用户信息
+func (s *LDAPAuthService) buildUserInfo(u *ent.User, permissions []string) *base.UserInfo {
+	roles := make([]string, 0, len(u.Edges.Roles))
+	for _, r := range u.Edges.Roles {
+		roles = append(roles, r.Code)
+	}
+
+	return &base.UserInfo{
+		Id:           strconv.Itoa(u.ID),
+		Username:     u.Username,
+		Nickname:     u.Nickname,
+		Avatar:       u.Avatar,
+		Email:        u.Email,
+		Roles:        roles,
+		Permissions:  permissions,
+		DefaultRoute: defaultRouteForUser(u),
+	}
+}
+
+// ConvertToLDAPConfigProto 转换 LDAPConfig 为 Proto 消息
+func ConvertToLDAPConfigProto(config *LDAPConfig) *base.LDAPConfig {
+	return &base.LDAPConfig{
+		Enabled:         config.Enabled,
+		ServerUrl:       config.ServerURL,
+		BindDn:          config.BindDN,
+		BindPassword:    config.BindPassword,
+		BaseDn:          config.BaseDN,
+		UserFilter:      config.UserFilter,
+		UsernameAttr:    config.UsernameAttr,
+		EmailAttr:       config.EmailAttr,
+		DisplayNameAttr: config.DisplayNameAttr,
+		DefaultRole:     config.DefaultRole,
+		AutoCreateUser:  config.AutoCreateUser,
+		SkipTlsVerify:   config.SkipTLSVerify,
+	}
+}
+
+// ConvertFromLDAPConfigProto 从 Proto 消息转换为 LDAPConfig
+func ConvertFromLDAPConfigProto(proto *base.LDAPConfig) *LDAPConfig {
+	return &LDAPConfig{
+		Enabled:         proto.Enabled,
+		ServerURL:       proto.ServerUrl,
+		BindDN:          proto.BindDn,
+		BindPassword:    proto.BindPassword,
+		BaseDN:          proto.BaseDn,
+		UserFilter:      proto.UserFilter,
+		UsernameAttr:    proto.UsernameAttr,
+		EmailAttr:       proto.EmailAttr,
+		DisplayNameAttr: proto.DisplayNameAttr,
+		DefaultRole:     proto.DefaultRole,
+		AutoCreateUser:  proto.AutoCreateUser,
+		SkipTLSVerify:   proto.SkipTlsVerify,
+	}
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// loginStatsWindow 登录失败率统计的滑动窗口长度
+const loginStatsWindow = 5 * time.Minute
+
+// loginStats 登录结果滑动窗口统计
+// 供业务健康指标读取最近窗口内的登录失败率
+type loginStats struct {
+	mu        sync.Mutex
+	successes []time.Time
+	failures  []time.Time
+}
+
+// record 记录一次登录结果
+func (s *loginStats) record(success bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.successes = pruneBefore(s.successes, now.Add(-loginStatsWindow))
+	s.failures = pruneBefore(s.failures, now.Add(-loginStatsWindow))
+	if success {
+		s.successes = append(s.successes, now)
+	} else {
+		s.failures = append(s.failures, now)
+	}
+}
+
+// failureRate 返回窗口内的登录失败率（0-1），窗口内无登录尝试时为 0
+func (s *loginStats) failureRate() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := time.Now().Add(-loginStatsWindow)
+	s.successes = pruneBefore(s.successes, cutoff)
+	s.failures = pruneBefore(s.failures, cutoff)
+
+	total := len(s.successes) + len(s.failures)
+	if total == 0 {
+		return 0
+	}
+	return float64(len(s.failures)) / float64(total)
+}
+
+// pruneBefore 移除截止时间之前的时间戳
+func pruneBefore(times []time.Time, cutoff time.Time) []time.Time {
+	recent := times[:0]
+	for _, t := range times {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	return recent
+}
+
+// LoginFailureRate 返回最近 5 分钟的登录失败率（0-1），供业务健康指标读取
+func (s *AuthService) LoginFailureRate() float64 {
+	return s.loginStats.failureRate()
+}
@@ -0,0 +1,851 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"zera/ent"
+	"zera/ent/role"
+	"zera/ent/systemsetting"
+	"zera/ent/user"
+	"zera/gen/base"
+	"zera/internal/auth"
+	"zera/internal/logger"
+	"zera/internal/pinyin"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OIDC 配置键名常量
+const (
+	SettingKeyOIDCConfig = "oidc.config"
+)
+
+// OIDC 相关错误
+var (
+	ErrOIDCNotEnabled       = errors.New("OIDC authentication is not enabled")
+	ErrOIDCStateInvalid     = errors.New("OIDC state is invalid or expired")
+	ErrOIDCExchangeFailed   = errors.New("failed to exchange authorization code")
+	ErrOIDCTokenInvalid     = errors.New("OIDC id_token validation failed")
+	ErrOIDCUserCreateFailed = errors.New("failed to create user from OIDC")
+	ErrOIDCDiscoveryFailed  = errors.New("failed to discover OIDC endpoints")
+)
+
+// OIDCConfig OIDC / OAuth2 配置结构
+type OIDCConfig struct {
+	Enabled         bool     `json:"enabled"`
+	Issuer          string   `json:"issuer"`          // Issuer 地址，用于发现端点和校验 id_token
+	ClientID        string   `json:"clientId"`        // OAuth2 Client ID
+	ClientSecret    string   `json:"clientSecret"`    // OAuth2 Client Secret
+	Scopes          []string `json:"scopes"`          // 请求的 scope 列表
+	RedirectURL     string   `json:"redirectUrl"`     // 授权回调地址
+	DefaultRole     string   `json:"defaultRole"`     // 默认角色
+	AutoCreateUser  bool     `json:"autoCreateUser"`  // 是否自动创建用户
+	LoginButtonText string   `json:"loginButtonText"` // 登录按钮文本
+}
+
+// applyDefaults 填充未配置的默认值
+func (c *OIDCConfig) applyDefaults() {
+	if len(c.Scopes) == 0 {
+		c.Scopes = []string{"openid", "profile", "email"}
+	}
+}
+
+// OIDCUserInfo OIDC 用户信息 (从 id_token claims 解析)
+type OIDCUserInfo struct {
+	Subject           string
+	Email             string
+	DisplayName       string
+	PreferredUsername string
+}
+
+// oidcDiscovery OIDC 发现文档中用到的端点
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcStateTTL 授权 state 的有效期，超时未回调则作废
+const oidcStateTTL = 10 * time.Minute
+
+// oidcDiscoveryTTL 发现文档与 JWKS 的缓存时长
+const oidcDiscoveryTTL = time.Hour
+
+// oidcStateStore 防 CSRF 的 state 一次性存储
+// 授权时下发随机 state，回调时消费，未命中或过期视为无效
+type oidcStateStore struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+func newOIDCStateStore() *oidcStateStore {
+	return &oidcStateStore{entries: make(map[string]time.Time)}
+}
+
+// Issue 生成并记录一个新 state
+func (s *oidcStateStore) Issue() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	state := hex.EncodeToString(buf)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 顺带清理过期条目，避免 map 无限增长
+	now := time.Now()
+	for k, exp := range s.entries {
+		if exp.Before(now) {
+			delete(s.entries, k)
+		}
+	}
+	s.entries[state] = now.Add(oidcStateTTL)
+	return state, nil
+}
+
+// Consume 消费 state（一次性），有效返回 true
+func (s *oidcStateStore) Consume(state string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	exp, ok := s.entries[state]
+	if !ok {
+		return false
+	}
+	delete(s.entries, state)
+	return exp.After(time.Now())
+}
+
+// OIDCAuthService 通用 OIDC / OAuth2 认证服务
+// 支持任意标准 OIDC 提供方（Google、Azure AD、Authentik 等），
+// 通过发现文档获取端点，authorization code 换令牌后校验 id_token 签名与 claims
+type OIDCAuthService struct {
+	client     *ent.Client
+	jwtManager *auth.JWTManager
+	httpClient *http.Client
+	states     *oidcStateStore
+
+	// 发现文档与 JWKS 缓存（按 issuer 失效）
+	discoveryMu     sync.Mutex
+	discoveryIssuer string
+	discovery       *oidcDiscovery
+	jwks            map[string]*rsa.PublicKey
+	discoveredAt    time.Time
+}
+
+// NewOIDCAuthService 创建 OIDC 认证服务
+func NewOIDCAuthService(client *ent.Client, jwtManager *auth.JWTManager) *OIDCAuthService {
+	return &OIDCAuthService{
+		client:     client,
+		jwtManager: jwtManager,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		states:     newOIDCStateStore(),
+	}
+}
+
+// ============================================
+// OIDC 配置管理
+// ============================================
+
+// GetOIDCConfig 获取 OIDC 配置
+func (s *OIDCAuthService) GetOIDCConfig(ctx context.Context) (*OIDCConfig, error) {
+	setting, err := s.client.SystemSetting.Query().
+		Where(systemsetting.Key(SettingKeyOIDCConfig)).
+		Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			// 返回默认配置
+			config := &OIDCConfig{
+				Enabled:        false,
+				DefaultRole:    "user",
+				AutoCreateUser: true,
+			}
+			config.applyDefaults()
+			return config, nil
+		}
+		return nil, err
+	}
+
+	var config OIDCConfig
+	if err := json.Unmarshal([]byte(setting.Value), &config); err != nil {
+		return nil, fmt.Errorf("failed to parse OIDC config: %w", err)
+	}
+	config.applyDefaults()
+
+	return &config, nil
+}
+
+// UpdateOIDCConfig 更新 OIDC 配置
+func (s *OIDCAuthService) UpdateOIDCConfig(ctx context.Context, config *OIDCConfig) error {
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to serialize OIDC config: %w", err)
+	}
+
+	// 尝试更新
+	n, err := s.client.SystemSetting.Update().
+		Where(systemsetting.Key(SettingKeyOIDCConfig)).
+		SetValue(string(configJSON)).
+		Save(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 如果没有更新任何记录，则创建新记录
+	if n == 0 {
+		_, err = s.client.SystemSetting.Create().
+			SetKey(SettingKeyOIDCConfig).
+			SetValue(string(configJSON)).
+			SetType("json").
+			SetGroup(SettingGroupSecurity).
+			SetDescription("OIDC / OAuth2 认证配置").
+			Save(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	// issuer 可能变化，失效发现缓存
+	s.discoveryMu.Lock()
+	s.discovery = nil
+	s.jwks = nil
+	s.discoveryMu.Unlock()
+
+	return nil
+}
+
+// ============================================
+// OIDC 认证流程
+// ============================================
+
+// GetAuthURL 生成授权地址
+// redirectURI 为空时使用配置中的回调地址，state 由服务端生成并在回调时校验
+func (s *OIDCAuthService) GetAuthURL(ctx context.Context, redirectURI string) (*base.GetOIDCAuthURLResponse, error) {
+	config, err := s.GetOIDCConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !config.Enabled {
+		return nil, ErrOIDCNotEnabled
+	}
+
+	discovery, err := s.discover(ctx, config.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	if redirectURI == "" {
+		redirectURI = config.RedirectURL
+	}
+
+	state, err := s.states.Issue()
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", config.ClientID)
+	params.Set("redirect_uri", redirectURI)
+	params.Set("scope", strings.Join(config.Scopes, " "))
+	params.Set("state", state)
+
+	sep := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+
+	return &base.GetOIDCAuthURLResponse{
+		AuthUrl: discovery.AuthorizationEndpoint + sep + params.Encode(),
+		State:   state,
+	}, nil
+}
+
+// HandleCallback 处理授权回调
+// 校验 state 后用授权码换取令牌，验证 id_token 签名与 claims，
+// 按 sub 创建或关联本地用户并签发本系统令牌
+func (s *OIDCAuthService) HandleCallback(ctx context.Context, code, state, redirectURI string) (*base.OIDCCallbackResponse, error) {
+	config, err := s.GetOIDCConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !config.Enabled {
+		return nil, ErrOIDCNotEnabled
+	}
+
+	if !s.states.Consume(state) {
+		return nil, ErrOIDCStateInvalid
+	}
+
+	if redirectURI == "" {
+		redirectURI = config.RedirectURL
+	}
+
+	idToken, err := s.exchangeCode(ctx, config, code, redirectURI)
+	if err != nil {
+		return nil, err
+	}
+
+	oidcUser, err := s.validateIDToken(ctx, config, idToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// 创建或更新用户
+	u, isNewUser, err := s.CreateOrUpdateUser(ctx, config, oidcUser)
+	if err != nil {
+		return nil, err
+	}
+
+	// 检查用户状态，softban 用户仍允许登录但权限会被降级
+	if u.Status != user.StatusActive && u.Status != user.StatusSoftban {
+		return nil, ErrUserInactive
+	}
+
+	// 获取安全设置
+	settingService := NewSystemSettingService(s.client)
+	securitySettings, err := s.getSecuritySettings(ctx, settingService)
+	if err != nil {
+		return nil, err
+	}
+
+	// 获取用户角色和权限
+	roles, permissions, err := s.extractRolesAndPermissions(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	// 条款合规检查：与本地登录同一套降级规则，OIDC 用户同样不能绕过
+	permissions, needAcceptTerms, termsVersion, err := applyTermsDowngrade(ctx, s.client, u, permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	// 生成令牌
+	accessToken, err := s.jwtManager.GenerateAccessTokenWithExpire(u.ID, u.Username, roles, permissions, securitySettings.sessionTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, jti, err := s.jwtManager.GenerateRefreshToken(u.ID, u.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	// 记录刷新令牌，用于轮换和重放检测
+	if err := StoreRefreshToken(ctx, s.client, s.jwtManager, u.ID, jti); err != nil {
+		return nil, err
+	}
+
+	// 更新最后登录时间
+	_, err = u.Update().
+		SetLastLoginAt(time.Now()).
+		Save(ctx)
+	if err != nil {
+		// 非致命错误，继续
+	}
+
+	return &base.OIDCCallbackResponse{
+		AccessToken:     accessToken,
+		RefreshToken:    refreshToken,
+		ExpiresIn:       int64(securitySettings.sessionTimeout * 60),
+		User:            s.buildUserInfo(u, permissions),
+		IsNewUser:       isNewUser,
+		NeedAcceptTerms: needAcceptTerms,
+		TermsVersion:    termsVersion,
+	}, nil
+}
+
+// discover 获取并缓存 OIDC 发现文档
+func (s *OIDCAuthService) discover(ctx context.Context, issuer string) (*oidcDiscovery, error) {
+	s.discoveryMu.Lock()
+	defer s.discoveryMu.Unlock()
+
+	if s.discovery != nil && s.discoveryIssuer == issuer && time.Since(s.discoveredAt) < oidcDiscoveryTTL {
+		return s.discovery, nil
+	}
+
+	wellKnown := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCDiscoveryFailed, err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCDiscoveryFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: discovery endpoint returned %d", ErrOIDCDiscoveryFailed, resp.StatusCode)
+	}
+
+	var discovery oidcDiscovery
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&discovery); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCDiscoveryFailed, err)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: discovery document is incomplete", ErrOIDCDiscoveryFailed)
+	}
+
+	s.discovery = &discovery
+	s.discoveryIssuer = issuer
+	s.jwks = nil // 端点可能变化，JWKS 随发现文档重新拉取
+	s.discoveredAt = time.Now()
+
+	return s.discovery, nil
+}
+
+// exchangeCode 用授权码向 token_endpoint 换取令牌，返回 id_token
+func (s *OIDCAuthService) exchangeCode(ctx context.Context, config *OIDCConfig, code, redirectURI string) (string, error) {
+	discovery, err := s.discover(ctx, config.Issuer)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("client_id", config.ClientID)
+	form.Set("client_secret", config.ClientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrOIDCExchangeFailed, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrOIDCExchangeFailed, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrOIDCExchangeFailed, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		logger.Warn("OIDC token exchange failed",
+			"status", resp.StatusCode,
+			"body", string(body),
+		)
+		return "", fmt.Errorf("%w: token endpoint returned %d", ErrOIDCExchangeFailed, resp.StatusCode)
+	}
+
+	var tokenResp struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrOIDCExchangeFailed, err)
+	}
+	if tokenResp.IDToken == "" {
+		return "", fmt.Errorf("%w: response does not contain id_token", ErrOIDCExchangeFailed)
+	}
+
+	return tokenResp.IDToken, nil
+}
+
+// validateIDToken 校验 id_token 签名与 claims，解析用户信息
+// 签名用 JWKS 公钥验证 (RS256)，并检查 iss 与 aud
+func (s *OIDCAuthService) validateIDToken(ctx context.Context, config *OIDCConfig, idToken string) (*OIDCUserInfo, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return s.signingKey(ctx, config.Issuer, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(config.Issuer),
+		jwt.WithAudience(config.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCTokenInvalid, err)
+	}
+
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return nil, fmt.Errorf("%w: missing sub claim", ErrOIDCTokenInvalid)
+	}
+
+	email, _ := claims["email"].(string)
+	displayName, _ := claims["name"].(string)
+	preferredUsername, _ := claims["preferred_username"].(string)
+
+	return &OIDCUserInfo{
+		Subject:           sub,
+		Email:             email,
+		DisplayName:       displayName,
+		PreferredUsername: preferredUsername,
+	}, nil
+}
+
+// signingKey 按 kid 返回验签公钥，缓存未命中时重新拉取 JWKS（应对密钥轮换）
+func (s *OIDCAuthService) signingKey(ctx context.Context, issuer, kid string) (*rsa.PublicKey, error) {
+	s.discoveryMu.Lock()
+	if s.jwks != nil && s.discoveryIssuer == issuer {
+		if key, ok := s.jwks[kid]; ok {
+			s.discoveryMu.Unlock()
+			return key, nil
+		}
+	}
+	s.discoveryMu.Unlock()
+
+	discovery, err := s.discover(ctx, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := s.fetchJWKS(ctx, discovery.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+
+	s.discoveryMu.Lock()
+	s.jwks = keys
+	key, ok := keys[kid]
+	s.discoveryMu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: signing key %q not found in JWKS", ErrOIDCTokenInvalid, kid)
+	}
+	return key, nil
+}
+
+// fetchJWKS 拉取 JWKS 并解析其中的 RSA 公钥，按 kid 索引
+func (s *OIDCAuthService) fetchJWKS(ctx context.Context, jwksURI string) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCDiscoveryFailed, err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCDiscoveryFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: JWKS endpoint returned %d", ErrOIDCDiscoveryFailed, resp.StatusCode)
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&jwks); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCDiscoveryFailed, err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" || k.N == "" || k.E == "" {
+			continue
+		}
+		nBytes, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		eBytes, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(nBytes),
+			E: int(new(big.Int).SetBytes(eBytes).Int64()),
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: no usable RSA keys in JWKS", ErrOIDCDiscoveryFailed)
+	}
+
+	return keys, nil
+}
+
+// CreateOrUpdateUser 根据 OIDC 用户信息创建或更新本地用户
+// 以 sub 作为 external_id 关联，复用 CAS/LDAP 的创建/更新与用户名冲突处理模式
+func (s *OIDCAuthService) CreateOrUpdateUser(ctx context.Context, config *OIDCConfig, oidcUser *OIDCUserInfo) (*ent.User, bool, error) {
+	// 先尝试通过 external_id 查找 OIDC 用户
+	u, err := s.client.User.Query().
+		Where(
+			user.AuthProviderEQ(user.AuthProviderOidc),
+			user.ExternalIDEQ(oidcUser.Subject),
+		).
+		WithRoles(withActiveRolesAndPermissions).
+		Only(ctx)
+
+	if err == nil {
+		// 用户已存在，更新信息
+		update := u.Update()
+		if oidcUser.Email != "" && oidcUser.Email != u.Email {
+			// 检查邮箱是否被其他用户使用
+			exists, _ := s.client.User.Query().
+				Where(user.Email(oidcUser.Email), user.IDNEQ(u.ID)).
+				Exist(ctx)
+			if !exists {
+				update = update.SetEmail(oidcUser.Email)
+			}
+		}
+		if oidcUser.DisplayName != "" {
+			update = update.SetNickname(oidcUser.DisplayName).
+				SetPinyin(pinyin.Index(oidcUser.DisplayName))
+		}
+
+		u, err = update.Save(ctx)
+		if err != nil {
+			return nil, false, err
+		}
+
+		return s.reloadUser(ctx, u.ID, false)
+	}
+
+	if !ent.IsNotFound(err) {
+		return nil, false, err
+	}
+
+	// 用户不存在，检查是否允许自动创建
+	if !config.AutoCreateUser {
+		return nil, false, errors.New("user does not exist and auto-creation is disabled")
+	}
+
+	// 选择用户名：优先 preferred_username，其次邮箱本地部分，最后用 sub 派生
+	username := oidcUser.PreferredUsername
+	if username == "" && oidcUser.Email != "" {
+		username = strings.SplitN(oidcUser.Email, "@", 2)[0]
+	}
+	if username == "" {
+		subSuffix := oidcUser.Subject
+		if len(subSuffix) > 8 {
+			subSuffix = subSuffix[:8]
+		}
+		username = "oidc_" + subSuffix
+	}
+
+	// 检查用户名冲突，已被其他认证来源占用时添加前缀
+	exists, err := s.client.User.Query().
+		Where(user.Username(username)).
+		Exist(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	if exists {
+		logger.Info("OIDC 用户名已被占用，添加前缀",
+			"oidcUsername", username,
+			"sub", oidcUser.Subject,
+		)
+		username = "oidc_" + username
+	}
+
+	// 检查邮箱冲突
+	email := oidcUser.Email
+	if email == "" {
+		email = fmt.Sprintf("%s@oidc.local", username)
+	} else {
+		exists, _ := s.client.User.Query().
+			Where(user.Email(email)).
+			Exist(ctx)
+		if exists {
+			// 邮箱已被使用，生成一个唯一的邮箱
+			email = fmt.Sprintf("%s@oidc.local", username)
+		}
+	}
+
+	displayName := oidcUser.DisplayName
+	if displayName == "" {
+		displayName = username
+	}
+
+	// 创建新用户
+	userCreate := s.client.User.Create().
+		SetUsername(username).
+		SetEmail(email).
+		SetPasswordHash("OIDC_USER_NO_PASSWORD"). // OIDC 用户没有本地密码
+		SetNickname(displayName).
+		SetPinyin(pinyin.Index(displayName)).
+		SetStatus(user.StatusActive).
+		SetAuthProvider(user.AuthProviderOidc).
+		SetExternalID(oidcUser.Subject)
+
+	// 分配默认角色
+	if config.DefaultRole != "" {
+		defaultRole, err := s.client.Role.Query().
+			Where(role.Code(config.DefaultRole)).
+			Only(ctx)
+		if err == nil {
+			userCreate = userCreate.AddRoles(defaultRole)
+		}
+	}
+
+	u, err = userCreate.Save(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", ErrOIDCUserCreateFailed, err)
+	}
+
+	return s.reloadUser(ctx, u.ID, true)
+}
+
+// reloadUser 重新加载用户（带角色和权限）
+func (s *OIDCAuthService) reloadUser(ctx context.Context, id int, isNewUser bool) (*ent.User, bool, error) {
+	u, err := s.client.User.Query().
+		Where(user.ID(id)).
+		WithRoles(withActiveRolesAndPermissions).
+		Only(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	return u, isNewUser, nil
+}
+
+// GetPublicOIDCSettings 获取公开的 OIDC 设置
+func (s *OIDCAuthService) GetPublicOIDCSettings(ctx context.Context) (*base.GetPublicOIDCSettingsResponse, error) {
+	config, err := s.GetOIDCConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &base.GetPublicOIDCSettingsResponse{
+		OidcEnabled:     config.Enabled,
+		LoginButtonText: config.LoginButtonText,
+	}, nil
+}
+
+// ============================================
+// 辅助方法
+// ============================================
+
+// getSecuritySettings 获取安全设置 (复用 CAS 的数据结构)
+func (s *OIDCAuthService) getSecuritySettings(ctx context.Context, settingService *SystemSettingService) (*casSecuritySettingsData, error) {
+	resp, err := settingService.GetAllSettings(ctx)
+	if err != nil {
+		return &casSecuritySettingsData{
+			sessionTimeout: 60,
+		}, nil
+	}
+
+	settings := &casSecuritySettingsData{
+		sessionTimeout: 60,
+	}
+
+	if resp.Settings != nil && resp.Settings.Security != nil {
+		if resp.Settings.Security.SessionTimeout > 0 {
+			settings.sessionTimeout = int(resp.Settings.Security.SessionTimeout)
+		}
+	}
+
+	return settings, nil
+}
+
+// extractRolesAndPermissions 从用户实体中提取角色和权限
+// 沿父角色链递归合并继承的权限；softban 用户的权限会被降级为只读
+func (s *OIDCAuthService) extractRolesAndPermissions(ctx context.Context, u *ent.User) ([]string, []string, error) {
+	roles := make([]string, 0, len(u.Edges.Roles))
+	permissionSet := make(map[string]bool)
+	isAdmin := false
+
+	for _, r := range u.Edges.Roles {
+		roles = append(roles, r.Code)
+		if r.Code == "admin" || r.Code == "super_admin" {
+			isAdmin = true
+		}
+		for _, p := range r.Edges.Permissions {
+			permissionSet[p.Code] = true
+		}
+	}
+
+	// 角色继承：递归合并父角色的权限
+	if err := mergeInheritedRolePermissions(ctx, s.client, u.Edges.Roles, permissionSet); err != nil {
+		return nil, nil, err
+	}
+
+	// 用户直接授予的权限（绕过角色的一次性授权）并入最终权限集合
+	if err := mergeDirectUserPermissions(ctx, s.client, u.ID, permissionSet); err != nil {
+		return nil, nil, err
+	}
+
+	// softban 用户仅保留只读权限，管理员也不例外
+	if u.Status == user.StatusSoftban {
+		return roles, filterReadOnlyPermissions(permissionSet), nil
+	}
+
+	if isAdmin {
+		return roles, []string{"*"}, nil
+	}
+
+	permissions := make([]string, 0, len(permissionSet))
+	for code := range permissionSet {
+		permissions = append(permissions, code)
+	}
+
+	return roles, permissions, nil
+}
+
+// buildUserInfo 构建用户信息
+func (s *OIDCAuthService) buildUserInfo(u *ent.User, permissions []string) *base.UserInfo {
+	roles := make([]string, 0, len(u.Edges.Roles))
+	for _, r := range u.Edges.Roles {
+		roles = append(roles, r.Code)
+	}
+
+	return &base.UserInfo{
+		Id:           strconv.Itoa(u.ID),
+		Username:     u.Username,
+		Nickname:     u.Nickname,
+		Avatar:       u.Avatar,
+		Email:        u.Email,
+		Roles:        roles,
+		Permissions:  permissions,
+		DefaultRoute: defaultRouteForUser(u),
+	}
+}
+
+// ConvertToOIDCConfigProto 转换 OIDCConfig 为 Proto 消息
+func ConvertToOIDCConfigProto(config *OIDCConfig) *base.OIDCConfig {
+	return &base.OIDCConfig{
+		Enabled:         config.Enabled,
+		Issuer:          config.Issuer,
+		ClientId:        config.ClientID,
+		ClientSecret:    config.ClientSecret,
+		Scopes:          config.Scopes,
+		RedirectUrl:     config.RedirectURL,
+		DefaultRole:     config.DefaultRole,
+		AutoCreateUser:  config.AutoCreateUser,
+		LoginButtonText: config.LoginButtonText,
+	}
+}
+
+// ConvertFromOIDCConfigProto 从 Proto 消息转换为 OIDCConfig
+func ConvertFromOIDCConfigProto(proto *base.OIDCConfig) *OIDCConfig {
+	return &OIDCConfig{
+		Enabled:         proto.Enabled,
+		Issuer:          proto.Issuer,
+		ClientID:        proto.ClientId,
+		ClientSecret:    proto.ClientSecret,
+		Scopes:          proto.Scopes,
+		RedirectURL:     proto.RedirectUrl,
+		DefaultRole:     proto.DefaultRole,
+		AutoCreateUser:  proto.AutoCreateUser,
+		LoginButtonText: proto.LoginButtonText,
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"zera/ent/user"
+	"zera/gen/base"
+	"zera/internal/cache"
+)
+
+// defaultOnlineWindow 在线判定的默认活跃窗口
+const defaultOnlineWindow = 5 * time.Minute
+
+// SetOnlineTracker 注入在线用户追踪器
+// 追踪器由权限拦截器更新，服务启动时统一装配
+func (s *UserService) SetOnlineTracker(t *cache.OnlineTracker) {
+	s.onlineTracker = t
+}
+
+// ActiveSessionCount 返回默认在线窗口内的活跃会话数，供业务健康指标读取
+func (s *UserService) ActiveSessionCount() int64 {
+	if s.onlineTracker == nil {
+		return 0
+	}
+	return int64(len(s.onlineTracker.ActiveSince(defaultOnlineWindow)))
+}
+
+// GetOnlineUsers 获取窗口期内有活跃请求的在线用户列表
+func (s *UserService) GetOnlineUsers(ctx context.Context, req *base.GetOnlineUsersRequest) (*base.GetOnlineUsersResponse, error) {
+	window := time.Duration(req.WindowMinutes) * time.Minute
+	if window <= 0 {
+		window = defaultOnlineWindow
+	}
+
+	resp := &base.GetOnlineUsersResponse{Users: []*base.OnlineUser{}}
+	if s.onlineTracker == nil {
+		return resp, nil
+	}
+
+	active := s.onlineTracker.ActiveSince(window)
+	if len(active) == 0 {
+		return resp, nil
+	}
+
+	ids := make([]int, 0, len(active))
+	for id := range active {
+		ids = append(ids, id)
+	}
+
+	users, err := s.client.User.Query().
+		Where(user.IDIn(ids...)).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, u := range users {
+		resp.Users = append(resp.Users, &base.OnlineUser{
+			Id:         strconv.Itoa(u.ID),
+			Username:   u.Username,
+			Nickname:   u.Nickname,
+			Avatar:     u.Avatar,
+			LastSeenAt: active[u.ID].Format(time.RFC3339),
+		})
+	}
+
+	// 按最近活跃时间倒序
+	sort.Slice(resp.Users, func(i, j int) bool {
+		return resp.Users[i].LastSeenAt > resp.Users[j].LastSeenAt
+	})
+
+	resp.Total = int64(len(resp.Users))
+	return resp, nil
+}
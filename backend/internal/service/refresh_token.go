@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"zera/ent"
+	"zera/ent/refreshtoken"
+	"zera/ent/user"
+	"zera/internal/auth"
+	"zera/internal/logger"
+)
+
+// StoreRefreshToken 记录新签发的刷新令牌
+// 同时顺带清理该用户已过期的令牌记录，避免表无限增长
+func StoreRefreshToken(ctx context.Context, client *ent.Client, jwtManager *auth.JWTManager, userID int, jti string) error {
+	return StoreRefreshTokenForClient(ctx, client, jwtManager, userID, jti, jti, "")
+}
+
+// StoreRefreshTokenForClient 记录按客户端类型签发的刷新令牌
+// 记录的过期时间与令牌自身有效期保持一致；
+// familyID 标识同一次登录轮换出的令牌链，登录时传首个令牌的 jti，轮换时沿用旧令牌的家族
+func StoreRefreshTokenForClient(ctx context.Context, client *ent.Client, jwtManager *auth.JWTManager, userID int, jti, familyID, clientType string) error {
+	expiresAt := time.Now().Add(jwtManager.ClientRefreshTokenExpire(clientType))
+
+	_, err := client.RefreshToken.Create().
+		SetTokenID(jti).
+		SetFamilyID(familyID).
+		SetExpiresAt(expiresAt).
+		SetUserID(userID).
+		Save(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 清理已过期的令牌记录
+	_, err = client.RefreshToken.Delete().
+		Where(
+			refreshtoken.HasUserWith(user.ID(userID)),
+			refreshtoken.ExpiresAtLT(time.Now()),
+		).
+		Exec(ctx)
+	if err != nil {
+		// 清理失败不影响签发流程
+		logger.Warn("failed to clean up expired refresh tokens", "userID", userID, "error", err)
+	}
+
+	return nil
+}
+
+// ConsumeRefreshToken 消费刷新令牌（一次性使用）
+// 令牌有效时将其标记为已使用并返回所属家族标识；
+// 若检测到已使用的令牌被再次使用（重放攻击迹象），吊销该令牌所在的整个家族并返回 ErrRefreshTokenReused
+func ConsumeRefreshToken(ctx context.Context, client *ent.Client, userID int, jti string) (string, error) {
+	// 旧版令牌不含 jti，视为无效，强制重新登录
+	if jti == "" {
+		return "", ErrInvalidToken
+	}
+
+	rt, err := client.RefreshToken.Query().
+		Where(refreshtoken.TokenID(jti)).
+		Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return "", ErrInvalidToken
+		}
+		return "", err
+	}
+
+	if rt.Revoked {
+		return "", ErrInvalidToken
+	}
+
+	if rt.Used {
+		// 已使用的令牌被再次使用，疑似重放攻击：吊销该令牌家族并告警
+		// 旧版记录无家族标识，退化为吊销该用户所有刷新令牌
+		if rt.FamilyID != "" {
+			if err := RevokeRefreshTokenFamily(ctx, client, rt.FamilyID); err != nil {
+				logger.Error("failed to revoke refresh token family after reuse detected",
+					"userID", userID,
+					"familyID", rt.FamilyID,
+					"error", err,
+				)
+			}
+		} else {
+			if err := RevokeUserRefreshTokens(ctx, client, userID); err != nil {
+				logger.Error("failed to revoke refresh tokens after reuse detected",
+					"userID", userID,
+					"error", err,
+				)
+			}
+		}
+		logger.Warn("refresh token reuse detected, token family revoked",
+			"userID", userID,
+			"tokenID", jti,
+			"familyID", rt.FamilyID,
+		)
+		return "", ErrRefreshTokenReused
+	}
+
+	if rt.ExpiresAt.Before(time.Now()) {
+		return "", ErrInvalidToken
+	}
+
+	// 标记为已使用
+	if err := rt.Update().SetUsed(true).Exec(ctx); err != nil {
+		return "", err
+	}
+	return rt.FamilyID, nil
+}
+
+// RevokeRefreshTokenFamily 吊销同一登录会话轮换出的所有刷新令牌
+func RevokeRefreshTokenFamily(ctx context.Context, client *ent.Client, familyID string) error {
+	_, err := client.RefreshToken.Update().
+		Where(refreshtoken.FamilyID(familyID)).
+		SetRevoked(true).
+		Save(ctx)
+	return err
+}
+
+// RevokeUserRefreshTokens 吊销指定用户的所有刷新令牌
+func RevokeUserRefreshTokens(ctx context.Context, client *ent.Client, userID int) error {
+	_, err := client.RefreshToken.Update().
+		Where(refreshtoken.HasUserWith(user.ID(userID))).
+		SetRevoked(true).
+		Save(ctx)
+	return err
+}
@@ -2,14 +2,20 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"zera/ent"
-	"zera/ent/permission"
+	entPermission "zera/ent/permission"
 	"zera/ent/role"
 	"zera/gen/base"
+	"zera/internal/logger"
+	"zera/internal/permission"
 )
 
 var (
@@ -19,6 +25,18 @@ var (
 	ErrRoleExists = errors.New("role already exists")
 	// ErrRoleIsSystem 系统角色不可删除
 	ErrRoleIsSystem = errors.New("system role cannot be deleted")
+	// ErrInvalidImportData 导入数据格式无效
+	ErrInvalidImportData = errors.New("invalid import data")
+	// ErrInvalidPermissionCodes 请求包含不存在的权限代码
+	ErrInvalidPermissionCodes = errors.New("invalid permission codes")
+	// ErrSensitivePermission 敏感权限仅允许分配给系统角色
+	ErrSensitivePermission = errors.New("sensitive permissions can only be assigned to system roles")
+	// ErrRoleCycle 角色继承关系出现环
+	ErrRoleCycle = errors.New("role inheritance cycle detected")
+	// ErrRoleHasChildren 角色存在子角色，不能直接删除
+	ErrRoleHasChildren = errors.New("role has child roles")
+	// ErrRoleInUse 角色仍关联用户，非强制删除时拒绝
+	ErrRoleInUse = errors.New("role is still assigned to users")
 )
 
 // RoleService 角色管理服务
@@ -34,10 +52,16 @@ func NewRoleService(client *ent.Client) *RoleService {
 }
 
 // ListRoles 获取角色列表
+// 用户数量用聚合 count 查询统计，不加载用户实体，
+// 避免角色关联大量用户时把整张用户表拉进内存
 func (s *RoleService) ListRoles(ctx context.Context, req *base.ListRolesRequest) (*base.ListRolesResponse, error) {
 	query := s.client.Role.Query().
-		WithPermissions().
-		WithUsers()
+		WithPermissions()
+
+	// 默认过滤已软删除的角色
+	if !req.IncludeDeleted {
+		query = query.Where(role.DeletedAtIsNil())
+	}
 
 	// 搜索条件
 	if req.Keyword != "" {
@@ -82,10 +106,16 @@ func (s *RoleService) ListRoles(ctx context.Context, req *base.ListRolesRequest)
 		return nil, err
 	}
 
-	// 转换为响应
+	// 转换为响应，仅对当前页角色做用户计数
 	roleInfos := make([]*base.RoleInfo, 0, len(roles))
 	for _, r := range roles {
-		roleInfos = append(roleInfos, s.toRoleInfo(r))
+		count, err := r.QueryUsers().Count(ctx)
+		if err != nil {
+			return nil, err
+		}
+		info := s.toRoleInfo(r)
+		info.UserCount = int32(count)
+		roleInfos = append(roleInfos, info)
 	}
 
 	return &base.ListRolesResponse{
@@ -99,7 +129,7 @@ func (s *RoleService) ListRoles(ctx context.Context, req *base.ListRolesRequest)
 // GetRole 获取角色详情
 func (s *RoleService) GetRole(ctx context.Context, id int) (*base.GetRoleResponse, error) {
 	r, err := s.client.Role.Query().
-		Where(role.ID(id)).
+		Where(role.ID(id), role.DeletedAtIsNil()).
 		WithPermissions().
 		WithUsers().
 		Only(ctx)
@@ -126,12 +156,62 @@ func (s *RoleService) CreateRole(ctx context.Context, req *base.CreateRoleReques
 		return nil, ErrRoleExists
 	}
 
+	// 指定模板时，将模板权限集与请求中的权限合并（去重）
+	permCodes := req.Permissions
+	if req.Template != "" {
+		tpl := findRoleTemplate(req.Template)
+		if tpl == nil {
+			return nil, fmt.Errorf("%w: %s", ErrRoleTemplateNotFound, req.Template)
+		}
+		permCodes = mergePermissionCodes(tpl.Permissions, req.Permissions)
+	}
+
+	// 预先校验权限代码，避免创建出权限不完整的角色
+	rolePermissions, err := s.queryPermissionsByCodes(ctx, permCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	// 新建角色均为非系统角色，不允许携带敏感权限
+	if err := checkSensitiveAssignment(false, permCodes); err != nil {
+		return nil, err
+	}
+
+	// 解析数据范围，为空默认为全部
+	dataScope, err := parseDataScope(req.DataScope)
+	if err != nil {
+		return nil, err
+	}
+
+	// 父角色校验：父角色必须存在且未被软删除
+	// 新角色尚无子角色，此时设置父角色不会形成环
+	var parentID *int
+	if req.ParentId != "" {
+		pid, err := parseRoleParentID(req.ParentId)
+		if err != nil {
+			return nil, err
+		}
+		exists, err := s.client.Role.Query().
+			Where(role.ID(pid), role.DeletedAtIsNil()).
+			Exist(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("父角色不存在: %w", ErrRoleNotFound)
+		}
+		parentID = &pid
+	}
+
 	// 创建角色
 	create := s.client.Role.Create().
 		SetCode(req.Code).
 		SetName(req.Name).
 		SetDescription(req.Description).
-		SetSortOrder(int(req.SortOrder))
+		SetDefaultRoute(req.DefaultRoute).
+		SetDataScope(dataScope).
+		SetSortOrder(int(req.SortOrder)).
+		SetNillableParentID(parentID)
 
 	r, err := create.Save(ctx)
 	if err != nil {
@@ -139,19 +219,11 @@ func (s *RoleService) CreateRole(ctx context.Context, req *base.CreateRoleReques
 	}
 
 	// 分配权限
-	if len(req.Permissions) > 0 {
-		permissions, err := s.client.Permission.Query().
-			Where(permission.CodeIn(req.Permissions...)).
-			All(ctx)
+	if len(rolePermissions) > 0 {
+		_, err = r.Update().AddPermissions(rolePermissions...).Save(ctx)
 		if err != nil {
 			return nil, err
 		}
-		if len(permissions) > 0 {
-			_, err = r.Update().AddPermissions(permissions...).Save(ctx)
-			if err != nil {
-				return nil, err
-			}
-		}
 	}
 
 	// 重新查询以获取关联数据
@@ -171,7 +243,7 @@ func (s *RoleService) CreateRole(ctx context.Context, req *base.CreateRoleReques
 
 // UpdateRole 更新角色
 func (s *RoleService) UpdateRole(ctx context.Context, id int, req *base.UpdateRoleRequest) (*base.UpdateRoleResponse, error) {
-	r, err := s.client.Role.Query().Where(role.ID(id)).Only(ctx)
+	r, err := s.client.Role.Query().Where(role.ID(id), role.DeletedAtIsNil()).Only(ctx)
 	if err != nil {
 		if ent.IsNotFound(err) {
 			return nil, ErrRoleNotFound
@@ -190,6 +262,31 @@ func (s *RoleService) UpdateRole(ctx context.Context, id int, req *base.UpdateRo
 	if req.SortOrder != nil {
 		update = update.SetSortOrder(int(*req.SortOrder))
 	}
+	if req.DefaultRoute != nil {
+		update = update.SetDefaultRoute(*req.DefaultRoute)
+	}
+	if req.DataScope != nil {
+		dataScope, err := parseDataScope(*req.DataScope)
+		if err != nil {
+			return nil, err
+		}
+		update = update.SetDataScope(dataScope)
+	}
+	if req.ParentId != nil {
+		if *req.ParentId == "" {
+			update = update.ClearParentID()
+		} else {
+			pid, err := parseRoleParentID(*req.ParentId)
+			if err != nil {
+				return nil, err
+			}
+			// 阻止自引用与环形继承
+			if err := s.checkInheritanceCycle(ctx, id, pid); err != nil {
+				return nil, err
+			}
+			update = update.SetParentID(pid)
+		}
+	}
 
 	_, err = update.Save(ctx)
 	if err != nil {
@@ -198,15 +295,17 @@ func (s *RoleService) UpdateRole(ctx context.Context, id int, req *base.UpdateRo
 
 	// 更新权限
 	if len(req.Permissions) > 0 {
-		// 清除现有权限
-		_, err = r.Update().ClearPermissions().Save(ctx)
+		// 校验权限代码，不存在的直接报错而不是静默忽略
+		permissions, err := s.queryPermissionsByCodes(ctx, req.Permissions)
 		if err != nil {
 			return nil, err
 		}
-		// 添加新权限
-		permissions, err := s.client.Permission.Query().
-			Where(permission.CodeIn(req.Permissions...)).
-			All(ctx)
+		// 敏感权限仅允许分配给系统角色
+		if err := checkSensitiveAssignment(r.IsSystem, req.Permissions); err != nil {
+			return nil, err
+		}
+		// 清除现有权限
+		_, err = r.Update().ClearPermissions().Save(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -234,8 +333,16 @@ func (s *RoleService) UpdateRole(ctx context.Context, id int, req *base.UpdateRo
 }
 
 // DeleteRole 删除角色
-func (s *RoleService) DeleteRole(ctx context.Context, id int) error {
-	r, err := s.client.Role.Query().Where(role.ID(id)).Only(ctx)
+// 角色仍关联用户时默认拒绝删除，避免用户悄悄失去该角色；
+// force 为 true 时在同一事务内先解绑所有关联用户再删除
+func (s *RoleService) DeleteRole(ctx context.Context, id int, force bool) error {
+	tx, err := s.client.Tx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	r, err := tx.Role.Query().Where(role.ID(id), role.DeletedAtIsNil()).Only(ctx)
 	if err != nil {
 		if ent.IsNotFound(err) {
 			return ErrRoleNotFound
@@ -248,12 +355,158 @@ func (s *RoleService) DeleteRole(ctx context.Context, id int) error {
 		return ErrRoleIsSystem
 	}
 
-	err = s.client.Role.DeleteOneID(id).Exec(ctx)
+	// 存在未删除的子角色时不允许删除，避免子角色的继承链悬空
+	hasChildren, err := tx.Role.Query().
+		Where(role.ParentID(id), role.DeletedAtIsNil()).
+		Exist(ctx)
 	if err != nil {
 		return err
 	}
+	if hasChildren {
+		return ErrRoleHasChildren
+	}
 
-	return nil
+	// 仍有用户关联时默认拒绝，强制删除则先解绑全部用户
+	userCount, err := r.QueryUsers().Count(ctx)
+	if err != nil {
+		return err
+	}
+	if userCount > 0 {
+		if !force {
+			return fmt.Errorf("%w: %d user(s) still assigned", ErrRoleInUse, userCount)
+		}
+		if err := r.Update().ClearUsers().Exec(ctx); err != nil {
+			return err
+		}
+		logger.InfoContext(ctx, "role users detached before force delete",
+			"roleID", id,
+			"userCount", userCount,
+		)
+	}
+
+	// 软删除：标记删除时间，被软删除的角色在认证授权时不再参与权限计算
+	err = tx.Role.UpdateOneID(id).
+		SetDeletedAt(time.Now()).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CloneRole 克隆角色
+// 复制源角色的全部权限到新角色，新角色一律为非系统角色；
+// 角色创建与权限复制包在一个事务中，避免克隆出权限不完整的角色
+func (s *RoleService) CloneRole(ctx context.Context, sourceID int, newCode, newName string) (*base.CloneRoleResponse, error) {
+	tx, err := s.client.Tx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	source, err := tx.Role.Query().
+		Where(role.ID(sourceID), role.DeletedAtIsNil()).
+		WithPermissions().
+		Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, err
+	}
+
+	// 检查新角色代码是否已被占用（含已软删除的角色，code 全局唯一）
+	exists, err := tx.Role.Query().Where(role.Code(newCode)).Exist(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrRoleExists
+	}
+
+	// 新角色为非系统角色，源角色携带的敏感权限不允许复制过去
+	permCodes := make([]string, 0, len(source.Edges.Permissions))
+	for _, p := range source.Edges.Permissions {
+		permCodes = append(permCodes, p.Code)
+	}
+	if err := checkSensitiveAssignment(false, permCodes); err != nil {
+		return nil, err
+	}
+
+	create := tx.Role.Create().
+		SetCode(newCode).
+		SetName(newName).
+		SetDescription(source.Description).
+		SetDefaultRoute(source.DefaultRoute).
+		SetDataScope(source.DataScope).
+		SetSortOrder(source.SortOrder)
+	if len(source.Edges.Permissions) > 0 {
+		create = create.AddPermissions(source.Edges.Permissions...)
+	}
+
+	cloned, err := create.Save(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// 重新查询以获取关联数据
+	r, err := s.client.Role.Query().
+		Where(role.ID(cloned.ID)).
+		WithPermissions().
+		WithUsers().
+		Only(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "role cloned",
+		"sourceID", sourceID,
+		"roleID", r.ID,
+		"code", newCode,
+	)
+	return &base.CloneRoleResponse{
+		Role: s.toRoleInfo(r),
+	}, nil
+}
+
+// RestoreRole 恢复已软删除的角色
+// 保留的用户关联随恢复重新生效
+func (s *RoleService) RestoreRole(ctx context.Context, id int) (*base.RestoreRoleResponse, error) {
+	exists, err := s.client.Role.Query().
+		Where(role.ID(id), role.DeletedAtNotNil()).
+		Exist(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrRoleNotFound
+	}
+
+	err = s.client.Role.UpdateOneID(id).
+		ClearDeletedAt().
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 重新查询以获取关联数据
+	r, err := s.client.Role.Query().
+		Where(role.ID(id)).
+		WithPermissions().
+		WithUsers().
+		Only(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &base.RestoreRoleResponse{
+		Role: s.toRoleInfo(r),
+	}, nil
 }
 
 // ListPermissions 获取权限列表
@@ -262,11 +515,11 @@ func (s *RoleService) ListPermissions(ctx context.Context, req *base.ListPermiss
 
 	// 资源筛选
 	if req.Resource != "" {
-		query = query.Where(permission.Resource(req.Resource))
+		query = query.Where(entPermission.Resource(req.Resource))
 	}
 
 	// 排序
-	query = query.Order(ent.Asc(permission.FieldResource), ent.Asc(permission.FieldAction))
+	query = query.Order(ent.Asc(entPermission.FieldResource), ent.Asc(entPermission.FieldAction))
 
 	permissions, err := query.All(ctx)
 	if err != nil {
@@ -308,7 +561,7 @@ func (s *RoleService) ListPermissions(ctx context.Context, req *base.ListPermiss
 // GetRolePermissions 获取角色权限
 func (s *RoleService) GetRolePermissions(ctx context.Context, roleID int) (*base.GetRolePermissionsResponse, error) {
 	r, err := s.client.Role.Query().
-		Where(role.ID(roleID)).
+		Where(role.ID(roleID), role.DeletedAtIsNil()).
 		WithPermissions().
 		Only(ctx)
 	if err != nil {
@@ -318,12 +571,27 @@ func (s *RoleService) GetRolePermissions(ctx context.Context, roleID int) (*base
 		return nil, err
 	}
 
-	// 获取角色拥有的权限代码
+	// 直接授予该角色的权限代码
+	directSet := make(map[string]bool, len(r.Edges.Permissions))
 	permCodes := make([]string, 0, len(r.Edges.Permissions))
 	for _, p := range r.Edges.Permissions {
+		directSet[p.Code] = true
 		permCodes = append(permCodes, p.Code)
 	}
 
+	// 沿父角色链收集继承权限，排除已直接授予的，便于前端区分展示
+	inheritedSet := make(map[string]bool)
+	if err := mergeInheritedRolePermissions(ctx, s.client, []*ent.Role{r}, inheritedSet); err != nil {
+		return nil, err
+	}
+	inherited := make([]string, 0, len(inheritedSet))
+	for code := range inheritedSet {
+		if !directSet[code] {
+			inherited = append(inherited, code)
+		}
+	}
+	sort.Strings(inherited)
+
 	// 获取所有权限分组
 	allPermResp, err := s.ListPermissions(ctx, &base.ListPermissionsRequest{})
 	if err != nil {
@@ -331,44 +599,461 @@ func (s *RoleService) GetRolePermissions(ctx context.Context, roleID int) (*base
 	}
 
 	return &base.GetRolePermissionsResponse{
-		Permissions:         permCodes,
-		AllPermissionGroups: allPermResp.Groups,
+		Permissions:          permCodes,
+		AllPermissionGroups:  allPermResp.Groups,
+		InheritedPermissions: inherited,
 	}, nil
 }
 
+// checkInheritanceCycle 校验将 parentID 设为 roleID 的父角色是否会形成环
+// 从 parentID 沿父链向上遍历，途中遇到 roleID 或重复节点即为环；
+// 同时校验父角色存在且未被软删除
+func (s *RoleService) checkInheritanceCycle(ctx context.Context, roleID, parentID int) error {
+	visited := map[int]bool{roleID: true}
+	current := parentID
+	for {
+		if visited[current] {
+			return ErrRoleCycle
+		}
+		visited[current] = true
+		r, err := s.client.Role.Query().
+			Where(role.ID(current), role.DeletedAtIsNil()).
+			Only(ctx)
+		if err != nil {
+			if ent.IsNotFound(err) {
+				return fmt.Errorf("父角色不存在: %w", ErrRoleNotFound)
+			}
+			return err
+		}
+		if r.ParentID == nil {
+			return nil
+		}
+		current = *r.ParentID
+	}
+}
+
+// parseRoleParentID 解析字符串形式的父角色 ID
+func parseRoleParentID(id string) (int, error) {
+	pid, err := strconv.Atoi(id)
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("父角色不存在: %w", ErrRoleNotFound)
+	}
+	return pid, nil
+}
+
+// mergeInheritedRolePermissions 沿父角色链向上递归合并继承的权限
+// 子角色拥有父角色的全部权限；visited 集合兜底防止环形继承导致死循环
+// （环在写入时已被拦截），已软删除的父角色中断继承链
+func mergeInheritedRolePermissions(ctx context.Context, client *ent.Client, roles []*ent.Role, permissionSet map[string]bool) error {
+	visited := make(map[int]bool, len(roles))
+	for _, r := range roles {
+		visited[r.ID] = true
+	}
+	for _, r := range roles {
+		parentID := r.ParentID
+		for parentID != nil && !visited[*parentID] {
+			visited[*parentID] = true
+			parent, err := client.Role.Query().
+				Where(role.ID(*parentID), role.DeletedAtIsNil()).
+				WithPermissions().
+				Only(ctx)
+			if err != nil {
+				if ent.IsNotFound(err) {
+					break
+				}
+				return err
+			}
+			for _, p := range parent.Edges.Permissions {
+				permissionSet[p.Code] = true
+			}
+			parentID = parent.ParentID
+		}
+	}
+	return nil
+}
+
 // UpdateRolePermissions 更新角色权限
-func (s *RoleService) UpdateRolePermissions(ctx context.Context, roleID int, permissionCodes []string) error {
-	r, err := s.client.Role.Query().Where(role.ID(roleID)).Only(ctx)
+// 系统角色的权限变更不直接生效，创建变更请求等待另一管理员审批，返回请求ID；
+// 普通角色直接应用变更并返回 0
+func (s *RoleService) UpdateRolePermissions(ctx context.Context, operatorID, roleID int, permissionCodes []string) (int, error) {
+	r, err := s.client.Role.Query().Where(role.ID(roleID), role.DeletedAtIsNil()).Only(ctx)
 	if err != nil {
 		if ent.IsNotFound(err) {
-			return ErrRoleNotFound
+			return 0, ErrRoleNotFound
+		}
+		return 0, err
+	}
+
+	// 校验权限代码，不存在的直接报错而不是静默忽略
+	permissions, err := s.queryPermissionsByCodes(ctx, permissionCodes)
+	if err != nil {
+		return 0, err
+	}
+
+	// 敏感权限仅允许分配给系统角色
+	if err := checkSensitiveAssignment(r.IsSystem, permissionCodes); err != nil {
+		return 0, err
+	}
+
+	// 系统角色进入审批流程，变更请求入库等待审批
+	if r.IsSystem {
+		if permissionCodes == nil {
+			permissionCodes = []string{}
+		}
+		changeReq, err := s.client.RoleChangeRequest.Create().
+			SetRoleID(r.ID).
+			SetPermissions(permissionCodes).
+			SetRequestedBy(operatorID).
+			Save(ctx)
+		if err != nil {
+			return 0, err
+		}
+		logger.InfoContext(ctx, "role change request created",
+			"requestID", changeReq.ID,
+			"roleID", r.ID,
+			"operatorID", operatorID,
+		)
+		return changeReq.ID, nil
+	}
+
+	return 0, s.applyRolePermissions(ctx, r, permissions)
+}
+
+// BatchUpdateRolePermissions 批量更新多个角色的权限
+// 整批包在一个事务中：任一角色失败则全部回滚，返回每个角色的结果（按角色代码排序）。
+// 系统角色的权限变更需走审批流程，批量接口直接拒绝，避免误清空 admin 等关键权限。
+func (s *RoleService) BatchUpdateRolePermissions(ctx context.Context, rolePermissions map[string][]string) ([]*base.BatchRolePermissionsResult, error) {
+	codes := make([]string, 0, len(rolePermissions))
+	for code := range rolePermissions {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	tx, err := s.client.Tx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// 任一角色失败则整体回滚，避免出现更新到一半的状态
+	defer tx.Rollback()
+
+	results := make([]*base.BatchRolePermissionsResult, 0, len(codes))
+	var hasFailure bool
+	for _, code := range codes {
+		result := &base.BatchRolePermissionsResult{RoleCode: code, Success: true}
+		if err := s.updateRolePermissionsInTx(ctx, tx, code, rolePermissions[code]); err != nil {
+			result.Success = false
+			result.ErrorMessage = err.Error()
+			hasFailure = true
+		}
+		results = append(results, result)
+	}
+
+	if hasFailure {
+		// 回滚后已处理成功的角色同样未生效，统一标记避免误导调用方
+		for _, r := range results {
+			if r.Success {
+				r.Success = false
+				r.ErrorMessage = "同批次存在失败条目，变更已整体回滚"
+			}
 		}
+		return results, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "role permissions batch updated", "roles", len(results))
+	return results, nil
+}
+
+// updateRolePermissionsInTx 在事务内按角色代码重建单个角色的权限
+func (s *RoleService) updateRolePermissionsInTx(ctx context.Context, tx *ent.Tx, roleCode string, permCodes []string) error {
+	r, err := tx.Role.Query().Where(role.Code(roleCode), role.DeletedAtIsNil()).Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return fmt.Errorf("角色不存在: %w", ErrRoleNotFound)
+		}
+		return err
+	}
+
+	// 系统角色不支持批量更新，保护 admin 等关键权限不被误清空
+	if r.IsSystem {
+		return fmt.Errorf("系统角色权限变更需走审批流程: %w", ErrRoleIsSystem)
+	}
+
+	// 敏感权限仅允许分配给系统角色
+	if err := checkSensitiveAssignment(r.IsSystem, permCodes); err != nil {
 		return err
 	}
 
+	// 校验权限代码，不存在的直接报错而不是静默忽略
+	var permissions []*ent.Permission
+	if len(permCodes) > 0 {
+		permissions, err = tx.Permission.Query().
+			Where(entPermission.CodeIn(permCodes...)).
+			All(ctx)
+		if err != nil {
+			return err
+		}
+		found := make(map[string]bool, len(permissions))
+		for _, p := range permissions {
+			found[p.Code] = true
+		}
+		var invalid []string
+		for _, code := range permCodes {
+			if !found[code] {
+				found[code] = true
+				invalid = append(invalid, code)
+			}
+		}
+		if len(invalid) > 0 {
+			return fmt.Errorf("%w: %s", ErrInvalidPermissionCodes, strings.Join(invalid, ", "))
+		}
+	}
+
+	// 清除现有权限后重建
+	if _, err := r.Update().ClearPermissions().Save(ctx); err != nil {
+		return err
+	}
+	if len(permissions) > 0 {
+		if _, err := r.Update().AddPermissions(permissions...).Save(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyRolePermissions 清空并重建角色权限
+func (s *RoleService) applyRolePermissions(ctx context.Context, r *ent.Role, permissions []*ent.Permission) error {
 	// 清除现有权限
-	_, err = r.Update().ClearPermissions().Save(ctx)
+	_, err := r.Update().ClearPermissions().Save(ctx)
 	if err != nil {
 		return err
 	}
 
 	// 添加新权限
-	if len(permissionCodes) > 0 {
+	if len(permissions) > 0 {
+		_, err = r.Update().AddPermissions(permissions...).Save(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkSensitiveAssignment 校验敏感权限分配
+// 敏感权限（高危操作，如 user:delete）仅允许分配给系统角色，
+// 请求中包含敏感权限且目标为非系统角色时返回 ErrSensitivePermission 并列出代码
+func checkSensitiveAssignment(isSystem bool, codes []string) error {
+	if isSystem {
+		return nil
+	}
+
+	var sensitive []string
+	for _, code := range codes {
+		if permission.IsSensitiveCode(code) {
+			sensitive = append(sensitive, code)
+		}
+	}
+	if len(sensitive) > 0 {
+		return fmt.Errorf("%w: %s", ErrSensitivePermission, strings.Join(sensitive, ", "))
+	}
+	return nil
+}
+
+// queryPermissionsByCodes 按权限代码查询权限实体
+// 若请求中包含数据库不存在的代码，返回 ErrInvalidPermissionCodes 并列出无效代码
+func (s *RoleService) queryPermissionsByCodes(ctx context.Context, codes []string) ([]*ent.Permission, error) {
+	if len(codes) == 0 {
+		return nil, nil
+	}
+
+	permissions, err := s.client.Permission.Query().
+		Where(entPermission.CodeIn(codes...)).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool, len(permissions))
+	for _, p := range permissions {
+		found[p.Code] = true
+	}
+
+	var invalid []string
+	for _, code := range codes {
+		if !found[code] {
+			found[code] = true // 去重，同一无效代码只报告一次
+			invalid = append(invalid, code)
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPermissionCodes, strings.Join(invalid, ", "))
+	}
+
+	return permissions, nil
+}
+
+// exportedRole 角色配置的导出格式
+type exportedRole struct {
+	Code         string   `json:"code"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	DefaultRoute string   `json:"default_route,omitempty"`
+	SortOrder    int      `json:"sort_order"`
+	Permissions  []string `json:"permissions"`
+}
+
+// ExportRoles 导出所有非系统角色及其权限为 JSON
+func (s *RoleService) ExportRoles(ctx context.Context) (*base.ExportRolesResponse, error) {
+	roles, err := s.client.Role.Query().
+		Where(role.IsSystem(false), role.DeletedAtIsNil()).
+		WithPermissions().
+		Order(ent.Asc(role.FieldSortOrder), ent.Asc(role.FieldID)).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	exported := make([]exportedRole, 0, len(roles))
+	for _, r := range roles {
+		permCodes := make([]string, 0, len(r.Edges.Permissions))
+		for _, p := range r.Edges.Permissions {
+			permCodes = append(permCodes, p.Code)
+		}
+		exported = append(exported, exportedRole{
+			Code:         r.Code,
+			Name:         r.Name,
+			Description:  r.Description,
+			DefaultRoute: r.DefaultRoute,
+			SortOrder:    r.SortOrder,
+			Permissions:  permCodes,
+		})
+	}
+
+	data, err := json.MarshalIndent(exported, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return &base.ExportRolesResponse{
+		Data:  string(data),
+		Count: int32(len(exported)),
+	}, nil
+}
+
+// ImportRoles 导入角色配置（JSON）
+// mode 为 merge 时仅为已存在角色补充缺失的权限；
+// mode 为 overwrite 时覆盖角色信息并替换全部权限。
+// 目标环境不存在的权限 code 记录警告并跳过。
+func (s *RoleService) ImportRoles(ctx context.Context, data, mode string) (*base.ImportRolesResponse, error) {
+	var imported []exportedRole
+	if err := json.Unmarshal([]byte(data), &imported); err != nil {
+		return nil, ErrInvalidImportData
+	}
+
+	resp := &base.ImportRolesResponse{}
+	for _, item := range imported {
+		if item.Code == "" {
+			resp.Warnings = append(resp.Warnings, "跳过缺少 code 的角色配置")
+			continue
+		}
+
+		// 解析权限 code，不存在的记录警告跳过
 		permissions, err := s.client.Permission.Query().
-			Where(permission.CodeIn(permissionCodes...)).
+			Where(entPermission.CodeIn(item.Permissions...)).
 			All(ctx)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		if len(permissions) > 0 {
-			_, err = r.Update().AddPermissions(permissions...).Save(ctx)
+		foundCodes := make(map[string]bool, len(permissions))
+		for _, p := range permissions {
+			foundCodes[p.Code] = true
+		}
+		for _, code := range item.Permissions {
+			if !foundCodes[code] {
+				warning := "角色 " + item.Code + " 的权限不存在，已跳过: " + code
+				resp.Warnings = append(resp.Warnings, warning)
+				logger.Warn("import roles: permission not found", "role", item.Code, "permission", code)
+			}
+		}
+
+		existing, err := s.client.Role.Query().Where(role.Code(item.Code)).Only(ctx)
+		if err != nil && !ent.IsNotFound(err) {
+			return nil, err
+		}
+
+		if existing == nil {
+			// 创建新角色
+			create := s.client.Role.Create().
+				SetCode(item.Code).
+				SetName(item.Name).
+				SetDescription(item.Description).
+				SetDefaultRoute(item.DefaultRoute).
+				SetSortOrder(item.SortOrder)
+			if len(permissions) > 0 {
+				create = create.AddPermissions(permissions...)
+			}
+			if _, err := create.Save(ctx); err != nil {
+				return nil, err
+			}
+			resp.Created++
+			continue
+		}
+
+		// 系统角色不允许通过导入修改
+		if existing.IsSystem {
+			resp.Warnings = append(resp.Warnings, "跳过系统角色: "+item.Code)
+			logger.Warn("import roles: system role skipped", "role", item.Code)
+			continue
+		}
+
+		switch mode {
+		case "overwrite":
+			// 覆盖角色信息并替换全部权限
+			update := existing.Update().
+				SetName(item.Name).
+				SetDescription(item.Description).
+				SetDefaultRoute(item.DefaultRoute).
+				SetSortOrder(item.SortOrder).
+				ClearPermissions()
+			if _, err := update.Save(ctx); err != nil {
+				return nil, err
+			}
+			if len(permissions) > 0 {
+				if _, err := existing.Update().AddPermissions(permissions...).Save(ctx); err != nil {
+					return nil, err
+				}
+			}
+		default: // merge
+			// 仅补充缺失的权限
+			existingPerms, err := existing.QueryPermissions().All(ctx)
 			if err != nil {
-				return err
+				return nil, err
+			}
+			existingCodes := make(map[string]bool, len(existingPerms))
+			for _, p := range existingPerms {
+				existingCodes[p.Code] = true
+			}
+			toAdd := make([]*ent.Permission, 0, len(permissions))
+			for _, p := range permissions {
+				if !existingCodes[p.Code] {
+					toAdd = append(toAdd, p)
+				}
+			}
+			if len(toAdd) > 0 {
+				if _, err := existing.Update().AddPermissions(toAdd...).Save(ctx); err != nil {
+					return nil, err
+				}
 			}
 		}
+		resp.Updated++
 	}
 
-	return nil
+	return resp, nil
 }
 
 // toRoleInfo 将 ent.Role 转换为 base.RoleInfo
@@ -381,6 +1066,16 @@ func (s *RoleService) toRoleInfo(r *ent.Role) *base.RoleInfo {
 	// admin 角色拥有全部权限
 	isAllPermissions := r.Code == "admin"
 
+	deletedAt := ""
+	if r.DeletedAt != nil {
+		deletedAt = r.DeletedAt.Format(time.RFC3339)
+	}
+
+	parentID := ""
+	if r.ParentID != nil {
+		parentID = strconv.Itoa(*r.ParentID)
+	}
+
 	return &base.RoleInfo{
 		Id:               strconv.Itoa(r.ID),
 		Code:             r.Code,
@@ -393,9 +1088,19 @@ func (s *RoleService) toRoleInfo(r *ent.Role) *base.RoleInfo {
 		CreatedAt:        r.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:        r.UpdatedAt.Format(time.RFC3339),
 		IsAllPermissions: isAllPermissions,
+		DefaultRoute:     r.DefaultRoute,
+		DataScope:        string(r.DataScope),
+		DeletedAt:        deletedAt,
+		ParentId:         parentID,
 	}
 }
 
+// withActiveRolesAndPermissions 预加载用户的有效角色及其权限
+// 已软删除的角色不参与认证授权，恢复后重新生效
+func withActiveRolesAndPermissions(q *ent.RoleQuery) {
+	q.Where(role.DeletedAtIsNil()).WithPermissions()
+}
+
 // toPermissionInfo 将 ent.Permission 转换为 base.PermissionInfo
 func (s *RoleService) toPermissionInfo(p *ent.Permission) *base.PermissionInfo {
 	return &base.PermissionInfo{
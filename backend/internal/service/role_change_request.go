@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"zera/ent"
+	"zera/ent/role"
+	"zera/ent/rolechangerequest"
+	"zera/ent/user"
+	"zera/gen/base"
+	"zera/internal/logger"
+)
+
+var (
+	// ErrChangeRequestNotFound 变更请求不存在
+	ErrChangeRequestNotFound = errors.New("role change request not found")
+	// ErrChangeRequestReviewed 变更请求已处理
+	ErrChangeRequestReviewed = errors.New("role change request already reviewed")
+	// ErrSelfReview 不能审批自己发起的变更请求
+	ErrSelfReview = errors.New("cannot review own change request")
+)
+
+// ListRoleChangeRequests 获取角色权限变更请求列表
+func (s *RoleService) ListRoleChangeRequests(ctx context.Context, req *base.ListRoleChangeRequestsRequest) (*base.ListRoleChangeRequestsResponse, error) {
+	query := s.client.RoleChangeRequest.Query()
+
+	// 审批状态过滤
+	if req.Status != "" {
+		query = query.Where(rolechangerequest.StatusEQ(rolechangerequest.Status(req.Status)))
+	}
+
+	// 获取总数
+	total, err := query.Clone().Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 分页，最新的请求在前
+	page := int(req.Page)
+	if page < 1 {
+		page = 1
+	}
+	pageSize := int(req.PageSize)
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	offset := (page - 1) * pageSize
+	requests, err := query.
+		Order(ent.Desc(rolechangerequest.FieldCreatedAt), ent.Desc(rolechangerequest.FieldID)).
+		Offset(offset).
+		Limit(pageSize).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	roleNames, userNames, err := s.changeRequestNames(ctx, requests)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]*base.RoleChangeRequestInfo, 0, len(requests))
+	for _, cr := range requests {
+		info := &base.RoleChangeRequestInfo{
+			Id:            strconv.Itoa(cr.ID),
+			RoleId:        strconv.Itoa(cr.RoleID),
+			RoleName:      roleNames[cr.RoleID],
+			Permissions:   cr.Permissions,
+			Status:        string(cr.Status),
+			RequestedBy:   userNames[cr.RequestedBy],
+			ReviewComment: cr.ReviewComment,
+			CreatedAt:     cr.CreatedAt.Format(time.RFC3339),
+		}
+		if cr.ReviewedBy != nil {
+			info.ReviewedBy = userNames[*cr.ReviewedBy]
+		}
+		if cr.ReviewedAt != nil {
+			info.ReviewedAt = cr.ReviewedAt.Format(time.RFC3339)
+		}
+		infos = append(infos, info)
+	}
+
+	return &base.ListRoleChangeRequestsResponse{
+		Requests: infos,
+		Total:    int32(total),
+	}, nil
+}
+
+// changeRequestNames 批量解析变更请求涉及的角色名和用户名
+func (s *RoleService) changeRequestNames(ctx context.Context, requests []*ent.RoleChangeRequest) (map[int]string, map[int]string, error) {
+	roleIDSet := make(map[int]bool)
+	userIDSet := make(map[int]bool)
+	for _, cr := range requests {
+		roleIDSet[cr.RoleID] = true
+		userIDSet[cr.RequestedBy] = true
+		if cr.ReviewedBy != nil {
+			userIDSet[*cr.ReviewedBy] = true
+		}
+	}
+
+	roleNames := make(map[int]string, len(roleIDSet))
+	if len(roleIDSet) > 0 {
+		roleIDs := make([]int, 0, len(roleIDSet))
+		for id := range roleIDSet {
+			roleIDs = append(roleIDs, id)
+		}
+		roles, err := s.client.Role.Query().
+			Where(role.IDIn(roleIDs...)).
+			Select(role.FieldID, role.FieldName).
+			All(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, r := range roles {
+			roleNames[r.ID] = r.Name
+		}
+	}
+
+	userNames := make(map[int]string, len(userIDSet))
+	if len(userIDSet) > 0 {
+		userIDs := make([]int, 0, len(userIDSet))
+		for id := range userIDSet {
+			userIDs = append(userIDs, id)
+		}
+		users, err := s.client.User.Query().
+			Where(user.IDIn(userIDs...)).
+			Select(user.FieldID, user.FieldUsername).
+			All(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, u := range users {
+			userNames[u.ID] = u.Username
+		}
+	}
+
+	return roleNames, userNames, nil
+}
+
+// ReviewRoleChangeRequest 审批角色权限变更请求
+// 批准后立即应用权限变更；发起人不能审批自己的请求
+func (s *RoleService) ReviewRoleChangeRequest(ctx context.Context, reviewerID, requestID int, approve bool, comment string) error {
+	cr, err := s.client.RoleChangeRequest.Query().
+		Where(rolechangerequest.ID(requestID)).
+		Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return ErrChangeRequestNotFound
+		}
+		return err
+	}
+
+	if cr.Status != rolechangerequest.StatusPending {
+		return ErrChangeRequestReviewed
+	}
+
+	// 发起人与审批人必须为不同的管理员
+	if cr.RequestedBy == reviewerID {
+		return ErrSelfReview
+	}
+
+	status := rolechangerequest.StatusRejected
+	if approve {
+		// 重新校验并应用变更（角色或权限代码可能在等待期间发生变化）
+		r, err := s.client.Role.Query().Where(role.ID(cr.RoleID)).Only(ctx)
+		if err != nil {
+			if ent.IsNotFound(err) {
+				return ErrRoleNotFound
+			}
+			return err
+		}
+
+		permissions, err := s.queryPermissionsByCodes(ctx, cr.Permissions)
+		if err != nil {
+			return err
+		}
+		if err := checkSensitiveAssignment(r.IsSystem, cr.Permissions); err != nil {
+			return err
+		}
+		if err := s.applyRolePermissions(ctx, r, permissions); err != nil {
+			return err
+		}
+		status = rolechangerequest.StatusApproved
+	}
+
+	update := cr.Update().
+		SetStatus(status).
+		SetReviewedBy(reviewerID).
+		SetReviewedAt(time.Now())
+	if comment != "" {
+		update = update.SetReviewComment(comment)
+	}
+	if err := update.Exec(ctx); err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "role change request reviewed",
+		"requestID", cr.ID,
+		"roleID", cr.RoleID,
+		"approved", approve,
+		"reviewerID", reviewerID,
+	)
+	return nil
+}
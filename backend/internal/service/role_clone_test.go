@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"zera/ent"
+	entrole "zera/ent/role"
+)
+
+// createCloneTestRole 创建克隆测试角色，用例结束后自动清理（物理删除）
+func createCloneTestRole(t *testing.T, client *ent.Client, code, name string) *ent.Role {
+	t.Helper()
+	r, err := client.Role.Create().
+		SetCode(code).
+		SetName(name).
+		Save(context.Background())
+	if err != nil {
+		t.Fatalf("创建测试角色失败: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = client.Role.Delete().Where(entrole.ID(r.ID)).Exec(context.Background())
+	})
+	return r
+}
+
+// cleanupRoleByCode 按代码清理克隆出来的角色
+func cleanupRoleByCode(t *testing.T, client *ent.Client, code string) {
+	t.Helper()
+	t.Cleanup(func() {
+		_, _ = client.Role.Delete().Where(entrole.Code(code)).Exec(context.Background())
+	})
+}
+
+// TestCloneRoleWithoutPermissions 源角色没有任何权限时克隆也应成功，
+// 新角色为无权限的非系统角色
+func TestCloneRoleWithoutPermissions(t *testing.T) {
+	client := openTestClient(t)
+	svc := NewRoleService(client)
+	ctx := context.Background()
+
+	suffix := fmt.Sprintf("%d", time.Now().UnixNano())
+	source := createCloneTestRole(t, client, "clone_src_"+suffix, "克隆源角色")
+	newCode := "clone_dst_" + suffix
+	cleanupRoleByCode(t, client, newCode)
+
+	resp, err := svc.CloneRole(ctx, source.ID, newCode, "克隆角色")
+	if err != nil {
+		t.Fatalf("CloneRole: %v", err)
+	}
+	if resp.Role.Code != newCode {
+		t.Errorf("克隆角色代码不符: got %q, want %q", resp.Role.Code, newCode)
+	}
+	if len(resp.Role.Permissions) != 0 {
+		t.Errorf("无权限源角色克隆出了 %d 个权限", len(resp.Role.Permissions))
+	}
+	if resp.Role.IsSystem {
+		t.Error("克隆出的角色不应为系统角色")
+	}
+}
+
+// TestCloneRoleSourceNotFound 源角色不存在时应返回 ErrRoleNotFound
+func TestCloneRoleSourceNotFound(t *testing.T) {
+	client := openTestClient(t)
+	svc := NewRoleService(client)
+
+	suffix := fmt.Sprintf("%d", time.Now().UnixNano())
+	_, err := svc.CloneRole(context.Background(), -1, "clone_missing_"+suffix, "不存在的源")
+	if !errors.Is(err, ErrRoleNotFound) {
+		t.Fatalf("源角色不存在应返回 ErrRoleNotFound, got %v", err)
+	}
+}
+
+// TestCloneRoleCodeConflict 新角色代码已被占用时应返回 ErrRoleExists
+func TestCloneRoleCodeConflict(t *testing.T) {
+	client := openTestClient(t)
+	svc := NewRoleService(client)
+
+	suffix := fmt.Sprintf("%d", time.Now().UnixNano())
+	source := createCloneTestRole(t, client, "clone_conflict_src_"+suffix, "冲突测试源角色")
+	occupied := createCloneTestRole(t, client, "clone_conflict_dst_"+suffix, "已占用代码的角色")
+
+	_, err := svc.CloneRole(context.Background(), source.ID, occupied.Code, "冲突角色")
+	if !errors.Is(err, ErrRoleExists) {
+		t.Fatalf("代码冲突应返回 ErrRoleExists, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"zera/ent"
+	entrole "zera/ent/role"
+	entuser "zera/ent/user"
+
+	"zera/gen/base"
+)
+
+// benchRoleUserCount 基准测试中关联到单个角色的用户数量
+// 用于验证 ListRoles 的用户计数在大数据量下不随用户数加载实体
+const benchRoleUserCount = 5000
+
+// setupBenchRoleWithUsers 创建一个关联大量用户的角色，基准结束后清理
+func setupBenchRoleWithUsers(b *testing.B, client *ent.Client) *ent.Role {
+	b.Helper()
+	ctx := context.Background()
+
+	suffix := fmt.Sprintf("%d", time.Now().UnixNano())
+	r, err := client.Role.Create().
+		SetCode("bench_role_" + suffix).
+		SetName("基准测试角色").
+		Save(ctx)
+	if err != nil {
+		b.Fatalf("创建基准角色失败: %v", err)
+	}
+	b.Cleanup(func() {
+		_, _ = client.User.Delete().
+			Where(entuser.UsernameHasPrefix("bench_u_" + suffix)).
+			Exec(context.Background())
+		_, _ = client.Role.Delete().Where(entrole.ID(r.ID)).Exec(context.Background())
+	})
+
+	// 分批建号并挂到角色上，避免单条超大 SQL
+	const batch = 500
+	for start := 0; start < benchRoleUserCount; start += batch {
+		bulk := make([]*ent.UserCreate, 0, batch)
+		for i := start; i < start+batch && i < benchRoleUserCount; i++ {
+			name := fmt.Sprintf("bench_u_%s_%d", suffix, i)
+			bulk = append(bulk, client.User.Create().
+				SetUsername(name).
+				SetEmail(name+"@bench.example.com").
+				SetPasswordHash("$2a$04$placeholderplaceholderplace").
+				SetNickname(name).
+				SetStatus(entuser.StatusActive).
+				AddRoles(r))
+		}
+		if _, err := client.User.CreateBulk(bulk...).Save(ctx); err != nil {
+			b.Fatalf("批量创建基准用户失败: %v", err)
+		}
+	}
+	return r
+}
+
+// BenchmarkListRolesWithLargeUserCount 大数据量下的 ListRoles 基准：
+// 角色关联数千用户时用户计数应走聚合查询，而非加载全部用户实体
+func BenchmarkListRolesWithLargeUserCount(b *testing.B) {
+	client := openTestClient(b)
+	r := setupBenchRoleWithUsers(b, client)
+	svc := NewRoleService(client)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resp, err := svc.ListRoles(ctx, &base.ListRolesRequest{Page: 1, PageSize: 100})
+		if err != nil {
+			b.Fatalf("ListRoles: %v", err)
+		}
+		found := false
+		for _, info := range resp.Roles {
+			if info.Code == r.Code {
+				found = true
+				if info.UserCount != benchRoleUserCount {
+					b.Fatalf("用户计数不符: got %d, want %d", info.UserCount, benchRoleUserCount)
+				}
+			}
+		}
+		if !found {
+			b.Fatal("基准角色未出现在列表中")
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"zera/gen/base"
+)
+
+// ErrRoleTemplateNotFound 角色模板不存在
+var ErrRoleTemplateNotFound = errors.New("role template not found")
+
+// RoleTemplate 角色模板
+// 预定义一组常见的权限组合，创建角色时可按模板快速初始化权限集
+type RoleTemplate struct {
+	// Code 模板代码
+	Code string
+	// Name 模板名称
+	Name string
+	// Description 模板描述
+	Description string
+	// Permissions 模板包含的权限代码列表
+	Permissions []string
+}
+
+// roleTemplates 内置角色模板
+// 权限代码必须与 internal/permission/registry.go 中的注册保持一致
+var roleTemplates = []RoleTemplate{
+	{
+		Code:        "readonly_auditor",
+		Name:        "只读审计员",
+		Description: "可查看用户、角色、部门和审计日志，不可做任何变更",
+		Permissions: []string{
+			"user:read",
+			"role:read",
+			"permission:read",
+			"department:read",
+			"audit_log:read",
+			"audit_log:export",
+			"system_setting:read",
+		},
+	},
+	{
+		Code:        "user_manager",
+		Name:        "用户管理员",
+		Description: "管理用户的完整生命周期，可查看角色和部门",
+		Permissions: []string{
+			"user:read",
+			"user:create",
+			"user:update",
+			"user:delete",
+			"user:reset-password",
+			"role:read",
+			"role:assign",
+			"department:read",
+		},
+	},
+	{
+		Code:        "department_manager",
+		Name:        "部门管理员",
+		Description: "管理部门结构，可查看用户",
+		Permissions: []string{
+			"department:read",
+			"department:create",
+			"department:update",
+			"department:delete",
+			"user:read",
+		},
+	},
+}
+
+// mergePermissionCodes 合并模板权限与请求权限并去重，保持出现顺序
+func mergePermissionCodes(template, extra []string) []string {
+	merged := make([]string, 0, len(template)+len(extra))
+	seen := make(map[string]bool, len(template)+len(extra))
+	for _, code := range append(append([]string{}, template...), extra...) {
+		if !seen[code] {
+			seen[code] = true
+			merged = append(merged, code)
+		}
+	}
+	return merged
+}
+
+// findRoleTemplate 按代码查找角色模板
+func findRoleTemplate(code string) *RoleTemplate {
+	for i := range roleTemplates {
+		if roleTemplates[i].Code == code {
+			return &roleTemplates[i]
+		}
+	}
+	return nil
+}
+
+// ListRoleTemplates 获取内置角色模板列表
+func (s *RoleService) ListRoleTemplates(ctx context.Context, req *base.ListRoleTemplatesRequest) (*base.ListRoleTemplatesResponse, error) {
+	templates := make([]*base.RoleTemplateInfo, 0, len(roleTemplates))
+	for _, t := range roleTemplates {
+		templates = append(templates, &base.RoleTemplateInfo{
+			Code:        t.Code,
+			Name:        t.Name,
+			Description: t.Description,
+			Permissions: t.Permissions,
+		})
+	}
+
+	return &base.ListRoleTemplatesResponse{
+		Templates: templates,
+	}, nil
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"zera/internal/logger"
+)
+
+// SIEM 导出格式
+const (
+	// SIEMFormatCEF ArcSight Common Event Format
+	SIEMFormatCEF = "cef"
+	// SIEMFormatLEEF IBM QRadar Log Event Extended Format
+	SIEMFormatLEEF = "leef"
+)
+
+// siemVendor SIEM 头部的厂商/产品标识
+const (
+	siemVendor  = "Zera"
+	siemProduct = "Zera"
+	siemVersion = "1.0"
+)
+
+// ErrInvalidExportFormat 无效的导出格式
+var ErrInvalidExportFormat = errors.New("invalid export format")
+
+// FormatSIEM 将日志条目格式化为指定 SIEM 格式，每行一条记录
+func FormatSIEM(entries []*logger.Entry, format string) (string, error) {
+	var formatLine func(*logger.Entry) string
+	switch format {
+	case SIEMFormatCEF:
+		formatLine = formatCEF
+	case SIEMFormatLEEF:
+		formatLine = formatLEEF
+	default:
+		return "", fmt.Errorf("%w: %s", ErrInvalidExportFormat, format)
+	}
+
+	var b strings.Builder
+	for _, e := range entries {
+		b.WriteString(formatLine(e))
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+// formatCEF 将日志条目格式化为 CEF
+// CEF:0|厂商|产品|版本|事件ID|事件名|严重度|扩展字段
+func formatCEF(e *logger.Entry) string {
+	signature := e.Module + ":" + e.Action
+	name := e.Module + " " + e.Action
+
+	ext := make([]string, 0, 12)
+	appendExt := func(key, value string) {
+		if value != "" {
+			ext = append(ext, key+"="+cefEscapeExtension(value))
+		}
+	}
+
+	appendExt("rt", strconv.FormatInt(e.CreatedAt.UnixMilli(), 10))
+	appendExt("src", e.IP)
+	appendExt("suser", e.Username)
+	if e.UserID != nil {
+		appendExt("suid", strconv.Itoa(*e.UserID))
+	}
+	appendExt("act", e.Action)
+	appendExt("app", e.Module)
+	appendExt("outcome", entryOutcome(e))
+	appendExt("requestMethod", e.Method)
+	appendExt("request", e.Path)
+	if e.StatusCode != nil {
+		appendExt("cn1", strconv.Itoa(*e.StatusCode))
+		ext = append(ext, "cn1Label=statusCode")
+	}
+	appendExt("cs1", e.Resource)
+	if e.Resource != "" {
+		ext = append(ext, "cs1Label=resource")
+	}
+	appendExt("msg", e.ErrorMessage)
+
+	return fmt.Sprintf("CEF:0|%s|%s|%s|%s|%s|%d|%s",
+		cefEscapeHeader(siemVendor),
+		cefEscapeHeader(siemProduct),
+		cefEscapeHeader(siemVersion),
+		cefEscapeHeader(signature),
+		cefEscapeHeader(name),
+		siemSeverity(e.Level),
+		strings.Join(ext, " "),
+	)
+}
+
+// formatLEEF 将日志条目格式化为 LEEF 2.0（制表符分隔扩展字段）
+// LEEF:2.0|厂商|产品|版本|事件ID|扩展字段
+func formatLEEF(e *logger.Entry) string {
+	attrs := make([]string, 0, 12)
+	appendAttr := func(key, value string) {
+		if value != "" {
+			attrs = append(attrs, key+"="+leefEscape(value))
+		}
+	}
+
+	appendAttr("devTime", e.CreatedAt.Format("2006-01-02 15:04:05"))
+	appendAttr("src", e.IP)
+	appendAttr("usrName", e.Username)
+	if e.UserID != nil {
+		appendAttr("usrID", strconv.Itoa(*e.UserID))
+	}
+	appendAttr("action", e.Action)
+	appendAttr("module", e.Module)
+	appendAttr("sev", strconv.Itoa(siemSeverity(e.Level)))
+	appendAttr("outcome", entryOutcome(e))
+	appendAttr("method", e.Method)
+	appendAttr("url", e.Path)
+	if e.StatusCode != nil {
+		appendAttr("responseCode", strconv.Itoa(*e.StatusCode))
+	}
+	appendAttr("resource", e.Resource)
+	appendAttr("msg", e.ErrorMessage)
+
+	return fmt.Sprintf("LEEF:2.0|%s|%s|%s|%s|%s",
+		siemVendor,
+		siemProduct,
+		siemVersion,
+		e.Module+":"+e.Action,
+		strings.Join(attrs, "\t"),
+	)
+}
+
+// siemSeverity 将日志级别映射为 SIEM 严重度 (0-10)
+func siemSeverity(level logger.Level) int {
+	switch level {
+	case logger.LevelDebug:
+		return 2
+	case logger.LevelInfo:
+		return 3
+	case logger.LevelWarning:
+		return 6
+	case logger.LevelError:
+		return 8
+	default:
+		return 3
+	}
+}
+
+// entryOutcome 根据状态码和错误信息判断操作结果
+func entryOutcome(e *logger.Entry) string {
+	if e.ErrorMessage != "" {
+		return "failure"
+	}
+	if e.StatusCode != nil && *e.StatusCode >= 400 {
+		return "failure"
+	}
+	return "success"
+}
+
+// cefEscapeHeader 转义 CEF 头部字段（反斜杠和管道符）
+func cefEscapeHeader(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return s
+}
+
+// cefEscapeExtension 转义 CEF 扩展字段（反斜杠、等号和换行）
+func cefEscapeExtension(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "=", `\=`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	s = strings.ReplaceAll(s, "\r", `\r`)
+	return s
+}
+
+// leefEscape 转义 LEEF 扩展字段（制表符和换行）
+func leefEscape(s string) string {
+	s = strings.ReplaceAll(s, "\t", " ")
+	s = strings.ReplaceAll(s, "\n", " ")
+	s = strings.ReplaceAll(s, "\r", " ")
+	return s
+}
@@ -2,7 +2,10 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"zera/ent"
@@ -10,6 +13,9 @@ import (
 	"zera/gen/base"
 )
 
+// ErrInvalidSettingValue 设置值不符合校验规则
+var ErrInvalidSettingValue = errors.New("invalid setting value")
+
 // 系统设置键名常量
 const (
 	// 通用设置
@@ -18,10 +24,20 @@ const (
 	SettingKeySiteLogoType    = "site_logo_type" // Logo 类型: "default" | "custom"
 	SettingKeySiteLogoPath    = "site_logo_path" // 自定义 Logo 相对路径
 
+	// 品牌设置
+	SettingKeyThemeColor          = "theme_color"           // 主题色（十六进制颜色值）
+	SettingKeyLoginBackgroundType = "login_background_type" // 登录页背景类型: "default" | "custom"
+	SettingKeyLoginBackgroundPath = "login_background_path" // 自定义登录页背景图相对路径
+	SettingKeyFooterHTML          = "footer_html"           // 页脚 HTML 内容
+	SettingKeyContactEmail        = "contact_email"         // 联系邮箱
+
 	// 功能开关
 	SettingKeyEnableRegistration  = "enable_registration"
+	SettingKeyShowRegisterLink    = "show_register_link"
 	SettingKeyMaintenanceMode     = "maintenance_mode"
+	SettingKeyReadOnlyMode        = "read_only_mode"
 	SettingKeyDefaultRegisterRole = "default_register_role"
+	SettingKeyTermsVersion        = "terms_version" // 当前服务条款版本号，为空表示不要求同意
 
 	// 安全设置
 	SettingKeyMaxLoginAttempts         = "max_login_attempts"
@@ -40,28 +56,53 @@ const (
 	SettingGroupSecurity = "security"
 )
 
-// 默认设置值
-var defaultSettings = map[string]struct {
+// settingDefinition 设置项定义，包含默认值与校验规则
+type settingDefinition struct {
 	Value       string
 	Type        string
 	Group       string
 	Description string
-}{
-	SettingKeySiteName:            {"Zera", "string", SettingGroupGeneral, "站点名称"},
-	SettingKeySiteDescription:     {"Zera 管理系统", "string", SettingGroupGeneral, "站点描述"},
-	SettingKeySiteLogoType:        {"default", "string", SettingGroupGeneral, "Logo 类型: default 或 custom"},
-	SettingKeySiteLogoPath:        {"", "string", SettingGroupGeneral, "自定义 Logo 相对路径"},
-	SettingKeyEnableRegistration:  {"true", "bool", SettingGroupFeature, "允许新用户自行注册账号"},
-	SettingKeyMaintenanceMode:     {"false", "bool", SettingGroupFeature, "开启后普通用户将无法访问系统"},
-	SettingKeyDefaultRegisterRole: {"user", "string", SettingGroupFeature, "新注册用户的默认角色"},
+	Min         *int64   // int 类型允许的最小值（含），nil 表示不限制
+	Max         *int64   // int 类型允许的最大值（含），nil 表示不限制
+	Allowed     []string // 允许的枚举值，为空表示不限制
+}
+
+// intLimit 构造整数范围约束
+func intLimit(v int64) *int64 {
+	return &v
+}
+
+// 默认设置值
+var defaultSettings = map[string]settingDefinition{
+	SettingKeySiteName:        {Value: "Zera", Type: "string", Group: SettingGroupGeneral, Description: "站点名称"},
+	SettingKeySiteDescription: {Value: "Zera 管理系统", Type: "string", Group: SettingGroupGeneral, Description: "站点描述"},
+	SettingKeySiteLogoType: {Value: "default", Type: "string", Group: SettingGroupGeneral, Description: "Logo 类型: default 或 custom",
+		Allowed: []string{"default", "custom"}},
+	SettingKeySiteLogoPath: {Value: "", Type: "string", Group: SettingGroupGeneral, Description: "自定义 Logo 相对路径"},
+	SettingKeyThemeColor:   {Value: "#1677ff", Type: "string", Group: SettingGroupGeneral, Description: "主题色（十六进制颜色值）"},
+	SettingKeyLoginBackgroundType: {Value: "default", Type: "string", Group: SettingGroupGeneral, Description: "登录页背景类型: default 或 custom",
+		Allowed: []string{"default", "custom"}},
+	SettingKeyLoginBackgroundPath: {Value: "", Type: "string", Group: SettingGroupGeneral, Description: "自定义登录页背景图相对路径"},
+	SettingKeyFooterHTML:          {Value: "", Type: "string", Group: SettingGroupGeneral, Description: "页脚 HTML 内容"},
+	SettingKeyContactEmail:        {Value: "", Type: "string", Group: SettingGroupGeneral, Description: "联系邮箱"},
+	SettingKeyEnableRegistration:  {Value: "true", Type: "bool", Group: SettingGroupFeature, Description: "允许新用户自行注册账号"},
+	SettingKeyShowRegisterLink:    {Value: "true", Type: "bool", Group: SettingGroupFeature, Description: "登录页显示注册入口，关闭后仍可通过邀请链接注册"},
+	SettingKeyMaintenanceMode:     {Value: "false", Type: "bool", Group: SettingGroupFeature, Description: "开启后普通用户将无法访问系统"},
+	SettingKeyReadOnlyMode:        {Value: "false", Type: "bool", Group: SettingGroupFeature, Description: "开启后系统只读，拒绝所有写操作"},
+	SettingKeyDefaultRegisterRole: {Value: "user", Type: "string", Group: SettingGroupFeature, Description: "新注册用户的默认角色"},
+	SettingKeyTermsVersion:        {Value: "", Type: "string", Group: SettingGroupFeature, Description: "当前服务条款版本号，更新后用户需重新同意"},
 	// 安全设置
-	SettingKeyMaxLoginAttempts:         {"5", "int", SettingGroupSecurity, "超过此次数后账号将被临时锁定"},
-	SettingKeyLockoutDuration:          {"30", "int", SettingGroupSecurity, "账号锁定时长（分钟）"},
-	SettingKeySessionTimeout:           {"60", "int", SettingGroupSecurity, "会话超时时间（分钟）"},
-	SettingKeyPasswordMinLength:        {"8", "int", SettingGroupSecurity, "密码最小长度"},
-	SettingKeyPasswordRequireUppercase: {"true", "bool", SettingGroupSecurity, "密码必须包含至少一个大写字母"},
-	SettingKeyPasswordRequireNumber:    {"true", "bool", SettingGroupSecurity, "密码必须包含至少一个数字"},
-	SettingKeyPasswordRequireSpecial:   {"false", "bool", SettingGroupSecurity, "密码必须包含至少一个特殊字符"},
+	SettingKeyMaxLoginAttempts: {Value: "5", Type: "int", Group: SettingGroupSecurity, Description: "超过此次数后账号将被临时锁定",
+		Min: intLimit(1), Max: intLimit(100)},
+	SettingKeyLockoutDuration: {Value: "30", Type: "int", Group: SettingGroupSecurity, Description: "账号锁定时长（分钟）",
+		Min: intLimit(1), Max: intLimit(1440)},
+	SettingKeySessionTimeout: {Value: "60", Type: "int", Group: SettingGroupSecurity, Description: "会话超时时间（分钟）",
+		Min: intLimit(1), Max: intLimit(10080)},
+	SettingKeyPasswordMinLength: {Value: "8", Type: "int", Group: SettingGroupSecurity, Description: "密码最小长度",
+		Min: intLimit(6), Max: intLimit(64)},
+	SettingKeyPasswordRequireUppercase: {Value: "true", Type: "bool", Group: SettingGroupSecurity, Description: "密码必须包含至少一个大写字母"},
+	SettingKeyPasswordRequireNumber:    {Value: "true", Type: "bool", Group: SettingGroupSecurity, Description: "密码必须包含至少一个数字"},
+	SettingKeyPasswordRequireSpecial:   {Value: "false", Type: "bool", Group: SettingGroupSecurity, Description: "密码必须包含至少一个特殊字符"},
 }
 
 // SystemSettingService 系统设置服务
@@ -137,18 +178,34 @@ func (s *SystemSettingService) GetAllSettings(ctx context.Context) (*base.GetSys
 		logoURL = "/uploads/static/" + logoPath
 	}
 
+	// 构建登录页背景图 URL
+	backgroundType := getOrDefault(settingsMap, SettingKeyLoginBackgroundType, "default")
+	backgroundPath := getOrDefault(settingsMap, SettingKeyLoginBackgroundPath, "")
+	backgroundURL := ""
+	if backgroundType == "custom" && backgroundPath != "" {
+		backgroundURL = "/uploads/static/" + backgroundPath
+	}
+
 	return &base.GetSystemSettingsResponse{
 		Settings: &base.SystemSettings{
 			General: &base.GeneralSettings{
-				SiteName:        getOrDefault(settingsMap, SettingKeySiteName, "Zera"),
-				SiteDescription: getOrDefault(settingsMap, SettingKeySiteDescription, "Zera 管理系统"),
-				SiteLogoType:    logoType,
-				SiteLogoUrl:     logoURL,
+				SiteName:            getOrDefault(settingsMap, SettingKeySiteName, "Zera"),
+				SiteDescription:     getOrDefault(settingsMap, SettingKeySiteDescription, "Zera 管理系统"),
+				SiteLogoType:        logoType,
+				SiteLogoUrl:         logoURL,
+				ThemeColor:          getOrDefault(settingsMap, SettingKeyThemeColor, "#1677ff"),
+				LoginBackgroundType: backgroundType,
+				LoginBackgroundUrl:  backgroundURL,
+				FooterHtml:          getOrDefault(settingsMap, SettingKeyFooterHTML, ""),
+				ContactEmail:        getOrDefault(settingsMap, SettingKeyContactEmail, ""),
 			},
 			Features: &base.FeatureSettings{
 				EnableRegistration:  parseBool(getOrDefault(settingsMap, SettingKeyEnableRegistration, "true")),
+				ShowRegisterLink:    parseBool(getOrDefault(settingsMap, SettingKeyShowRegisterLink, "true")),
 				MaintenanceMode:     parseBool(getOrDefault(settingsMap, SettingKeyMaintenanceMode, "false")),
+				ReadOnlyMode:        parseBool(getOrDefault(settingsMap, SettingKeyReadOnlyMode, "false")),
 				DefaultRegisterRole: getOrDefault(settingsMap, SettingKeyDefaultRegisterRole, "user"),
+				TermsVersion:        getOrDefault(settingsMap, SettingKeyTermsVersion, ""),
 			},
 			Security: &base.SecuritySettings{
 				MaxLoginAttempts:         parseInt32(getOrDefault(settingsMap, SettingKeyMaxLoginAttempts, "5")),
@@ -179,6 +236,18 @@ func (s *SystemSettingService) UpdateSettings(ctx context.Context, settings *bas
 		if err := s.updateSetting(ctx, SettingKeySiteDescription, settings.General.SiteDescription, "string", SettingGroupGeneral); err != nil {
 			return nil, err
 		}
+		if settings.General.ThemeColor != "" {
+			if err := s.updateSetting(ctx, SettingKeyThemeColor, settings.General.ThemeColor, "string", SettingGroupGeneral); err != nil {
+				return nil, err
+			}
+		}
+		// 页脚和联系邮箱允许清空
+		if err := s.updateSetting(ctx, SettingKeyFooterHTML, settings.General.FooterHtml, "string", SettingGroupGeneral); err != nil {
+			return nil, err
+		}
+		if err := s.updateSetting(ctx, SettingKeyContactEmail, settings.General.ContactEmail, "string", SettingGroupGeneral); err != nil {
+			return nil, err
+		}
 	}
 
 	// 更新功能开关
@@ -186,14 +255,24 @@ func (s *SystemSettingService) UpdateSettings(ctx context.Context, settings *bas
 		if err := s.updateSetting(ctx, SettingKeyEnableRegistration, strconv.FormatBool(settings.Features.EnableRegistration), "bool", SettingGroupFeature); err != nil {
 			return nil, err
 		}
+		if err := s.updateSetting(ctx, SettingKeyShowRegisterLink, strconv.FormatBool(settings.Features.ShowRegisterLink), "bool", SettingGroupFeature); err != nil {
+			return nil, err
+		}
 		if err := s.updateSetting(ctx, SettingKeyMaintenanceMode, strconv.FormatBool(settings.Features.MaintenanceMode), "bool", SettingGroupFeature); err != nil {
 			return nil, err
 		}
+		if err := s.updateSetting(ctx, SettingKeyReadOnlyMode, strconv.FormatBool(settings.Features.ReadOnlyMode), "bool", SettingGroupFeature); err != nil {
+			return nil, err
+		}
 		if settings.Features.DefaultRegisterRole != "" {
 			if err := s.updateSetting(ctx, SettingKeyDefaultRegisterRole, settings.Features.DefaultRegisterRole, "string", SettingGroupFeature); err != nil {
 				return nil, err
 			}
 		}
+		// 条款版本允许清空（清空即不再要求同意）
+		if err := s.updateSetting(ctx, SettingKeyTermsVersion, settings.Features.TermsVersion, "string", SettingGroupFeature); err != nil {
+			return nil, err
+		}
 	}
 
 	// 更新安全设置
@@ -258,12 +337,26 @@ func (s *SystemSettingService) GetPublicSettings(ctx context.Context) (*base.Get
 		logoURL = "/uploads/static/" + logoPath
 	}
 
+	// 构建登录页背景图 URL
+	backgroundType := getOrDefault(settingsMap, SettingKeyLoginBackgroundType, "default")
+	backgroundPath := getOrDefault(settingsMap, SettingKeyLoginBackgroundPath, "")
+	backgroundURL := ""
+	if backgroundType == "custom" && backgroundPath != "" {
+		backgroundURL = "/uploads/static/" + backgroundPath
+	}
+
 	return &base.GetPublicSettingsResponse{
 		SiteName:           getOrDefault(settingsMap, SettingKeySiteName, "Zera"),
 		SiteDescription:    getOrDefault(settingsMap, SettingKeySiteDescription, "Zera 管理系统"),
 		EnableRegistration: parseBool(getOrDefault(settingsMap, SettingKeyEnableRegistration, "true")),
+		ShowRegisterLink:   parseBool(getOrDefault(settingsMap, SettingKeyShowRegisterLink, "true")),
 		MaintenanceMode:    parseBool(getOrDefault(settingsMap, SettingKeyMaintenanceMode, "false")),
+		ReadOnlyMode:       parseBool(getOrDefault(settingsMap, SettingKeyReadOnlyMode, "false")),
 		SiteLogoUrl:        logoURL,
+		ThemeColor:         getOrDefault(settingsMap, SettingKeyThemeColor, "#1677ff"),
+		LoginBackgroundUrl: backgroundURL,
+		FooterHtml:         getOrDefault(settingsMap, SettingKeyFooterHTML, ""),
+		ContactEmail:       getOrDefault(settingsMap, SettingKeyContactEmail, ""),
 	}, nil
 }
 
@@ -281,6 +374,20 @@ func (s *SystemSettingService) IsMaintenanceMode(ctx context.Context) (bool, err
 	return parseBool(setting.Value), nil
 }
 
+// IsReadOnlyMode 检查是否处于只读模式
+func (s *SystemSettingService) IsReadOnlyMode(ctx context.Context) (bool, error) {
+	setting, err := s.client.SystemSetting.Query().
+		Where(systemsetting.Key(SettingKeyReadOnlyMode)).
+		Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return parseBool(setting.Value), nil
+}
+
 // IsRegistrationEnabled 检查是否启用注册
 func (s *SystemSettingService) IsRegistrationEnabled(ctx context.Context) (bool, error) {
 	setting, err := s.client.SystemSetting.Query().
@@ -295,6 +402,20 @@ func (s *SystemSettingService) IsRegistrationEnabled(ctx context.Context) (bool,
 	return parseBool(setting.Value), nil
 }
 
+// GetTermsVersion 获取当前服务条款版本号，为空表示不要求同意条款
+func (s *SystemSettingService) GetTermsVersion(ctx context.Context) (string, error) {
+	setting, err := s.client.SystemSetting.Query().
+		Where(systemsetting.Key(SettingKeyTermsVersion)).
+		Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return setting.Value, nil
+}
+
 // GetSiteName 获取站点名称
 func (s *SystemSettingService) GetSiteName(ctx context.Context) (string, error) {
 	setting, err := s.client.SystemSetting.Query().
@@ -366,8 +487,70 @@ func (s *SystemSettingService) GetLogoSettings(ctx context.Context) (logoType, l
 	return logoType, logoPath, nil
 }
 
+// UpdateLoginBackgroundSettings 更新登录页背景设置
+func (s *SystemSettingService) UpdateLoginBackgroundSettings(ctx context.Context, backgroundType, backgroundPath string) error {
+	// 更新背景类型
+	if err := s.updateSetting(ctx, SettingKeyLoginBackgroundType, backgroundType, "string", SettingGroupGeneral); err != nil {
+		return err
+	}
+
+	// 更新背景图路径
+	if err := s.updateSetting(ctx, SettingKeyLoginBackgroundPath, backgroundPath, "string", SettingGroupGeneral); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateSettingValue 按设置项定义校验值
+// 未在 defaultSettings 中定义的键不做校验
+func validateSettingValue(key, value string) error {
+	def, ok := defaultSettings[key]
+	if !ok {
+		return nil
+	}
+
+	switch def.Type {
+	case "bool":
+		if value != "true" && value != "false" && value != "0" && value != "1" {
+			return fmt.Errorf("%w: %s 必须为布尔值", ErrInvalidSettingValue, key)
+		}
+	case "int":
+		v, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return fmt.Errorf("%w: %s 必须为整数", ErrInvalidSettingValue, key)
+		}
+		if def.Min != nil && v < *def.Min {
+			return fmt.Errorf("%w: %s 不能小于 %d", ErrInvalidSettingValue, key, *def.Min)
+		}
+		if def.Max != nil && v > *def.Max {
+			return fmt.Errorf("%w: %s 不能大于 %d", ErrInvalidSettingValue, key, *def.Max)
+		}
+	}
+
+	if len(def.Allowed) > 0 {
+		allowed := false
+		for _, a := range def.Allowed {
+			if value == a {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%w: %s 的取值必须是 %s 之一", ErrInvalidSettingValue, key, strings.Join(def.Allowed, "/"))
+		}
+	}
+
+	return nil
+}
+
 // updateSetting 更新单个设置
 func (s *SystemSettingService) updateSetting(ctx context.Context, key, value, valueType, group string) error {
+	// 写库前先按校验规则拦截非法值
+	if err := validateSettingValue(key, value); err != nil {
+		return err
+	}
+
 	// 尝试更新
 	n, err := s.client.SystemSetting.Update().
 		Where(systemsetting.Key(key)).
@@ -3,22 +3,48 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"zera/ent"
+	"zera/ent/auditlog"
+	"zera/ent/department"
+	"zera/ent/predicate"
+	"zera/ent/refreshtoken"
 	"zera/ent/role"
+	"zera/ent/tag"
 	"zera/ent/user"
 	"zera/gen/base"
+	"zera/internal/auth"
+	"zera/internal/cache"
 	"zera/internal/casdoor"
 	"zera/internal/logger"
+	"zera/internal/pagination"
+	"zera/internal/pinyin"
+
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/sqljson"
 )
 
 var (
 	// ErrUserExists 用户已存在
 	ErrUserExists = errors.New("user already exists")
+	// ErrMergeSameUser 不能与自身合并
+	ErrMergeSameUser = errors.New("cannot merge user with itself")
+	// ErrExternalAuthUser 外部认证用户（CAS/LDAP/OIDC）没有本地密码
+	ErrExternalAuthUser = errors.New("external auth user has no local password")
+	// ErrSelfOperation 禁止对自己的账号执行删除/封禁操作
+	ErrSelfOperation = errors.New("cannot perform this operation on your own account")
 )
 
+// userCountCache 用户列表总数的短 TTL 缓存
+// 大表上 COUNT 查询开销高，常见筛选组合的总数短暂缓存即可满足列表展示
+var userCountCache = cache.NewCountCache(30 * time.Second)
+
 // 批量操作错误码
 const (
 	ErrCodeNotFound      = "NOT_FOUND"
@@ -26,6 +52,17 @@ const (
 	ErrCodeDeleteFailed  = "DELETE_FAILED"
 	ErrCodeUpdateFailed  = "UPDATE_FAILED"
 	ErrCodeInvalidStatus = "INVALID_STATUS"
+	ErrCodeImportFailed  = "IMPORT_FAILED"
+	ErrCodeResetFailed   = "RESET_FAILED"
+	ErrCodeUnlockFailed  = "UNLOCK_FAILED"
+	// ErrCodeCancelled 请求被取消，剩余条目未处理
+	ErrCodeCancelled = "CANCELLED"
+	// ErrCodePermissionDenied 目标超出操作者数据范围，无权操作
+	ErrCodePermissionDenied = "PERMISSION_DENIED"
+	// ErrCodeSelfOperation 禁止对自己的账号执行删除/封禁
+	ErrCodeSelfOperation = "SELF_OPERATION"
+	// ErrCodeLastAdmin 操作会清空系统中处于 active 状态的管理员
+	ErrCodeLastAdmin = "LAST_ADMIN"
 )
 
 // BatchOperationResult 批量操作单个结果
@@ -40,6 +77,69 @@ type BatchOperationResult struct {
 type UserService struct {
 	client        *ent.Client
 	casdoorClient *casdoor.Client
+	casdoorRouter *casdoor.Router
+	onlineTracker *cache.OnlineTracker
+	// cursorCodec 列表游标分页的加密编解码器，未设置时游标分页不可用
+	cursorCodec *pagination.CursorCodec
+	// casdoorSyncPending 进行中的 Casdoor 异步同步任务数，供业务健康指标读取
+	casdoorSyncPending atomic.Int64
+	// casdoorSyncWG 追踪在途的异步同步 goroutine，优雅关闭时可等待 drain
+	casdoorSyncWG sync.WaitGroup
+}
+
+// casdoorSyncTimeout 单次异步 Casdoor 同步的超时时间
+// 请求返回后原 ctx 会被取消，异步同步统一使用独立的带超时 background context
+const casdoorSyncTimeout = 30 * time.Second
+
+// goCasdoorSync 在独立 goroutine 中执行异步 Casdoor 同步
+// 所有异步同步统一经此入口：context 不随请求取消、带超时兜底，
+// goroutine 纳入 WaitGroup，关闭时通过 WaitCasdoorSyncs 等待在途任务完成
+func (s *UserService) goCasdoorSync(fn func(ctx context.Context)) {
+	s.casdoorSyncWG.Add(1)
+	go func() {
+		defer s.casdoorSyncWG.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), casdoorSyncTimeout)
+		defer cancel()
+		fn(ctx)
+	}()
+}
+
+// WaitCasdoorSyncs 等待在途的异步 Casdoor 同步完成，超时未完成时返回 false
+func (s *UserService) WaitCasdoorSyncs(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		s.casdoorSyncWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// SetCursorCodec 设置游标分页编解码器
+func (s *UserService) SetCursorCodec(codec *pagination.CursorCodec) {
+	s.cursorCodec = codec
+}
+
+// userListCursor 用户列表游标载荷
+// 经 CursorCodec 加密编码后对客户端不透明
+type userListCursor struct {
+	Time time.Time `json:"t"`
+	ID   int       `json:"id"`
+}
+
+// CasdoorSyncPending 返回当前进行中的 Casdoor 同步任务数
+func (s *UserService) CasdoorSyncPending() int64 {
+	return s.casdoorSyncPending.Load()
+}
+
+// TotalUsers 返回当前用户总数，供业务健康指标读取
+func (s *UserService) TotalUsers(ctx context.Context) (int64, error) {
+	count, err := s.client.User.Query().Count(ctx)
+	return int64(count), err
 }
 
 // NewUserService 创建用户管理服务
@@ -101,6 +201,44 @@ func (s *UserService) InitCasdoorClient(ctx context.Context) error {
 	return nil
 }
 
+// SetCasdoorRoutes 配置多 Casdoor 组织路由
+// 按用户部门将同步请求路由到独立的 Casdoor 连接，未命中规则时使用默认客户端
+func (s *UserService) SetCasdoorRoutes(routes []casdoor.Route) error {
+	router := casdoor.NewRouter(s.casdoorClient)
+	if err := router.SetRoutes(routes); err != nil {
+		return err
+	}
+	s.casdoorRouter = router
+	return nil
+}
+
+// casdoorClientFor 按用户所属部门选择 Casdoor 客户端
+// 未配置路由、用户无部门或未命中规则时返回默认客户端
+func (s *UserService) casdoorClientFor(ctx context.Context, u *ent.User) *casdoor.Client {
+	if s.casdoorRouter == nil {
+		return s.casdoorClient
+	}
+
+	dept := u.Edges.Department
+	if dept == nil {
+		if d, err := u.QueryDepartment().Only(ctx); err == nil {
+			dept = d
+		}
+	}
+	if dept == nil {
+		return s.casdoorClient
+	}
+	return s.casdoorRouter.ClientFor(dept.Name)
+}
+
+// CasdoorStatus 返回 Casdoor 同步状态（是否启用、客户端是否初始化成功）
+func (s *UserService) CasdoorStatus() (enabled, initialized bool) {
+	if s.casdoorClient == nil {
+		return false, false
+	}
+	return s.casdoorClient.IsSyncEnabled(), s.casdoorClient.IsInitialized()
+}
+
 // shouldSyncToCasdoor 检查是否应该同步到 Casdoor
 func (s *UserService) shouldSyncToCasdoor(ctx context.Context, u *ent.User) bool {
 	// 仅同步本地用户 (auth_provider = local)
@@ -141,6 +279,9 @@ func (s *UserService) shouldSyncToCasdoor(ctx context.Context, u *ent.User) bool
 // syncUserToCasdoor 同步用户到 Casdoor
 // 同步成功后会更新本地用户的 external_id，以便 CAS 登录时能正确关联用户
 func (s *UserService) syncUserToCasdoor(ctx context.Context, u *ent.User, password string) {
+	s.casdoorSyncPending.Add(1)
+	defer s.casdoorSyncPending.Add(-1)
+
 	logger.Debug("syncUserToCasdoor called",
 		"userID", u.ID,
 		"username", u.Username,
@@ -164,7 +305,7 @@ func (s *UserService) syncUserToCasdoor(ctx context.Context, u *ent.User, passwo
 		"hasPassword", password != "",
 	)
 
-	casdoorID, err := s.casdoorClient.AddUserAndGetID(ctx, casdoorUser)
+	casdoorID, err := s.casdoorClientFor(ctx, u).AddUserAndGetID(ctx, casdoorUser)
 	if err != nil {
 		if errors.Is(err, casdoor.ErrUserAlreadyExists) {
 			// 用户已存在于 Casdoor，尝试更新本地用户的 external_id
@@ -228,6 +369,9 @@ func (s *UserService) updateUserExternalID(ctx context.Context, userID int, exte
 
 // syncUserUpdateToCasdoor 同步用户更新到 Casdoor
 func (s *UserService) syncUserUpdateToCasdoor(ctx context.Context, u *ent.User, password string) {
+	s.casdoorSyncPending.Add(1)
+	defer s.casdoorSyncPending.Add(-1)
+
 	if !s.shouldSyncToCasdoor(ctx, u) {
 		return
 	}
@@ -240,7 +384,7 @@ func (s *UserService) syncUserUpdateToCasdoor(ctx context.Context, u *ent.User,
 		Avatar:      u.Avatar,
 	}
 
-	if err := s.casdoorClient.UpdateUser(ctx, casdoorUser); err != nil {
+	if err := s.casdoorClientFor(ctx, u).UpdateUser(ctx, casdoorUser); err != nil {
 		if errors.Is(err, casdoor.ErrUserNotFound) {
 			// 用户在 Casdoor 中不存在，尝试创建
 			logger.Info("user not found in casdoor, attempting to create",
@@ -260,6 +404,9 @@ func (s *UserService) syncUserUpdateToCasdoor(ctx context.Context, u *ent.User,
 
 // syncUserDeleteToCasdoor 同步用户删除到 Casdoor
 func (s *UserService) syncUserDeleteToCasdoor(ctx context.Context, u *ent.User) {
+	s.casdoorSyncPending.Add(1)
+	defer s.casdoorSyncPending.Add(-1)
+
 	// 检查是否为本地用户
 	if u.AuthProvider != user.AuthProviderLocal {
 		return
@@ -270,7 +417,7 @@ func (s *UserService) syncUserDeleteToCasdoor(ctx context.Context, u *ent.User)
 		return
 	}
 
-	if err := s.casdoorClient.DeleteUser(ctx, u.Username); err != nil {
+	if err := s.casdoorClientFor(ctx, u).DeleteUser(ctx, u.Username); err != nil {
 		logger.Error("failed to sync user deletion to casdoor",
 			"userID", u.ID,
 			"username", u.Username,
@@ -279,9 +426,42 @@ func (s *UserService) syncUserDeleteToCasdoor(ctx context.Context, u *ent.User)
 	}
 }
 
+// syncUserStatusToCasdoor 将用户状态同步为 Casdoor 的封禁状态
+// 口径与本地登录校验一致：active/softban 可登录，其余状态视为封禁
+func (s *UserService) syncUserStatusToCasdoor(ctx context.Context, u *ent.User, status user.Status) {
+	s.casdoorSyncPending.Add(1)
+	defer s.casdoorSyncPending.Add(-1)
+
+	if !s.shouldSyncToCasdoor(ctx, u) {
+		return
+	}
+
+	forbidden := status != user.StatusActive && status != user.StatusSoftban
+	if err := s.casdoorClientFor(ctx, u).SetUserForbidden(ctx, u.Username, forbidden); err != nil {
+		logger.Error("failed to sync user status to casdoor",
+			"userID", u.ID,
+			"username", u.Username,
+			"forbidden", forbidden,
+			"error", err,
+		)
+	}
+}
+
 // ListUsers 获取用户列表
-func (s *UserService) ListUsers(ctx context.Context, req *base.ListUsersRequest) (*base.ListUsersResponse, error) {
-	query := s.client.User.Query().WithRoles()
+// operatorID 为发起请求的用户，用于按其角色数据范围过滤可见用户
+// userListQuery 构建用户列表查询：数据范围过滤 + 搜索/状态/角色/部门/标签筛选
+// 返回的 scopePred 供调用方判断总数是否可缓存（数据范围受限时因操作者而异）
+func (s *UserService) userListQuery(ctx context.Context, operatorID int, req *base.ListUsersRequest) (*ent.UserQuery, predicate.User, error) {
+	query := s.client.User.Query().WithRoles().WithDepartment().WithTags()
+
+	// 数据范围过滤：按操作者角色限制可见的用户集合
+	scopePred, err := s.userScopePredicate(ctx, operatorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if scopePred != nil {
+		query = query.Where(scopePred)
+	}
 
 	// 搜索条件
 	if req.Keyword != "" {
@@ -290,6 +470,8 @@ func (s *UserService) ListUsers(ctx context.Context, req *base.ListUsersRequest)
 				user.UsernameContains(req.Keyword),
 				user.EmailContains(req.Keyword),
 				user.NicknameContains(req.Keyword),
+				// 拼音索引匹配，支持全拼和首字母搜索
+				user.PinyinContains(strings.ToLower(req.Keyword)),
 			),
 		)
 	}
@@ -307,14 +489,98 @@ func (s *UserService) ListUsers(ctx context.Context, req *base.ListUsersRequest)
 		query = query.Where(user.HasRolesWith(role.Code(req.Role)))
 	}
 
-	// 获取总数
-	total, err := query.Clone().Count(ctx)
+	// 部门筛选
+	if req.DepartmentId != "" {
+		deptID, err := strconv.Atoi(req.DepartmentId)
+		if err != nil {
+			return nil, nil, ErrDepartmentNotFound
+		}
+		deptService := NewDepartmentService(s.client)
+		deptIDs, err := deptService.CollectDepartmentIDs(ctx, deptID, req.IncludeSubDepartments)
+		if err != nil {
+			return nil, nil, err
+		}
+		query = query.Where(user.HasDepartmentWith(department.IDIn(deptIDs...)))
+	}
+
+	// 标签筛选
+	if len(req.Tags) > 0 {
+		if req.MatchAllTags {
+			// 含全部标签
+			for _, name := range req.Tags {
+				query = query.Where(user.HasTagsWith(tag.Name(name)))
+			}
+		} else {
+			// 含任一标签
+			query = query.Where(user.HasTagsWith(tag.NameIn(req.Tags...)))
+		}
+	}
+
+	// 扩展属性筛选：JSONB 查询 metadata 的指定 key，值为空时仅要求 key 存在
+	if req.MetadataKey != "" {
+		key, value := req.MetadataKey, req.MetadataValue
+		query = query.Where(predicate.User(func(s *sql.Selector) {
+			if value != "" {
+				s.Where(sqljson.ValueEQ(user.FieldMetadata, value, sqljson.Path(key)))
+			} else {
+				s.Where(sqljson.HasKey(user.FieldMetadata, sqljson.Path(key)))
+			}
+		}))
+	}
+
+	return query, scopePred, nil
+}
+
+func (s *UserService) ListUsers(ctx context.Context, operatorID int, req *base.ListUsersRequest) (*base.ListUsersResponse, error) {
+	query, scopePred, err := s.userListQuery(ctx, operatorID, req)
 	if err != nil {
 		return nil, err
 	}
 
-	// 排序
-	if req.SortBy != "" {
+	// 获取总数
+	// 关键词搜索和标签的筛选组合不可枚举，不参与缓存；
+	// 请求要求精确总数时跳过缓存
+	countKey := fmt.Sprintf("users|status=%d|role=%s|dept=%s|sub=%t",
+		req.Status, req.Role, req.DepartmentId, req.IncludeSubDepartments)
+	// 数据范围受限时总数因操作者而异，不参与缓存
+	cacheable := req.Keyword == "" && len(req.Tags) == 0 && req.MetadataKey == "" && !req.ExactTotal && scopePred == nil
+	total, cached := 0, false
+	if cacheable {
+		total, cached = userCountCache.Get(countKey)
+	}
+	if !cached {
+		var err error
+		total, err = query.Clone().Count(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if cacheable {
+			userCountCache.Set(countKey, total)
+		}
+	}
+
+	// 游标分页：按 (created_at, id) 键集过滤，创建时间相同的按 ID 断开
+	// 仅配合默认的创建时间降序使用，不经过 offset
+	cursorMode := false
+	if req.Cursor != "" && s.cursorCodec != nil {
+		var c userListCursor
+		if err := s.cursorCodec.Decode(req.Cursor, &c); err != nil {
+			return nil, err
+		}
+		query = query.Where(
+			user.Or(
+				user.CreatedAtLT(c.Time),
+				user.And(
+					user.CreatedAtEQ(c.Time),
+					user.IDLT(c.ID),
+				),
+			),
+		)
+		cursorMode = true
+	}
+
+	// 排序（游标分页固定按创建时间降序，忽略排序参数）
+	if !cursorMode && req.SortBy != "" {
 		orderFunc := ent.Asc
 		if req.Descending {
 			orderFunc = ent.Desc
@@ -332,12 +598,12 @@ func (s *UserService) ListUsers(ctx context.Context, req *base.ListUsersRequest)
 			query = query.Order(ent.Desc(user.FieldCreatedAt))
 		}
 	} else {
-		query = query.Order(ent.Desc(user.FieldCreatedAt))
+		query = query.Order(ent.Desc(user.FieldCreatedAt), ent.Desc(user.FieldID))
 	}
 
 	// 分页
 	page := int(req.Page)
-	if page < 1 {
+	if page < 1 || cursorMode {
 		page = 1
 	}
 	pageSize := int(req.PageSize)
@@ -348,8 +614,10 @@ func (s *UserService) ListUsers(ctx context.Context, req *base.ListUsersRequest)
 		pageSize = 100
 	}
 
-	offset := (page - 1) * pageSize
-	users, err := query.Offset(offset).Limit(pageSize).All(ctx)
+	if !cursorMode {
+		query = query.Offset((page - 1) * pageSize)
+	}
+	users, err := query.Limit(pageSize).All(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -360,19 +628,166 @@ func (s *UserService) ListUsers(ctx context.Context, req *base.ListUsersRequest)
 		userDetails = append(userDetails, s.toUserDetail(u))
 	}
 
+	// 默认排序下满页时生成下一页游标，便于大数据集稳定翻页
+	nextCursor := ""
+	if s.cursorCodec != nil && (cursorMode || req.SortBy == "") && len(users) == pageSize {
+		last := users[len(users)-1]
+		nextCursor, err = s.cursorCodec.Encode(userListCursor{
+			Time: last.CreatedAt,
+			ID:   last.ID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	totalPages := 0
+	if pageSize > 0 {
+		totalPages = (total + pageSize - 1) / pageSize
+	}
+
 	return &base.ListUsersResponse{
-		Users:    userDetails,
-		Total:    int64(total),
-		Page:     int32(page),
-		PageSize: int32(pageSize),
+		Users:      userDetails,
+		Total:      int64(total),
+		Page:       int32(page),
+		PageSize:   int32(pageSize),
+		TotalPages: int32(totalPages),
+		NextCursor: nextCursor,
 	}, nil
 }
 
+// exportUsersBatchSize 导出用户时的单批查询行数
+// 分批按 ID 键集翻页，避免大数据集一次性加载到内存
+const exportUsersBatchSize = 1000
+
+// UserExportFields 导出用户支持的字段，顺序即默认导出的列顺序
+var UserExportFields = []string{
+	"id", "username", "email", "nickname", "status",
+	"roles", "department", "tags", "created_at", "last_login_at",
+}
+
+// ErrInvalidExportField 导出请求包含不支持的字段
+var ErrInvalidExportField = errors.New("invalid export field")
+
+// ValidateExportFields 校验导出字段是否受支持
+// 供处理器在写出任何响应字节前预校验，避免 400 错误混入已提交的 CSV 响应
+func ValidateExportFields(fields []string) error {
+	supported := make(map[string]bool, len(UserExportFields))
+	for _, f := range UserExportFields {
+		supported[f] = true
+	}
+	for _, f := range fields {
+		if !supported[f] {
+			return fmt.Errorf("%w: %s", ErrInvalidExportField, f)
+		}
+	}
+	return nil
+}
+
+// ExportUsers 按 ListUsers 的筛选条件流式导出用户
+// 不分页，按 ID 升序分批查询，每行通过 write 回调写出，返回导出的行数。
+// fields 为空时导出 UserExportFields 的全部字段
+func (s *UserService) ExportUsers(ctx context.Context, operatorID int, req *base.ListUsersRequest, fields []string, write func(record []string) error) (int, error) {
+	if len(fields) == 0 {
+		fields = UserExportFields
+	} else if err := ValidateExportFields(fields); err != nil {
+		return 0, err
+	}
+
+	// 表头行
+	if err := write(fields); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	lastID := 0
+	for {
+		query, _, err := s.userListQuery(ctx, operatorID, req)
+		if err != nil {
+			return count, err
+		}
+		users, err := query.
+			Where(user.IDGT(lastID)).
+			Order(ent.Asc(user.FieldID)).
+			Limit(exportUsersBatchSize).
+			All(ctx)
+		if err != nil {
+			return count, err
+		}
+
+		for _, u := range users {
+			record := make([]string, 0, len(fields))
+			for _, f := range fields {
+				record = append(record, userExportValue(u, f))
+			}
+			if err := write(record); err != nil {
+				return count, err
+			}
+			count++
+			lastID = u.ID
+		}
+
+		if len(users) < exportUsersBatchSize {
+			break
+		}
+	}
+
+	return count, nil
+}
+
+// userExportValue 取用户单个导出字段的文本值
+func userExportValue(u *ent.User, field string) string {
+	switch field {
+	case "id":
+		return strconv.Itoa(u.ID)
+	case "username":
+		return u.Username
+	case "email":
+		return u.Email
+	case "nickname":
+		return u.Nickname
+	case "status":
+		return string(u.Status)
+	case "roles":
+		codes := make([]string, 0, len(u.Edges.Roles))
+		for _, r := range u.Edges.Roles {
+			codes = append(codes, r.Code)
+		}
+		return strings.Join(codes, ";")
+	case "department":
+		if u.Edges.Department != nil {
+			return u.Edges.Department.Name
+		}
+		return ""
+	case "tags":
+		names := make([]string, 0, len(u.Edges.Tags))
+		for _, t := range u.Edges.Tags {
+			names = append(names, t.Name)
+		}
+		return strings.Join(names, ";")
+	case "created_at":
+		return u.CreatedAt.Format(time.RFC3339)
+	case "last_login_at":
+		if u.LastLoginAt == nil {
+			return ""
+		}
+		return u.LastLoginAt.Format(time.RFC3339)
+	}
+	return ""
+}
+
 // GetUser 获取用户详情
-func (s *UserService) GetUser(ctx context.Context, id int) (*base.GetUserResponse, error) {
+func (s *UserService) GetUser(ctx context.Context, operatorID, id int) (*base.GetUserResponse, error) {
+	// 校验目标用户在操作者数据范围内
+	if err := s.checkUserInScope(ctx, operatorID, id); err != nil {
+		return nil, err
+	}
+
 	u, err := s.client.User.Query().
 		Where(user.ID(id)).
 		WithRoles().
+		WithDepartment().
+		WithTags().
 		Only(ctx)
 	if err != nil {
 		if ent.IsNotFound(err) {
@@ -417,11 +832,16 @@ func (s *UserService) CreateUser(ctx context.Context, req *base.CreateUserReques
 	}
 
 	// 创建用户 (本地用户默认 auth_provider = local)
+	passwordHash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
 	create := s.client.User.Create().
 		SetUsername(req.Username).
 		SetEmail(req.Email).
-		SetPasswordHash(hashPassword(req.Password)).
+		SetPasswordHash(passwordHash).
 		SetNickname(req.Nickname).
+		SetPinyin(pinyin.Index(req.Nickname)).
 		SetAvatar(req.Avatar).
 		SetAuthProvider(user.AuthProviderLocal)
 
@@ -433,6 +853,22 @@ func (s *UserService) CreateUser(ctx context.Context, req *base.CreateUserReques
 		}
 	}
 
+	// 设置所属部门
+	if req.DepartmentId != "" {
+		deptID, err := strconv.Atoi(req.DepartmentId)
+		if err != nil {
+			return nil, ErrDepartmentNotFound
+		}
+		exists, err := s.client.Department.Query().Where(department.ID(deptID)).Exist(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrDepartmentNotFound
+		}
+		create = create.SetDepartmentID(deptID)
+	}
+
 	u, err := create.Save(ctx)
 	if err != nil {
 		return nil, err
@@ -456,13 +892,18 @@ func (s *UserService) CreateUser(ctx context.Context, req *base.CreateUserReques
 	u, err = s.client.User.Query().
 		Where(user.ID(u.ID)).
 		WithRoles().
+		WithDepartment().
+		WithTags().
 		Only(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	// 同步用户到 Casdoor (异步，不影响本地操作)
-	go s.syncUserToCasdoor(ctx, u, req.Password)
+	s.goCasdoorSync(func(ctx context.Context) { s.syncUserToCasdoor(ctx, u, req.Password) })
+
+	// 用户数量变化，失效总数缓存
+	userCountCache.Clear()
 
 	return &base.CreateUserResponse{
 		User: s.toUserDetail(u),
@@ -470,7 +911,12 @@ func (s *UserService) CreateUser(ctx context.Context, req *base.CreateUserReques
 }
 
 // UpdateUser 更新用户
-func (s *UserService) UpdateUser(ctx context.Context, id int, req *base.UpdateUserRequest) (*base.UpdateUserResponse, error) {
+func (s *UserService) UpdateUser(ctx context.Context, operatorID, id int, req *base.UpdateUserRequest) (*base.UpdateUserResponse, error) {
+	// 校验目标用户在操作者数据范围内
+	if err := s.checkUserInScope(ctx, operatorID, id); err != nil {
+		return nil, err
+	}
+
 	u, err := s.client.User.Query().Where(user.ID(id)).Only(ctx)
 	if err != nil {
 		if ent.IsNotFound(err) {
@@ -482,7 +928,8 @@ func (s *UserService) UpdateUser(ctx context.Context, id int, req *base.UpdateUs
 	update := u.Update()
 
 	if req.Nickname != nil {
-		update = update.SetNickname(*req.Nickname)
+		update = update.SetNickname(*req.Nickname).
+			SetPinyin(pinyin.Index(*req.Nickname))
 	}
 	if req.Email != nil {
 		// 检查邮箱是否被其他用户使用
@@ -500,10 +947,36 @@ func (s *UserService) UpdateUser(ctx context.Context, id int, req *base.UpdateUs
 	if req.Avatar != nil {
 		update = update.SetAvatar(*req.Avatar)
 	}
+	statusChanged := false
 	if req.Status != nil {
 		status := protoStatusToEnt(*req.Status)
 		if status != "" {
 			update = update.SetStatus(status)
+			statusChanged = status != u.Status
+		}
+	}
+	if req.LoginNotify != nil {
+		update = update.SetLoginNotify(*req.LoginNotify)
+	}
+
+	// 调整所属部门
+	if req.DepartmentId != nil {
+		if *req.DepartmentId == "" {
+			// 移出部门
+			update = update.ClearDepartment()
+		} else {
+			deptID, err := strconv.Atoi(*req.DepartmentId)
+			if err != nil {
+				return nil, ErrDepartmentNotFound
+			}
+			exists, err := s.client.Department.Query().Where(department.ID(deptID)).Exist(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				return nil, ErrDepartmentNotFound
+			}
+			update = update.SetDepartmentID(deptID)
 		}
 	}
 
@@ -536,13 +1009,20 @@ func (s *UserService) UpdateUser(ctx context.Context, id int, req *base.UpdateUs
 	u, err = s.client.User.Query().
 		Where(user.ID(id)).
 		WithRoles().
+		WithDepartment().
+		WithTags().
 		Only(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	// 同步用户更新到 Casdoor (异步，不影响本地操作)
-	go s.syncUserUpdateToCasdoor(ctx, u, "")
+	s.goCasdoorSync(func(ctx context.Context) { s.syncUserUpdateToCasdoor(ctx, u, "") })
+
+	// 状态变化时同步封禁状态，保持两侧登录一致
+	if statusChanged {
+		s.goCasdoorSync(func(ctx context.Context) { s.syncUserStatusToCasdoor(ctx, u, u.Status) })
+	}
 
 	return &base.UpdateUserResponse{
 		User: s.toUserDetail(u),
@@ -550,7 +1030,122 @@ func (s *UserService) UpdateUser(ctx context.Context, id int, req *base.UpdateUs
 }
 
 // DeleteUser 删除用户
-func (s *UserService) DeleteUser(ctx context.Context, id int) error {
+// activeAdminIDs 返回处于 active 状态且拥有管理员角色的用户 ID 集合
+func (s *UserService) activeAdminIDs(ctx context.Context) (map[int]bool, error) {
+	ids, err := s.client.User.Query().
+		Where(
+			user.StatusEQ(user.StatusActive),
+			user.HasRolesWith(role.CodeIn("admin", "super_admin")),
+		).
+		IDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set, nil
+}
+
+// isLastActiveAdmin 判断目标用户是否是最后一个处于 active 状态的管理员
+// 删除或封禁这样的账号会导致系统无人能登录管理
+func (s *UserService) isLastActiveAdmin(ctx context.Context, targetID int) (bool, error) {
+	admins, err := s.activeAdminIDs(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !admins[targetID] {
+		return false, nil
+	}
+	return len(admins) == 1, nil
+}
+
+// filterProtectedIDs 批量删除/封禁前的保护校验
+// 操作者自己以及会清空全部活跃管理员的条目以具体错误码标记失败，
+// 返回剩余可操作的 ID；第二个返回值为 false 表示校验失败、批量操作应整体终止
+func (s *UserService) filterProtectedIDs(ctx context.Context, operatorID int, ids []int,
+	results *[]BatchOperationResult, failedCount *int, failCode string) ([]int, bool) {
+	candidates := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if id == operatorID {
+			*results = append(*results, BatchOperationResult{
+				ID:           strconv.Itoa(id),
+				Success:      false,
+				ErrorCode:    ErrCodeSelfOperation,
+				ErrorMessage: "禁止对自己的账号执行该操作",
+			})
+			*failedCount++
+			continue
+		}
+		candidates = append(candidates, id)
+	}
+	if len(candidates) == 0 {
+		return candidates, true
+	}
+
+	admins, err := s.activeAdminIDs(ctx)
+	if err != nil {
+		for _, id := range candidates {
+			*results = append(*results, BatchOperationResult{
+				ID:           strconv.Itoa(id),
+				Success:      false,
+				ErrorCode:    failCode,
+				ErrorMessage: "校验管理员保护失败",
+			})
+			*failedCount++
+		}
+		return nil, false
+	}
+
+	// 统计本批次覆盖的活跃管理员，若会清空全部管理员则拒绝其中的管理员条目
+	adminsInBatch := 0
+	for _, id := range candidates {
+		if admins[id] {
+			adminsInBatch++
+		}
+	}
+	if len(admins) == 0 || adminsInBatch < len(admins) {
+		return candidates, true
+	}
+
+	allowed := make([]int, 0, len(candidates))
+	for _, id := range candidates {
+		if !admins[id] {
+			allowed = append(allowed, id)
+			continue
+		}
+		*results = append(*results, BatchOperationResult{
+			ID:           strconv.Itoa(id),
+			Success:      false,
+			ErrorCode:    ErrCodeLastAdmin,
+			ErrorMessage: "系统至少需要保留一名处于启用状态的管理员",
+		})
+		*failedCount++
+	}
+	return allowed, true
+}
+
+func (s *UserService) DeleteUser(ctx context.Context, operatorID, id int) error {
+	// 禁止删除自己，误操作会导致当前会话瞬间失效
+	if operatorID == id {
+		return ErrSelfOperation
+	}
+
+	// 校验目标用户在操作者数据范围内
+	if err := s.checkUserInScope(ctx, operatorID, id); err != nil {
+		return err
+	}
+
+	// 最后一个管理员保护：删除后系统不能没有可登录的管理员
+	last, err := s.isLastActiveAdmin(ctx, id)
+	if err != nil {
+		return err
+	}
+	if last {
+		return ErrLastAdmin
+	}
+
 	// 先查询用户信息 (用于同步删除到 Casdoor)
 	u, err := s.client.User.Query().Where(user.ID(id)).Only(ctx)
 	if err != nil {
@@ -570,7 +1165,10 @@ func (s *UserService) DeleteUser(ctx context.Context, id int) error {
 	}
 
 	// 同步用户删除到 Casdoor (异步，不影响本地操作)
-	go s.syncUserDeleteToCasdoor(ctx, u)
+	s.goCasdoorSync(func(ctx context.Context) { s.syncUserDeleteToCasdoor(ctx, u) })
+
+	// 用户数量变化，失效总数缓存
+	userCountCache.Clear()
 
 	return nil
 }
@@ -587,6 +1185,11 @@ func (s *UserService) ResetPassword(ctx context.Context, id int, newPassword str
 		return err
 	}
 
+	return s.resetPassword(ctx, id, newPassword)
+}
+
+// resetPassword 重置密码（调用方已完成密码策略校验）
+func (s *UserService) resetPassword(ctx context.Context, id int, newPassword string) error {
 	// 先查询用户信息 (用于同步密码到 Casdoor)
 	u, err := s.client.User.Query().Where(user.ID(id)).Only(ctx)
 	if err != nil {
@@ -596,8 +1199,13 @@ func (s *UserService) ResetPassword(ctx context.Context, id int, newPassword str
 		return err
 	}
 
+	passwordHash, err := auth.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
 	err = s.client.User.UpdateOneID(id).
-		SetPasswordHash(hashPassword(newPassword)).
+		SetPasswordHash(passwordHash).
 		SetLoginAttempts(0).
 		ClearLockedUntil().
 		Exec(ctx)
@@ -609,50 +1217,173 @@ func (s *UserService) ResetPassword(ctx context.Context, id int, newPassword str
 	}
 
 	// 同步密码到 Casdoor (异步，不影响本地操作)
-	go s.syncPasswordToCasdoor(ctx, u, newPassword)
+	s.goCasdoorSync(func(ctx context.Context) { s.syncPasswordToCasdoor(ctx, u, newPassword) })
 
 	return nil
 }
 
-// syncPasswordToCasdoor 同步密码到 Casdoor
-func (s *UserService) syncPasswordToCasdoor(ctx context.Context, u *ent.User, newPassword string) {
-	// 检查是否为本地用户
-	if u.AuthProvider != user.AuthProviderLocal {
-		return
+// UpdateOwnProfile 用户自助更新个人资料（昵称、头像、邮箱）
+// 仅修改当前用户自己的基础字段，不涉及角色、状态等管理属性
+func (s *UserService) UpdateOwnProfile(ctx context.Context, userID int, nickname, email, avatar *string) error {
+	u, err := s.client.User.Query().Where(user.ID(userID)).Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return ErrUserNotFound
+		}
+		return err
 	}
 
-	// 检查 Casdoor 客户端是否启用
-	if s.casdoorClient == nil || !s.casdoorClient.IsSyncEnabled() {
-		return
-	}
+	update := u.Update()
 
-	if err := s.casdoorClient.UpdatePassword(ctx, u.Username, newPassword); err != nil {
-		if errors.Is(err, casdoor.ErrUserNotFound) {
-			logger.Info("user not found in casdoor, password sync skipped",
-				"userID", u.ID,
-				"username", u.Username,
-			)
-		} else {
-			logger.Error("failed to sync password to casdoor",
-				"userID", u.ID,
-				"username", u.Username,
-				"error", err,
-			)
+	if nickname != nil {
+		update = update.SetNickname(*nickname).
+			SetPinyin(pinyin.Index(*nickname))
+	}
+	if email != nil {
+		// 检查邮箱是否被其他用户使用
+		exists, err := s.client.User.Query().
+			Where(user.Email(*email), user.IDNEQ(userID)).
+			Exist(ctx)
+		if err != nil {
+			return err
 		}
+		if exists {
+			return ErrUserExists
+		}
+		update = update.SetEmail(*email)
 	}
-}
-
-// BatchDeleteUsers 批量删除用户
-// 优化: 使用批量查询获取用户信息，支持 Casdoor 同步，返回详细错误信息
-func (s *UserService) BatchDeleteUsers(ctx context.Context, ids []int) ([]BatchOperationResult, int, int) {
-	results := make([]BatchOperationResult, 0, len(ids))
-	var successCount, failedCount int
-
-	if len(ids) == 0 {
-		return results, 0, 0
+	if avatar != nil {
+		update = update.SetAvatar(*avatar)
 	}
 
-	// 批量查询所有用户信息（用于 Casdoor 同步）
+	updated, err := update.Save(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 同步用户信息到 Casdoor (异步，不影响本地操作)
+	s.goCasdoorSync(func(ctx context.Context) { s.syncUserUpdateToCasdoor(ctx, updated, "") })
+
+	return nil
+}
+
+// ChangeOwnPassword 用户自助修改密码
+// 必须校验旧密码，新密码走密码策略，成功后同步到 Casdoor
+func (s *UserService) ChangeOwnPassword(ctx context.Context, userID int, oldPassword, newPassword string) error {
+	u, err := s.client.User.Query().Where(user.ID(userID)).Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	// 外部认证用户的密码由身份提供方管理，不允许本地修改
+	if u.AuthProvider != user.AuthProviderLocal {
+		return ErrExternalAuthUser
+	}
+
+	if !auth.VerifyPassword(oldPassword, u.PasswordHash) {
+		return ErrInvalidCredentials
+	}
+
+	// 验证密码策略
+	settingService := NewSystemSettingService(s.client)
+	policy, err := GetPasswordPolicy(ctx, settingService)
+	if err != nil {
+		return err
+	}
+	if err := ValidatePassword(newPassword, policy); err != nil {
+		return err
+	}
+
+	return s.resetPassword(ctx, userID, newPassword)
+}
+
+// UnlockUser 解锁被登录失败锁定的用户
+// 清除锁定时间并将失败次数归零，返回解锁前用户是否确实处于锁定状态
+func (s *UserService) UnlockUser(ctx context.Context, id int) (bool, error) {
+	u, err := s.client.User.Query().
+		Where(user.ID(id)).
+		Select(user.FieldID, user.FieldLockedUntil).
+		Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return false, ErrUserNotFound
+		}
+		return false, err
+	}
+
+	wasLocked := u.LockedUntil != nil && u.LockedUntil.After(time.Now())
+
+	err = s.client.User.UpdateOneID(id).
+		SetLoginAttempts(0).
+		ClearLockedUntil().
+		Exec(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return false, ErrUserNotFound
+		}
+		return false, err
+	}
+
+	return wasLocked, nil
+}
+
+// syncPasswordToCasdoor 同步密码到 Casdoor
+func (s *UserService) syncPasswordToCasdoor(ctx context.Context, u *ent.User, newPassword string) {
+	s.casdoorSyncPending.Add(1)
+	defer s.casdoorSyncPending.Add(-1)
+
+	// 检查是否为本地用户
+	if u.AuthProvider != user.AuthProviderLocal {
+		return
+	}
+
+	// 检查 Casdoor 客户端是否启用
+	if s.casdoorClient == nil || !s.casdoorClient.IsSyncEnabled() {
+		return
+	}
+
+	if err := s.casdoorClient.UpdatePassword(ctx, u.Username, newPassword); err != nil {
+		if errors.Is(err, casdoor.ErrUserNotFound) {
+			logger.Info("user not found in casdoor, password sync skipped",
+				"userID", u.ID,
+				"username", u.Username,
+			)
+		} else {
+			logger.Error("failed to sync password to casdoor",
+				"userID", u.ID,
+				"username", u.Username,
+				"error", err,
+			)
+		}
+	}
+}
+
+// BatchDeleteUsers 批量删除用户
+// 优化: 使用批量查询获取用户信息，支持 Casdoor 同步，返回详细错误信息
+func (s *UserService) BatchDeleteUsers(ctx context.Context, operatorID int, ids []int) ([]BatchOperationResult, int, int) {
+	results := make([]BatchOperationResult, 0, len(ids))
+	var successCount, failedCount int
+
+	if len(ids) == 0 {
+		return results, 0, 0
+	}
+
+	// 逐个校验操作者数据范围，无权操作的目标标记失败而不是静默执行
+	ids, ok := s.filterIDsInScope(ctx, operatorID, ids, &results, &failedCount, ErrCodeDeleteFailed)
+	if !ok || len(ids) == 0 {
+		return results, successCount, failedCount
+	}
+
+	// 保护校验：禁止删除自己，且不能清空全部活跃管理员
+	ids, ok = s.filterProtectedIDs(ctx, operatorID, ids, &results, &failedCount, ErrCodeDeleteFailed)
+	if !ok || len(ids) == 0 {
+		return results, successCount, failedCount
+	}
+
+	// 批量查询所有用户信息（用于 Casdoor 同步）
 	users, err := s.client.User.Query().
 		Where(user.IDIn(ids...)).
 		All(ctx)
@@ -728,7 +1459,7 @@ func (s *UserService) BatchDeleteUsers(ctx context.Context, ids []int) ([]BatchO
 			successCount++
 
 			// 异步同步删除到 Casdoor（使用独立 context 避免请求取消后同步失败）
-			go s.syncUserDeleteToCasdoor(context.Background(), u)
+			s.goCasdoorSync(func(ctx context.Context) { s.syncUserDeleteToCasdoor(ctx, u) })
 		} else {
 			// 删除失败
 			results = append(results, BatchOperationResult{
@@ -741,12 +1472,17 @@ func (s *UserService) BatchDeleteUsers(ctx context.Context, ids []int) ([]BatchO
 		}
 	}
 
+	// 用户数量变化，失效总数缓存
+	if successCount > 0 {
+		userCountCache.Clear()
+	}
+
 	return results, successCount, failedCount
 }
 
 // BatchUpdateStatus 批量更新用户状态
 // 优化: 使用批量更新，返回详细错误信息
-func (s *UserService) BatchUpdateStatus(ctx context.Context, ids []int, status base.UserStatus) ([]BatchOperationResult, int, int) {
+func (s *UserService) BatchUpdateStatus(ctx context.Context, operatorID int, ids []int, status base.UserStatus) ([]BatchOperationResult, int, int) {
 	results := make([]BatchOperationResult, 0, len(ids))
 	var successCount, failedCount int
 
@@ -769,10 +1505,24 @@ func (s *UserService) BatchUpdateStatus(ctx context.Context, ids []int, status b
 		return results, successCount, failedCount
 	}
 
-	// 批量查询存在的用户
+	// 逐个校验操作者数据范围，无权操作的目标标记失败而不是静默执行
+	ids, ok := s.filterIDsInScope(ctx, operatorID, ids, &results, &failedCount, ErrCodeUpdateFailed)
+	if !ok || len(ids) == 0 {
+		return results, successCount, failedCount
+	}
+
+	// 非启用状态视为封禁类操作：禁止封禁自己，且不能清空全部活跃管理员
+	if entStatus != user.StatusActive {
+		ids, ok = s.filterProtectedIDs(ctx, operatorID, ids, &results, &failedCount, ErrCodeUpdateFailed)
+		if !ok || len(ids) == 0 {
+			return results, successCount, failedCount
+		}
+	}
+
+	// 批量查询存在的用户 (用户名和认证来源用于同步封禁状态到 Casdoor)
 	existingUsers, err := s.client.User.Query().
 		Where(user.IDIn(ids...)).
-		Select(user.FieldID).
+		Select(user.FieldID, user.FieldUsername, user.FieldAuthProvider).
 		All(ctx)
 	if err != nil {
 		// 查询失败，所有 ID 都标记为失败
@@ -809,6 +1559,11 @@ func (s *UserService) BatchUpdateStatus(ctx context.Context, ids []int, status b
 		} else {
 			// 批量更新成功
 			updatedIDs = existingIDSet
+
+			// 同步封禁状态到 Casdoor (异步，不影响本地操作)
+			for _, u := range existingUsers {
+				s.goCasdoorSync(func(ctx context.Context) { s.syncUserStatusToCasdoor(ctx, u, entStatus) })
+			}
 		}
 	} else {
 		updatedIDs = make(map[int]bool)
@@ -849,9 +1604,451 @@ func (s *UserService) BatchUpdateStatus(ctx context.Context, ids []int, status b
 		}
 	}
 
+	// 状态筛选的总数可能变化，失效总数缓存
+	if successCount > 0 {
+		userCountCache.Clear()
+	}
+
+	return results, successCount, failedCount
+}
+
+// CheckExistence 批量检查用户名和邮箱是否已被占用
+// 各用一次 IN 查询判断，供集成方在创建或导入前预检
+func (s *UserService) CheckExistence(ctx context.Context, usernames, emails []string) (map[string]bool, map[string]bool, error) {
+	usernameExists := make(map[string]bool, len(usernames))
+	emailExists := make(map[string]bool, len(emails))
+
+	if len(usernames) > 0 {
+		for _, name := range usernames {
+			usernameExists[name] = false
+		}
+		taken, err := s.client.User.Query().
+			Where(user.UsernameIn(usernames...)).
+			Select(user.FieldUsername).
+			Strings(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, name := range taken {
+			usernameExists[name] = true
+		}
+	}
+
+	if len(emails) > 0 {
+		for _, email := range emails {
+			emailExists[email] = false
+		}
+		taken, err := s.client.User.Query().
+			Where(user.EmailIn(emails...)).
+			Select(user.FieldEmail).
+			Strings(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, email := range taken {
+			emailExists[email] = true
+		}
+	}
+
+	return usernameExists, emailExists, nil
+}
+
+// BatchUnlockUsers 批量解锁用户
+// 清除锁定时间并将失败次数归零，返回每个 ID 的详细结果
+func (s *UserService) BatchUnlockUsers(ctx context.Context, operatorID int, ids []int) ([]BatchOperationResult, int, int) {
+	results := make([]BatchOperationResult, 0, len(ids))
+	var successCount, failedCount int
+
+	if len(ids) == 0 {
+		return results, 0, 0
+	}
+
+	// 逐个校验操作者数据范围，无权操作的目标标记失败而不是静默执行
+	ids, ok := s.filterIDsInScope(ctx, operatorID, ids, &results, &failedCount, ErrCodeUnlockFailed)
+	if !ok || len(ids) == 0 {
+		return results, successCount, failedCount
+	}
+
+	// 批量查询存在的用户
+	existingIDs, err := s.client.User.Query().
+		Where(user.IDIn(ids...)).
+		IDs(ctx)
+	if err != nil {
+		// 查询失败，所有 ID 都标记为失败
+		for _, id := range ids {
+			results = append(results, BatchOperationResult{
+				ID:           strconv.Itoa(id),
+				Success:      false,
+				ErrorCode:    ErrCodeUnlockFailed,
+				ErrorMessage: "查询用户信息失败",
+			})
+			failedCount++
+		}
+		return results, successCount, failedCount
+	}
+
+	existingIDSet := make(map[int]bool, len(existingIDs))
+	for _, id := range existingIDs {
+		existingIDSet[id] = true
+	}
+
+	// 批量清除锁定状态
+	var unlockedIDs map[int]bool
+	if len(existingIDs) > 0 {
+		_, err = s.client.User.Update().
+			Where(user.IDIn(existingIDs...)).
+			SetLoginAttempts(0).
+			ClearLockedUntil().
+			Save(ctx)
+		if err != nil {
+			unlockedIDs = make(map[int]bool)
+		} else {
+			unlockedIDs = existingIDSet
+		}
+	} else {
+		unlockedIDs = make(map[int]bool)
+	}
+
+	// 处理每个请求的 ID，生成详细结果
+	for _, id := range ids {
+		idStr := strconv.Itoa(id)
+
+		if !existingIDSet[id] {
+			// 用户不存在
+			results = append(results, BatchOperationResult{
+				ID:           idStr,
+				Success:      false,
+				ErrorCode:    ErrCodeNotFound,
+				ErrorMessage: "用户不存在",
+			})
+			failedCount++
+			continue
+		}
+
+		if unlockedIDs[id] {
+			results = append(results, BatchOperationResult{
+				ID:      idStr,
+				Success: true,
+			})
+			successCount++
+		} else {
+			results = append(results, BatchOperationResult{
+				ID:           idStr,
+				Success:      false,
+				ErrorCode:    ErrCodeUnlockFailed,
+				ErrorMessage: "解锁用户失败",
+			})
+			failedCount++
+		}
+	}
+
+	return results, successCount, failedCount
+}
+
+// MergeUsers 合并用户账号
+// 将次账号的审计日志、角色迁移到主账号，主账号缺少 CAS 绑定时继承次账号的
+// external_id（本地密码 + CAS 登录两者兼得），最后删除次账号
+func (s *UserService) MergeUsers(ctx context.Context, primaryID, secondaryID int) (*base.MergeUsersResponse, error) {
+	if primaryID == secondaryID {
+		return nil, ErrMergeSameUser
+	}
+
+	tx, err := s.client.Tx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// 任一步骤失败则整体回滚，避免出现合并到一半的状态
+	defer tx.Rollback()
+
+	primary, err := tx.User.Query().Where(user.ID(primaryID)).WithRoles().Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	secondary, err := tx.User.Query().Where(user.ID(secondaryID)).WithRoles().Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	// 迁移审计日志归属
+	_, err = tx.AuditLog.Update().
+		Where(auditlog.UserIDEQ(secondaryID)).
+		SetUserID(primaryID).
+		Save(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 合并角色（补上主账号缺少的）
+	if len(secondary.Edges.Roles) > 0 {
+		existing := make(map[int]bool, len(primary.Edges.Roles))
+		for _, r := range primary.Edges.Roles {
+			existing[r.ID] = true
+		}
+		addRoles := make([]*ent.Role, 0, len(secondary.Edges.Roles))
+		for _, r := range secondary.Edges.Roles {
+			if !existing[r.ID] {
+				addRoles = append(addRoles, r)
+			}
+		}
+		if len(addRoles) > 0 {
+			_, err = tx.User.UpdateOneID(primaryID).AddRoles(addRoles...).Save(ctx)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// 删除次账号的刷新令牌与账号本身
+	// 先删除账号以释放 (auth_provider, external_id) 唯一索引
+	_, err = tx.RefreshToken.Delete().
+		Where(refreshtoken.HasUserWith(user.ID(secondaryID))).
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.User.DeleteOneID(secondaryID).Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	// 主账号缺少 CAS 绑定时继承次账号的 external_id
+	if (primary.ExternalID == nil || *primary.ExternalID == "") &&
+		secondary.ExternalID != nil && *secondary.ExternalID != "" {
+		_, err = tx.User.UpdateOneID(primaryID).
+			SetExternalID(*secondary.ExternalID).
+			Save(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// 用户数量变化，失效总数缓存
+	userCountCache.Clear()
+
+	// 异步同步删除到 Casdoor（主账号已继承 CAS 绑定，仅清理本地次账号）
+	s.goCasdoorSync(func(ctx context.Context) { s.syncUserDeleteToCasdoor(ctx, secondary) })
+
+	// 重新查询主账号返回合并结果
+	merged, err := s.client.User.Query().
+		Where(user.ID(primaryID)).
+		WithRoles().
+		WithDepartment().
+		WithTags().
+		Only(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &base.MergeUsersResponse{
+		User: s.toUserDetail(merged),
+	}, nil
+}
+
+// BatchProgress 批量操作进度
+type BatchProgress struct {
+	// Processed 已处理数
+	Processed int
+	// Total 总数
+	Total int
+	// Failed 当前失败数
+	Failed int
+}
+
+// batchProgressInterval 每处理多少条上报一次进度
+const batchProgressInterval = 100
+
+// BatchImportUsers 批量导入用户
+// 每处理 batchProgressInterval 条通过 onProgress 回调上报一次进度，
+// 调用方可借此实现流式进度反馈
+func (s *UserService) BatchImportUsers(ctx context.Context, items []*base.ImportUserItem, onProgress func(BatchProgress)) ([]BatchOperationResult, int, int) {
+	results := make([]BatchOperationResult, 0, len(items))
+	var successCount, failedCount int
+	total := len(items)
+
+	for i, item := range items {
+		// 客户端断开或请求取消时不再继续处理，剩余条目标记为已取消
+		if ctx.Err() != nil {
+			for _, rest := range items[i:] {
+				results = append(results, BatchOperationResult{
+					ID:           rest.Username,
+					Success:      false,
+					ErrorCode:    ErrCodeCancelled,
+					ErrorMessage: "请求已取消，该条目未处理",
+				})
+				failedCount++
+			}
+			break
+		}
+
+		_, err := s.CreateUser(ctx, &base.CreateUserRequest{
+			Username:     item.Username,
+			Password:     item.Password,
+			Email:        item.Email,
+			Nickname:     item.Nickname,
+			Roles:        item.Roles,
+			DepartmentId: item.DepartmentId,
+		})
+		if err != nil {
+			results = append(results, BatchOperationResult{
+				ID:           item.Username,
+				Success:      false,
+				ErrorCode:    ErrCodeImportFailed,
+				ErrorMessage: ImportErrorMessage(err),
+			})
+			failedCount++
+		} else {
+			results = append(results, BatchOperationResult{
+				ID:      item.Username,
+				Success: true,
+			})
+			successCount++
+		}
+
+		// 中间进度上报（最后一条由调用方的汇总消息覆盖）
+		if onProgress != nil && (i+1)%batchProgressInterval == 0 && i+1 < total {
+			onProgress(BatchProgress{Processed: i + 1, Total: total, Failed: failedCount})
+		}
+	}
+
 	return results, successCount, failedCount
 }
 
+// ValidateImportUser 仅校验导入条目而不写库，供 CSV 导入的 dry-run 模式复用
+// 校验内容与 CreateUser 保持一致：密码策略、用户名/邮箱唯一性、所属部门存在性
+func (s *UserService) ValidateImportUser(ctx context.Context, item *base.ImportUserItem) error {
+	settingService := NewSystemSettingService(s.client)
+	policy, err := GetPasswordPolicy(ctx, settingService)
+	if err != nil {
+		return err
+	}
+	if err := ValidatePassword(item.Password, policy); err != nil {
+		return err
+	}
+
+	exists, err := s.client.User.Query().Where(user.Username(item.Username)).Exist(ctx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrUserExists
+	}
+
+	exists, err = s.client.User.Query().Where(user.Email(item.Email)).Exist(ctx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrUserExists
+	}
+
+	if item.DepartmentId != "" {
+		deptID, err := strconv.Atoi(item.DepartmentId)
+		if err != nil {
+			return ErrDepartmentNotFound
+		}
+		exists, err := s.client.Department.Query().Where(department.ID(deptID)).Exist(ctx)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrDepartmentNotFound
+		}
+	}
+
+	return nil
+}
+
+// ImportErrorMessage 将导入错误转换为用户可读的消息
+func ImportErrorMessage(err error) string {
+	if errors.Is(err, ErrUserExists) {
+		return "用户名或邮箱已存在"
+	}
+	if errors.Is(err, ErrDepartmentNotFound) {
+		return "所属部门不存在"
+	}
+	return err.Error()
+}
+
+// BatchResetPasswords 批量重置用户密码
+// 密码策略只校验一次，每处理 batchProgressInterval 条通过 onProgress 回调上报进度
+func (s *UserService) BatchResetPasswords(ctx context.Context, operatorID int, ids []int, newPassword string, onProgress func(BatchProgress)) ([]BatchOperationResult, int, int, error) {
+	// 验证密码策略（所有用户共用同一新密码，只校验一次）
+	settingService := NewSystemSettingService(s.client)
+	policy, err := GetPasswordPolicy(ctx, settingService)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if err := ValidatePassword(newPassword, policy); err != nil {
+		return nil, 0, 0, err
+	}
+
+	results := make([]BatchOperationResult, 0, len(ids))
+	var successCount, failedCount int
+
+	// 逐个校验操作者数据范围，无权操作的目标标记失败而不是静默执行
+	ids, ok := s.filterIDsInScope(ctx, operatorID, ids, &results, &failedCount, ErrCodeResetFailed)
+	if !ok {
+		return results, successCount, failedCount, nil
+	}
+	total := len(ids)
+
+	for i, id := range ids {
+		idStr := strconv.Itoa(id)
+
+		// 客户端断开或请求取消时不再继续处理，剩余条目标记为已取消
+		if ctx.Err() != nil {
+			for _, rest := range ids[i:] {
+				results = append(results, BatchOperationResult{
+					ID:           strconv.Itoa(rest),
+					Success:      false,
+					ErrorCode:    ErrCodeCancelled,
+					ErrorMessage: "请求已取消，该条目未处理",
+				})
+				failedCount++
+			}
+			break
+		}
+
+		if err := s.resetPassword(ctx, id, newPassword); err != nil {
+			errCode := ErrCodeResetFailed
+			errMessage := "重置密码失败"
+			if errors.Is(err, ErrUserNotFound) {
+				errCode = ErrCodeNotFound
+				errMessage = "用户不存在"
+			}
+			results = append(results, BatchOperationResult{
+				ID:           idStr,
+				Success:      false,
+				ErrorCode:    errCode,
+				ErrorMessage: errMessage,
+			})
+			failedCount++
+		} else {
+			results = append(results, BatchOperationResult{
+				ID:      idStr,
+				Success: true,
+			})
+			successCount++
+		}
+
+		// 中间进度上报（最后一条由调用方的汇总消息覆盖）
+		if onProgress != nil && (i+1)%batchProgressInterval == 0 && i+1 < total {
+			onProgress(BatchProgress{Processed: i + 1, Total: total, Failed: failedCount})
+		}
+	}
+
+	return results, successCount, failedCount, nil
+}
+
 // toUserDetail 将 ent.User 转换为 base.UserDetail
 func (s *UserService) toUserDetail(u *ent.User) *base.UserDetail {
 	roles := make([]string, 0, len(u.Edges.Roles))
@@ -860,21 +2057,39 @@ func (s *UserService) toUserDetail(u *ent.User) *base.UserDetail {
 	}
 
 	detail := &base.UserDetail{
-		Id:        strconv.Itoa(u.ID),
-		Username:  u.Username,
-		Nickname:  u.Nickname,
-		Avatar:    u.Avatar,
-		Email:     u.Email,
-		Status:    entStatusToProto(u.Status),
-		Roles:     roles,
-		CreatedAt: u.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: u.UpdatedAt.Format(time.RFC3339),
+		Id:          strconv.Itoa(u.ID),
+		Username:    u.Username,
+		Nickname:    u.Nickname,
+		Avatar:      u.Avatar,
+		Email:       u.Email,
+		Status:      entStatusToProto(u.Status),
+		Roles:       roles,
+		CreatedAt:   u.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   u.UpdatedAt.Format(time.RFC3339),
+		LoginNotify: u.LoginNotify,
 	}
 
 	if u.LastLoginAt != nil {
 		detail.LastLoginAt = u.LastLoginAt.Format(time.RFC3339)
 	}
 
+	if u.Edges.Department != nil {
+		detail.DepartmentId = strconv.Itoa(u.Edges.Department.ID)
+		detail.DepartmentName = u.Edges.Department.Name
+	}
+
+	if len(u.Edges.Tags) > 0 {
+		tags := make([]string, 0, len(u.Edges.Tags))
+		for _, t := range u.Edges.Tags {
+			tags = append(tags, t.Name)
+		}
+		detail.Tags = tags
+	}
+
+	if len(u.Metadata) > 0 {
+		detail.Metadata = u.Metadata
+	}
+
 	return detail
 }
 
@@ -887,6 +2102,8 @@ func protoStatusToEnt(status base.UserStatus) user.Status {
 		return user.StatusInactive
 	case base.UserStatus_USER_STATUS_BANNED:
 		return user.StatusBanned
+	case base.UserStatus_USER_STATUS_SOFTBAN:
+		return user.StatusSoftban
 	default:
 		return ""
 	}
@@ -901,7 +2118,176 @@ func entStatusToProto(status user.Status) base.UserStatus {
 		return base.UserStatus_USER_STATUS_INACTIVE
 	case user.StatusBanned:
 		return base.UserStatus_USER_STATUS_BANNED
+	case user.StatusSoftban:
+		return base.UserStatus_USER_STATUS_SOFTBAN
 	default:
 		return base.UserStatus_USER_STATUS_UNSPECIFIED
 	}
 }
+
+// ListTags 获取所有标签及使用数
+func (s *UserService) ListTags(ctx context.Context) ([]*base.TagInfo, error) {
+	tags, err := s.client.Tag.Query().
+		Order(ent.Asc(tag.FieldName)).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]*base.TagInfo, 0, len(tags))
+	for _, t := range tags {
+		count, err := t.QueryUsers().Count(ctx)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, &base.TagInfo{
+			Id:        strconv.Itoa(t.ID),
+			Name:      t.Name,
+			UserCount: int32(count),
+			CreatedAt: t.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	return infos, nil
+}
+
+// AddUserTags 给用户打标签，不存在的标签自动创建
+func (s *UserService) AddUserTags(ctx context.Context, id int, names []string) (*base.UserDetail, error) {
+	u, err := s.client.User.Get(ctx, id)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	tags, err := s.findOrCreateTags(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	// 过滤已关联的标签，避免重复关联报错
+	existing, err := u.QueryTags().All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existingIDs := make(map[int]bool, len(existing))
+	for _, t := range existing {
+		existingIDs[t.ID] = true
+	}
+	toAdd := make([]*ent.Tag, 0, len(tags))
+	for _, t := range tags {
+		if !existingIDs[t.ID] {
+			toAdd = append(toAdd, t)
+		}
+	}
+
+	if len(toAdd) > 0 {
+		if err := u.Update().AddTags(toAdd...).Exec(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.requeryUserDetail(ctx, id)
+}
+
+// UpdateUserMetadata 更新用户扩展属性
+// metadata 整体替换原有扩展属性，传空 map 即清空；
+// 扩展属性用于存储组织特有字段（如工号、职位），不参与权限计算
+func (s *UserService) UpdateUserMetadata(ctx context.Context, id int, metadata map[string]string) (*base.UserDetail, error) {
+	u, err := s.client.User.Get(ctx, id)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	update := u.Update()
+	if len(metadata) == 0 {
+		update = update.ClearMetadata()
+	} else {
+		update = update.SetMetadata(metadata)
+	}
+	if err := update.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.requeryUserDetail(ctx, id)
+}
+
+// RemoveUserTags 移除用户标签
+// 标签实体保留，仅解除与用户的关联
+func (s *UserService) RemoveUserTags(ctx context.Context, id int, names []string) (*base.UserDetail, error) {
+	u, err := s.client.User.Get(ctx, id)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	toRemove, err := u.QueryTags().
+		Where(tag.NameIn(names...)).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(toRemove) > 0 {
+		if err := u.Update().RemoveTags(toRemove...).Exec(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.requeryUserDetail(ctx, id)
+}
+
+// findOrCreateTags 按名称查找标签，不存在的自动创建
+func (s *UserService) findOrCreateTags(ctx context.Context, names []string) ([]*ent.Tag, error) {
+	existing, err := s.client.Tag.Query().
+		Where(tag.NameIn(names...)).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*ent.Tag, len(existing))
+	for _, t := range existing {
+		byName[t.Name] = t
+	}
+
+	tags := make([]*ent.Tag, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		if t, ok := byName[name]; ok {
+			tags = append(tags, t)
+			continue
+		}
+		t, err := s.client.Tag.Create().SetName(name).Save(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+
+	return tags, nil
+}
+
+// requeryUserDetail 重新查询用户并转换为 UserDetail
+func (s *UserService) requeryUserDetail(ctx context.Context, id int) (*base.UserDetail, error) {
+	u, err := s.client.User.Query().
+		Where(user.ID(id)).
+		WithRoles().
+		WithDepartment().
+		WithTags().
+		Only(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.toUserDetail(u), nil
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"zera/ent"
+	"zera/gen/base"
+
+	"entgo.io/ent/dialect"
+	entsql "entgo.io/ent/dialect/sql"
+
+	_ "github.com/lib/pq"
+)
+
+// newUnconnectedClient 构造一个指向不可达地址的 ent 客户端
+// sql.Open 懒连接，不实际建连也能构造；database/sql 在获取连接前
+// 会先检查 ctx 是否已取消，因此可以离线验证 ctx 的传播路径
+func newUnconnectedClient(t *testing.T) *ent.Client {
+	t.Helper()
+	db, err := sql.Open("postgres", "postgres://127.0.0.1:1/zera_test?sslmode=disable")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	client := ent.NewClient(ent.Driver(entsql.OpenDB(dialect.Postgres, db)))
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// TestListUsersCancelledContext 已取消的 context 调用 ListUsers
+// 应快速返回 context 错误而非继续执行查询；
+// 若任何一步没有透传 ctx（用了 context.Background()），错误会变成连接失败而非取消
+func TestListUsersCancelledContext(t *testing.T) {
+	svc := NewUserService(newUnconnectedClient(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := svc.ListUsers(ctx, 0, &base.ListUsersRequest{
+		Page:     1,
+		PageSize: 10,
+		// 要求精确总数，绕过总数缓存，保证命中数据库查询路径
+		ExactTotal: true,
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ListUsers 未传播取消错误, got %v", err)
+	}
+}
+
+// TestGetUserCancelledContext 读单个用户同样应响应取消
+func TestGetUserCancelledContext(t *testing.T) {
+	svc := NewUserService(newUnconnectedClient(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := svc.GetUser(ctx, 0, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetUser 未传播取消错误, got %v", err)
+	}
+}
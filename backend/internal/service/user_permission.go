@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"zera/ent"
+	entPermission "zera/ent/permission"
+	"zera/ent/user"
+	"zera/internal/logger"
+)
+
+// GrantUserPermission 直接授予用户权限（绕过角色的一次性授权）
+// 重复授予视为成功，保证接口幂等
+func (s *UserService) GrantUserPermission(ctx context.Context, userID int, permissionCode string) error {
+	u, err := s.client.User.Query().Where(user.ID(userID)).Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	p, err := s.client.Permission.Query().
+		Where(entPermission.Code(permissionCode)).
+		Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return fmt.Errorf("%w: %s", ErrInvalidPermissionCodes, permissionCode)
+		}
+		return err
+	}
+
+	// 已授予则直接返回，避免重复写入触发联接表唯一约束
+	exists, err := u.QueryDirectPermissions().
+		Where(entPermission.ID(p.ID)).
+		Exist(ctx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	if err := u.Update().AddDirectPermissions(p).Exec(ctx); err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "user permission granted",
+		"userID", userID,
+		"permission", permissionCode,
+	)
+	return nil
+}
+
+// RevokeUserPermission 撤销用户的直接权限
+// 未授予时同样返回成功，保证接口幂等；不影响经由角色获得的权限
+func (s *UserService) RevokeUserPermission(ctx context.Context, userID int, permissionCode string) error {
+	u, err := s.client.User.Query().Where(user.ID(userID)).Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	p, err := s.client.Permission.Query().
+		Where(entPermission.Code(permissionCode)).
+		Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return fmt.Errorf("%w: %s", ErrInvalidPermissionCodes, permissionCode)
+		}
+		return err
+	}
+
+	if err := u.Update().RemoveDirectPermissions(p).Exec(ctx); err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "user permission revoked",
+		"userID", userID,
+		"permission", permissionCode,
+	)
+	return nil
+}
+
+// directUserPermissionCodes 查询用户直接授予的权限代码（排序后返回）
+func directUserPermissionCodes(ctx context.Context, client *ent.Client, userID int) ([]string, error) {
+	perms, err := client.User.Query().
+		Where(user.ID(userID)).
+		QueryDirectPermissions().
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, len(perms))
+	for _, p := range perms {
+		codes = append(codes, p.Code)
+	}
+	sort.Strings(codes)
+	return codes, nil
+}
+
+// mergeDirectUserPermissions 将用户直接授予的权限并入权限集合
+// 与角色权限（含继承）合并后构成用户的最终权限
+func mergeDirectUserPermissions(ctx context.Context, client *ent.Client, userID int, permissionSet map[string]bool) error {
+	codes, err := directUserPermissionCodes(ctx, client, userID)
+	if err != nil {
+		return err
+	}
+	for _, code := range codes {
+		permissionSet[code] = true
+	}
+	return nil
+}
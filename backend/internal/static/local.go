@@ -36,6 +36,12 @@ func (s *LocalStorage) EnsureLogoDir() error {
 	return os.MkdirAll(logoDir, 0755)
 }
 
+// EnsureBackgroundDir 确保登录页背景图目录存在
+func (s *LocalStorage) EnsureBackgroundDir() error {
+	backgroundDir := filepath.Join(s.basePath, "background")
+	return os.MkdirAll(backgroundDir, 0755)
+}
+
 // SaveFile 保存文件到指定子路径
 // subPath: 相对于 basePath 的路径，如 "logo/logo.png"
 func (s *LocalStorage) SaveFile(subPath string, data []byte) error {
@@ -120,6 +126,30 @@ func (s *LocalStorage) DeleteLogoFiles() error {
 	return nil
 }
 
+// DeleteBackgroundFiles 删除 background 目录下所有文件
+func (s *LocalStorage) DeleteBackgroundFiles() error {
+	backgroundDir := filepath.Join(s.basePath, "background")
+
+	entries, err := os.ReadDir(backgroundDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read background directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			filePath := filepath.Join(backgroundDir, entry.Name())
+			if err := os.Remove(filePath); err != nil {
+				return fmt.Errorf("failed to delete file %s: %w", entry.Name(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
 // FileExists 检查文件是否存在
 func (s *LocalStorage) FileExists(subPath string) bool {
 	fullPath := filepath.Join(s.basePath, subPath)
@@ -175,3 +205,37 @@ func (s *LocalStorage) FindLogoFile() (string, bool) {
 
 	return "", false
 }
+
+// FindBackgroundFile 查找 background 目录下的背景图文件
+// 返回文件相对路径（如 "background/background.png"）和是否找到
+func (s *LocalStorage) FindBackgroundFile() (string, bool) {
+	backgroundDir := filepath.Join(s.basePath, "background")
+
+	entries, err := os.ReadDir(backgroundDir)
+	if err != nil {
+		return "", false
+	}
+
+	// 支持的扩展名
+	supportedExts := map[string]bool{
+		".png":  true,
+		".jpg":  true,
+		".jpeg": true,
+		".svg":  true,
+		".webp": true,
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			name := entry.Name()
+			ext := strings.ToLower(filepath.Ext(name))
+			// 查找以 "background" 开头的文件
+			if strings.HasPrefix(strings.ToLower(name), "background") && supportedExts[ext] {
+				// 使用正斜杠作为 URL 路径分隔符（跨平台兼容）
+				return "background/" + name, true
+			}
+		}
+	}
+
+	return "", false
+}
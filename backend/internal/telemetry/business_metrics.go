@@ -0,0 +1,86 @@
+package telemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+// BusinessMetricsSource 业务健康指标数据源
+// 各字段由对应的 service 提供当前值的读取方法，为 nil 的指标不上报；
+// 回调在每个导出周期执行，读取方法应足够轻量
+type BusinessMetricsSource struct {
+	// TotalUsers 当前用户总数
+	TotalUsers func(ctx context.Context) (int64, error)
+	// ActiveSessions 活跃会话数（窗口期内有请求的在线用户数）
+	ActiveSessions func() int64
+	// LoginFailureRate 最近 5 分钟登录失败率（0-1）
+	LoginFailureRate func() float64
+	// AuditLogBacklog 审计日志写入积压数（缓冲区中等待写入的条数）
+	AuditLogBacklog func() int64
+	// CasdoorSyncQueue Casdoor 同步队列长度（进行中的异步同步任务数）
+	CasdoorSyncQueue func() int64
+}
+
+// RegisterBusinessMetrics 注册业务健康指标
+// 通过 ObservableGauge 暴露，运维可在 Prometheus/SigNoz 上据此设告警
+func (p *Provider) RegisterBusinessMetrics(src *BusinessMetricsSource) {
+	if p.meterProvider == nil || src == nil {
+		return
+	}
+
+	meter := p.meterProvider.Meter("zera.business")
+
+	usersGauge, _ := meter.Int64ObservableGauge(
+		"zera.business.users.total",
+		metric.WithDescription("Total number of users"),
+	)
+
+	sessionsGauge, _ := meter.Int64ObservableGauge(
+		"zera.business.sessions.active",
+		metric.WithDescription("Number of active sessions in the online window"),
+	)
+
+	loginFailureGauge, _ := meter.Float64ObservableGauge(
+		"zera.business.login.failure_rate",
+		metric.WithDescription("Login failure rate over the last 5 minutes (0-1)"),
+	)
+
+	auditBacklogGauge, _ := meter.Int64ObservableGauge(
+		"zera.business.audit.backlog",
+		metric.WithDescription("Number of audit log entries waiting to be written"),
+	)
+
+	casdoorQueueGauge, _ := meter.Int64ObservableGauge(
+		"zera.business.casdoor.sync_queue",
+		metric.WithDescription("Number of in-flight Casdoor sync tasks"),
+	)
+
+	_, _ = meter.RegisterCallback(
+		func(ctx context.Context, o metric.Observer) error {
+			if src.TotalUsers != nil {
+				if total, err := src.TotalUsers(ctx); err == nil {
+					o.ObserveInt64(usersGauge, total)
+				}
+			}
+			if src.ActiveSessions != nil {
+				o.ObserveInt64(sessionsGauge, src.ActiveSessions())
+			}
+			if src.LoginFailureRate != nil {
+				o.ObserveFloat64(loginFailureGauge, src.LoginFailureRate())
+			}
+			if src.AuditLogBacklog != nil {
+				o.ObserveInt64(auditBacklogGauge, src.AuditLogBacklog())
+			}
+			if src.CasdoorSyncQueue != nil {
+				o.ObserveInt64(casdoorQueueGauge, src.CasdoorSyncQueue())
+			}
+			return nil
+		},
+		usersGauge,
+		sessionsGauge,
+		loginFailureGauge,
+		auditBacklogGauge,
+		casdoorQueueGauge,
+	)
+}
@@ -5,6 +5,8 @@ import (
 	"context"
 	"time"
 
+	"zera/internal/logger"
+
 	"connectrpc.com/connect"
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/otel"
@@ -25,13 +27,16 @@ const (
 type OtelTraceInterceptor struct {
 	provider  *Provider
 	loggerSet *LoggerSet
+	// bodySampler 请求体/响应体记录采样器，nil 表示全量记录
+	bodySampler *logger.BodySampler
 }
 
 // NewOtelTraceInterceptor 创建 OpenTelemetry 追踪拦截器
-func NewOtelTraceInterceptor(provider *Provider, loggerSet *LoggerSet) *OtelTraceInterceptor {
+func NewOtelTraceInterceptor(provider *Provider, loggerSet *LoggerSet, bodySampler *logger.BodySampler) *OtelTraceInterceptor {
 	return &OtelTraceInterceptor{
-		provider:  provider,
-		loggerSet: loggerSet,
+		provider:    provider,
+		loggerSet:   loggerSet,
+		bodySampler: bodySampler,
 	}
 }
 
@@ -62,19 +67,21 @@ func (i *OtelTraceInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFu
 
 		startTime := time.Now()
 
-		// 序列化请求体为 JSON
-		reqBody := marshalProtoMessage(req.Any())
-
 		// 执行请求
 		resp, err := next(ctx, req)
 
 		// 计算耗时
 		duration := time.Since(startTime)
 
-		// 序列化响应体为 JSON
-		var respBody string
-		if resp != nil {
-			respBody = marshalProtoMessage(resp.Any())
+		// 序列化请求体/响应体为 JSON
+		// 采样决策在请求完成后进行，慢请求与错误请求始终记录完整 body，
+		// 正常的快请求按配置比例采样，未命中时只记录元数据
+		var reqBody, respBody string
+		if i.bodySampler.ShouldRecord(duration.Milliseconds(), err) {
+			reqBody = marshalProtoMessage(req.Any())
+			if resp != nil {
+				respBody = marshalProtoMessage(resp.Any())
+			}
 		}
 
 		// 设置 span 状态
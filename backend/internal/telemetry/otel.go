@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"zera/internal/config"
+	"zera/internal/instanceid"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -57,7 +58,7 @@ func NewProvider(cfg *config.TelemetryConfig, logCfg *config.LogConfig) (*Provid
 	}
 
 	// 创建资源
-	res, err := newResource(logCfg)
+	res, err := newResource(logCfg, cfg)
 	if err != nil {
 		conn.Close()
 		return nil, fmt.Errorf("failed to create resource: %w", err)
@@ -113,15 +114,27 @@ func NewProvider(cfg *config.TelemetryConfig, logCfg *config.LogConfig) (*Provid
 }
 
 // newResource 创建资源描述
-func newResource(logCfg *config.LogConfig) (*resource.Resource, error) {
+func newResource(logCfg *config.LogConfig, cfg *config.TelemetryConfig) (*resource.Resource, error) {
+	attrs := []attribute.KeyValue{
+		semconv.ServiceName(logCfg.ServiceName),
+		semconv.ServiceVersion(logCfg.ServiceVersion),
+		semconv.DeploymentEnvironmentKey.String(logCfg.Environment),
+		// 实例标识，多实例部署时在 SigNoz 中按实例过滤
+		semconv.ServiceInstanceID(instanceid.ID()),
+		attribute.String("service.namespace", "zera"),
+	}
+
+	// 合并配置中的自定义资源属性（如 cluster、region、pod）
+	for key, value := range cfg.ResourceAttributes {
+		attrs = append(attrs, attribute.String(key, value))
+	}
+
 	return resource.New(
 		context.Background(),
-		resource.WithAttributes(
-			semconv.ServiceName(logCfg.ServiceName),
-			semconv.ServiceVersion(logCfg.ServiceVersion),
-			semconv.DeploymentEnvironmentKey.String(logCfg.Environment),
-			attribute.String("service.namespace", "zera"),
-		),
+		// 支持标准 OTEL_RESOURCE_ATTRIBUTES 等环境变量
+		// 放在前面，显式配置的属性优先级更高
+		resource.WithFromEnv(),
+		resource.WithAttributes(attrs...),
 		resource.WithHost(),
 		resource.WithOS(),
 		resource.WithProcess(),